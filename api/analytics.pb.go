@@ -74,12 +74,68 @@ func (CRCStatus) EnumDescriptor() ([]byte, []int) {
 	return file_analytics_proto_rawDescGZIP(), []int{0}
 }
 
+type GatewayEventType int32
+
+const (
+	GatewayEventType_EVENT_UNKNOWN     GatewayEventType = 0
+	GatewayEventType_POSITION_ACQUIRED GatewayEventType = 1
+	GatewayEventType_POSITION_MOVED    GatewayEventType = 2
+	GatewayEventType_GPS_LOCK_LOST     GatewayEventType = 3
+	GatewayEventType_GPS_LOCK_RESTORED GatewayEventType = 4
+)
+
+// Enum value maps for GatewayEventType.
+var (
+	GatewayEventType_name = map[int32]string{
+		0: "EVENT_UNKNOWN",
+		1: "POSITION_ACQUIRED",
+		2: "POSITION_MOVED",
+		3: "GPS_LOCK_LOST",
+		4: "GPS_LOCK_RESTORED",
+	}
+	GatewayEventType_value = map[string]int32{
+		"EVENT_UNKNOWN":     0,
+		"POSITION_ACQUIRED": 1,
+		"POSITION_MOVED":    2,
+		"GPS_LOCK_LOST":     3,
+		"GPS_LOCK_RESTORED": 4,
+	}
+)
+
+func (x GatewayEventType) Enum() *GatewayEventType {
+	p := new(GatewayEventType)
+	*p = x
+	return p
+}
+
+func (x GatewayEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (GatewayEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_analytics_proto_enumTypes[1].Descriptor()
+}
+
+func (GatewayEventType) Type() protoreflect.EnumType {
+	return &file_analytics_proto_enumTypes[1]
+}
+
+func (x GatewayEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use GatewayEventType.Descriptor instead.
+func (GatewayEventType) EnumDescriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{1}
+}
+
 type Modulation int32
 
 const (
 	Modulation_UNKNOWN Modulation = 0
 	Modulation_LORA    Modulation = 1
 	Modulation_FSK     Modulation = 2
+	Modulation_LR_FHSS Modulation = 3
 )
 
 // Enum value maps for Modulation.
@@ -88,11 +144,13 @@ var (
 		0: "UNKNOWN",
 		1: "LORA",
 		2: "FSK",
+		3: "LR_FHSS",
 	}
 	Modulation_value = map[string]int32{
 		"UNKNOWN": 0,
 		"LORA":    1,
 		"FSK":     2,
+		"LR_FHSS": 3,
 	}
 )
 
@@ -107,11 +165,11 @@ func (x Modulation) String() string {
 }
 
 func (Modulation) Descriptor() protoreflect.EnumDescriptor {
-	return file_analytics_proto_enumTypes[1].Descriptor()
+	return file_analytics_proto_enumTypes[2].Descriptor()
 }
 
 func (Modulation) Type() protoreflect.EnumType {
-	return &file_analytics_proto_enumTypes[1]
+	return &file_analytics_proto_enumTypes[2]
 }
 
 func (x Modulation) Number() protoreflect.EnumNumber {
@@ -120,7 +178,7 @@ func (x Modulation) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use Modulation.Descriptor instead.
 func (Modulation) EnumDescriptor() ([]byte, []int) {
-	return file_analytics_proto_rawDescGZIP(), []int{1}
+	return file_analytics_proto_rawDescGZIP(), []int{2}
 }
 
 type LoRaCodingRate int32
@@ -140,6 +198,11 @@ const (
 	LoRaCodingRate_CR_4_14    LoRaCodingRate = 11
 	LoRaCodingRate_CR_4_15    LoRaCodingRate = 12
 	LoRaCodingRate_CR_4_16    LoRaCodingRate = 13
+	// LR-FHSS coding rates
+	LoRaCodingRate_CR_1_3 LoRaCodingRate = 14
+	LoRaCodingRate_CR_2_3 LoRaCodingRate = 15
+	LoRaCodingRate_CR_1_2 LoRaCodingRate = 16
+	LoRaCodingRate_CR_5_6 LoRaCodingRate = 17
 )
 
 // Enum value maps for LoRaCodingRate.
@@ -159,6 +222,10 @@ var (
 		11: "CR_4_14",
 		12: "CR_4_15",
 		13: "CR_4_16",
+		14: "CR_1_3",
+		15: "CR_2_3",
+		16: "CR_1_2",
+		17: "CR_5_6",
 	}
 	LoRaCodingRate_value = map[string]int32{
 		"CR_OFF":     0,
@@ -175,6 +242,10 @@ var (
 		"CR_4_14":    11,
 		"CR_4_15":    12,
 		"CR_4_16":    13,
+		"CR_1_3":     14,
+		"CR_2_3":     15,
+		"CR_1_2":     16,
+		"CR_5_6":     17,
 	}
 )
 
@@ -189,11 +260,11 @@ func (x LoRaCodingRate) String() string {
 }
 
 func (LoRaCodingRate) Descriptor() protoreflect.EnumDescriptor {
-	return file_analytics_proto_enumTypes[2].Descriptor()
+	return file_analytics_proto_enumTypes[3].Descriptor()
 }
 
 func (LoRaCodingRate) Type() protoreflect.EnumType {
-	return &file_analytics_proto_enumTypes[2]
+	return &file_analytics_proto_enumTypes[3]
 }
 
 func (x LoRaCodingRate) Number() protoreflect.EnumNumber {
@@ -202,7 +273,7 @@ func (x LoRaCodingRate) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use LoRaCodingRate.Descriptor instead.
 func (LoRaCodingRate) EnumDescriptor() ([]byte, []int) {
-	return file_analytics_proto_rawDescGZIP(), []int{2}
+	return file_analytics_proto_rawDescGZIP(), []int{3}
 }
 
 type LoRaSF int32
@@ -215,6 +286,8 @@ const (
 	LoRaSF_SF9        LoRaSF = 4
 	LoRaSF_SF8        LoRaSF = 5
 	LoRaSF_SF7        LoRaSF = 6
+	LoRaSF_SF6        LoRaSF = 7
+	LoRaSF_SF5        LoRaSF = 8
 )
 
 // Enum value maps for LoRaSF.
@@ -227,6 +300,8 @@ var (
 		4: "SF9",
 		5: "SF8",
 		6: "SF7",
+		7: "SF6",
+		8: "SF5",
 	}
 	LoRaSF_value = map[string]int32{
 		"SF_UNKNOWN": 0,
@@ -236,6 +311,8 @@ var (
 		"SF9":        4,
 		"SF8":        5,
 		"SF7":        6,
+		"SF6":        7,
+		"SF5":        8,
 	}
 )
 
@@ -250,11 +327,11 @@ func (x LoRaSF) String() string {
 }
 
 func (LoRaSF) Descriptor() protoreflect.EnumDescriptor {
-	return file_analytics_proto_enumTypes[3].Descriptor()
+	return file_analytics_proto_enumTypes[4].Descriptor()
 }
 
 func (LoRaSF) Type() protoreflect.EnumType {
-	return &file_analytics_proto_enumTypes[3]
+	return &file_analytics_proto_enumTypes[4]
 }
 
 func (x LoRaSF) Number() protoreflect.EnumNumber {
@@ -263,7 +340,7 @@ func (x LoRaSF) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use LoRaSF.Descriptor instead.
 func (LoRaSF) EnumDescriptor() ([]byte, []int) {
-	return file_analytics_proto_rawDescGZIP(), []int{3}
+	return file_analytics_proto_rawDescGZIP(), []int{4}
 }
 
 type LoRaBW int32
@@ -273,6 +350,11 @@ const (
 	LoRaBW_BW_125k    LoRaBW = 1
 	LoRaBW_BW_250k    LoRaBW = 3
 	LoRaBW_BW_500k    LoRaBW = 2
+	// 2.4 GHz bandwidths
+	LoRaBW_BW_203k  LoRaBW = 4
+	LoRaBW_BW_406k  LoRaBW = 5
+	LoRaBW_BW_812k  LoRaBW = 6
+	LoRaBW_BW_1625k LoRaBW = 7
 )
 
 // Enum value maps for LoRaBW.
@@ -282,12 +364,20 @@ var (
 		1: "BW_125k",
 		3: "BW_250k",
 		2: "BW_500k",
+		4: "BW_203k",
+		5: "BW_406k",
+		6: "BW_812k",
+		7: "BW_1625k",
 	}
 	LoRaBW_value = map[string]int32{
 		"BW_UNKNOWN": 0,
 		"BW_125k":    1,
 		"BW_250k":    3,
 		"BW_500k":    2,
+		"BW_203k":    4,
+		"BW_406k":    5,
+		"BW_812k":    6,
+		"BW_1625k":   7,
 	}
 )
 
@@ -302,11 +392,11 @@ func (x LoRaBW) String() string {
 }
 
 func (LoRaBW) Descriptor() protoreflect.EnumDescriptor {
-	return file_analytics_proto_enumTypes[4].Descriptor()
+	return file_analytics_proto_enumTypes[5].Descriptor()
 }
 
 func (LoRaBW) Type() protoreflect.EnumType {
-	return &file_analytics_proto_enumTypes[4]
+	return &file_analytics_proto_enumTypes[5]
 }
 
 func (x LoRaBW) Number() protoreflect.EnumNumber {
@@ -315,7 +405,7 @@ func (x LoRaBW) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use LoRaBW.Descriptor instead.
 func (LoRaBW) EnumDescriptor() ([]byte, []int) {
-	return file_analytics_proto_rawDescGZIP(), []int{4}
+	return file_analytics_proto_rawDescGZIP(), []int{5}
 }
 
 //*
@@ -331,6 +421,12 @@ type AnalyticsMetrics struct {
 	Downlinks  []*AnalyticsDownlink      `protobuf:"bytes,3,rep,name=downlinks,proto3" json:"downlinks,omitempty"`
 	Stats      []*AnalyticsStat          `protobuf:"bytes,4,rep,name=stats,proto3" json:"stats,omitempty"`
 	Metrics    *AnalyticsInternalMetrics `protobuf:"bytes,7,opt,name=metrics,proto3,oneof" json:"metrics,omitempty"`
+	Events     []*AnalyticsGatewayEvent  `protobuf:"bytes,8,rep,name=events,proto3" json:"events,omitempty"`
+	// The uplinks and downlinks of the flush window, when aggregated by the
+	// forwarder (`uplinks`/`downlinks` then only contain a sample of them)
+	Aggregate *AnalyticsAggregate `protobuf:"bytes,9,opt,name=aggregate,proto3,oneof" json:"aggregate,omitempty"`
+	// The health of the forwarder, sent periodically with one of the frames
+	Forwarder *ForwarderInfo `protobuf:"bytes,10,opt,name=forwarder,proto3,oneof" json:"forwarder,omitempty"`
 }
 
 func (x *AnalyticsMetrics) Reset() {
@@ -407,6 +503,27 @@ func (x *AnalyticsMetrics) GetMetrics() *AnalyticsInternalMetrics {
 	return nil
 }
 
+func (x *AnalyticsMetrics) GetEvents() []*AnalyticsGatewayEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *AnalyticsMetrics) GetAggregate() *AnalyticsAggregate {
+	if x != nil {
+		return x.Aggregate
+	}
+	return nil
+}
+
+func (x *AnalyticsMetrics) GetForwarder() *ForwarderInfo {
+	if x != nil {
+		return x.Forwarder
+	}
+	return nil
+}
+
 type AnalyticsUplinkAntenna struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -547,6 +664,7 @@ type AnalyticsUplink struct {
 	// Types that are assignable to DataRate:
 	//	*AnalyticsUplink_DataRateLoRa
 	//	*AnalyticsUplink_DataRateFSK
+	//	*AnalyticsUplink_DataRateLRFHSS
 	DataRate isAnalyticsUplink_DataRate `protobuf_oneof:"dataRate"`
 	// float rssi = 12; (removed)
 	// float lsnr = 13; (removed)
@@ -554,6 +672,11 @@ type AnalyticsUplink struct {
 	Fhdr     []byte                    `protobuf:"bytes,15,opt,name=fhdr,proto3" json:"fhdr,omitempty"`
 	UniqueId []byte                    `protobuf:"bytes,16,opt,name=uniqueId,proto3" json:"uniqueId,omitempty"`
 	Ant      []*AnalyticsUplinkAntenna `protobuf:"bytes,17,rep,name=ant,proto3" json:"ant,omitempty"`
+	// Vendor-specific rxpk fields, as raw JSON values
+	CustomFields map[string]string `protobuf:"bytes,19,rep,name=customFields,proto3" json:"customFields,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// The concentrator counter (tmst) unwrapped into a monotonic 64-bit
+	// value (in microseconds)
+	RxTimestamp int64 `protobuf:"varint,20,opt,name=rxTimestamp,proto3" json:"rxTimestamp,omitempty"`
 }
 
 func (x *AnalyticsUplink) Reset() {
@@ -665,6 +788,13 @@ func (x *AnalyticsUplink) GetDataRateFSK() uint32 {
 	return 0
 }
 
+func (x *AnalyticsUplink) GetDataRateLRFHSS() *LRFHSSDataRate {
+	if x, ok := x.GetDataRate().(*AnalyticsUplink_DataRateLRFHSS); ok {
+		return x.DataRateLRFHSS
+	}
+	return nil
+}
+
 func (x *AnalyticsUplink) GetSize() uint32 {
 	if x != nil {
 		return x.Size
@@ -693,6 +823,20 @@ func (x *AnalyticsUplink) GetAnt() []*AnalyticsUplinkAntenna {
 	return nil
 }
 
+func (x *AnalyticsUplink) GetCustomFields() map[string]string {
+	if x != nil {
+		return x.CustomFields
+	}
+	return nil
+}
+
+func (x *AnalyticsUplink) GetRxTimestamp() int64 {
+	if x != nil {
+		return x.RxTimestamp
+	}
+	return 0
+}
+
 type isAnalyticsUplink_DataRate interface {
 	isAnalyticsUplink_DataRate()
 }
@@ -705,10 +849,16 @@ type AnalyticsUplink_DataRateFSK struct {
 	DataRateFSK uint32 `protobuf:"varint,11,opt,name=dataRateFSK,proto3,oneof"`
 }
 
+type AnalyticsUplink_DataRateLRFHSS struct {
+	DataRateLRFHSS *LRFHSSDataRate `protobuf:"bytes,18,opt,name=dataRateLRFHSS,proto3,oneof"`
+}
+
 func (*AnalyticsUplink_DataRateLoRa) isAnalyticsUplink_DataRate() {}
 
 func (*AnalyticsUplink_DataRateFSK) isAnalyticsUplink_DataRate() {}
 
+func (*AnalyticsUplink_DataRateLRFHSS) isAnalyticsUplink_DataRate() {}
+
 //*
 // Downlink Analytics Message
 // (Received from the server)
@@ -738,6 +888,8 @@ type AnalyticsDownlink struct {
 	NoCrc          bool                         `protobuf:"varint,17,opt,name=noCrc,proto3" json:"noCrc,omitempty"`
 	RxWallTime     int64                        `protobuf:"varint,18,opt,name=rxWallTime,proto3" json:"rxWallTime,omitempty"`
 	UniqueId       []byte                       `protobuf:"bytes,19,opt,name=uniqueId,proto3" json:"uniqueId,omitempty"`
+	// The concentrator counter (tmst) unwrapped like `rxTimestamp` of the uplinks
+	TxTimestamp int64 `protobuf:"varint,20,opt,name=txTimestamp,proto3" json:"txTimestamp,omitempty"`
 }
 
 func (x *AnalyticsDownlink) Reset() {
@@ -912,6 +1064,13 @@ func (x *AnalyticsDownlink) GetUniqueId() []byte {
 	return nil
 }
 
+func (x *AnalyticsDownlink) GetTxTimestamp() int64 {
+	if x != nil {
+		return x.TxTimestamp
+	}
+	return 0
+}
+
 type isAnalyticsDownlink_DataRate interface {
 	isAnalyticsDownlink_DataRate()
 }
@@ -953,6 +1112,11 @@ type AnalyticsStat struct {
 	IsGauge bool `protobuf:"varint,11,opt,name=isGauge,proto3" json:"isGauge,omitempty"`
 	// Gateway temperature
 	GwTemp *float32 `protobuf:"fixed32,12,opt,name=gwTemp,proto3,oneof" json:"gwTemp,omitempty"`
+	// Full-precision location (gwLatitude/gwLongitude are kept for older servers)
+	GwLatitudePrecise  float64 `protobuf:"fixed64,13,opt,name=gwLatitudePrecise,proto3" json:"gwLatitudePrecise,omitempty"`
+	GwLongitudePrecise float64 `protobuf:"fixed64,14,opt,name=gwLongitudePrecise,proto3" json:"gwLongitudePrecise,omitempty"`
+	// Estimated drift of the concentrator clock against the forwarder (in ppm)
+	GwClockDrift *float32 `protobuf:"fixed32,15,opt,name=gwClockDrift,proto3,oneof" json:"gwClockDrift,omitempty"`
 }
 
 func (x *AnalyticsStat) Reset() {
@@ -1071,29 +1235,43 @@ func (x *AnalyticsStat) GetGwTemp() float32 {
 	return 0
 }
 
+func (x *AnalyticsStat) GetGwLatitudePrecise() float64 {
+	if x != nil {
+		return x.GwLatitudePrecise
+	}
+	return 0
+}
+
+func (x *AnalyticsStat) GetGwLongitudePrecise() float64 {
+	if x != nil {
+		return x.GwLongitudePrecise
+	}
+	return 0
+}
+
+func (x *AnalyticsStat) GetGwClockDrift() float32 {
+	if x != nil && x.GwClockDrift != nil {
+		return *x.GwClockDrift
+	}
+	return 0
+}
+
 //*
-// Analytics Internal Metrics
-// (Collected from the UDP proxy)
-type AnalyticsInternalMetrics struct {
+// Aggregated Traffic
+// (Reduced by the forwarder over a flush window)
+type AnalyticsAggregate struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	GatewayIp    string `protobuf:"bytes,1,opt,name=gatewayIp,proto3" json:"gatewayIp,omitempty"`
-	UpRxPackets  uint32 `protobuf:"varint,2,opt,name=upRxPackets,proto3" json:"upRxPackets,omitempty"`
-	UpTxPackets  uint32 `protobuf:"varint,3,opt,name=upTxPackets,proto3" json:"upTxPackets,omitempty"`
-	DnRxPackets  uint32 `protobuf:"varint,4,opt,name=dnRxPackets,proto3" json:"dnRxPackets,omitempty"`
-	DnTxPackets  uint32 `protobuf:"varint,5,opt,name=dnTxPackets,proto3" json:"dnTxPackets,omitempty"`
-	PktPUSH_DATA uint32 `protobuf:"varint,6,opt,name=pktPUSH_DATA,json=pktPUSHDATA,proto3" json:"pktPUSH_DATA,omitempty"`
-	PktPUSH_ACK  uint32 `protobuf:"varint,7,opt,name=pktPUSH_ACK,json=pktPUSHACK,proto3" json:"pktPUSH_ACK,omitempty"`
-	PktPULL_DATA uint32 `protobuf:"varint,8,opt,name=pktPULL_DATA,json=pktPULLDATA,proto3" json:"pktPULL_DATA,omitempty"`
-	PktPULL_ACK  uint32 `protobuf:"varint,9,opt,name=pktPULL_ACK,json=pktPULLACK,proto3" json:"pktPULL_ACK,omitempty"`
-	PktPULL_RESP uint32 `protobuf:"varint,10,opt,name=pktPULL_RESP,json=pktPULLRESP,proto3" json:"pktPULL_RESP,omitempty"`
-	PktTX_ACK    uint32 `protobuf:"varint,11,opt,name=pktTX_ACK,json=pktTXACK,proto3" json:"pktTX_ACK,omitempty"`
+	StartTime int64                         `protobuf:"varint,1,opt,name=startTime,proto3" json:"startTime,omitempty"`
+	EndTime   int64                         `protobuf:"varint,2,opt,name=endTime,proto3" json:"endTime,omitempty"`
+	Uplinks   []*AnalyticsUplinkAggregate   `protobuf:"bytes,3,rep,name=uplinks,proto3" json:"uplinks,omitempty"`
+	Downlinks []*AnalyticsDownlinkAggregate `protobuf:"bytes,4,rep,name=downlinks,proto3" json:"downlinks,omitempty"`
 }
 
-func (x *AnalyticsInternalMetrics) Reset() {
-	*x = AnalyticsInternalMetrics{}
+func (x *AnalyticsAggregate) Reset() {
+	*x = AnalyticsAggregate{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_analytics_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1101,13 +1279,13 @@ func (x *AnalyticsInternalMetrics) Reset() {
 	}
 }
 
-func (x *AnalyticsInternalMetrics) String() string {
+func (x *AnalyticsAggregate) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AnalyticsInternalMetrics) ProtoMessage() {}
+func (*AnalyticsAggregate) ProtoMessage() {}
 
-func (x *AnalyticsInternalMetrics) ProtoReflect() protoreflect.Message {
+func (x *AnalyticsAggregate) ProtoReflect() protoreflect.Message {
 	mi := &file_analytics_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1119,114 +1297,219 @@ func (x *AnalyticsInternalMetrics) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AnalyticsInternalMetrics.ProtoReflect.Descriptor instead.
-func (*AnalyticsInternalMetrics) Descriptor() ([]byte, []int) {
+// Deprecated: Use AnalyticsAggregate.ProtoReflect.Descriptor instead.
+func (*AnalyticsAggregate) Descriptor() ([]byte, []int) {
 	return file_analytics_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *AnalyticsInternalMetrics) GetGatewayIp() string {
+func (x *AnalyticsAggregate) GetStartTime() int64 {
 	if x != nil {
-		return x.GatewayIp
+		return x.StartTime
 	}
-	return ""
+	return 0
 }
 
-func (x *AnalyticsInternalMetrics) GetUpRxPackets() uint32 {
+func (x *AnalyticsAggregate) GetEndTime() int64 {
 	if x != nil {
-		return x.UpRxPackets
+		return x.EndTime
 	}
 	return 0
 }
 
-func (x *AnalyticsInternalMetrics) GetUpTxPackets() uint32 {
+func (x *AnalyticsAggregate) GetUplinks() []*AnalyticsUplinkAggregate {
 	if x != nil {
-		return x.UpTxPackets
+		return x.Uplinks
 	}
-	return 0
+	return nil
 }
 
-func (x *AnalyticsInternalMetrics) GetDnRxPackets() uint32 {
+func (x *AnalyticsAggregate) GetDownlinks() []*AnalyticsDownlinkAggregate {
 	if x != nil {
-		return x.DnRxPackets
+		return x.Downlinks
 	}
-	return 0
+	return nil
 }
 
-func (x *AnalyticsInternalMetrics) GetDnTxPackets() uint32 {
+// The uplinks with the same data rate, frequency and CRC status
+type AnalyticsUplinkAggregate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Modulation Modulation `protobuf:"varint,1,opt,name=modulation,proto3,enum=api.Modulation" json:"modulation,omitempty"`
+	// Types that are assignable to DataRate:
+	//	*AnalyticsUplinkAggregate_DataRateLoRa
+	//	*AnalyticsUplinkAggregate_DataRateFSK
+	//	*AnalyticsUplinkAggregate_DataRateLRFHSS
+	DataRate  isAnalyticsUplinkAggregate_DataRate `protobuf_oneof:"dataRate"`
+	Frequency float32                             `protobuf:"fixed32,5,opt,name=frequency,proto3" json:"frequency,omitempty"`
+	Crc       CRCStatus                           `protobuf:"varint,6,opt,name=crc,proto3,enum=api.CRCStatus" json:"crc,omitempty"`
+	Count     uint32                              `protobuf:"varint,7,opt,name=count,proto3" json:"count,omitempty"`
+	// The sum of the time on air (in microseconds) and of the payload sizes
+	Airtime uint64                       `protobuf:"varint,8,opt,name=airtime,proto3" json:"airtime,omitempty"`
+	Size    uint64                       `protobuf:"varint,9,opt,name=size,proto3" json:"size,omitempty"`
+	Ant     []*AnalyticsAntennaAggregate `protobuf:"bytes,10,rep,name=ant,proto3" json:"ant,omitempty"`
+}
+
+func (x *AnalyticsUplinkAggregate) Reset() {
+	*x = AnalyticsUplinkAggregate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analytics_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnalyticsUplinkAggregate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyticsUplinkAggregate) ProtoMessage() {}
+
+func (x *AnalyticsUplinkAggregate) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyticsUplinkAggregate.ProtoReflect.Descriptor instead.
+func (*AnalyticsUplinkAggregate) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AnalyticsUplinkAggregate) GetModulation() Modulation {
 	if x != nil {
-		return x.DnTxPackets
+		return x.Modulation
+	}
+	return Modulation_UNKNOWN
+}
+
+func (m *AnalyticsUplinkAggregate) GetDataRate() isAnalyticsUplinkAggregate_DataRate {
+	if m != nil {
+		return m.DataRate
+	}
+	return nil
+}
+
+func (x *AnalyticsUplinkAggregate) GetDataRateLoRa() *LoRaDataRate {
+	if x, ok := x.GetDataRate().(*AnalyticsUplinkAggregate_DataRateLoRa); ok {
+		return x.DataRateLoRa
+	}
+	return nil
+}
+
+func (x *AnalyticsUplinkAggregate) GetDataRateFSK() uint32 {
+	if x, ok := x.GetDataRate().(*AnalyticsUplinkAggregate_DataRateFSK); ok {
+		return x.DataRateFSK
 	}
 	return 0
 }
 
-func (x *AnalyticsInternalMetrics) GetPktPUSH_DATA() uint32 {
+func (x *AnalyticsUplinkAggregate) GetDataRateLRFHSS() *LRFHSSDataRate {
+	if x, ok := x.GetDataRate().(*AnalyticsUplinkAggregate_DataRateLRFHSS); ok {
+		return x.DataRateLRFHSS
+	}
+	return nil
+}
+
+func (x *AnalyticsUplinkAggregate) GetFrequency() float32 {
 	if x != nil {
-		return x.PktPUSH_DATA
+		return x.Frequency
 	}
 	return 0
 }
 
-func (x *AnalyticsInternalMetrics) GetPktPUSH_ACK() uint32 {
+func (x *AnalyticsUplinkAggregate) GetCrc() CRCStatus {
 	if x != nil {
-		return x.PktPUSH_ACK
+		return x.Crc
 	}
-	return 0
+	return CRCStatus_MISSING
 }
 
-func (x *AnalyticsInternalMetrics) GetPktPULL_DATA() uint32 {
+func (x *AnalyticsUplinkAggregate) GetCount() uint32 {
 	if x != nil {
-		return x.PktPULL_DATA
+		return x.Count
 	}
 	return 0
 }
 
-func (x *AnalyticsInternalMetrics) GetPktPULL_ACK() uint32 {
+func (x *AnalyticsUplinkAggregate) GetAirtime() uint64 {
 	if x != nil {
-		return x.PktPULL_ACK
+		return x.Airtime
 	}
 	return 0
 }
 
-func (x *AnalyticsInternalMetrics) GetPktPULL_RESP() uint32 {
+func (x *AnalyticsUplinkAggregate) GetSize() uint64 {
 	if x != nil {
-		return x.PktPULL_RESP
+		return x.Size
 	}
 	return 0
 }
 
-func (x *AnalyticsInternalMetrics) GetPktTX_ACK() uint32 {
+func (x *AnalyticsUplinkAggregate) GetAnt() []*AnalyticsAntennaAggregate {
 	if x != nil {
-		return x.PktTX_ACK
+		return x.Ant
 	}
-	return 0
+	return nil
 }
 
-type LoRaDataRate struct {
+type isAnalyticsUplinkAggregate_DataRate interface {
+	isAnalyticsUplinkAggregate_DataRate()
+}
+
+type AnalyticsUplinkAggregate_DataRateLoRa struct {
+	DataRateLoRa *LoRaDataRate `protobuf:"bytes,2,opt,name=dataRateLoRa,proto3,oneof"`
+}
+
+type AnalyticsUplinkAggregate_DataRateFSK struct {
+	DataRateFSK uint32 `protobuf:"varint,3,opt,name=dataRateFSK,proto3,oneof"`
+}
+
+type AnalyticsUplinkAggregate_DataRateLRFHSS struct {
+	DataRateLRFHSS *LRFHSSDataRate `protobuf:"bytes,4,opt,name=dataRateLRFHSS,proto3,oneof"`
+}
+
+func (*AnalyticsUplinkAggregate_DataRateLoRa) isAnalyticsUplinkAggregate_DataRate() {}
+
+func (*AnalyticsUplinkAggregate_DataRateFSK) isAnalyticsUplinkAggregate_DataRate() {}
+
+func (*AnalyticsUplinkAggregate_DataRateLRFHSS) isAnalyticsUplinkAggregate_DataRate() {}
+
+// The signal quality of the uplinks received by an antenna
+type AnalyticsAntennaAggregate struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	SpreadingFactor LoRaSF `protobuf:"varint,1,opt,name=spreadingFactor,proto3,enum=api.LoRaSF" json:"spreadingFactor,omitempty"`
-	Bandwidth       LoRaBW `protobuf:"varint,2,opt,name=bandwidth,proto3,enum=api.LoRaBW" json:"bandwidth,omitempty"`
+	Antenna int32               `protobuf:"varint,1,opt,name=antenna,proto3" json:"antenna,omitempty"`
+	Rssi    *AnalyticsHistogram `protobuf:"bytes,2,opt,name=rssi,proto3" json:"rssi,omitempty"`
+	Snr     *AnalyticsHistogram `protobuf:"bytes,3,opt,name=snr,proto3" json:"snr,omitempty"`
 }
 
-func (x *LoRaDataRate) Reset() {
-	*x = LoRaDataRate{}
+func (x *AnalyticsAntennaAggregate) Reset() {
+	*x = AnalyticsAntennaAggregate{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_analytics_proto_msgTypes[6]
+		mi := &file_analytics_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *LoRaDataRate) String() string {
+func (x *AnalyticsAntennaAggregate) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LoRaDataRate) ProtoMessage() {}
+func (*AnalyticsAntennaAggregate) ProtoMessage() {}
 
-func (x *LoRaDataRate) ProtoReflect() protoreflect.Message {
-	mi := &file_analytics_proto_msgTypes[6]
+func (x *AnalyticsAntennaAggregate) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1237,36 +1520,843 @@ func (x *LoRaDataRate) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LoRaDataRate.ProtoReflect.Descriptor instead.
-func (*LoRaDataRate) Descriptor() ([]byte, []int) {
-	return file_analytics_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use AnalyticsAntennaAggregate.ProtoReflect.Descriptor instead.
+func (*AnalyticsAntennaAggregate) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *LoRaDataRate) GetSpreadingFactor() LoRaSF {
+func (x *AnalyticsAntennaAggregate) GetAntenna() int32 {
 	if x != nil {
-		return x.SpreadingFactor
+		return x.Antenna
 	}
-	return LoRaSF_SF_UNKNOWN
+	return 0
 }
 
-func (x *LoRaDataRate) GetBandwidth() LoRaBW {
+func (x *AnalyticsAntennaAggregate) GetRssi() *AnalyticsHistogram {
 	if x != nil {
-		return x.Bandwidth
+		return x.Rssi
 	}
-	return LoRaBW_BW_UNKNOWN
+	return nil
 }
 
-var File_analytics_proto protoreflect.FileDescriptor
+func (x *AnalyticsAntennaAggregate) GetSnr() *AnalyticsHistogram {
+	if x != nil {
+		return x.Snr
+	}
+	return nil
+}
 
-var file_analytics_proto_rawDesc = []byte{
-	0x0a, 0x0f, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x12, 0x03, 0x61, 0x70, 0x69, 0x22, 0xaa, 0x02, 0x0a, 0x10, 0x41, 0x6e, 0x61, 0x6c, 0x79,
-	0x74, 0x69, 0x63, 0x73, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x67,
-	0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
-	0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x67, 0x61, 0x74,
-	0x65, 0x77, 0x61, 0x79, 0x45, 0x75, 0x69, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x67,
-	0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x45, 0x75, 0x69, 0x12, 0x2e, 0x0a, 0x07, 0x75, 0x70, 0x6c,
-	0x69, 0x6e, 0x6b, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x61, 0x70, 0x69,
+// The downlinks with the same data rate and frequency
+type AnalyticsDownlinkAggregate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Modulation Modulation `protobuf:"varint,1,opt,name=modulation,proto3,enum=api.Modulation" json:"modulation,omitempty"`
+	// Types that are assignable to DataRate:
+	//	*AnalyticsDownlinkAggregate_DataRateLoRa
+	//	*AnalyticsDownlinkAggregate_DataRateFSK
+	DataRate  isAnalyticsDownlinkAggregate_DataRate `protobuf_oneof:"dataRate"`
+	Frequency float32                               `protobuf:"fixed32,4,opt,name=frequency,proto3" json:"frequency,omitempty"`
+	Count     uint32                                `protobuf:"varint,5,opt,name=count,proto3" json:"count,omitempty"`
+	// The sum of the time on air (in microseconds) and of the payload sizes
+	Airtime uint64 `protobuf:"varint,6,opt,name=airtime,proto3" json:"airtime,omitempty"`
+	Size    uint64 `protobuf:"varint,7,opt,name=size,proto3" json:"size,omitempty"`
+}
+
+func (x *AnalyticsDownlinkAggregate) Reset() {
+	*x = AnalyticsDownlinkAggregate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analytics_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnalyticsDownlinkAggregate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyticsDownlinkAggregate) ProtoMessage() {}
+
+func (x *AnalyticsDownlinkAggregate) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyticsDownlinkAggregate.ProtoReflect.Descriptor instead.
+func (*AnalyticsDownlinkAggregate) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *AnalyticsDownlinkAggregate) GetModulation() Modulation {
+	if x != nil {
+		return x.Modulation
+	}
+	return Modulation_UNKNOWN
+}
+
+func (m *AnalyticsDownlinkAggregate) GetDataRate() isAnalyticsDownlinkAggregate_DataRate {
+	if m != nil {
+		return m.DataRate
+	}
+	return nil
+}
+
+func (x *AnalyticsDownlinkAggregate) GetDataRateLoRa() *LoRaDataRate {
+	if x, ok := x.GetDataRate().(*AnalyticsDownlinkAggregate_DataRateLoRa); ok {
+		return x.DataRateLoRa
+	}
+	return nil
+}
+
+func (x *AnalyticsDownlinkAggregate) GetDataRateFSK() uint32 {
+	if x, ok := x.GetDataRate().(*AnalyticsDownlinkAggregate_DataRateFSK); ok {
+		return x.DataRateFSK
+	}
+	return 0
+}
+
+func (x *AnalyticsDownlinkAggregate) GetFrequency() float32 {
+	if x != nil {
+		return x.Frequency
+	}
+	return 0
+}
+
+func (x *AnalyticsDownlinkAggregate) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *AnalyticsDownlinkAggregate) GetAirtime() uint64 {
+	if x != nil {
+		return x.Airtime
+	}
+	return 0
+}
+
+func (x *AnalyticsDownlinkAggregate) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type isAnalyticsDownlinkAggregate_DataRate interface {
+	isAnalyticsDownlinkAggregate_DataRate()
+}
+
+type AnalyticsDownlinkAggregate_DataRateLoRa struct {
+	DataRateLoRa *LoRaDataRate `protobuf:"bytes,2,opt,name=dataRateLoRa,proto3,oneof"`
+}
+
+type AnalyticsDownlinkAggregate_DataRateFSK struct {
+	DataRateFSK uint32 `protobuf:"varint,3,opt,name=dataRateFSK,proto3,oneof"`
+}
+
+func (*AnalyticsDownlinkAggregate_DataRateLoRa) isAnalyticsDownlinkAggregate_DataRate() {}
+
+func (*AnalyticsDownlinkAggregate_DataRateFSK) isAnalyticsDownlinkAggregate_DataRate() {}
+
+// Counts of values in bins of equal width, the first one starting at `start`
+type AnalyticsHistogram struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Start  float32  `protobuf:"fixed32,1,opt,name=start,proto3" json:"start,omitempty"`
+	Width  float32  `protobuf:"fixed32,2,opt,name=width,proto3" json:"width,omitempty"`
+	Counts []uint32 `protobuf:"varint,3,rep,packed,name=counts,proto3" json:"counts,omitempty"`
+}
+
+func (x *AnalyticsHistogram) Reset() {
+	*x = AnalyticsHistogram{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analytics_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnalyticsHistogram) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyticsHistogram) ProtoMessage() {}
+
+func (x *AnalyticsHistogram) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyticsHistogram.ProtoReflect.Descriptor instead.
+func (*AnalyticsHistogram) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AnalyticsHistogram) GetStart() float32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *AnalyticsHistogram) GetWidth() float32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *AnalyticsHistogram) GetCounts() []uint32 {
+	if x != nil {
+		return x.Counts
+	}
+	return nil
+}
+
+//*
+// Gateway Event
+// (Detected by the forwarder from the gateway status)
+type AnalyticsGatewayEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Time int64            `protobuf:"varint,1,opt,name=time,proto3" json:"time,omitempty"`
+	Type GatewayEventType `protobuf:"varint,2,opt,name=type,proto3,enum=api.GatewayEventType" json:"type,omitempty"`
+	// The position reported by the gateway
+	Latitude  float64 `protobuf:"fixed64,3,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude float64 `protobuf:"fixed64,4,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	Altitude  float32 `protobuf:"fixed32,5,opt,name=altitude,proto3" json:"altitude,omitempty"`
+	// The previous position and the distance to it (in meters), when moved
+	PreviousLatitude  float64 `protobuf:"fixed64,6,opt,name=previousLatitude,proto3" json:"previousLatitude,omitempty"`
+	PreviousLongitude float64 `protobuf:"fixed64,7,opt,name=previousLongitude,proto3" json:"previousLongitude,omitempty"`
+	Distance          float32 `protobuf:"fixed32,8,opt,name=distance,proto3" json:"distance,omitempty"`
+}
+
+func (x *AnalyticsGatewayEvent) Reset() {
+	*x = AnalyticsGatewayEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analytics_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnalyticsGatewayEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyticsGatewayEvent) ProtoMessage() {}
+
+func (x *AnalyticsGatewayEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyticsGatewayEvent.ProtoReflect.Descriptor instead.
+func (*AnalyticsGatewayEvent) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *AnalyticsGatewayEvent) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *AnalyticsGatewayEvent) GetType() GatewayEventType {
+	if x != nil {
+		return x.Type
+	}
+	return GatewayEventType_EVENT_UNKNOWN
+}
+
+func (x *AnalyticsGatewayEvent) GetLatitude() float64 {
+	if x != nil {
+		return x.Latitude
+	}
+	return 0
+}
+
+func (x *AnalyticsGatewayEvent) GetLongitude() float64 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+func (x *AnalyticsGatewayEvent) GetAltitude() float32 {
+	if x != nil {
+		return x.Altitude
+	}
+	return 0
+}
+
+func (x *AnalyticsGatewayEvent) GetPreviousLatitude() float64 {
+	if x != nil {
+		return x.PreviousLatitude
+	}
+	return 0
+}
+
+func (x *AnalyticsGatewayEvent) GetPreviousLongitude() float64 {
+	if x != nil {
+		return x.PreviousLongitude
+	}
+	return 0
+}
+
+func (x *AnalyticsGatewayEvent) GetDistance() float32 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+//*
+// Analytics Internal Metrics
+// (Collected from the UDP proxy)
+type AnalyticsInternalMetrics struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	GatewayIp    string `protobuf:"bytes,1,opt,name=gatewayIp,proto3" json:"gatewayIp,omitempty"`
+	UpRxPackets  uint32 `protobuf:"varint,2,opt,name=upRxPackets,proto3" json:"upRxPackets,omitempty"`
+	UpTxPackets  uint32 `protobuf:"varint,3,opt,name=upTxPackets,proto3" json:"upTxPackets,omitempty"`
+	DnRxPackets  uint32 `protobuf:"varint,4,opt,name=dnRxPackets,proto3" json:"dnRxPackets,omitempty"`
+	DnTxPackets  uint32 `protobuf:"varint,5,opt,name=dnTxPackets,proto3" json:"dnTxPackets,omitempty"`
+	PktPUSH_DATA uint32 `protobuf:"varint,6,opt,name=pktPUSH_DATA,json=pktPUSHDATA,proto3" json:"pktPUSH_DATA,omitempty"`
+	PktPUSH_ACK  uint32 `protobuf:"varint,7,opt,name=pktPUSH_ACK,json=pktPUSHACK,proto3" json:"pktPUSH_ACK,omitempty"`
+	PktPULL_DATA uint32 `protobuf:"varint,8,opt,name=pktPULL_DATA,json=pktPULLDATA,proto3" json:"pktPULL_DATA,omitempty"`
+	PktPULL_ACK  uint32 `protobuf:"varint,9,opt,name=pktPULL_ACK,json=pktPULLACK,proto3" json:"pktPULL_ACK,omitempty"`
+	PktPULL_RESP uint32 `protobuf:"varint,10,opt,name=pktPULL_RESP,json=pktPULLRESP,proto3" json:"pktPULL_RESP,omitempty"`
+	PktTX_ACK    uint32 `protobuf:"varint,11,opt,name=pktTX_ACK,json=pktTXACK,proto3" json:"pktTX_ACK,omitempty"`
+	// Datagrams that could not be decoded, by reason
+	MalformedTruncated   uint32 `protobuf:"varint,12,opt,name=malformedTruncated,proto3" json:"malformedTruncated,omitempty"`
+	MalformedBadVersion  uint32 `protobuf:"varint,13,opt,name=malformedBadVersion,proto3" json:"malformedBadVersion,omitempty"`
+	MalformedUnknownKind uint32 `protobuf:"varint,14,opt,name=malformedUnknownKind,proto3" json:"malformedUnknownKind,omitempty"`
+	MalformedBadJSON     uint32 `protobuf:"varint,15,opt,name=malformedBadJSON,proto3" json:"malformedBadJSON,omitempty"`
+	// Concentrator counter (tmst) wrap-arounds and restarts
+	TmstRollovers uint32 `protobuf:"varint,16,opt,name=tmstRollovers,proto3" json:"tmstRollovers,omitempty"`
+	TmstResets    uint32 `protobuf:"varint,17,opt,name=tmstResets,proto3" json:"tmstResets,omitempty"`
+}
+
+func (x *AnalyticsInternalMetrics) Reset() {
+	*x = AnalyticsInternalMetrics{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analytics_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnalyticsInternalMetrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyticsInternalMetrics) ProtoMessage() {}
+
+func (x *AnalyticsInternalMetrics) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyticsInternalMetrics.ProtoReflect.Descriptor instead.
+func (*AnalyticsInternalMetrics) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AnalyticsInternalMetrics) GetGatewayIp() string {
+	if x != nil {
+		return x.GatewayIp
+	}
+	return ""
+}
+
+func (x *AnalyticsInternalMetrics) GetUpRxPackets() uint32 {
+	if x != nil {
+		return x.UpRxPackets
+	}
+	return 0
+}
+
+func (x *AnalyticsInternalMetrics) GetUpTxPackets() uint32 {
+	if x != nil {
+		return x.UpTxPackets
+	}
+	return 0
+}
+
+func (x *AnalyticsInternalMetrics) GetDnRxPackets() uint32 {
+	if x != nil {
+		return x.DnRxPackets
+	}
+	return 0
+}
+
+func (x *AnalyticsInternalMetrics) GetDnTxPackets() uint32 {
+	if x != nil {
+		return x.DnTxPackets
+	}
+	return 0
+}
+
+func (x *AnalyticsInternalMetrics) GetPktPUSH_DATA() uint32 {
+	if x != nil {
+		return x.PktPUSH_DATA
+	}
+	return 0
+}
+
+func (x *AnalyticsInternalMetrics) GetPktPUSH_ACK() uint32 {
+	if x != nil {
+		return x.PktPUSH_ACK
+	}
+	return 0
+}
+
+func (x *AnalyticsInternalMetrics) GetPktPULL_DATA() uint32 {
+	if x != nil {
+		return x.PktPULL_DATA
+	}
+	return 0
+}
+
+func (x *AnalyticsInternalMetrics) GetPktPULL_ACK() uint32 {
+	if x != nil {
+		return x.PktPULL_ACK
+	}
+	return 0
+}
+
+func (x *AnalyticsInternalMetrics) GetPktPULL_RESP() uint32 {
+	if x != nil {
+		return x.PktPULL_RESP
+	}
+	return 0
+}
+
+func (x *AnalyticsInternalMetrics) GetPktTX_ACK() uint32 {
+	if x != nil {
+		return x.PktTX_ACK
+	}
+	return 0
+}
+
+func (x *AnalyticsInternalMetrics) GetMalformedTruncated() uint32 {
+	if x != nil {
+		return x.MalformedTruncated
+	}
+	return 0
+}
+
+func (x *AnalyticsInternalMetrics) GetMalformedBadVersion() uint32 {
+	if x != nil {
+		return x.MalformedBadVersion
+	}
+	return 0
+}
+
+func (x *AnalyticsInternalMetrics) GetMalformedUnknownKind() uint32 {
+	if x != nil {
+		return x.MalformedUnknownKind
+	}
+	return 0
+}
+
+func (x *AnalyticsInternalMetrics) GetMalformedBadJSON() uint32 {
+	if x != nil {
+		return x.MalformedBadJSON
+	}
+	return 0
+}
+
+func (x *AnalyticsInternalMetrics) GetTmstRollovers() uint32 {
+	if x != nil {
+		return x.TmstRollovers
+	}
+	return 0
+}
+
+func (x *AnalyticsInternalMetrics) GetTmstResets() uint32 {
+	if x != nil {
+		return x.TmstResets
+	}
+	return 0
+}
+
+//*
+// Forwarder Diagnostics
+// (Collected from the forwarder itself)
+type ForwarderInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version string `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	// The VCS revision the forwarder was built from
+	Revision string `protobuf:"bytes,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	// Since when the forwarder is running (in seconds)
+	Uptime    uint64 `protobuf:"varint,3,opt,name=uptime,proto3" json:"uptime,omitempty"`
+	Os        string `protobuf:"bytes,4,opt,name=os,proto3" json:"os,omitempty"`
+	Arch      string `protobuf:"bytes,5,opt,name=arch,proto3" json:"arch,omitempty"`
+	GoVersion string `protobuf:"bytes,6,opt,name=goVersion,proto3" json:"goVersion,omitempty"`
+	// The active UDP streams of the proxy, and how many were evicted to make
+	// room for new ones
+	UpStreams       uint32 `protobuf:"varint,7,opt,name=upStreams,proto3" json:"upStreams,omitempty"`
+	DnStreams       uint32 `protobuf:"varint,8,opt,name=dnStreams,proto3" json:"dnStreams,omitempty"`
+	StreamEvictions uint64 `protobuf:"varint,9,opt,name=streamEvictions,proto3" json:"streamEvictions,omitempty"`
+	// The datagrams waiting for analytics, and the ones dropped because the
+	// queue was full
+	QueueDepth       uint32 `protobuf:"varint,10,opt,name=queueDepth,proto3" json:"queueDepth,omitempty"`
+	DroppedDatagrams uint64 `protobuf:"varint,11,opt,name=droppedDatagrams,proto3" json:"droppedDatagrams,omitempty"`
+	Pushes           uint64 `protobuf:"varint,12,opt,name=pushes,proto3" json:"pushes,omitempty"`
+	PushErrors       uint64 `protobuf:"varint,13,opt,name=pushErrors,proto3" json:"pushErrors,omitempty"`
+	// The latest errors, newest last
+	LastErrors []string `protobuf:"bytes,14,rep,name=lastErrors,proto3" json:"lastErrors,omitempty"`
+	// The effective configuration (with the secrets redacted), and the version
+	// of the remote changes applied to it (0 for none)
+	Config        map[string]string `protobuf:"bytes,15,rep,name=config,proto3" json:"config,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	ConfigVersion uint64            `protobuf:"varint,16,opt,name=configVersion,proto3" json:"configVersion,omitempty"`
+	// Datagrams that could not be decoded since the forwarder started, by
+	// reason (also reported per gateway in the internal metrics on the
+	// server-side)
+	MalformedTruncated   uint64 `protobuf:"varint,17,opt,name=malformedTruncated,proto3" json:"malformedTruncated,omitempty"`
+	MalformedBadVersion  uint64 `protobuf:"varint,18,opt,name=malformedBadVersion,proto3" json:"malformedBadVersion,omitempty"`
+	MalformedUnknownKind uint64 `protobuf:"varint,19,opt,name=malformedUnknownKind,proto3" json:"malformedUnknownKind,omitempty"`
+	MalformedBadJSON     uint64 `protobuf:"varint,20,opt,name=malformedBadJSON,proto3" json:"malformedBadJSON,omitempty"`
+}
+
+func (x *ForwarderInfo) Reset() {
+	*x = ForwarderInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analytics_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ForwarderInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForwarderInfo) ProtoMessage() {}
+
+func (x *ForwarderInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForwarderInfo.ProtoReflect.Descriptor instead.
+func (*ForwarderInfo) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ForwarderInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ForwarderInfo) GetRevision() string {
+	if x != nil {
+		return x.Revision
+	}
+	return ""
+}
+
+func (x *ForwarderInfo) GetUptime() uint64 {
+	if x != nil {
+		return x.Uptime
+	}
+	return 0
+}
+
+func (x *ForwarderInfo) GetOs() string {
+	if x != nil {
+		return x.Os
+	}
+	return ""
+}
+
+func (x *ForwarderInfo) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *ForwarderInfo) GetGoVersion() string {
+	if x != nil {
+		return x.GoVersion
+	}
+	return ""
+}
+
+func (x *ForwarderInfo) GetUpStreams() uint32 {
+	if x != nil {
+		return x.UpStreams
+	}
+	return 0
+}
+
+func (x *ForwarderInfo) GetDnStreams() uint32 {
+	if x != nil {
+		return x.DnStreams
+	}
+	return 0
+}
+
+func (x *ForwarderInfo) GetStreamEvictions() uint64 {
+	if x != nil {
+		return x.StreamEvictions
+	}
+	return 0
+}
+
+func (x *ForwarderInfo) GetQueueDepth() uint32 {
+	if x != nil {
+		return x.QueueDepth
+	}
+	return 0
+}
+
+func (x *ForwarderInfo) GetDroppedDatagrams() uint64 {
+	if x != nil {
+		return x.DroppedDatagrams
+	}
+	return 0
+}
+
+func (x *ForwarderInfo) GetPushes() uint64 {
+	if x != nil {
+		return x.Pushes
+	}
+	return 0
+}
+
+func (x *ForwarderInfo) GetPushErrors() uint64 {
+	if x != nil {
+		return x.PushErrors
+	}
+	return 0
+}
+
+func (x *ForwarderInfo) GetLastErrors() []string {
+	if x != nil {
+		return x.LastErrors
+	}
+	return nil
+}
+
+func (x *ForwarderInfo) GetConfig() map[string]string {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+func (x *ForwarderInfo) GetConfigVersion() uint64 {
+	if x != nil {
+		return x.ConfigVersion
+	}
+	return 0
+}
+
+func (x *ForwarderInfo) GetMalformedTruncated() uint64 {
+	if x != nil {
+		return x.MalformedTruncated
+	}
+	return 0
+}
+
+func (x *ForwarderInfo) GetMalformedBadVersion() uint64 {
+	if x != nil {
+		return x.MalformedBadVersion
+	}
+	return 0
+}
+
+func (x *ForwarderInfo) GetMalformedUnknownKind() uint64 {
+	if x != nil {
+		return x.MalformedUnknownKind
+	}
+	return 0
+}
+
+func (x *ForwarderInfo) GetMalformedBadJSON() uint64 {
+	if x != nil {
+		return x.MalformedBadJSON
+	}
+	return 0
+}
+
+type LoRaDataRate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SpreadingFactor LoRaSF `protobuf:"varint,1,opt,name=spreadingFactor,proto3,enum=api.LoRaSF" json:"spreadingFactor,omitempty"`
+	Bandwidth       LoRaBW `protobuf:"varint,2,opt,name=bandwidth,proto3,enum=api.LoRaBW" json:"bandwidth,omitempty"`
+}
+
+func (x *LoRaDataRate) Reset() {
+	*x = LoRaDataRate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analytics_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LoRaDataRate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoRaDataRate) ProtoMessage() {}
+
+func (x *LoRaDataRate) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoRaDataRate.ProtoReflect.Descriptor instead.
+func (*LoRaDataRate) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *LoRaDataRate) GetSpreadingFactor() LoRaSF {
+	if x != nil {
+		return x.SpreadingFactor
+	}
+	return LoRaSF_SF_UNKNOWN
+}
+
+func (x *LoRaDataRate) GetBandwidth() LoRaBW {
+	if x != nil {
+		return x.Bandwidth
+	}
+	return LoRaBW_BW_UNKNOWN
+}
+
+type LRFHSSDataRate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The modulation type (`M` in the data rate)
+	ModulationType uint32 `protobuf:"varint,1,opt,name=modulationType,proto3" json:"modulationType,omitempty"`
+	// The operating channel width in kHz (`CW` in the data rate)
+	ChannelWidth uint32 `protobuf:"varint,2,opt,name=channelWidth,proto3" json:"channelWidth,omitempty"`
+}
+
+func (x *LRFHSSDataRate) Reset() {
+	*x = LRFHSSDataRate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_analytics_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LRFHSSDataRate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LRFHSSDataRate) ProtoMessage() {}
+
+func (x *LRFHSSDataRate) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LRFHSSDataRate.ProtoReflect.Descriptor instead.
+func (*LRFHSSDataRate) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *LRFHSSDataRate) GetModulationType() uint32 {
+	if x != nil {
+		return x.ModulationType
+	}
+	return 0
+}
+
+func (x *LRFHSSDataRate) GetChannelWidth() uint32 {
+	if x != nil {
+		return x.ChannelWidth
+	}
+	return 0
+}
+
+var File_analytics_proto protoreflect.FileDescriptor
+
+var file_analytics_proto_rawDesc = []byte{
+	0x0a, 0x0f, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x03, 0x61, 0x70, 0x69, 0x22, 0xed, 0x03, 0x0a, 0x10, 0x41, 0x6e, 0x61, 0x6c, 0x79,
+	0x74, 0x69, 0x63, 0x73, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x67,
+	0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x67, 0x61, 0x74,
+	0x65, 0x77, 0x61, 0x79, 0x45, 0x75, 0x69, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x67,
+	0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x45, 0x75, 0x69, 0x12, 0x2e, 0x0a, 0x07, 0x75, 0x70, 0x6c,
+	0x69, 0x6e, 0x6b, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x61, 0x70, 0x69,
 	0x2e, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x55, 0x70, 0x6c, 0x69, 0x6e, 0x6b,
 	0x52, 0x07, 0x75, 0x70, 0x6c, 0x69, 0x6e, 0x6b, 0x73, 0x12, 0x34, 0x0a, 0x09, 0x64, 0x6f, 0x77,
 	0x6e, 0x6c, 0x69, 0x6e, 0x6b, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x61,
@@ -1278,187 +2368,403 @@ var file_analytics_proto_rawDesc = []byte{
 	0x72, 0x69, 0x63, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x61, 0x70, 0x69,
 	0x2e, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x6e,
 	0x61, 0x6c, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x48, 0x00, 0x52, 0x07, 0x6d, 0x65, 0x74,
-	0x72, 0x69, 0x63, 0x73, 0x88, 0x01, 0x01, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x6d, 0x65, 0x74, 0x72,
-	0x69, 0x63, 0x73, 0x22, 0xad, 0x02, 0x0a, 0x16, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63,
-	0x73, 0x55, 0x70, 0x6c, 0x69, 0x6e, 0x6b, 0x41, 0x6e, 0x74, 0x65, 0x6e, 0x6e, 0x61, 0x12, 0x18,
-	0x0a, 0x07, 0x41, 0x6e, 0x74, 0x65, 0x6e, 0x6e, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
-	0x07, 0x41, 0x6e, 0x74, 0x65, 0x6e, 0x6e, 0x61, 0x12, 0x16, 0x0a, 0x06, 0x49, 0x66, 0x43, 0x68,
-	0x61, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x49, 0x66, 0x43, 0x68, 0x61, 0x6e,
-	0x12, 0x14, 0x0a, 0x05, 0x52, 0x53, 0x53, 0x49, 0x43, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52,
-	0x05, 0x52, 0x53, 0x53, 0x49, 0x43, 0x12, 0x19, 0x0a, 0x05, 0x52, 0x53, 0x53, 0x49, 0x53, 0x18,
-	0x04, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x05, 0x52, 0x53, 0x53, 0x49, 0x53, 0x88, 0x01,
-	0x01, 0x12, 0x1b, 0x0a, 0x06, 0x52, 0x53, 0x53, 0x49, 0x53, 0x44, 0x18, 0x05, 0x20, 0x01, 0x28,
-	0x05, 0x48, 0x01, 0x52, 0x06, 0x52, 0x53, 0x53, 0x49, 0x53, 0x44, 0x88, 0x01, 0x01, 0x12, 0x12,
-	0x0a, 0x04, 0x4c, 0x53, 0x4e, 0x52, 0x18, 0x06, 0x20, 0x01, 0x28, 0x02, 0x52, 0x04, 0x4c, 0x53,
-	0x4e, 0x52, 0x12, 0x19, 0x0a, 0x05, 0x45, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28,
-	0x0c, 0x48, 0x02, 0x52, 0x05, 0x45, 0x54, 0x69, 0x6d, 0x65, 0x88, 0x01, 0x01, 0x12, 0x19, 0x0a,
-	0x05, 0x46, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x48, 0x03, 0x52, 0x05,
-	0x46, 0x54, 0x69, 0x6d, 0x65, 0x88, 0x01, 0x01, 0x12, 0x17, 0x0a, 0x04, 0x46, 0x6f, 0x66, 0x66,
-	0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x48, 0x04, 0x52, 0x04, 0x46, 0x6f, 0x66, 0x66, 0x88, 0x01,
-	0x01, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x52, 0x53, 0x53, 0x49, 0x53, 0x42, 0x09, 0x0a, 0x07, 0x5f,
-	0x52, 0x53, 0x53, 0x49, 0x53, 0x44, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x45, 0x54, 0x69, 0x6d, 0x65,
-	0x42, 0x08, 0x0a, 0x06, 0x5f, 0x46, 0x54, 0x69, 0x6d, 0x65, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x46,
-	0x6f, 0x66, 0x66, 0x22, 0x93, 0x04, 0x0a, 0x0f, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63,
-	0x73, 0x55, 0x70, 0x6c, 0x69, 0x6e, 0x6b, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x78, 0x57, 0x61, 0x6c,
-	0x6c, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x72, 0x78, 0x57,
-	0x61, 0x6c, 0x6c, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x78, 0x47, 0x70, 0x73,
-	0x54, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x72, 0x78, 0x47, 0x70,
-	0x73, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x78, 0x46, 0x69, 0x6e, 0x69, 0x73,
-	0x68, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x72,
-	0x78, 0x46, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1c, 0x0a,
+	0x72, 0x69, 0x63, 0x73, 0x88, 0x01, 0x01, 0x12, 0x32, 0x0a, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74,
+	0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x6e,
+	0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x47, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x52, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x3a, 0x0a, 0x09, 0x61,
+	0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x41, 0x67,
+	0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x48, 0x01, 0x52, 0x09, 0x61, 0x67, 0x67, 0x72, 0x65,
+	0x67, 0x61, 0x74, 0x65, 0x88, 0x01, 0x01, 0x12, 0x35, 0x0a, 0x09, 0x66, 0x6f, 0x72, 0x77, 0x61,
+	0x72, 0x64, 0x65, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x48, 0x02,
+	0x52, 0x09, 0x66, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x65, 0x72, 0x88, 0x01, 0x01, 0x42, 0x0a,
+	0x0a, 0x08, 0x5f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x61,
+	0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x66, 0x6f, 0x72,
+	0x77, 0x61, 0x72, 0x64, 0x65, 0x72, 0x22, 0xad, 0x02, 0x0a, 0x16, 0x41, 0x6e, 0x61, 0x6c, 0x79,
+	0x74, 0x69, 0x63, 0x73, 0x55, 0x70, 0x6c, 0x69, 0x6e, 0x6b, 0x41, 0x6e, 0x74, 0x65, 0x6e, 0x6e,
+	0x61, 0x12, 0x18, 0x0a, 0x07, 0x41, 0x6e, 0x74, 0x65, 0x6e, 0x6e, 0x61, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x07, 0x41, 0x6e, 0x74, 0x65, 0x6e, 0x6e, 0x61, 0x12, 0x16, 0x0a, 0x06, 0x49,
+	0x66, 0x43, 0x68, 0x61, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x49, 0x66, 0x43,
+	0x68, 0x61, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x52, 0x53, 0x53, 0x49, 0x43, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x05, 0x52, 0x53, 0x53, 0x49, 0x43, 0x12, 0x19, 0x0a, 0x05, 0x52, 0x53, 0x53,
+	0x49, 0x53, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x05, 0x52, 0x53, 0x53, 0x49,
+	0x53, 0x88, 0x01, 0x01, 0x12, 0x1b, 0x0a, 0x06, 0x52, 0x53, 0x53, 0x49, 0x53, 0x44, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x05, 0x48, 0x01, 0x52, 0x06, 0x52, 0x53, 0x53, 0x49, 0x53, 0x44, 0x88, 0x01,
+	0x01, 0x12, 0x12, 0x0a, 0x04, 0x4c, 0x53, 0x4e, 0x52, 0x18, 0x06, 0x20, 0x01, 0x28, 0x02, 0x52,
+	0x04, 0x4c, 0x53, 0x4e, 0x52, 0x12, 0x19, 0x0a, 0x05, 0x45, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x07,
+	0x20, 0x01, 0x28, 0x0c, 0x48, 0x02, 0x52, 0x05, 0x45, 0x54, 0x69, 0x6d, 0x65, 0x88, 0x01, 0x01,
+	0x12, 0x19, 0x0a, 0x05, 0x46, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x48,
+	0x03, 0x52, 0x05, 0x46, 0x54, 0x69, 0x6d, 0x65, 0x88, 0x01, 0x01, 0x12, 0x17, 0x0a, 0x04, 0x46,
+	0x6f, 0x66, 0x66, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x48, 0x04, 0x52, 0x04, 0x46, 0x6f, 0x66,
+	0x66, 0x88, 0x01, 0x01, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x52, 0x53, 0x53, 0x49, 0x53, 0x42, 0x09,
+	0x0a, 0x07, 0x5f, 0x52, 0x53, 0x53, 0x49, 0x53, 0x44, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x45, 0x54,
+	0x69, 0x6d, 0x65, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x46, 0x54, 0x69, 0x6d, 0x65, 0x42, 0x07, 0x0a,
+	0x05, 0x5f, 0x46, 0x6f, 0x66, 0x66, 0x22, 0x81, 0x06, 0x0a, 0x0f, 0x41, 0x6e, 0x61, 0x6c, 0x79,
+	0x74, 0x69, 0x63, 0x73, 0x55, 0x70, 0x6c, 0x69, 0x6e, 0x6b, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x78,
+	0x57, 0x61, 0x6c, 0x6c, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a,
+	0x72, 0x78, 0x57, 0x61, 0x6c, 0x6c, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x78,
+	0x47, 0x70, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x72,
+	0x78, 0x47, 0x70, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x78, 0x46, 0x69,
+	0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0e, 0x72, 0x78, 0x46, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65,
+	0x12, 0x1c, 0x0a, 0x09, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x02, 0x52, 0x09, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x18,
+	0x0a, 0x07, 0x72, 0x66, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x07, 0x72, 0x66, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x12, 0x20, 0x0a, 0x03, 0x63, 0x72, 0x63, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0e, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x43, 0x52, 0x43, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x03, 0x63, 0x72, 0x63, 0x12, 0x2f, 0x0a, 0x0a, 0x6d, 0x6f,
+	0x64, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0f,
+	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4d, 0x6f, 0x64, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x0a, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x33, 0x0a, 0x0a, 0x63,
+	0x6f, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x61, 0x74, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x13, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x6f, 0x52, 0x61, 0x43, 0x6f, 0x64, 0x69, 0x6e, 0x67,
+	0x52, 0x61, 0x74, 0x65, 0x52, 0x0a, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x61, 0x74, 0x65,
+	0x12, 0x37, 0x0a, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x6f, 0x52, 0x61,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x6f, 0x52,
+	0x61, 0x44, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x48, 0x00, 0x52, 0x0c, 0x64, 0x61, 0x74,
+	0x61, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x6f, 0x52, 0x61, 0x12, 0x22, 0x0a, 0x0b, 0x64, 0x61, 0x74,
+	0x61, 0x52, 0x61, 0x74, 0x65, 0x46, 0x53, 0x4b, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x00,
+	0x52, 0x0b, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x46, 0x53, 0x4b, 0x12, 0x3d, 0x0a,
+	0x0e, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x52, 0x46, 0x48, 0x53, 0x53, 0x18,
+	0x12, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x52, 0x46, 0x48,
+	0x53, 0x53, 0x44, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x48, 0x00, 0x52, 0x0e, 0x64, 0x61,
+	0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x52, 0x46, 0x48, 0x53, 0x53, 0x12, 0x12, 0x0a, 0x04,
+	0x73, 0x69, 0x7a, 0x65, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65,
+	0x12, 0x12, 0x0a, 0x04, 0x66, 0x68, 0x64, 0x72, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04,
+	0x66, 0x68, 0x64, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x6e, 0x69, 0x71, 0x75, 0x65, 0x49, 0x64,
+	0x18, 0x10, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x75, 0x6e, 0x69, 0x71, 0x75, 0x65, 0x49, 0x64,
+	0x12, 0x2d, 0x0a, 0x03, 0x61, 0x6e, 0x74, 0x18, 0x11, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x55, 0x70, 0x6c,
+	0x69, 0x6e, 0x6b, 0x41, 0x6e, 0x74, 0x65, 0x6e, 0x6e, 0x61, 0x52, 0x03, 0x61, 0x6e, 0x74, 0x12,
+	0x4a, 0x0a, 0x0c, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18,
+	0x13, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x6e, 0x61, 0x6c,
+	0x79, 0x74, 0x69, 0x63, 0x73, 0x55, 0x70, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x43, 0x75, 0x73, 0x74,
+	0x6f, 0x6d, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0c, 0x63,
+	0x75, 0x73, 0x74, 0x6f, 0x6d, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x72,
+	0x78, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x14, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0b, 0x72, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x1a, 0x3f, 0x0a,
+	0x11, 0x43, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x42, 0x0a,
+	0x0a, 0x08, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x22, 0xa6, 0x05, 0x0a, 0x11, 0x41,
+	0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x69, 0x6e, 0x6b,
+	0x12, 0x16, 0x0a, 0x06, 0x74, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x06, 0x74, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x78, 0x47, 0x70,
+	0x73, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x78, 0x47,
+	0x70, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x66, 0x73, 0x6b, 0x46, 0x72, 0x65,
+	0x71, 0x44, 0x65, 0x76, 0x18, 0x03, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0a, 0x66, 0x73, 0x6b, 0x46,
+	0x72, 0x65, 0x71, 0x44, 0x65, 0x76, 0x12, 0x1c, 0x0a, 0x09, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x6e, 0x63, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x02, 0x52, 0x09, 0x66, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x6e, 0x63, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x12, 0x18,
+	0x0a, 0x07, 0x72, 0x66, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x07, 0x72, 0x66, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x6f, 0x77, 0x65,
+	0x72, 0x18, 0x07, 0x20, 0x01, 0x28, 0x02, 0x52, 0x05, 0x70, 0x6f, 0x77, 0x65, 0x72, 0x12, 0x2f,
+	0x0a, 0x0a, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x0f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4d, 0x6f, 0x64, 0x75, 0x6c, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x33, 0x0a, 0x0a, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x61, 0x74, 0x65, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x6f, 0x52, 0x61, 0x43, 0x6f,
+	0x64, 0x69, 0x6e, 0x67, 0x52, 0x61, 0x74, 0x65, 0x52, 0x0a, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67,
+	0x52, 0x61, 0x74, 0x65, 0x12, 0x37, 0x0a, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65,
+	0x4c, 0x6f, 0x52, 0x61, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x4c, 0x6f, 0x52, 0x61, 0x44, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x48, 0x00, 0x52,
+	0x0c, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x6f, 0x52, 0x61, 0x12, 0x22, 0x0a,
+	0x0b, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x46, 0x53, 0x4b, 0x18, 0x0b, 0x20, 0x01,
+	0x28, 0x0d, 0x48, 0x00, 0x52, 0x0b, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x46, 0x53,
+	0x4b, 0x12, 0x26, 0x0a, 0x0e, 0x69, 0x6e, 0x76, 0x65, 0x72, 0x74, 0x50, 0x6f, 0x6c, 0x61, 0x72,
+	0x69, 0x74, 0x79, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x69, 0x6e, 0x76, 0x65, 0x72,
+	0x74, 0x50, 0x6f, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x12, 0x20, 0x0a, 0x0b, 0x69, 0x6d, 0x6d,
+	0x65, 0x64, 0x69, 0x61, 0x74, 0x65, 0x6c, 0x79, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b,
+	0x69, 0x6d, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x74, 0x65, 0x6c, 0x79, 0x12, 0x1e, 0x0a, 0x0a, 0x72,
+	0x66, 0x50, 0x72, 0x65, 0x61, 0x6d, 0x62, 0x6c, 0x65, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x0a, 0x72, 0x66, 0x50, 0x72, 0x65, 0x61, 0x6d, 0x62, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x73,
+	0x69, 0x7a, 0x65, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12,
+	0x12, 0x0a, 0x04, 0x66, 0x68, 0x64, 0x72, 0x18, 0x10, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x66,
+	0x68, 0x64, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x43, 0x72, 0x63, 0x18, 0x11, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x05, 0x6e, 0x6f, 0x43, 0x72, 0x63, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x78, 0x57,
+	0x61, 0x6c, 0x6c, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x12, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x72,
+	0x78, 0x57, 0x61, 0x6c, 0x6c, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x6e, 0x69,
+	0x71, 0x75, 0x65, 0x49, 0x64, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x75, 0x6e, 0x69,
+	0x71, 0x75, 0x65, 0x49, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x74, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x18, 0x14, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x74, 0x78, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x42, 0x0a, 0x0a, 0x08, 0x64, 0x61, 0x74, 0x61, 0x52,
+	0x61, 0x74, 0x65, 0x22, 0xa7, 0x04, 0x0a, 0x0d, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63,
+	0x73, 0x53, 0x74, 0x61, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x67, 0x77, 0x54, 0x69, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x67, 0x77, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1e, 0x0a,
+	0x0a, 0x67, 0x77, 0x4c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x02, 0x52, 0x0a, 0x67, 0x77, 0x4c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12, 0x20, 0x0a,
+	0x0b, 0x67, 0x77, 0x4c, 0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x02, 0x52, 0x0b, 0x67, 0x77, 0x4c, 0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12,
+	0x1e, 0x0a, 0x0a, 0x67, 0x77, 0x41, 0x6c, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x02, 0x52, 0x0a, 0x67, 0x77, 0x41, 0x6c, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12,
+	0x1c, 0x0a, 0x09, 0x72, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x09, 0x72, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x2c, 0x0a,
+	0x11, 0x72, 0x78, 0x57, 0x69, 0x74, 0x68, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x50, 0x68, 0x79, 0x43,
+	0x52, 0x43, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x11, 0x72, 0x78, 0x57, 0x69, 0x74, 0x68,
+	0x56, 0x61, 0x6c, 0x69, 0x64, 0x50, 0x68, 0x79, 0x43, 0x52, 0x43, 0x12, 0x20, 0x0a, 0x0b, 0x72,
+	0x78, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x0b, 0x72, 0x78, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x65, 0x64, 0x12, 0x16, 0x0a,
+	0x06, 0x72, 0x78, 0x41, 0x63, 0x6b, 0x72, 0x18, 0x08, 0x20, 0x01, 0x28, 0x02, 0x52, 0x06, 0x72,
+	0x78, 0x41, 0x63, 0x6b, 0x72, 0x12, 0x1e, 0x0a, 0x0a, 0x74, 0x78, 0x52, 0x65, 0x63, 0x65, 0x69,
+	0x76, 0x65, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x74, 0x78, 0x52, 0x65, 0x63,
+	0x65, 0x69, 0x76, 0x65, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x78, 0x45, 0x6d, 0x69, 0x74, 0x74,
+	0x65, 0x64, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x74, 0x78, 0x45, 0x6d, 0x69, 0x74,
+	0x74, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x69, 0x73, 0x47, 0x61, 0x75, 0x67, 0x65, 0x18, 0x0b,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x69, 0x73, 0x47, 0x61, 0x75, 0x67, 0x65, 0x12, 0x1b, 0x0a,
+	0x06, 0x67, 0x77, 0x54, 0x65, 0x6d, 0x70, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x02, 0x48, 0x00, 0x52,
+	0x06, 0x67, 0x77, 0x54, 0x65, 0x6d, 0x70, 0x88, 0x01, 0x01, 0x12, 0x2c, 0x0a, 0x11, 0x67, 0x77,
+	0x4c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73, 0x65, 0x18,
+	0x0d, 0x20, 0x01, 0x28, 0x01, 0x52, 0x11, 0x67, 0x77, 0x4c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64,
+	0x65, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73, 0x65, 0x12, 0x2e, 0x0a, 0x12, 0x67, 0x77, 0x4c, 0x6f,
+	0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73, 0x65, 0x18, 0x0e,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x12, 0x67, 0x77, 0x4c, 0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64,
+	0x65, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73, 0x65, 0x12, 0x27, 0x0a, 0x0c, 0x67, 0x77, 0x43, 0x6c,
+	0x6f, 0x63, 0x6b, 0x44, 0x72, 0x69, 0x66, 0x74, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x02, 0x48, 0x01,
+	0x52, 0x0c, 0x67, 0x77, 0x43, 0x6c, 0x6f, 0x63, 0x6b, 0x44, 0x72, 0x69, 0x66, 0x74, 0x88, 0x01,
+	0x01, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x67, 0x77, 0x54, 0x65, 0x6d, 0x70, 0x42, 0x0f, 0x0a, 0x0d,
+	0x5f, 0x67, 0x77, 0x43, 0x6c, 0x6f, 0x63, 0x6b, 0x44, 0x72, 0x69, 0x66, 0x74, 0x22, 0xc4, 0x01,
+	0x0a, 0x12, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x41, 0x67, 0x67, 0x72, 0x65,
+	0x67, 0x61, 0x74, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69,
+	0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x6e, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x07, 0x65, 0x6e, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x37, 0x0a, 0x07,
+	0x75, 0x70, 0x6c, 0x69, 0x6e, 0x6b, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1d, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x55, 0x70, 0x6c,
+	0x69, 0x6e, 0x6b, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x52, 0x07, 0x75, 0x70,
+	0x6c, 0x69, 0x6e, 0x6b, 0x73, 0x12, 0x3d, 0x0a, 0x09, 0x64, 0x6f, 0x77, 0x6e, 0x6c, 0x69, 0x6e,
+	0x6b, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x41,
+	0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x69, 0x6e, 0x6b,
+	0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x52, 0x09, 0x64, 0x6f, 0x77, 0x6e, 0x6c,
+	0x69, 0x6e, 0x6b, 0x73, 0x22, 0xa9, 0x03, 0x0a, 0x18, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69,
+	0x63, 0x73, 0x55, 0x70, 0x6c, 0x69, 0x6e, 0x6b, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74,
+	0x65, 0x12, 0x2f, 0x0a, 0x0a, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4d, 0x6f, 0x64, 0x75,
+	0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x37, 0x0a, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x6f,
+	0x52, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c,
+	0x6f, 0x52, 0x61, 0x44, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x48, 0x00, 0x52, 0x0c, 0x64,
+	0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x6f, 0x52, 0x61, 0x12, 0x22, 0x0a, 0x0b, 0x64,
+	0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x46, 0x53, 0x4b, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d,
+	0x48, 0x00, 0x52, 0x0b, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x46, 0x53, 0x4b, 0x12,
+	0x3d, 0x0a, 0x0e, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x52, 0x46, 0x48, 0x53,
+	0x53, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x52,
+	0x46, 0x48, 0x53, 0x53, 0x44, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x48, 0x00, 0x52, 0x0e,
+	0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x52, 0x46, 0x48, 0x53, 0x53, 0x12, 0x1c,
+	0x0a, 0x09, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x02, 0x52, 0x09, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x20, 0x0a, 0x03,
+	0x63, 0x72, 0x63, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0e, 0x2e, 0x61, 0x70, 0x69, 0x2e,
+	0x43, 0x52, 0x43, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x03, 0x63, 0x72, 0x63, 0x12, 0x14,
+	0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x69, 0x72, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x61, 0x69, 0x72, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x12,
+	0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x73, 0x69,
+	0x7a, 0x65, 0x12, 0x30, 0x0a, 0x03, 0x61, 0x6e, 0x74, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x1e, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x41,
+	0x6e, 0x74, 0x65, 0x6e, 0x6e, 0x61, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x52,
+	0x03, 0x61, 0x6e, 0x74, 0x42, 0x0a, 0x0a, 0x08, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65,
+	0x22, 0x8d, 0x01, 0x0a, 0x19, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x41, 0x6e,
+	0x74, 0x65, 0x6e, 0x6e, 0x61, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x12, 0x18,
+	0x0a, 0x07, 0x61, 0x6e, 0x74, 0x65, 0x6e, 0x6e, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x07, 0x61, 0x6e, 0x74, 0x65, 0x6e, 0x6e, 0x61, 0x12, 0x2b, 0x0a, 0x04, 0x72, 0x73, 0x73, 0x69,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x6e, 0x61,
+	0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x52,
+	0x04, 0x72, 0x73, 0x73, 0x69, 0x12, 0x29, 0x0a, 0x03, 0x73, 0x6e, 0x72, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x17, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69,
+	0x63, 0x73, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x52, 0x03, 0x73, 0x6e, 0x72,
+	0x22, 0x98, 0x02, 0x0a, 0x1a, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x44, 0x6f,
+	0x77, 0x6e, 0x6c, 0x69, 0x6e, 0x6b, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x12,
+	0x2f, 0x0a, 0x0a, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x0f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4d, 0x6f, 0x64, 0x75, 0x6c, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x37, 0x0a, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x6f, 0x52, 0x61,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x6f, 0x52,
+	0x61, 0x44, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x48, 0x00, 0x52, 0x0c, 0x64, 0x61, 0x74,
+	0x61, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x6f, 0x52, 0x61, 0x12, 0x22, 0x0a, 0x0b, 0x64, 0x61, 0x74,
+	0x61, 0x52, 0x61, 0x74, 0x65, 0x46, 0x53, 0x4b, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x00,
+	0x52, 0x0b, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x46, 0x53, 0x4b, 0x12, 0x1c, 0x0a,
 	0x09, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x02,
-	0x52, 0x09, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x72,
-	0x66, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x72, 0x66,
-	0x43, 0x68, 0x61, 0x69, 0x6e, 0x12, 0x20, 0x0a, 0x03, 0x63, 0x72, 0x63, 0x18, 0x07, 0x20, 0x01,
-	0x28, 0x0e, 0x32, 0x0e, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x43, 0x52, 0x43, 0x53, 0x74, 0x61, 0x74,
-	0x75, 0x73, 0x52, 0x03, 0x63, 0x72, 0x63, 0x12, 0x2f, 0x0a, 0x0a, 0x6d, 0x6f, 0x64, 0x75, 0x6c,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0f, 0x2e, 0x61, 0x70,
-	0x69, 0x2e, 0x4d, 0x6f, 0x64, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x6d, 0x6f,
-	0x64, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x33, 0x0a, 0x0a, 0x63, 0x6f, 0x64, 0x69,
-	0x6e, 0x67, 0x52, 0x61, 0x74, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x61,
-	0x70, 0x69, 0x2e, 0x4c, 0x6f, 0x52, 0x61, 0x43, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x61, 0x74,
-	0x65, 0x52, 0x0a, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x61, 0x74, 0x65, 0x12, 0x37, 0x0a,
-	0x0c, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x6f, 0x52, 0x61, 0x18, 0x0a, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x6f, 0x52, 0x61, 0x44, 0x61,
-	0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x48, 0x00, 0x52, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61,
-	0x74, 0x65, 0x4c, 0x6f, 0x52, 0x61, 0x12, 0x22, 0x0a, 0x0b, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61,
-	0x74, 0x65, 0x46, 0x53, 0x4b, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x00, 0x52, 0x0b, 0x64,
-	0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x46, 0x53, 0x4b, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69,
-	0x7a, 0x65, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x12,
-	0x0a, 0x04, 0x66, 0x68, 0x64, 0x72, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x66, 0x68,
-	0x64, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x6e, 0x69, 0x71, 0x75, 0x65, 0x49, 0x64, 0x18, 0x10,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x75, 0x6e, 0x69, 0x71, 0x75, 0x65, 0x49, 0x64, 0x12, 0x2d,
-	0x0a, 0x03, 0x61, 0x6e, 0x74, 0x18, 0x11, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x61, 0x70,
-	0x69, 0x2e, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x55, 0x70, 0x6c, 0x69, 0x6e,
-	0x6b, 0x41, 0x6e, 0x74, 0x65, 0x6e, 0x6e, 0x61, 0x52, 0x03, 0x61, 0x6e, 0x74, 0x42, 0x0a, 0x0a,
-	0x08, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x22, 0x84, 0x05, 0x0a, 0x11, 0x41, 0x6e,
-	0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x69, 0x6e, 0x6b, 0x12,
-	0x16, 0x0a, 0x06, 0x74, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
-	0x06, 0x74, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x78, 0x47, 0x70, 0x73,
-	0x54, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x78, 0x47, 0x70,
-	0x73, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x66, 0x73, 0x6b, 0x46, 0x72, 0x65, 0x71,
-	0x44, 0x65, 0x76, 0x18, 0x03, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0a, 0x66, 0x73, 0x6b, 0x46, 0x72,
-	0x65, 0x71, 0x44, 0x65, 0x76, 0x12, 0x1c, 0x0a, 0x09, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e,
-	0x63, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x02, 0x52, 0x09, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65,
-	0x6e, 0x63, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x18, 0x05,
-	0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x12, 0x18, 0x0a,
-	0x07, 0x72, 0x66, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07,
-	0x72, 0x66, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x6f, 0x77, 0x65, 0x72,
-	0x18, 0x07, 0x20, 0x01, 0x28, 0x02, 0x52, 0x05, 0x70, 0x6f, 0x77, 0x65, 0x72, 0x12, 0x2f, 0x0a,
-	0x0a, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x08, 0x20, 0x01, 0x28,
-	0x0e, 0x32, 0x0f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4d, 0x6f, 0x64, 0x75, 0x6c, 0x61, 0x74, 0x69,
-	0x6f, 0x6e, 0x52, 0x0a, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x33,
-	0x0a, 0x0a, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x61, 0x74, 0x65, 0x18, 0x09, 0x20, 0x01,
-	0x28, 0x0e, 0x32, 0x13, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x6f, 0x52, 0x61, 0x43, 0x6f, 0x64,
-	0x69, 0x6e, 0x67, 0x52, 0x61, 0x74, 0x65, 0x52, 0x0a, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x52,
-	0x61, 0x74, 0x65, 0x12, 0x37, 0x0a, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x4c,
-	0x6f, 0x52, 0x61, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x69, 0x2e,
-	0x4c, 0x6f, 0x52, 0x61, 0x44, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x48, 0x00, 0x52, 0x0c,
-	0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x6f, 0x52, 0x61, 0x12, 0x22, 0x0a, 0x0b,
-	0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x46, 0x53, 0x4b, 0x18, 0x0b, 0x20, 0x01, 0x28,
-	0x0d, 0x48, 0x00, 0x52, 0x0b, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x46, 0x53, 0x4b,
-	0x12, 0x26, 0x0a, 0x0e, 0x69, 0x6e, 0x76, 0x65, 0x72, 0x74, 0x50, 0x6f, 0x6c, 0x61, 0x72, 0x69,
-	0x74, 0x79, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x69, 0x6e, 0x76, 0x65, 0x72, 0x74,
-	0x50, 0x6f, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x12, 0x20, 0x0a, 0x0b, 0x69, 0x6d, 0x6d, 0x65,
-	0x64, 0x69, 0x61, 0x74, 0x65, 0x6c, 0x79, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x69,
-	0x6d, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x74, 0x65, 0x6c, 0x79, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x66,
-	0x50, 0x72, 0x65, 0x61, 0x6d, 0x62, 0x6c, 0x65, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a,
-	0x72, 0x66, 0x50, 0x72, 0x65, 0x61, 0x6d, 0x62, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69,
-	0x7a, 0x65, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x12,
-	0x0a, 0x04, 0x66, 0x68, 0x64, 0x72, 0x18, 0x10, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x66, 0x68,
-	0x64, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x43, 0x72, 0x63, 0x18, 0x11, 0x20, 0x01, 0x28,
-	0x08, 0x52, 0x05, 0x6e, 0x6f, 0x43, 0x72, 0x63, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x78, 0x57, 0x61,
-	0x6c, 0x6c, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x12, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x72, 0x78,
-	0x57, 0x61, 0x6c, 0x6c, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x6e, 0x69, 0x71,
-	0x75, 0x65, 0x49, 0x64, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x75, 0x6e, 0x69, 0x71,
-	0x75, 0x65, 0x49, 0x64, 0x42, 0x0a, 0x0a, 0x08, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65,
-	0x22, 0x8f, 0x03, 0x0a, 0x0d, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x53, 0x74,
-	0x61, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x67, 0x77, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x03, 0x52, 0x06, 0x67, 0x77, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x67, 0x77,
-	0x4c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0a,
-	0x67, 0x77, 0x4c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x67, 0x77,
-	0x4c, 0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x02, 0x52,
-	0x0b, 0x67, 0x77, 0x4c, 0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12, 0x1e, 0x0a, 0x0a,
-	0x67, 0x77, 0x41, 0x6c, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x02,
-	0x52, 0x0a, 0x67, 0x77, 0x41, 0x6c, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12, 0x1c, 0x0a, 0x09,
-	0x72, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52,
-	0x09, 0x72, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x2c, 0x0a, 0x11, 0x72, 0x78,
-	0x57, 0x69, 0x74, 0x68, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x50, 0x68, 0x79, 0x43, 0x52, 0x43, 0x18,
-	0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x11, 0x72, 0x78, 0x57, 0x69, 0x74, 0x68, 0x56, 0x61, 0x6c,
-	0x69, 0x64, 0x50, 0x68, 0x79, 0x43, 0x52, 0x43, 0x12, 0x20, 0x0a, 0x0b, 0x72, 0x78, 0x46, 0x6f,
-	0x72, 0x77, 0x61, 0x72, 0x64, 0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x72,
-	0x78, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x78,
-	0x41, 0x63, 0x6b, 0x72, 0x18, 0x08, 0x20, 0x01, 0x28, 0x02, 0x52, 0x06, 0x72, 0x78, 0x41, 0x63,
-	0x6b, 0x72, 0x12, 0x1e, 0x0a, 0x0a, 0x74, 0x78, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64,
-	0x18, 0x09, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x74, 0x78, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76,
-	0x65, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x78, 0x45, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x18,
-	0x0a, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x74, 0x78, 0x45, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64,
-	0x12, 0x18, 0x0a, 0x07, 0x69, 0x73, 0x47, 0x61, 0x75, 0x67, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28,
-	0x08, 0x52, 0x07, 0x69, 0x73, 0x47, 0x61, 0x75, 0x67, 0x65, 0x12, 0x1b, 0x0a, 0x06, 0x67, 0x77,
-	0x54, 0x65, 0x6d, 0x70, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x02, 0x48, 0x00, 0x52, 0x06, 0x67, 0x77,
-	0x54, 0x65, 0x6d, 0x70, 0x88, 0x01, 0x01, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x67, 0x77, 0x54, 0x65,
-	0x6d, 0x70, 0x22, 0x88, 0x03, 0x0a, 0x18, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73,
-	0x49, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12,
-	0x1c, 0x0a, 0x09, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x49, 0x70, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x09, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x49, 0x70, 0x12, 0x20, 0x0a,
-	0x0b, 0x75, 0x70, 0x52, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0d, 0x52, 0x0b, 0x75, 0x70, 0x52, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12,
-	0x20, 0x0a, 0x0b, 0x75, 0x70, 0x54, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x03,
-	0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x75, 0x70, 0x54, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74,
-	0x73, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x6e, 0x52, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73,
-	0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x64, 0x6e, 0x52, 0x78, 0x50, 0x61, 0x63, 0x6b,
-	0x65, 0x74, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x6e, 0x54, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65,
-	0x74, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x64, 0x6e, 0x54, 0x78, 0x50, 0x61,
-	0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x6b, 0x74, 0x50, 0x55, 0x53, 0x48,
-	0x5f, 0x44, 0x41, 0x54, 0x41, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x70, 0x6b, 0x74,
-	0x50, 0x55, 0x53, 0x48, 0x44, 0x41, 0x54, 0x41, 0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x6b, 0x74, 0x50,
-	0x55, 0x53, 0x48, 0x5f, 0x41, 0x43, 0x4b, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x70,
-	0x6b, 0x74, 0x50, 0x55, 0x53, 0x48, 0x41, 0x43, 0x4b, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x6b, 0x74,
-	0x50, 0x55, 0x4c, 0x4c, 0x5f, 0x44, 0x41, 0x54, 0x41, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0d, 0x52,
-	0x0b, 0x70, 0x6b, 0x74, 0x50, 0x55, 0x4c, 0x4c, 0x44, 0x41, 0x54, 0x41, 0x12, 0x1f, 0x0a, 0x0b,
-	0x70, 0x6b, 0x74, 0x50, 0x55, 0x4c, 0x4c, 0x5f, 0x41, 0x43, 0x4b, 0x18, 0x09, 0x20, 0x01, 0x28,
-	0x0d, 0x52, 0x0a, 0x70, 0x6b, 0x74, 0x50, 0x55, 0x4c, 0x4c, 0x41, 0x43, 0x4b, 0x12, 0x21, 0x0a,
-	0x0c, 0x70, 0x6b, 0x74, 0x50, 0x55, 0x4c, 0x4c, 0x5f, 0x52, 0x45, 0x53, 0x50, 0x18, 0x0a, 0x20,
-	0x01, 0x28, 0x0d, 0x52, 0x0b, 0x70, 0x6b, 0x74, 0x50, 0x55, 0x4c, 0x4c, 0x52, 0x45, 0x53, 0x50,
-	0x12, 0x1b, 0x0a, 0x09, 0x70, 0x6b, 0x74, 0x54, 0x58, 0x5f, 0x41, 0x43, 0x4b, 0x18, 0x0b, 0x20,
-	0x01, 0x28, 0x0d, 0x52, 0x08, 0x70, 0x6b, 0x74, 0x54, 0x58, 0x41, 0x43, 0x4b, 0x22, 0x70, 0x0a,
-	0x0c, 0x4c, 0x6f, 0x52, 0x61, 0x44, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x12, 0x35, 0x0a,
-	0x0f, 0x73, 0x70, 0x72, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x46, 0x61, 0x63, 0x74, 0x6f, 0x72,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x6f, 0x52,
-	0x61, 0x53, 0x46, 0x52, 0x0f, 0x73, 0x70, 0x72, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x46, 0x61,
-	0x63, 0x74, 0x6f, 0x72, 0x12, 0x29, 0x0a, 0x09, 0x62, 0x61, 0x6e, 0x64, 0x77, 0x69, 0x64, 0x74,
-	0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x6f,
-	0x52, 0x61, 0x42, 0x57, 0x52, 0x09, 0x62, 0x61, 0x6e, 0x64, 0x77, 0x69, 0x64, 0x74, 0x68, 0x2a,
-	0x2a, 0x0a, 0x09, 0x43, 0x52, 0x43, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07,
-	0x4d, 0x49, 0x53, 0x53, 0x49, 0x4e, 0x47, 0x10, 0x00, 0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4b, 0x10,
-	0x01, 0x12, 0x08, 0x0a, 0x04, 0x46, 0x41, 0x49, 0x4c, 0x10, 0x02, 0x2a, 0x2c, 0x0a, 0x0a, 0x4d,
-	0x6f, 0x64, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b,
-	0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x4c, 0x4f, 0x52, 0x41, 0x10, 0x01,
-	0x12, 0x07, 0x0a, 0x03, 0x46, 0x53, 0x4b, 0x10, 0x02, 0x2a, 0xc3, 0x01, 0x0a, 0x0e, 0x4c, 0x6f,
-	0x52, 0x61, 0x43, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x61, 0x74, 0x65, 0x12, 0x0a, 0x0a, 0x06,
-	0x43, 0x52, 0x5f, 0x4f, 0x46, 0x46, 0x10, 0x00, 0x12, 0x0e, 0x0a, 0x0a, 0x43, 0x52, 0x5f, 0x55,
-	0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x52, 0x5f, 0x34,
-	0x5f, 0x35, 0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x52, 0x5f, 0x34, 0x5f, 0x36, 0x10, 0x03,
-	0x12, 0x0a, 0x0a, 0x06, 0x43, 0x52, 0x5f, 0x34, 0x5f, 0x37, 0x10, 0x04, 0x12, 0x0a, 0x0a, 0x06,
-	0x43, 0x52, 0x5f, 0x34, 0x5f, 0x38, 0x10, 0x05, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x52, 0x5f, 0x34,
-	0x5f, 0x39, 0x10, 0x06, 0x12, 0x0b, 0x0a, 0x07, 0x43, 0x52, 0x5f, 0x34, 0x5f, 0x31, 0x30, 0x10,
-	0x07, 0x12, 0x0b, 0x0a, 0x07, 0x43, 0x52, 0x5f, 0x34, 0x5f, 0x31, 0x31, 0x10, 0x08, 0x12, 0x0b,
-	0x0a, 0x07, 0x43, 0x52, 0x5f, 0x34, 0x5f, 0x31, 0x32, 0x10, 0x09, 0x12, 0x0b, 0x0a, 0x07, 0x43,
-	0x52, 0x5f, 0x34, 0x5f, 0x31, 0x33, 0x10, 0x0a, 0x12, 0x0b, 0x0a, 0x07, 0x43, 0x52, 0x5f, 0x34,
-	0x5f, 0x31, 0x34, 0x10, 0x0b, 0x12, 0x0b, 0x0a, 0x07, 0x43, 0x52, 0x5f, 0x34, 0x5f, 0x31, 0x35,
-	0x10, 0x0c, 0x12, 0x0b, 0x0a, 0x07, 0x43, 0x52, 0x5f, 0x34, 0x5f, 0x31, 0x36, 0x10, 0x0d, 0x2a,
-	0x51, 0x0a, 0x06, 0x4c, 0x6f, 0x52, 0x61, 0x53, 0x46, 0x12, 0x0e, 0x0a, 0x0a, 0x53, 0x46, 0x5f,
-	0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x53, 0x46, 0x31,
-	0x32, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x53, 0x46, 0x31, 0x31, 0x10, 0x02, 0x12, 0x08, 0x0a,
-	0x04, 0x53, 0x46, 0x31, 0x30, 0x10, 0x03, 0x12, 0x07, 0x0a, 0x03, 0x53, 0x46, 0x39, 0x10, 0x04,
-	0x12, 0x07, 0x0a, 0x03, 0x53, 0x46, 0x38, 0x10, 0x05, 0x12, 0x07, 0x0a, 0x03, 0x53, 0x46, 0x37,
-	0x10, 0x06, 0x2a, 0x3f, 0x0a, 0x06, 0x4c, 0x6f, 0x52, 0x61, 0x42, 0x57, 0x12, 0x0e, 0x0a, 0x0a,
+	0x52, 0x09, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e,
+	0x74, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x69, 0x72, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x07, 0x61, 0x69, 0x72, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x73,
+	0x69, 0x7a, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x42,
+	0x0a, 0x0a, 0x08, 0x64, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x22, 0x58, 0x0a, 0x12, 0x41,
+	0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x67, 0x72, 0x61,
+	0x6d, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x02,
+	0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x02, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x12, 0x16, 0x0a,
+	0x06, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x06, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x73, 0x22, 0xa2, 0x02, 0x0a, 0x15, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74,
+	0x69, 0x63, 0x73, 0x47, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12,
+	0x12, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x74,
+	0x69, 0x6d, 0x65, 0x12, 0x29, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x15, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x47, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x1a,
+	0x0a, 0x08, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x08, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x6c, 0x6f,
+	0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x6c,
+	0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x61, 0x6c, 0x74, 0x69,
+	0x74, 0x75, 0x64, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x02, 0x52, 0x08, 0x61, 0x6c, 0x74, 0x69,
+	0x74, 0x75, 0x64, 0x65, 0x12, 0x2a, 0x0a, 0x10, 0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73,
+	0x4c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x52, 0x10,
+	0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x4c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65,
+	0x12, 0x2c, 0x0a, 0x11, 0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75, 0x73, 0x4c, 0x6f, 0x6e, 0x67,
+	0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x01, 0x52, 0x11, 0x70, 0x72, 0x65,
+	0x76, 0x69, 0x6f, 0x75, 0x73, 0x4c, 0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12, 0x1a,
+	0x0a, 0x08, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x02,
+	0x52, 0x08, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x22, 0x90, 0x05, 0x0a, 0x18, 0x41,
+	0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c,
+	0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x67, 0x61, 0x74, 0x65, 0x77,
+	0x61, 0x79, 0x49, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x67, 0x61, 0x74, 0x65,
+	0x77, 0x61, 0x79, 0x49, 0x70, 0x12, 0x20, 0x0a, 0x0b, 0x75, 0x70, 0x52, 0x78, 0x50, 0x61, 0x63,
+	0x6b, 0x65, 0x74, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x75, 0x70, 0x52, 0x78,
+	0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x75, 0x70, 0x54, 0x78, 0x50,
+	0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x75, 0x70,
+	0x54, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x6e, 0x52,
+	0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b,
+	0x64, 0x6e, 0x52, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x64,
+	0x6e, 0x54, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x0b, 0x64, 0x6e, 0x54, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x21, 0x0a,
+	0x0c, 0x70, 0x6b, 0x74, 0x50, 0x55, 0x53, 0x48, 0x5f, 0x44, 0x41, 0x54, 0x41, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x0b, 0x70, 0x6b, 0x74, 0x50, 0x55, 0x53, 0x48, 0x44, 0x41, 0x54, 0x41,
+	0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x6b, 0x74, 0x50, 0x55, 0x53, 0x48, 0x5f, 0x41, 0x43, 0x4b, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x70, 0x6b, 0x74, 0x50, 0x55, 0x53, 0x48, 0x41, 0x43,
+	0x4b, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x6b, 0x74, 0x50, 0x55, 0x4c, 0x4c, 0x5f, 0x44, 0x41, 0x54,
+	0x41, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x70, 0x6b, 0x74, 0x50, 0x55, 0x4c, 0x4c,
+	0x44, 0x41, 0x54, 0x41, 0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x6b, 0x74, 0x50, 0x55, 0x4c, 0x4c, 0x5f,
+	0x41, 0x43, 0x4b, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x70, 0x6b, 0x74, 0x50, 0x55,
+	0x4c, 0x4c, 0x41, 0x43, 0x4b, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x6b, 0x74, 0x50, 0x55, 0x4c, 0x4c,
+	0x5f, 0x52, 0x45, 0x53, 0x50, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x70, 0x6b, 0x74,
+	0x50, 0x55, 0x4c, 0x4c, 0x52, 0x45, 0x53, 0x50, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x6b, 0x74, 0x54,
+	0x58, 0x5f, 0x41, 0x43, 0x4b, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x70, 0x6b, 0x74,
+	0x54, 0x58, 0x41, 0x43, 0x4b, 0x12, 0x2e, 0x0a, 0x12, 0x6d, 0x61, 0x6c, 0x66, 0x6f, 0x72, 0x6d,
+	0x65, 0x64, 0x54, 0x72, 0x75, 0x6e, 0x63, 0x61, 0x74, 0x65, 0x64, 0x18, 0x0c, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x12, 0x6d, 0x61, 0x6c, 0x66, 0x6f, 0x72, 0x6d, 0x65, 0x64, 0x54, 0x72, 0x75, 0x6e,
+	0x63, 0x61, 0x74, 0x65, 0x64, 0x12, 0x30, 0x0a, 0x13, 0x6d, 0x61, 0x6c, 0x66, 0x6f, 0x72, 0x6d,
+	0x65, 0x64, 0x42, 0x61, 0x64, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x0d, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x13, 0x6d, 0x61, 0x6c, 0x66, 0x6f, 0x72, 0x6d, 0x65, 0x64, 0x42, 0x61, 0x64,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x32, 0x0a, 0x14, 0x6d, 0x61, 0x6c, 0x66, 0x6f,
+	0x72, 0x6d, 0x65, 0x64, 0x55, 0x6e, 0x6b, 0x6e, 0x6f, 0x77, 0x6e, 0x4b, 0x69, 0x6e, 0x64, 0x18,
+	0x0e, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x14, 0x6d, 0x61, 0x6c, 0x66, 0x6f, 0x72, 0x6d, 0x65, 0x64,
+	0x55, 0x6e, 0x6b, 0x6e, 0x6f, 0x77, 0x6e, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x2a, 0x0a, 0x10, 0x6d,
+	0x61, 0x6c, 0x66, 0x6f, 0x72, 0x6d, 0x65, 0x64, 0x42, 0x61, 0x64, 0x4a, 0x53, 0x4f, 0x4e, 0x18,
+	0x0f, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x10, 0x6d, 0x61, 0x6c, 0x66, 0x6f, 0x72, 0x6d, 0x65, 0x64,
+	0x42, 0x61, 0x64, 0x4a, 0x53, 0x4f, 0x4e, 0x12, 0x24, 0x0a, 0x0d, 0x74, 0x6d, 0x73, 0x74, 0x52,
+	0x6f, 0x6c, 0x6c, 0x6f, 0x76, 0x65, 0x72, 0x73, 0x18, 0x10, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d,
+	0x74, 0x6d, 0x73, 0x74, 0x52, 0x6f, 0x6c, 0x6c, 0x6f, 0x76, 0x65, 0x72, 0x73, 0x12, 0x1e, 0x0a,
+	0x0a, 0x74, 0x6d, 0x73, 0x74, 0x52, 0x65, 0x73, 0x65, 0x74, 0x73, 0x18, 0x11, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x0a, 0x74, 0x6d, 0x73, 0x74, 0x52, 0x65, 0x73, 0x65, 0x74, 0x73, 0x22, 0x84, 0x06,
+	0x0a, 0x0d, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x12,
+	0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x76,
+	0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x76,
+	0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x75, 0x70, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x75, 0x70, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x0e, 0x0a,
+	0x02, 0x6f, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x6f, 0x73, 0x12, 0x12, 0x0a,
+	0x04, 0x61, 0x72, 0x63, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x63,
+	0x68, 0x12, 0x1c, 0x0a, 0x09, 0x67, 0x6f, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x67, 0x6f, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12,
+	0x1c, 0x0a, 0x09, 0x75, 0x70, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x73, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x09, 0x75, 0x70, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x73, 0x12, 0x1c, 0x0a,
+	0x09, 0x64, 0x6e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x09, 0x64, 0x6e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x73, 0x12, 0x28, 0x0a, 0x0f, 0x73,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x45, 0x76, 0x69, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x09,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x0f, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x45, 0x76, 0x69, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x71, 0x75, 0x65, 0x75, 0x65, 0x44, 0x65,
+	0x70, 0x74, 0x68, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x71, 0x75, 0x65, 0x75, 0x65,
+	0x44, 0x65, 0x70, 0x74, 0x68, 0x12, 0x2a, 0x0a, 0x10, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64,
+	0x44, 0x61, 0x74, 0x61, 0x67, 0x72, 0x61, 0x6d, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x10, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x44, 0x61, 0x74, 0x61, 0x67, 0x72, 0x61, 0x6d,
+	0x73, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x75, 0x73, 0x68, 0x65, 0x73, 0x18, 0x0c, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x06, 0x70, 0x75, 0x73, 0x68, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x70, 0x75, 0x73,
+	0x68, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x70,
+	0x75, 0x73, 0x68, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x6c, 0x61, 0x73,
+	0x74, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x18, 0x0e, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x6c,
+	0x61, 0x73, 0x74, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x12, 0x36, 0x0a, 0x06, 0x63, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x18, 0x0f, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x61, 0x70, 0x69, 0x2e,
+	0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x2e, 0x43, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x12, 0x24, 0x0a, 0x0d, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x56, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x10, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x2e, 0x0a, 0x12, 0x6d, 0x61, 0x6c, 0x66, 0x6f,
+	0x72, 0x6d, 0x65, 0x64, 0x54, 0x72, 0x75, 0x6e, 0x63, 0x61, 0x74, 0x65, 0x64, 0x18, 0x11, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x12, 0x6d, 0x61, 0x6c, 0x66, 0x6f, 0x72, 0x6d, 0x65, 0x64, 0x54, 0x72,
+	0x75, 0x6e, 0x63, 0x61, 0x74, 0x65, 0x64, 0x12, 0x30, 0x0a, 0x13, 0x6d, 0x61, 0x6c, 0x66, 0x6f,
+	0x72, 0x6d, 0x65, 0x64, 0x42, 0x61, 0x64, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x12,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x13, 0x6d, 0x61, 0x6c, 0x66, 0x6f, 0x72, 0x6d, 0x65, 0x64, 0x42,
+	0x61, 0x64, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x32, 0x0a, 0x14, 0x6d, 0x61, 0x6c,
+	0x66, 0x6f, 0x72, 0x6d, 0x65, 0x64, 0x55, 0x6e, 0x6b, 0x6e, 0x6f, 0x77, 0x6e, 0x4b, 0x69, 0x6e,
+	0x64, 0x18, 0x13, 0x20, 0x01, 0x28, 0x04, 0x52, 0x14, 0x6d, 0x61, 0x6c, 0x66, 0x6f, 0x72, 0x6d,
+	0x65, 0x64, 0x55, 0x6e, 0x6b, 0x6e, 0x6f, 0x77, 0x6e, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x2a, 0x0a,
+	0x10, 0x6d, 0x61, 0x6c, 0x66, 0x6f, 0x72, 0x6d, 0x65, 0x64, 0x42, 0x61, 0x64, 0x4a, 0x53, 0x4f,
+	0x4e, 0x18, 0x14, 0x20, 0x01, 0x28, 0x04, 0x52, 0x10, 0x6d, 0x61, 0x6c, 0x66, 0x6f, 0x72, 0x6d,
+	0x65, 0x64, 0x42, 0x61, 0x64, 0x4a, 0x53, 0x4f, 0x4e, 0x1a, 0x39, 0x0a, 0x0b, 0x43, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x3a, 0x02, 0x38, 0x01, 0x22, 0x70, 0x0a, 0x0c, 0x4c, 0x6f, 0x52, 0x61, 0x44, 0x61, 0x74, 0x61,
+	0x52, 0x61, 0x74, 0x65, 0x12, 0x35, 0x0a, 0x0f, 0x73, 0x70, 0x72, 0x65, 0x61, 0x64, 0x69, 0x6e,
+	0x67, 0x46, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0b, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x4c, 0x6f, 0x52, 0x61, 0x53, 0x46, 0x52, 0x0f, 0x73, 0x70, 0x72, 0x65,
+	0x61, 0x64, 0x69, 0x6e, 0x67, 0x46, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x29, 0x0a, 0x09, 0x62,
+	0x61, 0x6e, 0x64, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0b,
+	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x6f, 0x52, 0x61, 0x42, 0x57, 0x52, 0x09, 0x62, 0x61, 0x6e,
+	0x64, 0x77, 0x69, 0x64, 0x74, 0x68, 0x22, 0x5c, 0x0a, 0x0e, 0x4c, 0x52, 0x46, 0x48, 0x53, 0x53,
+	0x44, 0x61, 0x74, 0x61, 0x52, 0x61, 0x74, 0x65, 0x12, 0x26, 0x0a, 0x0e, 0x6d, 0x6f, 0x64, 0x75,
+	0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x0e, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65,
+	0x12, 0x22, 0x0a, 0x0c, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x57, 0x69, 0x64, 0x74, 0x68,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x63, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x57,
+	0x69, 0x64, 0x74, 0x68, 0x2a, 0x2a, 0x0a, 0x09, 0x43, 0x52, 0x43, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x12, 0x0b, 0x0a, 0x07, 0x4d, 0x49, 0x53, 0x53, 0x49, 0x4e, 0x47, 0x10, 0x00, 0x12, 0x06,
+	0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x46, 0x41, 0x49, 0x4c, 0x10, 0x02,
+	0x2a, 0x7a, 0x0a, 0x10, 0x47, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x45, 0x76, 0x65, 0x6e, 0x74,
+	0x54, 0x79, 0x70, 0x65, 0x12, 0x11, 0x0a, 0x0d, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x55, 0x4e,
+	0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x15, 0x0a, 0x11, 0x50, 0x4f, 0x53, 0x49, 0x54,
+	0x49, 0x4f, 0x4e, 0x5f, 0x41, 0x43, 0x51, 0x55, 0x49, 0x52, 0x45, 0x44, 0x10, 0x01, 0x12, 0x12,
+	0x0a, 0x0e, 0x50, 0x4f, 0x53, 0x49, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x4d, 0x4f, 0x56, 0x45, 0x44,
+	0x10, 0x02, 0x12, 0x11, 0x0a, 0x0d, 0x47, 0x50, 0x53, 0x5f, 0x4c, 0x4f, 0x43, 0x4b, 0x5f, 0x4c,
+	0x4f, 0x53, 0x54, 0x10, 0x03, 0x12, 0x15, 0x0a, 0x11, 0x47, 0x50, 0x53, 0x5f, 0x4c, 0x4f, 0x43,
+	0x4b, 0x5f, 0x52, 0x45, 0x53, 0x54, 0x4f, 0x52, 0x45, 0x44, 0x10, 0x04, 0x2a, 0x39, 0x0a, 0x0a,
+	0x4d, 0x6f, 0x64, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e,
+	0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x4c, 0x4f, 0x52, 0x41, 0x10,
+	0x01, 0x12, 0x07, 0x0a, 0x03, 0x46, 0x53, 0x4b, 0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07, 0x4c, 0x52,
+	0x5f, 0x46, 0x48, 0x53, 0x53, 0x10, 0x03, 0x2a, 0xf3, 0x01, 0x0a, 0x0e, 0x4c, 0x6f, 0x52, 0x61,
+	0x43, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x61, 0x74, 0x65, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x52,
+	0x5f, 0x4f, 0x46, 0x46, 0x10, 0x00, 0x12, 0x0e, 0x0a, 0x0a, 0x43, 0x52, 0x5f, 0x55, 0x4e, 0x4b,
+	0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x52, 0x5f, 0x34, 0x5f, 0x35,
+	0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x52, 0x5f, 0x34, 0x5f, 0x36, 0x10, 0x03, 0x12, 0x0a,
+	0x0a, 0x06, 0x43, 0x52, 0x5f, 0x34, 0x5f, 0x37, 0x10, 0x04, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x52,
+	0x5f, 0x34, 0x5f, 0x38, 0x10, 0x05, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x52, 0x5f, 0x34, 0x5f, 0x39,
+	0x10, 0x06, 0x12, 0x0b, 0x0a, 0x07, 0x43, 0x52, 0x5f, 0x34, 0x5f, 0x31, 0x30, 0x10, 0x07, 0x12,
+	0x0b, 0x0a, 0x07, 0x43, 0x52, 0x5f, 0x34, 0x5f, 0x31, 0x31, 0x10, 0x08, 0x12, 0x0b, 0x0a, 0x07,
+	0x43, 0x52, 0x5f, 0x34, 0x5f, 0x31, 0x32, 0x10, 0x09, 0x12, 0x0b, 0x0a, 0x07, 0x43, 0x52, 0x5f,
+	0x34, 0x5f, 0x31, 0x33, 0x10, 0x0a, 0x12, 0x0b, 0x0a, 0x07, 0x43, 0x52, 0x5f, 0x34, 0x5f, 0x31,
+	0x34, 0x10, 0x0b, 0x12, 0x0b, 0x0a, 0x07, 0x43, 0x52, 0x5f, 0x34, 0x5f, 0x31, 0x35, 0x10, 0x0c,
+	0x12, 0x0b, 0x0a, 0x07, 0x43, 0x52, 0x5f, 0x34, 0x5f, 0x31, 0x36, 0x10, 0x0d, 0x12, 0x0a, 0x0a,
+	0x06, 0x43, 0x52, 0x5f, 0x31, 0x5f, 0x33, 0x10, 0x0e, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x52, 0x5f,
+	0x32, 0x5f, 0x33, 0x10, 0x0f, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x52, 0x5f, 0x31, 0x5f, 0x32, 0x10,
+	0x10, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x52, 0x5f, 0x35, 0x5f, 0x36, 0x10, 0x11, 0x2a, 0x63, 0x0a,
+	0x06, 0x4c, 0x6f, 0x52, 0x61, 0x53, 0x46, 0x12, 0x0e, 0x0a, 0x0a, 0x53, 0x46, 0x5f, 0x55, 0x4e,
+	0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x53, 0x46, 0x31, 0x32, 0x10,
+	0x01, 0x12, 0x08, 0x0a, 0x04, 0x53, 0x46, 0x31, 0x31, 0x10, 0x02, 0x12, 0x08, 0x0a, 0x04, 0x53,
+	0x46, 0x31, 0x30, 0x10, 0x03, 0x12, 0x07, 0x0a, 0x03, 0x53, 0x46, 0x39, 0x10, 0x04, 0x12, 0x07,
+	0x0a, 0x03, 0x53, 0x46, 0x38, 0x10, 0x05, 0x12, 0x07, 0x0a, 0x03, 0x53, 0x46, 0x37, 0x10, 0x06,
+	0x12, 0x07, 0x0a, 0x03, 0x53, 0x46, 0x36, 0x10, 0x07, 0x12, 0x07, 0x0a, 0x03, 0x53, 0x46, 0x35,
+	0x10, 0x08, 0x2a, 0x74, 0x0a, 0x06, 0x4c, 0x6f, 0x52, 0x61, 0x42, 0x57, 0x12, 0x0e, 0x0a, 0x0a,
 	0x42, 0x57, 0x5f, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07,
 	0x42, 0x57, 0x5f, 0x31, 0x32, 0x35, 0x6b, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x42, 0x57, 0x5f,
 	0x32, 0x35, 0x30, 0x6b, 0x10, 0x03, 0x12, 0x0b, 0x0a, 0x07, 0x42, 0x57, 0x5f, 0x35, 0x30, 0x30,
-	0x6b, 0x10, 0x02, 0x42, 0x21, 0x5a, 0x1f, 0x6b, 0x75, 0x64, 0x7a, 0x75, 0x74, 0x65, 0x63, 0x68,
-	0x6e, 0x6f, 0x6c, 0x6f, 0x67, 0x69, 0x65, 0x73, 0x2f, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69,
-	0x63, 0x73, 0x2f, 0x61, 0x70, 0x69, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x6b, 0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07, 0x42, 0x57, 0x5f, 0x32, 0x30, 0x33, 0x6b, 0x10, 0x04,
+	0x12, 0x0b, 0x0a, 0x07, 0x42, 0x57, 0x5f, 0x34, 0x30, 0x36, 0x6b, 0x10, 0x05, 0x12, 0x0b, 0x0a,
+	0x07, 0x42, 0x57, 0x5f, 0x38, 0x31, 0x32, 0x6b, 0x10, 0x06, 0x12, 0x0c, 0x0a, 0x08, 0x42, 0x57,
+	0x5f, 0x31, 0x36, 0x32, 0x35, 0x6b, 0x10, 0x07, 0x42, 0x21, 0x5a, 0x1f, 0x6b, 0x75, 0x64, 0x7a,
+	0x75, 0x74, 0x65, 0x63, 0x68, 0x6e, 0x6f, 0x6c, 0x6f, 0x67, 0x69, 0x65, 0x73, 0x2f, 0x61, 0x6e,
+	0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x2f, 0x61, 0x70, 0x69, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1473,42 +2779,71 @@ func file_analytics_proto_rawDescGZIP() []byte {
 	return file_analytics_proto_rawDescData
 }
 
-var file_analytics_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
-var file_analytics_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_analytics_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_analytics_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
 var file_analytics_proto_goTypes = []interface{}{
-	(CRCStatus)(0),                   // 0: api.CRCStatus
-	(Modulation)(0),                  // 1: api.Modulation
-	(LoRaCodingRate)(0),              // 2: api.LoRaCodingRate
-	(LoRaSF)(0),                      // 3: api.LoRaSF
-	(LoRaBW)(0),                      // 4: api.LoRaBW
-	(*AnalyticsMetrics)(nil),         // 5: api.AnalyticsMetrics
-	(*AnalyticsUplinkAntenna)(nil),   // 6: api.AnalyticsUplinkAntenna
-	(*AnalyticsUplink)(nil),          // 7: api.AnalyticsUplink
-	(*AnalyticsDownlink)(nil),        // 8: api.AnalyticsDownlink
-	(*AnalyticsStat)(nil),            // 9: api.AnalyticsStat
-	(*AnalyticsInternalMetrics)(nil), // 10: api.AnalyticsInternalMetrics
-	(*LoRaDataRate)(nil),             // 11: api.LoRaDataRate
+	(CRCStatus)(0),                     // 0: api.CRCStatus
+	(GatewayEventType)(0),              // 1: api.GatewayEventType
+	(Modulation)(0),                    // 2: api.Modulation
+	(LoRaCodingRate)(0),                // 3: api.LoRaCodingRate
+	(LoRaSF)(0),                        // 4: api.LoRaSF
+	(LoRaBW)(0),                        // 5: api.LoRaBW
+	(*AnalyticsMetrics)(nil),           // 6: api.AnalyticsMetrics
+	(*AnalyticsUplinkAntenna)(nil),     // 7: api.AnalyticsUplinkAntenna
+	(*AnalyticsUplink)(nil),            // 8: api.AnalyticsUplink
+	(*AnalyticsDownlink)(nil),          // 9: api.AnalyticsDownlink
+	(*AnalyticsStat)(nil),              // 10: api.AnalyticsStat
+	(*AnalyticsAggregate)(nil),         // 11: api.AnalyticsAggregate
+	(*AnalyticsUplinkAggregate)(nil),   // 12: api.AnalyticsUplinkAggregate
+	(*AnalyticsAntennaAggregate)(nil),  // 13: api.AnalyticsAntennaAggregate
+	(*AnalyticsDownlinkAggregate)(nil), // 14: api.AnalyticsDownlinkAggregate
+	(*AnalyticsHistogram)(nil),         // 15: api.AnalyticsHistogram
+	(*AnalyticsGatewayEvent)(nil),      // 16: api.AnalyticsGatewayEvent
+	(*AnalyticsInternalMetrics)(nil),   // 17: api.AnalyticsInternalMetrics
+	(*ForwarderInfo)(nil),              // 18: api.ForwarderInfo
+	(*LoRaDataRate)(nil),               // 19: api.LoRaDataRate
+	(*LRFHSSDataRate)(nil),             // 20: api.LRFHSSDataRate
+	nil,                                // 21: api.AnalyticsUplink.CustomFieldsEntry
+	nil,                                // 22: api.ForwarderInfo.ConfigEntry
 }
 var file_analytics_proto_depIdxs = []int32{
-	7,  // 0: api.AnalyticsMetrics.uplinks:type_name -> api.AnalyticsUplink
-	8,  // 1: api.AnalyticsMetrics.downlinks:type_name -> api.AnalyticsDownlink
-	9,  // 2: api.AnalyticsMetrics.stats:type_name -> api.AnalyticsStat
-	10, // 3: api.AnalyticsMetrics.metrics:type_name -> api.AnalyticsInternalMetrics
-	0,  // 4: api.AnalyticsUplink.crc:type_name -> api.CRCStatus
-	1,  // 5: api.AnalyticsUplink.modulation:type_name -> api.Modulation
-	2,  // 6: api.AnalyticsUplink.codingRate:type_name -> api.LoRaCodingRate
-	11, // 7: api.AnalyticsUplink.dataRateLoRa:type_name -> api.LoRaDataRate
-	6,  // 8: api.AnalyticsUplink.ant:type_name -> api.AnalyticsUplinkAntenna
-	1,  // 9: api.AnalyticsDownlink.modulation:type_name -> api.Modulation
-	2,  // 10: api.AnalyticsDownlink.codingRate:type_name -> api.LoRaCodingRate
-	11, // 11: api.AnalyticsDownlink.dataRateLoRa:type_name -> api.LoRaDataRate
-	3,  // 12: api.LoRaDataRate.spreadingFactor:type_name -> api.LoRaSF
-	4,  // 13: api.LoRaDataRate.bandwidth:type_name -> api.LoRaBW
-	14, // [14:14] is the sub-list for method output_type
-	14, // [14:14] is the sub-list for method input_type
-	14, // [14:14] is the sub-list for extension type_name
-	14, // [14:14] is the sub-list for extension extendee
-	0,  // [0:14] is the sub-list for field type_name
+	8,  // 0: api.AnalyticsMetrics.uplinks:type_name -> api.AnalyticsUplink
+	9,  // 1: api.AnalyticsMetrics.downlinks:type_name -> api.AnalyticsDownlink
+	10, // 2: api.AnalyticsMetrics.stats:type_name -> api.AnalyticsStat
+	17, // 3: api.AnalyticsMetrics.metrics:type_name -> api.AnalyticsInternalMetrics
+	16, // 4: api.AnalyticsMetrics.events:type_name -> api.AnalyticsGatewayEvent
+	11, // 5: api.AnalyticsMetrics.aggregate:type_name -> api.AnalyticsAggregate
+	18, // 6: api.AnalyticsMetrics.forwarder:type_name -> api.ForwarderInfo
+	0,  // 7: api.AnalyticsUplink.crc:type_name -> api.CRCStatus
+	2,  // 8: api.AnalyticsUplink.modulation:type_name -> api.Modulation
+	3,  // 9: api.AnalyticsUplink.codingRate:type_name -> api.LoRaCodingRate
+	19, // 10: api.AnalyticsUplink.dataRateLoRa:type_name -> api.LoRaDataRate
+	20, // 11: api.AnalyticsUplink.dataRateLRFHSS:type_name -> api.LRFHSSDataRate
+	7,  // 12: api.AnalyticsUplink.ant:type_name -> api.AnalyticsUplinkAntenna
+	21, // 13: api.AnalyticsUplink.customFields:type_name -> api.AnalyticsUplink.CustomFieldsEntry
+	2,  // 14: api.AnalyticsDownlink.modulation:type_name -> api.Modulation
+	3,  // 15: api.AnalyticsDownlink.codingRate:type_name -> api.LoRaCodingRate
+	19, // 16: api.AnalyticsDownlink.dataRateLoRa:type_name -> api.LoRaDataRate
+	12, // 17: api.AnalyticsAggregate.uplinks:type_name -> api.AnalyticsUplinkAggregate
+	14, // 18: api.AnalyticsAggregate.downlinks:type_name -> api.AnalyticsDownlinkAggregate
+	2,  // 19: api.AnalyticsUplinkAggregate.modulation:type_name -> api.Modulation
+	19, // 20: api.AnalyticsUplinkAggregate.dataRateLoRa:type_name -> api.LoRaDataRate
+	20, // 21: api.AnalyticsUplinkAggregate.dataRateLRFHSS:type_name -> api.LRFHSSDataRate
+	0,  // 22: api.AnalyticsUplinkAggregate.crc:type_name -> api.CRCStatus
+	13, // 23: api.AnalyticsUplinkAggregate.ant:type_name -> api.AnalyticsAntennaAggregate
+	15, // 24: api.AnalyticsAntennaAggregate.rssi:type_name -> api.AnalyticsHistogram
+	15, // 25: api.AnalyticsAntennaAggregate.snr:type_name -> api.AnalyticsHistogram
+	2,  // 26: api.AnalyticsDownlinkAggregate.modulation:type_name -> api.Modulation
+	19, // 27: api.AnalyticsDownlinkAggregate.dataRateLoRa:type_name -> api.LoRaDataRate
+	1,  // 28: api.AnalyticsGatewayEvent.type:type_name -> api.GatewayEventType
+	22, // 29: api.ForwarderInfo.config:type_name -> api.ForwarderInfo.ConfigEntry
+	4,  // 30: api.LoRaDataRate.spreadingFactor:type_name -> api.LoRaSF
+	5,  // 31: api.LoRaDataRate.bandwidth:type_name -> api.LoRaBW
+	32, // [32:32] is the sub-list for method output_type
+	32, // [32:32] is the sub-list for method input_type
+	32, // [32:32] is the sub-list for extension type_name
+	32, // [32:32] is the sub-list for extension extendee
+	0,  // [0:32] is the sub-list for field type_name
 }
 
 func init() { file_analytics_proto_init() }
@@ -1578,7 +2913,7 @@ func file_analytics_proto_init() {
 			}
 		}
 		file_analytics_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*AnalyticsInternalMetrics); i {
+			switch v := v.(*AnalyticsAggregate); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1590,6 +2925,90 @@ func file_analytics_proto_init() {
 			}
 		}
 		file_analytics_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnalyticsUplinkAggregate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analytics_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnalyticsAntennaAggregate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analytics_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnalyticsDownlinkAggregate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analytics_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnalyticsHistogram); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analytics_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnalyticsGatewayEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analytics_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnalyticsInternalMetrics); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analytics_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ForwarderInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_analytics_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*LoRaDataRate); i {
 			case 0:
 				return &v.state
@@ -1601,25 +3020,47 @@ func file_analytics_proto_init() {
 				return nil
 			}
 		}
+		file_analytics_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LRFHSSDataRate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_analytics_proto_msgTypes[0].OneofWrappers = []interface{}{}
 	file_analytics_proto_msgTypes[1].OneofWrappers = []interface{}{}
 	file_analytics_proto_msgTypes[2].OneofWrappers = []interface{}{
 		(*AnalyticsUplink_DataRateLoRa)(nil),
 		(*AnalyticsUplink_DataRateFSK)(nil),
+		(*AnalyticsUplink_DataRateLRFHSS)(nil),
 	}
 	file_analytics_proto_msgTypes[3].OneofWrappers = []interface{}{
 		(*AnalyticsDownlink_DataRateLoRa)(nil),
 		(*AnalyticsDownlink_DataRateFSK)(nil),
 	}
 	file_analytics_proto_msgTypes[4].OneofWrappers = []interface{}{}
+	file_analytics_proto_msgTypes[6].OneofWrappers = []interface{}{
+		(*AnalyticsUplinkAggregate_DataRateLoRa)(nil),
+		(*AnalyticsUplinkAggregate_DataRateFSK)(nil),
+		(*AnalyticsUplinkAggregate_DataRateLRFHSS)(nil),
+	}
+	file_analytics_proto_msgTypes[8].OneofWrappers = []interface{}{
+		(*AnalyticsDownlinkAggregate_DataRateLoRa)(nil),
+		(*AnalyticsDownlinkAggregate_DataRateFSK)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_analytics_proto_rawDesc,
-			NumEnums:      5,
-			NumMessages:   7,
+			NumEnums:      6,
+			NumMessages:   17,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -22,6 +22,16 @@ type AnalyticsServerClient interface {
 	Login(ctx context.Context, in *ReqLogin, opts ...grpc.CallOption) (*RespLogin, error)
 	// Pushes analytics data to the server
 	PushMetrics(ctx context.Context, in *AnalyticsMetrics, opts ...grpc.CallOption) (*RespPush, error)
+	// Reads back the analytics data of the account
+	ListGateways(ctx context.Context, in *ReqListGateways, opts ...grpc.CallOption) (AnalyticsServer_ListGatewaysClient, error)
+	GetGatewaySummary(ctx context.Context, in *ReqGatewaySummary, opts ...grpc.CallOption) (*GatewaySummary, error)
+	QueryUplinks(ctx context.Context, in *ReqQueryUplinks, opts ...grpc.CallOption) (AnalyticsServer_QueryUplinksClient, error)
+	QueryStats(ctx context.Context, in *ReqQueryStats, opts ...grpc.CallOption) (AnalyticsServer_QueryStatsClient, error)
+	// Streams the live traffic of the account as it's received
+	Subscribe(ctx context.Context, in *ReqSubscribe, opts ...grpc.CallOption) (AnalyticsServer_SubscribeClient, error)
+	// Opens the control channel of a forwarder, where the server pushes changes
+	// of the configuration and commands, and the forwarder reports back
+	Control(ctx context.Context, opts ...grpc.CallOption) (AnalyticsServer_ControlClient, error)
 }
 
 type analyticsServerClient struct {
@@ -59,6 +69,174 @@ func (c *analyticsServerClient) PushMetrics(ctx context.Context, in *AnalyticsMe
 	return out, nil
 }
 
+func (c *analyticsServerClient) ListGateways(ctx context.Context, in *ReqListGateways, opts ...grpc.CallOption) (AnalyticsServer_ListGatewaysClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_AnalyticsServer_serviceDesc.Streams[0], "/api.AnalyticsServer/ListGateways", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &analyticsServerListGatewaysClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AnalyticsServer_ListGatewaysClient interface {
+	Recv() (*GatewayInfo, error)
+	grpc.ClientStream
+}
+
+type analyticsServerListGatewaysClient struct {
+	grpc.ClientStream
+}
+
+func (x *analyticsServerListGatewaysClient) Recv() (*GatewayInfo, error) {
+	m := new(GatewayInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *analyticsServerClient) GetGatewaySummary(ctx context.Context, in *ReqGatewaySummary, opts ...grpc.CallOption) (*GatewaySummary, error) {
+	out := new(GatewaySummary)
+	err := c.cc.Invoke(ctx, "/api.AnalyticsServer/GetGatewaySummary", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analyticsServerClient) QueryUplinks(ctx context.Context, in *ReqQueryUplinks, opts ...grpc.CallOption) (AnalyticsServer_QueryUplinksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_AnalyticsServer_serviceDesc.Streams[1], "/api.AnalyticsServer/QueryUplinks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &analyticsServerQueryUplinksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AnalyticsServer_QueryUplinksClient interface {
+	Recv() (*UplinkRecord, error)
+	grpc.ClientStream
+}
+
+type analyticsServerQueryUplinksClient struct {
+	grpc.ClientStream
+}
+
+func (x *analyticsServerQueryUplinksClient) Recv() (*UplinkRecord, error) {
+	m := new(UplinkRecord)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *analyticsServerClient) QueryStats(ctx context.Context, in *ReqQueryStats, opts ...grpc.CallOption) (AnalyticsServer_QueryStatsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_AnalyticsServer_serviceDesc.Streams[2], "/api.AnalyticsServer/QueryStats", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &analyticsServerQueryStatsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AnalyticsServer_QueryStatsClient interface {
+	Recv() (*AnalyticsStat, error)
+	grpc.ClientStream
+}
+
+type analyticsServerQueryStatsClient struct {
+	grpc.ClientStream
+}
+
+func (x *analyticsServerQueryStatsClient) Recv() (*AnalyticsStat, error) {
+	m := new(AnalyticsStat)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *analyticsServerClient) Subscribe(ctx context.Context, in *ReqSubscribe, opts ...grpc.CallOption) (AnalyticsServer_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_AnalyticsServer_serviceDesc.Streams[3], "/api.AnalyticsServer/Subscribe", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &analyticsServerSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AnalyticsServer_SubscribeClient interface {
+	Recv() (*TrafficEvent, error)
+	grpc.ClientStream
+}
+
+type analyticsServerSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *analyticsServerSubscribeClient) Recv() (*TrafficEvent, error) {
+	m := new(TrafficEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *analyticsServerClient) Control(ctx context.Context, opts ...grpc.CallOption) (AnalyticsServer_ControlClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_AnalyticsServer_serviceDesc.Streams[4], "/api.AnalyticsServer/Control", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &analyticsServerControlClient{stream}
+	return x, nil
+}
+
+type AnalyticsServer_ControlClient interface {
+	Send(*ControlReport) error
+	Recv() (*ControlMessage, error)
+	grpc.ClientStream
+}
+
+type analyticsServerControlClient struct {
+	grpc.ClientStream
+}
+
+func (x *analyticsServerControlClient) Send(m *ControlReport) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *analyticsServerControlClient) Recv() (*ControlMessage, error) {
+	m := new(ControlMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // AnalyticsServerServer is the server API for AnalyticsServer service.
 // All implementations must embed UnimplementedAnalyticsServerServer
 // for forward compatibility
@@ -68,6 +246,16 @@ type AnalyticsServerServer interface {
 	Login(context.Context, *ReqLogin) (*RespLogin, error)
 	// Pushes analytics data to the server
 	PushMetrics(context.Context, *AnalyticsMetrics) (*RespPush, error)
+	// Reads back the analytics data of the account
+	ListGateways(*ReqListGateways, AnalyticsServer_ListGatewaysServer) error
+	GetGatewaySummary(context.Context, *ReqGatewaySummary) (*GatewaySummary, error)
+	QueryUplinks(*ReqQueryUplinks, AnalyticsServer_QueryUplinksServer) error
+	QueryStats(*ReqQueryStats, AnalyticsServer_QueryStatsServer) error
+	// Streams the live traffic of the account as it's received
+	Subscribe(*ReqSubscribe, AnalyticsServer_SubscribeServer) error
+	// Opens the control channel of a forwarder, where the server pushes changes
+	// of the configuration and commands, and the forwarder reports back
+	Control(AnalyticsServer_ControlServer) error
 	mustEmbedUnimplementedAnalyticsServerServer()
 }
 
@@ -84,6 +272,24 @@ func (UnimplementedAnalyticsServerServer) Login(context.Context, *ReqLogin) (*Re
 func (UnimplementedAnalyticsServerServer) PushMetrics(context.Context, *AnalyticsMetrics) (*RespPush, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PushMetrics not implemented")
 }
+func (UnimplementedAnalyticsServerServer) ListGateways(*ReqListGateways, AnalyticsServer_ListGatewaysServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListGateways not implemented")
+}
+func (UnimplementedAnalyticsServerServer) GetGatewaySummary(context.Context, *ReqGatewaySummary) (*GatewaySummary, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGatewaySummary not implemented")
+}
+func (UnimplementedAnalyticsServerServer) QueryUplinks(*ReqQueryUplinks, AnalyticsServer_QueryUplinksServer) error {
+	return status.Errorf(codes.Unimplemented, "method QueryUplinks not implemented")
+}
+func (UnimplementedAnalyticsServerServer) QueryStats(*ReqQueryStats, AnalyticsServer_QueryStatsServer) error {
+	return status.Errorf(codes.Unimplemented, "method QueryStats not implemented")
+}
+func (UnimplementedAnalyticsServerServer) Subscribe(*ReqSubscribe, AnalyticsServer_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedAnalyticsServerServer) Control(AnalyticsServer_ControlServer) error {
+	return status.Errorf(codes.Unimplemented, "method Control not implemented")
+}
 func (UnimplementedAnalyticsServerServer) mustEmbedUnimplementedAnalyticsServerServer() {}
 
 // UnsafeAnalyticsServerServer may be embedded to opt out of forward compatibility for this service.
@@ -151,6 +357,134 @@ func _AnalyticsServer_PushMetrics_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AnalyticsServer_ListGateways_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReqListGateways)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AnalyticsServerServer).ListGateways(m, &analyticsServerListGatewaysServer{stream})
+}
+
+type AnalyticsServer_ListGatewaysServer interface {
+	Send(*GatewayInfo) error
+	grpc.ServerStream
+}
+
+type analyticsServerListGatewaysServer struct {
+	grpc.ServerStream
+}
+
+func (x *analyticsServerListGatewaysServer) Send(m *GatewayInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _AnalyticsServer_GetGatewaySummary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReqGatewaySummary)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyticsServerServer).GetGatewaySummary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.AnalyticsServer/GetGatewaySummary",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyticsServerServer).GetGatewaySummary(ctx, req.(*ReqGatewaySummary))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnalyticsServer_QueryUplinks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReqQueryUplinks)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AnalyticsServerServer).QueryUplinks(m, &analyticsServerQueryUplinksServer{stream})
+}
+
+type AnalyticsServer_QueryUplinksServer interface {
+	Send(*UplinkRecord) error
+	grpc.ServerStream
+}
+
+type analyticsServerQueryUplinksServer struct {
+	grpc.ServerStream
+}
+
+func (x *analyticsServerQueryUplinksServer) Send(m *UplinkRecord) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _AnalyticsServer_QueryStats_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReqQueryStats)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AnalyticsServerServer).QueryStats(m, &analyticsServerQueryStatsServer{stream})
+}
+
+type AnalyticsServer_QueryStatsServer interface {
+	Send(*AnalyticsStat) error
+	grpc.ServerStream
+}
+
+type analyticsServerQueryStatsServer struct {
+	grpc.ServerStream
+}
+
+func (x *analyticsServerQueryStatsServer) Send(m *AnalyticsStat) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _AnalyticsServer_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReqSubscribe)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AnalyticsServerServer).Subscribe(m, &analyticsServerSubscribeServer{stream})
+}
+
+type AnalyticsServer_SubscribeServer interface {
+	Send(*TrafficEvent) error
+	grpc.ServerStream
+}
+
+type analyticsServerSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *analyticsServerSubscribeServer) Send(m *TrafficEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _AnalyticsServer_Control_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AnalyticsServerServer).Control(&analyticsServerControlServer{stream})
+}
+
+type AnalyticsServer_ControlServer interface {
+	Send(*ControlMessage) error
+	Recv() (*ControlReport, error)
+	grpc.ServerStream
+}
+
+type analyticsServerControlServer struct {
+	grpc.ServerStream
+}
+
+func (x *analyticsServerControlServer) Send(m *ControlMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *analyticsServerControlServer) Recv() (*ControlReport, error) {
+	m := new(ControlReport)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 var _AnalyticsServer_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "api.AnalyticsServer",
 	HandlerType: (*AnalyticsServerServer)(nil),
@@ -167,7 +501,38 @@ var _AnalyticsServer_serviceDesc = grpc.ServiceDesc{
 			MethodName: "PushMetrics",
 			Handler:    _AnalyticsServer_PushMetrics_Handler,
 		},
+		{
+			MethodName: "GetGatewaySummary",
+			Handler:    _AnalyticsServer_GetGatewaySummary_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListGateways",
+			Handler:       _AnalyticsServer_ListGateways_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "QueryUplinks",
+			Handler:       _AnalyticsServer_QueryUplinks_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "QueryStats",
+			Handler:       _AnalyticsServer_QueryStats_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Subscribe",
+			Handler:       _AnalyticsServer_Subscribe_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Control",
+			Handler:       _AnalyticsServer_Control_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api.proto",
 }
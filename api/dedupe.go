@@ -36,6 +36,12 @@ func ComputeUniqueIdUp(up *AnalyticsUplink, fullPayload []byte) {
 		extraDr := []byte{2, 0, 0, 0, 0}
 		binary.LittleEndian.PutUint32(extraDr[1:], uint32(dr.DataRateFSK))
 		extra = append(extra, extraDr...)
+
+	case *AnalyticsUplink_DataRateLRFHSS:
+		extraDr := []byte{3, 0, 0, 0, 0}
+		binary.LittleEndian.PutUint16(extraDr[1:], uint16(dr.DataRateLRFHSS.ModulationType))
+		binary.LittleEndian.PutUint16(extraDr[3:], uint16(dr.DataRateLRFHSS.ChannelWidth))
+		extra = append(extra, extraDr...)
 	}
 
 	csum := sha1.Sum(append(fullPayload, extra...))
@@ -10,28 +10,47 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/kudzutechnologies/analytics/api"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
 )
 
 // Revision:
 // v1 - First public release of the client
 // v2 - Added support for multiple antennas
-const ClientVersion = 2
+// v3 - Added LR-FHSS, SF5/SF6, 2.4 GHz bandwidths, gateway temperature and custom rxpk fields
+// v4 - Added unwrapped concentrator timestamps and gateway clock drift
+// v5 - Added gateway events (position changes and GPS lock)
+// v6 - Added aggregated uplinks and downlinks
+// v7 - Added compression negotiation and split pushes
+// v8 - Added endpoint fail-over
+// v9 - Added the HTTPS transport
+// v10 - Added rate limiting from the server hints
+// v11 - Added the queries to read analytics back
+// v12 - Added the live traffic subscriptions
+// v13 - Added the control channel of the forwarders
+// v14 - Added the forwarder diagnostics
+const ClientVersion = 14
 
 //go:embed cert/kudzu-root-ca-2023.pem
 var defaultRootCertificate []byte
 
 var defaultEndpoint string = "analytics.v2.kudzu.gr:50051"
 
+// How many times a request is retried while the server is overloaded, when
+// re-connecting is disabled (otherwise it's retried until it succeeds)
+const maxExhaustedRetries = 5
+
 var (
 	// An error thrown when trying to use the client while not connected
 	ErrNotConnected = fmt.Errorf("client is not connnected")
+	// An error thrown when querying over a transport other than gRPC
+	ErrQueriesNotSupported = fmt.Errorf("queries are only supported over gRPC")
 )
 
 // Configuration parameters that can be passed to the analytics client
@@ -42,6 +61,12 @@ type AnalyticsClientConfig struct {
 
 	// The endpoint to use for uploading the data (Optional)
 	Endpoint string `json:"endpoint,omitempty"`
+	// More endpoints to fail over to, in order of preference (Optional)
+	Endpoints []string `json:"endpoints,omitempty"`
+	// A DNS SRV name listing the endpoints, preferred over the static ones (Optional)
+	EndpointSRV string `json:"endpoint_srv,omitempty"`
+	// How frequently to check if a preferred endpoint recovered while failed over (seconds)
+	HealthCheckInterval int32 `json:"health_check_interval,omitempty"`
 	// The server CA certificate file to use for validating the connection (Optional)
 	CAFile string `json:"ca_file,omitempty"`
 	// The default timeout for connecting (seconds)
@@ -54,19 +79,53 @@ type AnalyticsClientConfig struct {
 	AutoReconnect *bool `json:"reconnect,omitempty"`
 	// Indicates that we are forwarding data from the server-side
 	ServerSide *bool `json:"server_side,omitempty"`
+	// The comma-separated compressions to offer, in order of preference ('zstd',
+	// 'gzip' or 'none' to disable compression)
+	Compression string `json:"compression,omitempty"`
+	// The largest push before compression (bytes), larger metrics are split
+	MaxMessageSize int32 `json:"max_message_size,omitempty"`
+	// The largest sustained push rate before compression (bytes per second, 0
+	// for no limit other than the one requested by the server)
+	RateLimit int32 `json:"rate_limit,omitempty"`
+	// The transport to use ('grpc', 'https' or empty for gRPC with HTTPS as a fall-back)
+	Transport string `json:"transport,omitempty"`
+	// The port of the HTTPS transport on the endpoint hosts (Optional)
+	HTTPPort int32 `json:"http_port,omitempty"`
+	// The encoding of the HTTPS transport ('protobuf' or 'json')
+	HTTPEncoding string `json:"http_encoding,omitempty"`
+	// Opens the transports instead of the built-in ones (eg. for testing)
+	Dialer TransportDialer `json:"-"`
 }
 
 // the RPC client
+//
+// The client is safe to use from several goroutines, although the pushes of
+// each of them wait for the same rate limit.
 type Client struct {
-	client       api.AnalyticsServerClient
-	config       AnalyticsClientConfig
-	conn         *grpc.ClientConn
-	reqTimeout   time.Duration
-	connTimeout  time.Duration
-	sessionToken string
+	// Guards the connection state (the transport, the session, the endpoints
+	// and their failures), which is replaced when re-connecting
+	lock sync.Mutex
+	// Serializes the connection attempts
+	connectLock sync.Mutex
+
+	transport      Transport
+	session        Session
+	config         AnalyticsClientConfig
+	reqTimeout     time.Duration
+	connTimeout    time.Duration
+	maxSize        int
+	stats          statsCollector
+	limiter        *rateLimiter
+	address        string
+	endpoints      []string
+	failed         map[string]time.Time
+	grpcBlocked    blockedEndpoints
+	healthInterval time.Duration
+	stopProbing    chan struct{}
+	recovered      chan *connection
 }
 
-func loadTLSCredentials(cc *AnalyticsClientConfig) (credentials.TransportCredentials, error) {
+func loadTLSConfig(cc *AnalyticsClientConfig) (*tls.Config, error) {
 	var (
 		pemServerCA []byte = defaultRootCertificate
 		err         error
@@ -84,8 +143,24 @@ func loadTLSCredentials(cc *AnalyticsClientConfig) (credentials.TransportCredent
 		return nil, fmt.Errorf("failed to add server CA's certificate")
 	}
 
-	config := &tls.Config{
+	return &tls.Config{
 		RootCAs: certPool,
+	}, nil
+}
+
+// Returns the root CA of the Kudzu services, that is embedded in the client
+func RootCAs() (*x509.CertPool, error) {
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(defaultRootCertificate) {
+		return nil, fmt.Errorf("failed to add the root CA's certificate")
+	}
+	return certPool, nil
+}
+
+func loadTLSCredentials(cc *AnalyticsClientConfig) (credentials.TransportCredentials, error) {
+	config, err := loadTLSConfig(cc)
+	if err != nil {
+		return nil, err
 	}
 	return credentials.NewTLS(config), nil
 }
@@ -106,67 +181,131 @@ func CreateAnalyticsClient(config AnalyticsClientConfig) *Client {
 		connTimeout = time.Second * time.Duration(config.ConnectTimeout)
 	}
 
+	// Default health check interval
+	healthInterval := defaultHealthCheckInterval
+	if config.HealthCheckInterval != 0 {
+		healthInterval = time.Second * time.Duration(config.HealthCheckInterval)
+	}
+
 	return &Client{
-		client:      nil,
-		config:      config,
-		connTimeout: connTimeout,
-		reqTimeout:  reqTimeout,
+		config:         config,
+		connTimeout:    connTimeout,
+		reqTimeout:     reqTimeout,
+		failed:         make(map[string]time.Time),
+		healthInterval: healthInterval,
+		limiter:        newRateLimiter(int(config.RateLimit)),
 	}
 }
 
 func (c *Client) Disconnect() error {
-	if c.conn == nil {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.disconnectLocked()
+}
+
+func (c *Client) disconnectLocked() error {
+	if c.transport == nil {
 		return ErrNotConnected
 	}
 
-	con := c.conn
-	c.conn = nil
-	c.client = nil
+	c.stopProbe()
+	transport := c.transport
+	c.transport = nil
+
+	return transport.Close()
+}
 
-	return con.Close()
+// Returns a copy of the active connection, or nil if not connected
+func (c *Client) current() *connection {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.transport == nil {
+		return nil
+	}
+	return &connection{address: c.address, transport: c.transport, session: c.session}
 }
 
+// Connects to the first healthy endpoint, in order of preference
 func (c *Client) Connect() error {
-	if c.conn != nil {
-		c.Disconnect()
+	c.connectLock.Lock()
+	defer c.connectLock.Unlock()
+	c.Disconnect()
+	return c.connect()
+}
+
+// Connects, unless another goroutine did it in the meantime
+func (c *Client) reconnect() error {
+	c.connectLock.Lock()
+	defer c.connectLock.Unlock()
+	if c.current() != nil {
+		return nil
 	}
+	return c.connect()
+}
 
-	tlsCredentials, err := loadTLSCredentials(&c.config)
+func (c *Client) connect() error {
+	endpoints, err := c.resolveEndpoints()
 	if err != nil {
-		return fmt.Errorf("could not load CA certificate: %w", err)
+		return err
 	}
 
-	endpoint := defaultEndpoint
-	if c.config.Endpoint != "" {
-		endpoint = c.config.Endpoint
+	for _, address := range c.connectOrder(endpoints) {
+		var conn *connection
+		conn, err = c.connectTo(address)
+		if err == nil {
+			c.use(conn, endpoints)
+			return nil
+		}
+		c.markFailed(address)
 	}
-	conn, err := grpc.Dial(endpoint, grpc.WithTransportCredentials(tlsCredentials), grpc.WithBlock(), grpc.WithTimeout(c.connTimeout))
+	return err
+}
+
+// Connects and logs in to the given endpoint
+func (c *Client) connectTo(address string) (*connection, error) {
+	transport, err := c.openTransport(address)
 	if err != nil {
-		return fmt.Errorf("could not connect to server: %w", err)
+		return nil, fmt.Errorf("could not connect to server: %w", err)
 	}
 
-	// Create a client for logging in
-	client := api.NewAnalyticsServerClient(conn)
 	ctx, cancel := context.WithTimeout(context.Background(), c.connTimeout)
 	defer cancel()
+	session, err := c.login(ctx, transport)
+	if err != nil {
+		transport.Close()
+		return nil, err
+	}
+	return &connection{address: address, transport: transport, session: *session}, nil
+}
 
+func (c *Client) login(ctx context.Context, client Transport) (*Session, error) {
 	// Send hello & get login challenge
 	clientId, err := hex.DecodeString(c.config.ClientId)
 	if err != nil {
-		return fmt.Errorf("invalid client ID")
+		return nil, fmt.Errorf("invalid client ID")
 	}
 
+	offered := parseCompression(c.config.Compression)
 	helloResp, err := client.Hello(ctx, &api.ReqHello{
-		Version: ClientVersion,
+		Version:     ClientVersion,
+		Compression: offered,
 	})
 	if err != nil {
-		return fmt.Errorf("could not handshake with server: %w", err)
+		return nil, fmt.Errorf("could not handshake with server: %w", err)
+	}
+
+	// Older servers don't choose any compression
+	compression := ""
+	for _, name := range offered {
+		if name == helloResp.Compression {
+			compression = name
+		}
 	}
 
 	// Use hello challenge to login
 	clientKey, err := hex.DecodeString(c.config.ClientKey)
 	if err != nil {
-		return fmt.Errorf("invalid client key")
+		return nil, fmt.Errorf("invalid client key")
 	}
 	b := append(append(helloResp.Challenge, '|'), clientKey...)
 	serverSide := false
@@ -179,17 +318,19 @@ func (c *Client) Connect() error {
 		ServerSide: serverSide,
 	})
 	if err != nil {
-		return fmt.Errorf("could not login: %w", err)
+		return nil, fmt.Errorf("could not login: %w", err)
 	}
 
-	// Store the new client parameters
-	c.client = client
-	c.conn = conn
-	c.sessionToken = loginResp.AccessToken
-	return nil
+	return &Session{
+		Token:          loginResp.AccessToken,
+		Compression:    compression,
+		MaxMessageSize: int(helloResp.MaxMessageSize),
+	}, nil
 }
 
-func (c *Client) withReconnect(fn func() error) error {
+// Runs the function with the active connection, re-connecting and retrying as
+// configured
+func (c *Client) withReconnect(fn func(conn *connection) error) error {
 	var err error
 	backoff := time.Second * 1
 	maxBackoff := time.Minute
@@ -197,23 +338,47 @@ func (c *Client) withReconnect(fn func() error) error {
 		maxBackoff = time.Second * time.Duration(c.config.MaxReconnectBackoff)
 	}
 
-	// If re-connect is disabled, don't bother
-	if c.config.AutoReconnect != nil && !*c.config.AutoReconnect {
-		return fn()
-	}
-
-	// Otherwise run the function in a reconnection loop
+	// Run the function in a reconnection loop, unless re-connect is disabled
+	reconnect := c.config.AutoReconnect == nil || *c.config.AutoReconnect
+	exhausted := 0
 	for {
-		if c.conn == nil {
-			// If not connected, try to connect
-			err = c.Connect()
+		conn := c.current()
+		if conn == nil {
+			// If not connected, try to connect and then use the function
+			err = c.reconnect()
+			if err == nil {
+				continue
+			}
 		} else {
 			// Otherwise try to use the function
-			err = fn()
+			err = fn(conn)
 		}
 
 		if err != nil {
+			// The connection was replaced while in use (eg. when failing back
+			// by another push), so try again with the new one
+			if conn != nil && c.replaced(conn) {
+				continue
+			}
+
 			code := grpc.Code(err)
+			if code == codes.ResourceExhausted && (reconnect || exhausted < maxExhaustedRetries) {
+				// The server is overloaded, so wait and try again with the same data
+				exhausted++
+				delay := retryDelay(err)
+				if delay == 0 {
+					delay = backoff
+					backoff += backoff * 2
+					if backoff > maxBackoff {
+						backoff = maxBackoff
+					}
+				}
+				c.limiter.delay(delay)
+				continue
+			}
+			if !reconnect {
+				return err
+			}
 			if code == codes.DeadlineExceeded || code == codes.Unavailable || errors.Is(err, context.DeadlineExceeded) {
 				// Sleep for back-off duration and try to re-connect
 				time.Sleep(backoff)
@@ -222,8 +387,8 @@ func (c *Client) withReconnect(fn func() error) error {
 					backoff = maxBackoff
 				}
 
-				// Connect again
-				c.Disconnect()
+				// Connect again, failing over to the next endpoint
+				c.drop(conn)
 				continue
 			}
 		}
@@ -238,25 +403,85 @@ func (c *Client) createContext() (context.Context, context.CancelFunc) {
 		ctx, cancel = context.WithTimeout(context.Background(), c.reqTimeout)
 	}
 
-	md := metadata.Pairs("token", c.sessionToken)
-	return metadata.NewOutgoingContext(ctx, md), cancel
+	return ctx, cancel
+}
+
+// Returns true if the connection is no longer the active one
+func (c *Client) replaced(conn *connection) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.transport != conn.transport
+}
+
+// Disconnects after a failure of the connection, unless it was already replaced
+func (c *Client) drop(conn *connection) {
+	if conn == nil {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.transport == conn.transport {
+		c.failed[conn.address] = time.Now()
+		c.disconnectLocked()
+	}
 }
 
 // Pushes analyics metrics to the service
+//
+// Metrics larger than the maximum message size are split into several pushes
+// for the same gateway.
 func (c *Client) PushMetrics(metrics *api.AnalyticsMetrics) error {
-	if c.conn == nil {
+	c.failBack()
+
+	c.lock.Lock()
+	connected := c.transport != nil
+	maxSize := c.maxSize
+	maxMessage := c.session.MaxMessageSize
+	c.lock.Unlock()
+	if !connected {
 		return ErrNotConnected
 	}
 
-	ctx, cancel := c.createContext()
-	defer cancel()
+	for _, part := range splitMetrics(metrics, maxSize) {
+		err := c.pushPart(part, maxMessage)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) pushPart(metrics *api.AnalyticsMetrics, maxMessage int) error {
+	// Too large messages fail with ResourceExhausted, which is otherwise retried
+	size := proto.Size(metrics)
+	if maxMessage > 0 && size > maxMessage {
+		return fmt.Errorf("metrics of %d bytes are larger than the %d bytes the server accepts", size, maxMessage)
+	}
+
+	return c.withReconnect(func(conn *connection) error {
+		c.stats.throttle(c.limiter.wait(size))
+		ctx, cancel := c.createContext()
+		defer cancel()
 
-	return c.withReconnect(func() error {
-		_, err := c.client.PushMetrics(ctx, metrics)
+		resp, err := conn.transport.PushMetrics(ctx, &conn.session, metrics)
 		if err != nil {
 			return err
 		}
 
+		c.limiter.update(resp)
 		return nil
 	})
 }
+
+// Returns the compression negotiated with the server (empty for none)
+func (c *Client) Compression() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.session.Compression
+}
+
+// Returns the counters of the pushed data, including the bytes saved by compression
+func (c *Client) Stats() ClientStats {
+	return c.stats.snapshot()
+}
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/kudzutechnologies/analytics/api"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/stats"
+)
+
+// The compressions offered to the server when none are configured, in order of preference
+var defaultCompression = []string{"zstd", "gzip"}
+
+func init() {
+	encoding.RegisterCompressor(&zstdCompressor{})
+}
+
+// A gRPC compressor using zstd, re-using the encoders and decoders between calls
+type zstdCompressor struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+type zstdWriter struct {
+	*zstd.Encoder
+	pool *sync.Pool
+}
+
+func (w *zstdWriter) Close() error {
+	err := w.Encoder.Close()
+	w.pool.Put(w)
+	return err
+}
+
+type zstdReader struct {
+	*zstd.Decoder
+	pool *sync.Pool
+}
+
+func (r *zstdReader) Read(p []byte) (int, error) {
+	n, err := r.Decoder.Read(p)
+	if err == io.EOF {
+		r.pool.Put(r)
+	}
+	return n, err
+}
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	if found, ok := c.encoders.Get().(*zstdWriter); ok {
+		found.Reset(w)
+		return found, nil
+	}
+	enc, err := zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &zstdWriter{Encoder: enc, pool: &c.encoders}, nil
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	if found, ok := c.decoders.Get().(*zstdReader); ok {
+		if err := found.Reset(r); err != nil {
+			return nil, err
+		}
+		return found, nil
+	}
+	dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &zstdReader{Decoder: dec, pool: &c.decoders}, nil
+}
+
+func (c *zstdCompressor) Name() string {
+	return "zstd"
+}
+
+// Parses the comma-separated list of compressions to offer, where 'none'
+// disables compression
+func parseCompression(value string) []string {
+	if value == "" {
+		return defaultCompression
+	}
+	var ret []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "none" {
+			return nil
+		}
+		if name != "" && encoding.GetCompressor(name) != nil {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
+
+// Traffic counters of the analytics client
+type ClientStats struct {
+	// How many pushes were made (including the ones from splitting)
+	Pushes uint64
+	// The size of the pushed metrics, before and after compression
+	RawBytes  uint64
+	WireBytes uint64
+	// How long the pushes were held back by the rate limits
+	Throttled time.Duration
+}
+
+// The bytes saved by the compression
+func (s ClientStats) BytesSaved() int64 {
+	return int64(s.RawBytes) - int64(s.WireBytes)
+}
+
+// Collects the sizes of the pushed metrics from the gRPC transport
+type statsCollector struct {
+	stats     ClientStats
+	throttled int64
+}
+
+func (s *statsCollector) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (s *statsCollector) HandleRPC(_ context.Context, rs stats.RPCStats) {
+	out, ok := rs.(*stats.OutPayload)
+	if !ok {
+		return
+	}
+	if _, ok := out.Payload.(*api.AnalyticsMetrics); !ok {
+		return
+	}
+
+	// The wire length includes the 5-byte gRPC message header
+	wire := 0
+	if out.WireLength > 5 {
+		wire = out.WireLength - 5
+	}
+	s.record(out.Length, wire)
+}
+
+// Counts a push of `raw` bytes, that were `wire` bytes after compression
+func (s *statsCollector) record(raw, wire int) {
+	atomic.AddUint64(&s.stats.Pushes, 1)
+	atomic.AddUint64(&s.stats.RawBytes, uint64(raw))
+	atomic.AddUint64(&s.stats.WireBytes, uint64(wire))
+}
+
+// Counts the time a push was held back
+func (s *statsCollector) throttle(d time.Duration) {
+	atomic.AddInt64(&s.throttled, int64(d))
+}
+
+func (s *statsCollector) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (s *statsCollector) HandleConn(context.Context, stats.ConnStats) {}
+
+func (s *statsCollector) snapshot() ClientStats {
+	return ClientStats{
+		Pushes:    atomic.LoadUint64(&s.stats.Pushes),
+		RawBytes:  atomic.LoadUint64(&s.stats.RawBytes),
+		WireBytes: atomic.LoadUint64(&s.stats.WireBytes),
+		Throttled: time.Duration(atomic.LoadInt64(&s.throttled)),
+	}
+}
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func testMetrics(uplinks int) *api.AnalyticsMetrics {
+	metrics := &api.AnalyticsMetrics{
+		GatewayId:  "gw-1",
+		GatewayEui: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		Metrics:    &api.AnalyticsInternalMetrics{GatewayIp: "10.0.0.1", UpTxPackets: 10},
+	}
+	for i := 0; i < uplinks; i++ {
+		metrics.Uplinks = append(metrics.Uplinks, &api.AnalyticsUplink{
+			RxWallTime: int64(1677664800000000 + i),
+			Frequency:  868.1,
+			Size:       23,
+			Fhdr:       []byte{0x40, 1, 2, 3, 4, 0, byte(i), 0},
+			UniqueId:   []byte(fmt.Sprintf("uplink-%08d", i)),
+			Ant:        []*api.AnalyticsUplinkAntenna{{RSSIC: -110, LSNR: 5.5}},
+		})
+	}
+	metrics.Stats = []*api.AnalyticsStat{{RxPackets: uint32(uplinks)}}
+	return metrics
+}
+
+func TestParseCompression(t *testing.T) {
+	assert.Equal(t, []string{"zstd", "gzip"}, parseCompression(""))
+	assert.Equal(t, []string{"gzip"}, parseCompression(" GZIP "))
+	assert.Equal(t, []string{"gzip", "zstd"}, parseCompression("gzip,brotli,zstd"))
+	assert.Nil(t, parseCompression("none"))
+}
+
+func TestZstdCompressor(t *testing.T) {
+	c := &zstdCompressor{}
+	data := bytes.Repeat([]byte("kudzu analytics "), 1000)
+
+	// The pooled encoders and decoders can be re-used
+	for i := 0; i < 3; i++ {
+		var buf bytes.Buffer
+		w, err := c.Compress(&buf)
+		assert.NoError(t, err)
+		w.Write(data)
+		assert.NoError(t, w.Close())
+		assert.Less(t, buf.Len(), len(data)/10)
+
+		r, err := c.Decompress(&buf)
+		assert.NoError(t, err)
+		decoded, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, data, decoded)
+	}
+}
+
+func TestSplitMetrics(t *testing.T) {
+	metrics := testMetrics(100)
+	metrics.Events = []*api.AnalyticsGatewayEvent{{Type: api.GatewayEventType_GPS_LOCK_LOST}}
+	metrics.Forwarder = &api.ForwarderInfo{Version: "0.1.11", Pushes: 10}
+
+	// Small enough metrics are not split
+	parts := splitMetrics(metrics, 0)
+	assert.Equal(t, []*api.AnalyticsMetrics{metrics}, parts)
+	parts = splitMetrics(metrics, proto.Size(metrics))
+	assert.Equal(t, []*api.AnalyticsMetrics{metrics}, parts)
+
+	budget := 1000
+	parts = splitMetrics(metrics, budget)
+	assert.Greater(t, len(parts), 5)
+
+	uplinks := 0
+	for i, part := range parts {
+		assert.LessOrEqual(t, proto.Size(part), budget)
+		assert.Equal(t, "gw-1", part.GatewayId)
+		assert.Equal(t, metrics.GatewayEui, part.GatewayEui)
+		if i == 0 {
+			assert.Equal(t, metrics.Metrics, part.Metrics)
+			assert.Equal(t, metrics.Forwarder, part.Forwarder)
+		} else {
+			assert.Nil(t, part.Metrics)
+			assert.Nil(t, part.Forwarder)
+		}
+		uplinks += len(part.Uplinks)
+	}
+	assert.Equal(t, 100, uplinks)
+	assert.Equal(t, metrics.Uplinks[0], parts[0].Uplinks[0])
+	assert.Equal(t, metrics.Stats, parts[len(parts)-1].Stats)
+	assert.Equal(t, metrics.Events, parts[len(parts)-1].Events)
+
+	// Items larger than the budget are sent on their own
+	parts = splitMetrics(metrics, 10)
+	assert.Len(t, parts, 103)
+	assert.Empty(t, parts[0].Uplinks)
+	assert.Len(t, parts[1].Uplinks, 1)
+}
+
+func TestPushCompression(t *testing.T) {
+	for _, tc := range []struct {
+		offer    string
+		accepted []string
+		expected string
+	}{
+		{"", []string{"gzip", "zstd"}, "zstd"},
+		{"gzip", []string{"gzip", "zstd"}, "gzip"},
+		{"", []string{"gzip"}, "gzip"},
+		{"", nil, ""},
+		{"none", []string{"gzip", "zstd"}, ""},
+	} {
+		server := startFakeServer(t, &fakeServer{compression: tc.accepted})
+		config := server.clientConfig()
+		config.Compression = tc.offer
+		c := CreateAnalyticsClient(config)
+		assert.NoError(t, c.Connect())
+		assert.Equal(t, tc.expected, c.Compression())
+
+		assert.NoError(t, c.PushMetrics(testMetrics(200)))
+		c.Disconnect()
+
+		assert.Len(t, server.pushes, 1)
+		assert.Len(t, server.pushes[0].Uplinks, 200)
+		stats := c.Stats()
+		assert.Equal(t, uint64(1), stats.Pushes)
+		assert.Equal(t, uint64(proto.Size(testMetrics(200))), stats.RawBytes)
+		if tc.expected == "" {
+			assert.Nil(t, server.encoding)
+			assert.Equal(t, int64(0), stats.BytesSaved())
+		} else {
+			assert.Equal(t, []string{tc.expected}, server.encoding)
+			assert.Greater(t, stats.BytesSaved(), int64(stats.RawBytes/2))
+		}
+	}
+}
+
+func TestPushSplitting(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{maxMessageSize: 4096})
+	config := server.clientConfig()
+	config.MaxMessageSize = 8192
+	c := CreateAnalyticsClient(config)
+	assert.NoError(t, c.Connect())
+	defer c.Disconnect()
+
+	// The smallest of the configured and the advertised limits is used
+	metrics := testMetrics(500)
+	assert.NoError(t, c.PushMetrics(metrics))
+	assert.Greater(t, len(server.pushes), 5)
+	uplinks := 0
+	for _, push := range server.pushes {
+		assert.LessOrEqual(t, proto.Size(push), 4096)
+		assert.Equal(t, metrics.GatewayEui, push.GatewayEui)
+		uplinks += len(push.Uplinks)
+	}
+	assert.Equal(t, 500, uplinks)
+	assert.Equal(t, uint64(len(server.pushes)), c.Stats().Pushes)
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"google.golang.org/grpc/metadata"
+)
+
+// The control channel of a forwarder, where the server pushes changes of the
+// configuration and commands
+type ControlChannel struct {
+	stream api.AnalyticsServer_ControlClient
+	cancel context.CancelFunc
+}
+
+// Opens the control channel, sending the initial report with the state of the
+// forwarder. The channel stays open until the context is done or it's closed.
+//
+// The channel is only available over gRPC, and it's not safe to push metrics
+// with the same client while the channel is open.
+func (c *Client) OpenControl(ctx context.Context, report *api.ControlReport) (*ControlChannel, error) {
+	if _, err := c.queryClient(); err != nil {
+		return nil, err
+	}
+
+	var ch *ControlChannel
+	err := c.withReconnect(func(conn *connection) error {
+		c.limiter.wait(0)
+		client, err := conn.queryClient()
+		if err != nil {
+			return err
+		}
+
+		streamCtx, cancel := context.WithCancel(metadata.AppendToOutgoingContext(ctx, "token", conn.session.Token))
+		stream, err := client.Control(streamCtx)
+		if err == nil {
+			err = stream.Send(report)
+		}
+		if err != nil {
+			cancel()
+			return err
+		}
+		ch = &ControlChannel{stream: stream, cancel: cancel}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// Waits for the next message of the server
+func (ch *ControlChannel) Recv() (*api.ControlMessage, error) {
+	return ch.stream.Recv()
+}
+
+// Reports the outcome of a message to the server
+func (ch *ControlChannel) Report(report *api.ControlReport) error {
+	return ch.stream.Send(report)
+}
+
+// Closes the channel
+func (ch *ControlChannel) Close() {
+	ch.stream.CloseSend()
+	ch.cancel()
+}
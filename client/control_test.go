@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestControl(t *testing.T) {
+	fake := startFakeServer(t, &fakeServer{
+		control: []*api.ControlMessage{
+			{Id: 1, Message: &api.ControlMessage_Config{Config: &api.ConfigPatch{
+				Version: 7,
+				Options: map[string]string{"flush-interval": "30"},
+			}}},
+			{Id: 2, Message: &api.ControlMessage_Command{Command: &api.ControlCommand{
+				Type: api.ControlCommandType_COMMAND_FLUSH,
+			}}},
+		},
+	})
+	client := CreateAnalyticsClient(fake.clientConfig())
+	assert.Nil(t, client.Connect())
+	defer client.Disconnect()
+
+	ch, err := client.OpenControl(context.Background(), &api.ControlReport{ForwarderVersion: "1.0"})
+	assert.Nil(t, err)
+
+	msg, err := ch.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), msg.Id)
+	assert.Equal(t, "30", msg.GetConfig().Options["flush-interval"])
+	assert.Nil(t, ch.Report(&api.ControlReport{Id: 1, ConfigVersion: 7}))
+
+	msg, err = ch.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, api.ControlCommandType_COMMAND_FLUSH, msg.GetCommand().Type)
+	assert.Nil(t, ch.Report(&api.ControlReport{Id: 2, ConfigVersion: 7, Error: "failed"}))
+
+	// The reports reach the server in order
+	assert.Eventually(t, func() bool {
+		fake.lock.Lock()
+		defer fake.lock.Unlock()
+		return len(fake.reports) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	fake.lock.Lock()
+	assert.Equal(t, "1.0", fake.reports[0].ForwarderVersion)
+	assert.Equal(t, uint64(7), fake.reports[1].ConfigVersion)
+	assert.Equal(t, "failed", fake.reports[2].Error)
+	fake.lock.Unlock()
+
+	ch.Close()
+	_, err = ch.Recv()
+	assert.NotNil(t, err)
+	assert.NotEqual(t, io.EOF, err)
+}
+
+func TestControlOverHTTPS(t *testing.T) {
+	fake := startFakeServer(t, &fakeServer{})
+	startFakeHTTPServer(t, fake)
+
+	config := fake.clientConfig()
+	config.Transport = TransportHTTPS
+	config.HTTPPort = fake.httpPort
+	client := CreateAnalyticsClient(config)
+	assert.Nil(t, client.Connect())
+	defer client.Disconnect()
+
+	_, err := client.OpenControl(context.Background(), &api.ControlReport{})
+	assert.Equal(t, ErrQueriesNotSupported, err)
+}
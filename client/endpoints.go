@@ -0,0 +1,192 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How frequently the preferred endpoints are probed while failed over, and
+// for how long an endpoint that failed is tried only after the others
+const defaultHealthCheckInterval = time.Minute
+
+// The SRV resolver, replaced in tests
+var lookupSRV = net.LookupSRV
+
+// An authenticated connection to one of the endpoints
+type connection struct {
+	address   string
+	transport Transport
+	session   Session
+}
+
+// Returns the endpoints to connect to, in order of preference.
+//
+// The targets of the SRV record (if configured) come first, sorted by priority
+// and weight, followed by the static endpoints as a fall-back.
+func (c *Client) resolveEndpoints() ([]string, error) {
+	var (
+		ret    []string
+		srvErr error
+	)
+	if c.config.EndpointSRV != "" {
+		_, addrs, err := lookupSRV("", "", c.config.EndpointSRV)
+		if err != nil {
+			srvErr = fmt.Errorf("could not resolve %s: %w", c.config.EndpointSRV, err)
+		}
+		for _, addr := range addrs {
+			host := strings.TrimSuffix(addr.Target, ".")
+			ret = append(ret, net.JoinHostPort(host, strconv.Itoa(int(addr.Port))))
+		}
+	}
+	if c.config.Endpoint != "" {
+		ret = append(ret, c.config.Endpoint)
+	}
+	ret = append(ret, c.config.Endpoints...)
+
+	if len(ret) == 0 {
+		if srvErr != nil {
+			return nil, srvErr
+		}
+		return []string{defaultEndpoint}, nil
+	}
+
+	// Drop the duplicates, keeping the most preferred position
+	seen := make(map[string]bool)
+	unique := ret[:0]
+	for _, address := range ret {
+		if !seen[address] {
+			seen[address] = true
+			unique = append(unique, address)
+		}
+	}
+	return unique, nil
+}
+
+// Returns the endpoints in the order to try them, where the ones that failed
+// recently are only tried after all the others
+func (c *Client) connectOrder(endpoints []string) []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var healthy, failed []string
+	for _, address := range endpoints {
+		if at, ok := c.failed[address]; ok && time.Since(at) < c.healthInterval {
+			failed = append(failed, address)
+		} else {
+			healthy = append(healthy, address)
+		}
+	}
+	return append(healthy, failed...)
+}
+
+// Remembers that the endpoint failed, so it's tried after the others
+func (c *Client) markFailed(address string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.failed[address] = time.Now()
+}
+
+// Replaces the active connection, probing the endpoints that are preferred over
+// the new one until one of them recovers
+func (c *Client) use(conn *connection, endpoints []string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.useLocked(conn, endpoints)
+}
+
+func (c *Client) useLocked(conn *connection, endpoints []string) {
+	c.stopProbe()
+	if c.transport != nil {
+		c.transport.Close()
+	}
+
+	c.transport = conn.transport
+	c.session = conn.session
+	c.address = conn.address
+	c.maxSize = defaultMaxMessageSize
+	if c.config.MaxMessageSize > 0 {
+		c.maxSize = int(c.config.MaxMessageSize)
+	}
+	if max := c.session.MaxMessageSize; max > 0 && max < c.maxSize {
+		c.maxSize = max
+	}
+	delete(c.failed, conn.address)
+
+	c.endpoints = endpoints
+	c.startProbe()
+}
+
+// Starts probing the endpoints that are preferred over the current one (if any),
+// with the lock held
+func (c *Client) startProbe() {
+	for i, address := range c.endpoints {
+		if address == c.address {
+			if i > 0 {
+				stop := make(chan struct{})
+				recovered := make(chan *connection)
+				c.stopProbing = stop
+				c.recovered = recovered
+				go c.probe(c.endpoints[:i], stop, recovered)
+			}
+			return
+		}
+	}
+}
+
+func (c *Client) stopProbe() {
+	if c.stopProbing != nil {
+		close(c.stopProbing)
+		c.stopProbing = nil
+		c.recovered = nil
+	}
+}
+
+// Periodically tries to connect to the preferred endpoints, handing over the
+// connection to the client once any of them is serving again
+func (c *Client) probe(preferred []string, stop chan struct{}, recovered chan *connection) {
+	ticker := time.NewTicker(c.healthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		for _, address := range preferred {
+			conn, err := c.connectTo(address)
+			if err != nil {
+				continue
+			}
+
+			select {
+			case recovered <- conn:
+			case <-stop:
+				conn.transport.Close()
+			}
+			return
+		}
+	}
+}
+
+// Moves back to a preferred endpoint if it recovered. The pushes still using the
+// previous connection are retried with the new one.
+func (c *Client) failBack() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	select {
+	case conn := <-c.recovered:
+		c.useLocked(conn, c.endpoints)
+	default:
+	}
+}
+
+// Returns the address of the endpoint the client is connected to
+func (c *Client) Endpoint() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.address
+}
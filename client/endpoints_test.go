@@ -0,0 +1,162 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveEndpoints(t *testing.T) {
+	c := CreateAnalyticsClient(AnalyticsClientConfig{})
+	endpoints, err := c.resolveEndpoints()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{defaultEndpoint}, endpoints)
+
+	c = CreateAnalyticsClient(AnalyticsClientConfig{
+		Endpoint:  "eu.example.com:50051",
+		Endpoints: []string{"apac.example.com:50051", "eu.example.com:50051"},
+	})
+	endpoints, err = c.resolveEndpoints()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"eu.example.com:50051", "apac.example.com:50051"}, endpoints)
+
+	// The SRV targets come first, with the static endpoints as a fall-back
+	defer func(fn func(string, string, string) (string, []*net.SRV, error)) { lookupSRV = fn }(lookupSRV)
+	lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+		assert.Equal(t, "_analytics._tcp.eu.example.com", name)
+		return "", []*net.SRV{
+			{Target: "eu1.example.com.", Port: 50051, Priority: 10},
+			{Target: "eu2.example.com.", Port: 50052, Priority: 20},
+		}, nil
+	}
+	c.config.EndpointSRV = "_analytics._tcp.eu.example.com"
+	endpoints, err = c.resolveEndpoints()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"eu1.example.com:50051", "eu2.example.com:50052", "eu.example.com:50051", "apac.example.com:50051"}, endpoints)
+
+	lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+		return "", nil, fmt.Errorf("no such host")
+	}
+	endpoints, err = c.resolveEndpoints()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"eu.example.com:50051", "apac.example.com:50051"}, endpoints)
+
+	c = CreateAnalyticsClient(AnalyticsClientConfig{EndpointSRV: "_analytics._tcp.eu.example.com"})
+	_, err = c.resolveEndpoints()
+	assert.EqualError(t, err, "could not resolve _analytics._tcp.eu.example.com: no such host")
+}
+
+func TestConnectOrder(t *testing.T) {
+	c := CreateAnalyticsClient(AnalyticsClientConfig{})
+	c.failed["a"] = time.Now()
+	c.failed["c"] = time.Now().Add(-2 * c.healthInterval)
+	assert.Equal(t, []string{"b", "c", "a"}, c.connectOrder([]string{"a", "b", "c"}))
+}
+
+func TestEndpointHealthCheck(t *testing.T) {
+	primary := startFakeServer(t, &fakeServer{})
+	secondary := startFakeServer(t, &fakeServer{})
+
+	// Unhealthy endpoints are skipped
+	primary.setServing(false)
+	config := primary.clientConfig()
+	config.Endpoints = []string{secondary.addr}
+	c := CreateAnalyticsClient(config)
+	c.healthInterval = 50 * time.Millisecond
+	assert.NoError(t, c.Connect())
+	defer c.Disconnect()
+	assert.Equal(t, secondary.addr, c.Endpoint())
+
+	assert.NoError(t, c.PushMetrics(testMetrics(1)))
+	assert.Equal(t, 0, primary.pushCount())
+	assert.Equal(t, 1, secondary.pushCount())
+
+	// The client moves back once the primary recovers
+	primary.setServing(true)
+	assert.Eventually(t, func() bool {
+		assert.NoError(t, c.PushMetrics(testMetrics(1)))
+		return c.Endpoint() == primary.addr
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, 1, primary.pushCount())
+}
+
+func TestEndpointFailBackConcurrent(t *testing.T) {
+	primary := startFakeServer(t, &fakeServer{})
+	secondary := startFakeServer(t, &fakeServer{})
+
+	primary.setServing(false)
+	config := primary.clientConfig()
+	config.Endpoints = []string{secondary.addr}
+	c := CreateAnalyticsClient(config)
+	c.healthInterval = 50 * time.Millisecond
+	assert.NoError(t, c.Connect())
+	defer c.Disconnect()
+	assert.Equal(t, secondary.addr, c.Endpoint())
+
+	// The pushes in flight when moving back to the primary are not lost
+	primary.setServing(true)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				assert.NoError(t, c.PushMetrics(testMetrics(1)))
+				time.Sleep(5 * time.Millisecond)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, primary.addr, c.Endpoint())
+	assert.Equal(t, 80, primary.pushCount()+secondary.pushCount())
+}
+
+func TestEndpointFailover(t *testing.T) {
+	primary := startFakeServer(t, &fakeServer{})
+	secondary := startFakeServer(t, &fakeServer{})
+
+	reconnect := true
+	config := primary.clientConfig()
+	config.Endpoints = []string{secondary.addr}
+	config.AutoReconnect = &reconnect
+	c := CreateAnalyticsClient(config)
+	assert.NoError(t, c.Connect())
+	defer c.Disconnect()
+	assert.Equal(t, primary.addr, c.Endpoint())
+
+	// Pushes fail over to the secondary when the primary is unavailable
+	primary.server.Stop()
+	assert.NoError(t, c.PushMetrics(testMetrics(1)))
+	assert.Equal(t, secondary.addr, c.Endpoint())
+	assert.Equal(t, 1, secondary.pushCount())
+}
+
+func TestEndpointSRV(t *testing.T) {
+	primary := startFakeServer(t, &fakeServer{})
+	secondary := startFakeServer(t, &fakeServer{})
+
+	defer func(fn func(string, string, string) (string, []*net.SRV, error)) { lookupSRV = fn }(lookupSRV)
+	lookupSRV = func(service, proto, name string) (string, []*net.SRV, error) {
+		var ret []*net.SRV
+		for _, addr := range []string{primary.addr, secondary.addr} {
+			host, port, _ := net.SplitHostPort(addr)
+			p, _ := strconv.Atoi(port)
+			ret = append(ret, &net.SRV{Target: host + ".", Port: uint16(p)})
+		}
+		return "", ret, nil
+	}
+
+	primary.setServing(false)
+	config := primary.clientConfig()
+	config.Endpoint = ""
+	config.EndpointSRV = "_analytics._tcp.example.com"
+	c := CreateAnalyticsClient(config)
+	assert.NoError(t, c.Connect())
+	defer c.Disconnect()
+	assert.Equal(t, secondary.addr, c.Endpoint())
+}
@@ -0,0 +1,184 @@
+package client
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"google.golang.org/grpc/metadata"
+)
+
+// Iterates over the results of a streaming query:
+//
+//	it, err := client.QueryUplinks(ctx, &api.ReqQueryUplinks{...})
+//	if err != nil {
+//		return err
+//	}
+//	defer it.Close()
+//	for it.Next() {
+//		record := it.Value()
+//		...
+//	}
+//	return it.Err()
+type Iterator[T any] struct {
+	recv   func() (T, error)
+	cancel context.CancelFunc
+	value  T
+	err    error
+	done   bool
+}
+
+func newIterator[T any](recv func() (T, error), cancel context.CancelFunc) *Iterator[T] {
+	return &Iterator[T]{recv: recv, cancel: cancel}
+}
+
+// Advances to the next result, returning false when there are no more results
+// or the query failed
+func (it *Iterator[T]) Next() bool {
+	if it.done {
+		return false
+	}
+	value, err := it.recv()
+	if err != nil {
+		if err != io.EOF {
+			it.err = err
+		}
+		it.Close()
+		return false
+	}
+	it.value = value
+	return true
+}
+
+// Returns the current result
+func (it *Iterator[T]) Value() T {
+	return it.value
+}
+
+// Returns the error that stopped the iteration (nil if all the results were read)
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Stops the query, which is safe to call more than once
+func (it *Iterator[T]) Close() {
+	if !it.done {
+		it.done = true
+		it.cancel()
+	}
+}
+
+// Returns the gRPC client of the active transport, since the queries are not
+// available over HTTPS
+func (c *Client) queryClient() (api.AnalyticsServerClient, error) {
+	conn := c.current()
+	if conn == nil {
+		return nil, ErrNotConnected
+	}
+	return conn.queryClient()
+}
+
+func (conn *connection) queryClient() (api.AnalyticsServerClient, error) {
+	transport, ok := conn.transport.(*grpcTransport)
+	if !ok {
+		return nil, ErrQueriesNotSupported
+	}
+	return transport.client, nil
+}
+
+// Opens a streaming query, where `open` starts the call in the context of the
+// session. Only starting the query is retried, since the results read so far
+// can't be taken back.
+func startQuery[T any](c *Client, ctx context.Context, open func(ctx context.Context, client api.AnalyticsServerClient) (func() (T, error), error)) (*Iterator[T], error) {
+	if _, err := c.queryClient(); err != nil {
+		return nil, err
+	}
+
+	var it *Iterator[T]
+	err := c.withReconnect(func(conn *connection) error {
+		// Only waits for the server to be ready again after ResourceExhausted
+		c.limiter.wait(0)
+		client, err := conn.queryClient()
+		if err != nil {
+			return err
+		}
+
+		streamCtx, cancel := context.WithCancel(metadata.AppendToOutgoingContext(ctx, "token", conn.session.Token))
+		recv, err := open(streamCtx, client)
+		if err != nil {
+			cancel()
+			return err
+		}
+		it = newIterator(recv, cancel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return it, nil
+}
+
+// Lists the gateways of the account, or only the ones seen after `since` if it's
+// not zero
+func (c *Client) ListGateways(ctx context.Context, since time.Time) (*Iterator[*api.GatewayInfo], error) {
+	req := &api.ReqListGateways{}
+	if !since.IsZero() {
+		req.SeenSince = since.UnixMicro()
+	}
+	return startQuery(c, ctx, func(ctx context.Context, client api.AnalyticsServerClient) (func() (*api.GatewayInfo, error), error) {
+		stream, err := client.ListGateways(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return stream.Recv, nil
+	})
+}
+
+// Queries the uplinks matching the request, newest first
+func (c *Client) QueryUplinks(ctx context.Context, req *api.ReqQueryUplinks) (*Iterator[*api.UplinkRecord], error) {
+	return startQuery(c, ctx, func(ctx context.Context, client api.AnalyticsServerClient) (func() (*api.UplinkRecord, error), error) {
+		stream, err := client.QueryUplinks(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return stream.Recv, nil
+	})
+}
+
+// Queries the statistics of a gateway, oldest first
+func (c *Client) QueryStats(ctx context.Context, req *api.ReqQueryStats) (*Iterator[*api.AnalyticsStat], error) {
+	return startQuery(c, ctx, func(ctx context.Context, client api.AnalyticsServerClient) (func() (*api.AnalyticsStat, error), error) {
+		stream, err := client.QueryStats(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return stream.Recv, nil
+	})
+}
+
+// Summarizes the traffic of a gateway over a time range
+func (c *Client) GetGatewaySummary(ctx context.Context, req *api.ReqGatewaySummary) (*api.GatewaySummary, error) {
+	if _, err := c.queryClient(); err != nil {
+		return nil, err
+	}
+
+	var resp *api.GatewaySummary
+	err := c.withReconnect(func(conn *connection) error {
+		c.limiter.wait(0)
+		client, err := conn.queryClient()
+		if err != nil {
+			return err
+		}
+
+		callCtx := metadata.AppendToOutgoingContext(ctx, "token", conn.session.Token)
+		if c.reqTimeout != 0 {
+			var cancel context.CancelFunc
+			callCtx, cancel = context.WithTimeout(callCtx, c.reqTimeout)
+			defer cancel()
+		}
+		resp, err = client.GetGatewaySummary(callCtx, req)
+		return err
+	})
+	return resp, err
+}
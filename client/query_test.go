@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func queryServer(t *testing.T) (*fakeServer, *Client) {
+	fake := &fakeServer{
+		gateways: []*api.GatewayInfo{
+			{GatewayId: "gw-1", GatewayEui: []byte{1, 2, 3, 4, 5, 6, 7, 8}, LastSeen: 1000},
+			{GatewayId: "gw-2", LastSeen: 2000},
+		},
+	}
+	for i := 0; i < 50; i++ {
+		fake.uplinks = append(fake.uplinks, &api.UplinkRecord{
+			GatewayId: fmt.Sprintf("gw-%d", i%2+1),
+			Uplink:    &api.AnalyticsUplink{RxWallTime: int64(1000 - i)},
+		})
+	}
+	startFakeServer(t, fake)
+
+	client := CreateAnalyticsClient(fake.clientConfig())
+	assert.Nil(t, client.Connect())
+	t.Cleanup(func() { client.Disconnect() })
+	return fake, client
+}
+
+func TestListGateways(t *testing.T) {
+	_, client := queryServer(t)
+
+	it, err := client.ListGateways(context.Background(), time.Time{})
+	assert.Nil(t, err)
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Value().GatewayId)
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, []string{"gw-1", "gw-2"}, ids)
+
+	// Only the gateways seen since the given time
+	it, err = client.ListGateways(context.Background(), time.UnixMicro(1500))
+	assert.Nil(t, err)
+	assert.True(t, it.Next())
+	assert.Equal(t, "gw-2", it.Value().GatewayId)
+	assert.False(t, it.Next())
+	assert.Nil(t, it.Err())
+}
+
+func TestQueryUplinks(t *testing.T) {
+	fake, client := queryServer(t)
+
+	req := &api.ReqQueryUplinks{
+		Gateway: &api.GatewaySelector{GatewayId: "gw-1"},
+		Range:   &api.TimeRange{Start: 100, End: 2000},
+		DevAddr: []uint32{0x26011234},
+		Limit:   20,
+	}
+	it, err := client.QueryUplinks(context.Background(), req)
+	assert.Nil(t, err)
+	count := 0
+	for it.Next() {
+		assert.Equal(t, int64(1000-count), it.Value().Uplink.RxWallTime)
+		count++
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, 20, count)
+
+	// The filters reach the server
+	fake.lock.Lock()
+	assert.Equal(t, 1, len(fake.queries))
+	assert.True(t, proto.Equal(req, fake.queries[0]))
+	fake.lock.Unlock()
+}
+
+func TestQueryClose(t *testing.T) {
+	_, client := queryServer(t)
+
+	it, err := client.QueryUplinks(context.Background(), &api.ReqQueryUplinks{})
+	assert.Nil(t, err)
+	assert.True(t, it.Next())
+	it.Close()
+	it.Close()
+	assert.False(t, it.Next())
+	assert.Nil(t, it.Err())
+}
+
+func TestQueryError(t *testing.T) {
+	_, client := queryServer(t)
+
+	it, err := client.QueryStats(context.Background(), &api.ReqQueryStats{
+		Gateway: &api.GatewaySelector{GatewayId: "gw-3"},
+	})
+	assert.Nil(t, err)
+	assert.False(t, it.Next())
+	assert.Equal(t, codes.NotFound, status.Code(it.Err()))
+}
+
+func TestGetGatewaySummary(t *testing.T) {
+	_, client := queryServer(t)
+
+	summary, err := client.GetGatewaySummary(context.Background(), &api.ReqGatewaySummary{
+		Gateway: &api.GatewaySelector{GatewayId: "gw-1"},
+		Range:   &api.TimeRange{Start: 100},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "gw-1", summary.Gateway.GatewayId)
+	assert.Equal(t, int64(100), summary.Range.Start)
+	assert.Equal(t, uint64(50), summary.Uplinks)
+
+	_, err = client.GetGatewaySummary(context.Background(), &api.ReqGatewaySummary{
+		Gateway: &api.GatewaySelector{GatewayId: "gw-3"},
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestQueryOverHTTPS(t *testing.T) {
+	fake := startFakeServer(t, &fakeServer{})
+	startFakeHTTPServer(t, fake)
+
+	config := fake.clientConfig()
+	config.Transport = TransportHTTPS
+	config.HTTPPort = fake.httpPort
+	client := CreateAnalyticsClient(config)
+	assert.Nil(t, client.Connect())
+	defer client.Disconnect()
+
+	_, err := client.ListGateways(context.Background(), time.Time{})
+	assert.Equal(t, ErrQueriesNotSupported, err)
+	_, err = client.GetGatewaySummary(context.Background(), &api.ReqGatewaySummary{})
+	assert.Equal(t, ErrQueriesNotSupported, err)
+}
+
+func TestQueryNotConnected(t *testing.T) {
+	client := CreateAnalyticsClient(AnalyticsClientConfig{})
+	_, err := client.QueryUplinks(context.Background(), &api.ReqQueryUplinks{})
+	assert.Equal(t, ErrNotConnected, err)
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// A token bucket pacing the pushes (in uncompressed bytes), at the smallest of
+// the configured rate and the rate hinted by the server. The pushes can come
+// from several goroutines, so the bucket is guarded by a lock that is released
+// before sleeping.
+type rateLimiter struct {
+	lock sync.Mutex
+
+	// The configured rate and the one hinted by the server (0 for no limit)
+	configRate  float64
+	serverRate  float64
+	serverBurst float64
+
+	tokens    float64
+	last      time.Time
+	notBefore time.Time
+
+	// The clock, replaced in tests
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+func newRateLimiter(rate int) *rateLimiter {
+	return &rateLimiter{
+		configRate: float64(rate),
+		now:        time.Now,
+		sleep:      time.Sleep,
+	}
+}
+
+// Returns the sustained rate and the burst, where a zero rate means no limit
+func (r *rateLimiter) limits() (float64, float64) {
+	rate := r.configRate
+	if r.serverRate > 0 && (rate == 0 || r.serverRate < rate) {
+		rate = r.serverRate
+	}
+	burst := r.serverBurst
+	if burst == 0 {
+		burst = rate
+	}
+	return rate, burst
+}
+
+// Applies the hints of the server from the response of a push
+func (r *rateLimiter) update(resp *api.RespPush) {
+	if resp == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.serverRate = float64(resp.RateLimit)
+	r.serverBurst = float64(resp.BurstLimit)
+	if resp.RetryAfter > 0 {
+		r.delayLocked(time.Duration(resp.RetryAfter) * time.Millisecond)
+	}
+}
+
+// Holds back the next push for the given time
+func (r *rateLimiter) delay(d time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.delayLocked(d)
+}
+
+func (r *rateLimiter) delayLocked(d time.Duration) {
+	if until := r.now().Add(d); until.After(r.notBefore) {
+		r.notBefore = until
+	}
+}
+
+// Waits until `size` bytes can be pushed and takes them from the bucket,
+// returning how long it waited. Pushes larger than the burst only wait for
+// the bucket to fill up.
+func (r *rateLimiter) wait(size int) time.Duration {
+	waited := r.reserve(size)
+	r.sleep(waited)
+	return waited
+}
+
+// Takes `size` bytes from the bucket and returns how long the caller has to
+// wait before pushing them
+func (r *rateLimiter) reserve(size int) time.Duration {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var waited time.Duration
+	now := r.now()
+	if now.Before(r.notBefore) {
+		waited = r.notBefore.Sub(now)
+		now = r.notBefore
+	}
+
+	rate, burst := r.limits()
+	if rate == 0 {
+		r.last = now
+		return waited
+	}
+
+	// Refill the bucket, which starts full
+	if r.last.IsZero() {
+		r.tokens = burst
+	} else if elapsed := now.Sub(r.last).Seconds(); elapsed > 0 {
+		r.tokens += elapsed * rate
+	}
+	if r.tokens > burst {
+		r.tokens = burst
+	}
+
+	need := float64(size)
+	if need > burst {
+		need = burst
+	}
+	if r.tokens < need {
+		d := time.Duration((need - r.tokens) / rate * float64(time.Second))
+		waited += d
+		now = now.Add(d)
+		r.tokens = need
+	}
+	r.tokens -= need
+	r.last = now
+	return waited
+}
+
+// Returns the time to wait before retrying, as requested by the server in the
+// error details (0 if not specified)
+func retryDelay(err error) time.Duration {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.RetryDelay != nil {
+			return info.RetryDelay.AsDuration()
+		}
+	}
+	return 0
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Creates a rate limiter with a clock that only advances when sleeping
+func testRateLimiter(rate int) (*rateLimiter, *time.Time) {
+	now := time.Unix(1677664800, 0)
+	r := newRateLimiter(rate)
+	r.now = func() time.Time { return now }
+	r.sleep = func(d time.Duration) { now = now.Add(d) }
+	return r, &now
+}
+
+func TestRateLimiter(t *testing.T) {
+	// No limit
+	r, _ := testRateLimiter(0)
+	assert.Equal(t, time.Duration(0), r.wait(1000000))
+
+	// The bucket starts full, and then refills at the configured rate
+	r, now := testRateLimiter(1000)
+	assert.Equal(t, time.Duration(0), r.wait(1000))
+	assert.Equal(t, 500*time.Millisecond, r.wait(500))
+	*now = now.Add(2 * time.Second)
+	assert.Equal(t, time.Duration(0), r.wait(1000))
+
+	// Pushes larger than the burst wait for a full bucket
+	assert.Equal(t, time.Second, r.wait(5000))
+
+	// The server can lower the rate, but not raise it
+	r.update(&api.RespPush{RateLimit: 100})
+	assert.Equal(t, time.Second, r.wait(100))
+	r.update(&api.RespPush{RateLimit: 100000, BurstLimit: 2000})
+	*now = now.Add(time.Hour)
+	assert.Equal(t, time.Duration(0), r.wait(2000))
+	assert.Equal(t, time.Second, r.wait(1000))
+
+	// Or ask for a pause
+	*now = now.Add(time.Hour)
+	r.update(&api.RespPush{RetryAfter: 1500})
+	assert.Equal(t, 1500*time.Millisecond, r.wait(1))
+
+	// The limits of the server are replaced by the last response
+	r, _ = testRateLimiter(0)
+	r.update(&api.RespPush{RateLimit: 10})
+	r.wait(10)
+	assert.Equal(t, time.Second, r.wait(10))
+	r.update(&api.RespPush{})
+	assert.Equal(t, time.Duration(0), r.wait(10))
+}
+
+func TestRateLimiterConcurrent(t *testing.T) {
+	r := newRateLimiter(1000)
+	r.sleep = func(time.Duration) {}
+
+	// Every push takes its share of the bucket, whichever goroutine it comes from
+	var wg sync.WaitGroup
+	var waited atomic.Int64
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 2; j++ {
+				waited.Add(int64(r.wait(250)))
+				r.update(&api.RespPush{RateLimit: 1000})
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The first 1000 bytes are the burst, the rest wait for a refill
+	assert.InDelta(t, float64(time.Second), float64(waited.Load()), float64(100*time.Millisecond))
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Duration(0), retryDelay(status.Error(codes.ResourceExhausted, "slow down")))
+
+	st, _ := status.New(codes.ResourceExhausted, "slow down").WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(3 * time.Second),
+	})
+	assert.Equal(t, 3*time.Second, retryDelay(st.Err()))
+
+	now := time.Unix(1677664800, 0)
+	assert.Equal(t, 120*time.Second, parseRetryAfter("120", now))
+	assert.Equal(t, 30*time.Second, parseRetryAfter(now.Add(30*time.Second).UTC().Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+}
+
+func TestPushBackpressure(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{
+		exhausted:  2,
+		retryDelay: 50 * time.Millisecond,
+		hints:      &api.RespPush{RetryAfter: 100},
+	})
+	c := CreateAnalyticsClient(server.clientConfig())
+	assert.NoError(t, c.Connect())
+	defer c.Disconnect()
+
+	// The exhausted pushes are retried with the same data, even without reconnecting
+	metrics := testMetrics(10)
+	start := time.Now()
+	assert.NoError(t, c.PushMetrics(metrics))
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(100*time.Millisecond))
+	assert.Len(t, server.pushes, 1)
+	assert.True(t, proto.Equal(metrics, server.pushes[0]))
+
+	// The next push waits as hinted by the server
+	assert.NoError(t, c.PushMetrics(metrics))
+	assert.Greater(t, int64(c.Stats().Throttled), int64(150*time.Millisecond))
+	assert.Len(t, server.pushes, 2)
+
+	// Pushes larger than the limit of the server are not retried
+	c.session.MaxMessageSize = 100
+	c.maxSize = 0
+	assert.Error(t, c.PushMetrics(metrics))
+}
+
+func TestPushBackpressureGivesUp(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{
+		exhausted:  100,
+		retryDelay: time.Millisecond,
+	})
+	c := CreateAnalyticsClient(server.clientConfig())
+	assert.NoError(t, c.Connect())
+	defer c.Disconnect()
+
+	// Without re-connecting, an overloaded server is only retried a few times
+	err := c.PushMetrics(testMetrics(1))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, 100-1-maxExhaustedRetries, server.exhausted)
+	assert.Len(t, server.pushes, 0)
+}
+
+func TestHTTPBackpressure(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{exhausted: 1})
+	startFakeHTTPServer(t, server)
+	config := server.clientConfig()
+	config.Transport = TransportHTTPS
+	config.HTTPPort = server.httpPort
+	c := CreateAnalyticsClient(config)
+	assert.NoError(t, c.Connect())
+	defer c.Disconnect()
+
+	_, err := c.transport.PushMetrics(context.Background(), &c.session, testMetrics(1))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, time.Second, retryDelay(err))
+}
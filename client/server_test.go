@@ -0,0 +1,405 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// An in-process analytics server for testing the client
+type fakeServer struct {
+	api.UnimplementedAnalyticsServerServer
+
+	// The compressions the server accepts, and the largest message it advertises
+	compression    []string
+	maxMessageSize uint32
+	// How many pushes to reject as exhausted, and the hints to respond with
+	exhausted  int
+	retryDelay time.Duration
+	hints      *api.RespPush
+	// The results of the queries
+	gateways []*api.GatewayInfo
+	uplinks  []*api.UplinkRecord
+	// The live traffic, after which subscriptions end with the error (or stay
+	// open if nil)
+	traffic  []*api.TrafficEvent
+	endError error
+	// The messages of the control channel, each sent after the previous report
+	control []*api.ControlMessage
+
+	lock     sync.Mutex
+	hello    *api.ReqHello
+	pushes   []*api.AnalyticsMetrics
+	encoding []string
+	httpReqs []string
+	queries  []proto.Message
+	reports  []*api.ControlReport
+
+	addr     string
+	caFile   string
+	server   *grpc.Server
+	health   *health.Server
+	httpPort int32
+}
+
+// A self-signed certificate for 'localhost', shared by all the servers so that
+// clients can fail over between them
+var (
+	testCertOnce sync.Once
+	testCert     tls.Certificate
+	testCertPem  []byte
+)
+
+func createTestCert(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCert = tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	testCertPem = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// Starts the server on a random port of 'localhost'
+func startFakeServer(t *testing.T, fake *fakeServer) *fakeServer {
+	testCertOnce.Do(func() { createTestCert(t) })
+	fake.caFile = filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(fake.caFile, testCertPem, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ := net.SplitHostPort(lis.Addr().String())
+	fake.addr = net.JoinHostPort("localhost", port)
+
+	fake.server = grpc.NewServer(
+		grpc.Creds(credentials.NewServerTLSFromCert(&testCert)),
+		grpc.StatsHandler(fake),
+	)
+	api.RegisterAnalyticsServerServer(fake.server, fake)
+	fake.health = health.NewServer()
+	healthpb.RegisterHealthServer(fake.server, fake.health)
+	go fake.server.Serve(lis)
+	t.Cleanup(fake.server.Stop)
+	return fake
+}
+
+func (s *fakeServer) clientConfig() AnalyticsClientConfig {
+	reconnect := false
+	return AnalyticsClientConfig{
+		ClientId:       "1122334455667788",
+		ClientKey:      "11223344556677889900aabbccddeeff",
+		Endpoint:       s.addr,
+		CAFile:         s.caFile,
+		ConnectTimeout: 5,
+		AutoReconnect:  &reconnect,
+	}
+}
+
+// Sets the status reported to the health checks of the clients
+func (s *fakeServer) setServing(serving bool) {
+	status := healthpb.HealthCheckResponse_SERVING
+	if !serving {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	s.health.SetServingStatus("", status)
+}
+
+func (s *fakeServer) pushCount() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.pushes)
+}
+
+func (s *fakeServer) Hello(ctx context.Context, req *api.ReqHello) (*api.RespHello, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.hello = req
+
+	resp := &api.RespHello{Revision: 1, Challenge: []byte("challenge"), MaxMessageSize: s.maxMessageSize}
+	for _, offered := range req.Compression {
+		for _, accepted := range s.compression {
+			if resp.Compression == "" && offered == accepted {
+				resp.Compression = offered
+			}
+		}
+	}
+	return resp, nil
+}
+
+func (s *fakeServer) Login(ctx context.Context, req *api.ReqLogin) (*api.RespLogin, error) {
+	return &api.RespLogin{AccessToken: "token"}, nil
+}
+
+func checkToken(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if token := md.Get("token"); len(token) != 1 || token[0] != "token" {
+		return status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return nil
+}
+
+func (s *fakeServer) PushMetrics(ctx context.Context, req *api.AnalyticsMetrics) (*api.RespPush, error) {
+	if err := checkToken(ctx); err != nil {
+		return nil, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.exhausted > 0 {
+		s.exhausted--
+		st, _ := status.New(codes.ResourceExhausted, "slow down").WithDetails(&errdetails.RetryInfo{
+			RetryDelay: durationpb.New(s.retryDelay),
+		})
+		return nil, st.Err()
+	}
+	s.pushes = append(s.pushes, req)
+	if s.hints != nil {
+		return s.hints, nil
+	}
+	return &api.RespPush{}, nil
+}
+
+func (s *fakeServer) recordQuery(ctx context.Context, req proto.Message) error {
+	if err := checkToken(ctx); err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.queries = append(s.queries, req)
+	return nil
+}
+
+func (s *fakeServer) ListGateways(req *api.ReqListGateways, stream api.AnalyticsServer_ListGatewaysServer) error {
+	if err := s.recordQuery(stream.Context(), req); err != nil {
+		return err
+	}
+	for _, gw := range s.gateways {
+		if gw.LastSeen >= req.SeenSince {
+			if err := stream.Send(gw); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *fakeServer) QueryUplinks(req *api.ReqQueryUplinks, stream api.AnalyticsServer_QueryUplinksServer) error {
+	if err := s.recordQuery(stream.Context(), req); err != nil {
+		return err
+	}
+	for i, record := range s.uplinks {
+		if req.Limit > 0 && i == int(req.Limit) {
+			break
+		}
+		if err := stream.Send(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fakeServer) QueryStats(req *api.ReqQueryStats, stream api.AnalyticsServer_QueryStatsServer) error {
+	if err := s.recordQuery(stream.Context(), req); err != nil {
+		return err
+	}
+	return status.Error(codes.NotFound, "unknown gateway")
+}
+
+func (s *fakeServer) GetGatewaySummary(ctx context.Context, req *api.ReqGatewaySummary) (*api.GatewaySummary, error) {
+	if err := s.recordQuery(ctx, req); err != nil {
+		return nil, err
+	}
+	for _, gw := range s.gateways {
+		if gw.GatewayId == req.Gateway.GetGatewayId() {
+			return &api.GatewaySummary{Gateway: gw, Range: req.Range, Uplinks: uint64(len(s.uplinks))}, nil
+		}
+	}
+	return nil, status.Error(codes.NotFound, "unknown gateway")
+}
+
+func (s *fakeServer) Subscribe(req *api.ReqSubscribe, stream api.AnalyticsServer_SubscribeServer) error {
+	if err := s.recordQuery(stream.Context(), req); err != nil {
+		return err
+	}
+	for _, event := range s.traffic {
+		if err := stream.Send(event); err != nil {
+			return err
+		}
+	}
+	if s.endError != nil {
+		return s.endError
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+func (s *fakeServer) Control(stream api.AnalyticsServer_ControlServer) error {
+	if err := checkToken(stream.Context()); err != nil {
+		return err
+	}
+	next := 0
+	for {
+		report, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+		s.lock.Lock()
+		s.reports = append(s.reports, report)
+		s.lock.Unlock()
+
+		if next < len(s.control) {
+			if err := stream.Send(s.control[next]); err != nil {
+				return err
+			}
+			next++
+		}
+	}
+}
+
+// Records the compression of the pushes
+func (s *fakeServer) HandleRPC(_ context.Context, rs stats.RPCStats) {
+	in, ok := rs.(*stats.InHeader)
+	if !ok || in.FullMethod != "/api.AnalyticsServer/PushMetrics" || in.Compression == "" {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.encoding = append(s.encoding, in.Compression)
+}
+
+func (s *fakeServer) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (s *fakeServer) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (s *fakeServer) HandleConn(context.Context, stats.ConnStats) {}
+
+// Serves the same API over HTTPS/1.1 on another port
+func startFakeHTTPServer(t *testing.T, fake *fakeServer) {
+	server := httptest.NewUnstartedServer(fake)
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{testCert}}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	fake.httpPort = int32(p)
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	s.httpReqs = append(s.httpReqs, r.Method+" "+r.URL.Path+" "+r.Header.Get("Content-Type"))
+	s.lock.Unlock()
+
+	var body io.Reader = r.Body
+	if name := r.Header.Get("Content-Encoding"); name != "" {
+		s.lock.Lock()
+		s.encoding = append(s.encoding, name)
+		s.lock.Unlock()
+		body, _ = encoding.GetCompressor(name).Decompress(r.Body)
+	}
+	payload, _ := io.ReadAll(body)
+
+	isJson := r.Header.Get("Content-Type") == "application/json"
+	decode := func(m proto.Message) {
+		if isJson {
+			protojson.Unmarshal(payload, m)
+		} else {
+			proto.Unmarshal(payload, m)
+		}
+	}
+
+	var (
+		resp proto.Message
+		err  error
+	)
+	ctx := metadata.NewIncomingContext(r.Context(), metadata.Pairs("token", r.Header.Get("Token")))
+	switch strings.TrimPrefix(r.URL.Path, "/api/v1/analytics/") {
+	case "hello":
+		req := &api.ReqHello{}
+		decode(req)
+		resp, err = s.Hello(ctx, req)
+	case "login":
+		req := &api.ReqLogin{}
+		decode(req)
+		resp, err = s.Login(ctx, req)
+	case "push":
+		req := &api.AnalyticsMetrics{}
+		decode(req)
+		resp, err = s.PushMetrics(ctx, req)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if status.Code(err) == codes.ResourceExhausted {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var out []byte
+	if isJson {
+		out, _ = protojson.Marshal(resp)
+	} else {
+		out, _ = proto.Marshal(resp)
+	}
+	w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+	w.Write(out)
+}
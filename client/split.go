@@ -0,0 +1,78 @@
+package client
+
+import (
+	"github.com/kudzutechnologies/analytics/api"
+	"google.golang.org/protobuf/proto"
+)
+
+// The largest (uncompressed) push when neither the configuration nor the
+// server give a limit, well below the 4 MiB default of gRPC servers
+const defaultMaxMessageSize = 1024 * 1024
+
+// The encoded size of a repeated message field, including its tag and length
+func fieldSize(m proto.Message) int {
+	size := proto.Size(m)
+	return 1 + varintSize(uint64(size)) + size
+}
+
+func varintSize(v uint64) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+	return n
+}
+
+// Splits the metrics into parts that are encoded in at most `budget` bytes
+// each. Every part is for the same gateway, while the internal metrics, the
+// aggregate and the health of the forwarder are only sent in the first one.
+// Items that are larger than the budget on their own are sent in a part of
+// their own.
+func splitMetrics(metrics *api.AnalyticsMetrics, budget int) []*api.AnalyticsMetrics {
+	if budget <= 0 || proto.Size(metrics) <= budget {
+		return []*api.AnalyticsMetrics{metrics}
+	}
+
+	newPart := func() *api.AnalyticsMetrics {
+		return &api.AnalyticsMetrics{
+			GatewayId:  metrics.GatewayId,
+			GatewayEui: metrics.GatewayEui,
+		}
+	}
+	first := newPart()
+	first.Metrics = metrics.Metrics
+	first.Aggregate = metrics.Aggregate
+	first.Forwarder = metrics.Forwarder
+
+	parts := []*api.AnalyticsMetrics{first}
+	current := first
+	currentSize := proto.Size(first)
+	reserve := func(m proto.Message) {
+		size := fieldSize(m)
+		if currentSize+size > budget && currentSize > proto.Size(newPart()) {
+			current = newPart()
+			currentSize = proto.Size(current)
+			parts = append(parts, current)
+		}
+		currentSize += size
+	}
+
+	for _, up := range metrics.Uplinks {
+		reserve(up)
+		current.Uplinks = append(current.Uplinks, up)
+	}
+	for _, dn := range metrics.Downlinks {
+		reserve(dn)
+		current.Downlinks = append(current.Downlinks, dn)
+	}
+	for _, stat := range metrics.Stats {
+		reserve(stat)
+		current.Stats = append(current.Stats, stat)
+	}
+	for _, event := range metrics.Events {
+		reserve(event)
+		current.Events = append(current.Events, event)
+	}
+	return parts
+}
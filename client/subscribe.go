@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+
+	"github.com/kudzutechnologies/analytics/api"
+)
+
+// How many events to buffer while the reader of a subscription is busy
+const subscriptionBuffer = 64
+
+// A subscription to the live traffic, delivering the events on a channel
+type Subscription struct {
+	events chan *api.TrafficEvent
+	cancel context.CancelFunc
+	err    error
+}
+
+// Subscribes to the live traffic matching the filter, until the context is
+// done or the subscription is closed.
+//
+// The events channel is closed when the subscription ends, after which Err()
+// returns the reason if it was not closed by the caller. The subscription does
+// not reconnect by itself, so subscribe again to resume.
+func (c *Client) Subscribe(ctx context.Context, filter *api.ReqSubscribe) (*Subscription, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	it, err := startQuery(c, ctx, func(ctx context.Context, client api.AnalyticsServerClient) (func() (*api.TrafficEvent, error), error) {
+		stream, err := client.Subscribe(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		return stream.Recv, nil
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	sub := &Subscription{
+		events: make(chan *api.TrafficEvent, subscriptionBuffer),
+		cancel: cancel,
+	}
+	go sub.run(ctx, it)
+	return sub, nil
+}
+
+func (s *Subscription) run(ctx context.Context, it *Iterator[*api.TrafficEvent]) {
+	defer close(s.events)
+	defer it.Close()
+	for it.Next() {
+		select {
+		case s.events <- it.Value():
+		case <-ctx.Done():
+			return
+		}
+	}
+	if ctx.Err() == nil {
+		s.err = it.Err()
+	}
+}
+
+// Returns the channel of the events, that is closed when the subscription ends
+func (s *Subscription) Events() <-chan *api.TrafficEvent {
+	return s.events
+}
+
+// Returns why the subscription ended, once the events channel is closed (nil
+// if it was closed or its context is done)
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+// Ends the subscription
+func (s *Subscription) Close() {
+	s.cancel()
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func subscribeServer(t *testing.T, endError error) (*fakeServer, *Client) {
+	fake := startFakeServer(t, &fakeServer{
+		traffic: []*api.TrafficEvent{
+			{GatewayId: "gw-1", Frame: &api.TrafficEvent_Uplink{Uplink: &api.AnalyticsUplink{Size: 23}}},
+			{GatewayId: "gw-1", Frame: &api.TrafficEvent_Downlink{Downlink: &api.AnalyticsDownlink{Size: 17}}},
+		},
+		endError: endError,
+	})
+	client := CreateAnalyticsClient(fake.clientConfig())
+	assert.Nil(t, client.Connect())
+	t.Cleanup(func() { client.Disconnect() })
+	return fake, client
+}
+
+// Reads the next event, failing if it takes too long
+func nextEvent(t *testing.T, sub *Subscription) (*api.TrafficEvent, bool) {
+	select {
+	case event, ok := <-sub.Events():
+		return event, ok
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+		return nil, false
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	fake, client := subscribeServer(t, nil)
+
+	filter := &api.ReqSubscribe{
+		Gateway:   &api.GatewaySelector{GatewayId: "gw-1"},
+		DevAddr:   []uint32{0x26011234},
+		FrameType: []api.FrameType{api.FrameType_FRAME_UNCONFIRMED_UP, api.FrameType_FRAME_CONFIRMED_UP},
+	}
+	sub, err := client.Subscribe(context.Background(), filter)
+	assert.Nil(t, err)
+
+	event, ok := nextEvent(t, sub)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(23), event.GetUplink().GetSize())
+	event, ok = nextEvent(t, sub)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(17), event.GetDownlink().GetSize())
+
+	fake.lock.Lock()
+	assert.True(t, proto.Equal(filter, fake.queries[0]))
+	fake.lock.Unlock()
+
+	// Closing ends the subscription without an error
+	sub.Close()
+	_, ok = nextEvent(t, sub)
+	assert.False(t, ok)
+	assert.Nil(t, sub.Err())
+}
+
+func TestSubscribeCancel(t *testing.T) {
+	_, client := subscribeServer(t, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := client.Subscribe(ctx, &api.ReqSubscribe{})
+	assert.Nil(t, err)
+	cancel()
+
+	// The buffered events may still arrive before the channel is closed
+	for {
+		if _, ok := nextEvent(t, sub); !ok {
+			break
+		}
+	}
+	assert.Nil(t, sub.Err())
+}
+
+func TestSubscribeError(t *testing.T) {
+	_, client := subscribeServer(t, status.Error(codes.Unavailable, "going away"))
+
+	sub, err := client.Subscribe(context.Background(), &api.ReqSubscribe{})
+	assert.Nil(t, err)
+	count := 0
+	for {
+		if _, ok := nextEvent(t, sub); !ok {
+			break
+		}
+		count++
+	}
+	assert.Equal(t, 2, count)
+	assert.Equal(t, codes.Unavailable, status.Code(sub.Err()))
+}
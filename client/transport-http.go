@@ -0,0 +1,246 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// The encodings of the HTTPS transport
+const (
+	HTTPEncodingProtobuf = "protobuf"
+	HTTPEncodingJSON     = "json"
+)
+
+// The path of the HTTPS API, on the same hosts as the gRPC endpoints
+const httpBasePath = "/api/v1/analytics/"
+
+// A transport using plain HTTPS/1.1 requests, for networks that block gRPC. It
+// uses the same Hello/Login/Push flow, with each call a POST of the encoded
+// request that responds with the encoded response.
+type httpTransport struct {
+	baseUrl string
+	json    bool
+	client  *http.Client
+	stats   *statsCollector
+}
+
+// Creates a transport to port 443 (by default) of the endpoint host, going
+// through the proxy in HTTPS_PROXY (if any)
+func (c *Client) dialHTTPS(ctx context.Context, address string) (Transport, error) {
+	tlsConfig, err := loadTLSConfig(&c.config)
+	if err != nil {
+		return nil, fmt.Errorf("could not load CA certificate: %w", err)
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+
+	json := false
+	switch c.config.HTTPEncoding {
+	case "", HTTPEncodingProtobuf:
+	case HTTPEncodingJSON:
+		json = true
+	default:
+		return nil, fmt.Errorf("unknown HTTP encoding '%s'", c.config.HTTPEncoding)
+	}
+
+	port := 443
+	if c.config.HTTPPort != 0 {
+		port = int(c.config.HTTPPort)
+	}
+
+	return &httpTransport{
+		baseUrl: "https://" + net.JoinHostPort(host, strconv.Itoa(port)) + httpBasePath,
+		json:    json,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+				// Only HTTP/1.1, since HTTP/2 is what gets blocked
+				TLSNextProto: map[string]func(string, *tls.Conn) http.RoundTripper{},
+			},
+		},
+		stats: &c.stats,
+	}, nil
+}
+
+// Maps the HTTP status codes to the gRPC ones, so the errors are handled the same
+func httpStatusCode(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.Unimplemented
+	case http.StatusRequestEntityTooLarge:
+		// Not retried, unlike the other ResourceExhausted errors
+		return codes.InvalidArgument
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codes.Unavailable
+	}
+	return codes.Unknown
+}
+
+// Parses the Retry-After header, that is either in seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+func (t *httpTransport) contentType() string {
+	if t.json {
+		return "application/json"
+	}
+	return "application/x-protobuf"
+}
+
+func (t *httpTransport) marshal(m proto.Message) ([]byte, error) {
+	if t.json {
+		return protojson.Marshal(m)
+	}
+	return proto.Marshal(m)
+}
+
+func (t *httpTransport) unmarshal(b []byte, m proto.Message) error {
+	if t.json {
+		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, m)
+	}
+	return proto.Unmarshal(b, m)
+}
+
+// Posts the request to the given method of the API, returning the errors as
+// gRPC status errors
+func (t *httpTransport) call(ctx context.Context, method string, session *Session, req proto.Message, resp proto.Message) error {
+	payload, err := t.marshal(req)
+	if err != nil {
+		return status.Errorf(codes.Internal, "could not encode request: %s", err.Error())
+	}
+
+	body := payload
+	compression := ""
+	if session != nil && session.Compression != "" {
+		var buf bytes.Buffer
+		w, err := encoding.GetCompressor(session.Compression).Compress(&buf)
+		if err == nil {
+			w.Write(payload)
+			err = w.Close()
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "could not compress request: %s", err.Error())
+		}
+		body = buf.Bytes()
+		compression = session.Compression
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.baseUrl+method, bytes.NewReader(body))
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	httpReq.Header.Set("Content-Type", t.contentType())
+	httpReq.Header.Set("Accept", t.contentType())
+	if compression != "" {
+		httpReq.Header.Set("Content-Encoding", compression)
+	}
+	if session != nil {
+		httpReq.Header.Set("Token", session.Token)
+	}
+
+	httpResp, err := t.client.Do(httpReq)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return status.Error(codes.DeadlineExceeded, err.Error())
+		}
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		message := strings.TrimSpace(string(respBody))
+		if len(message) > 256 || message == "" {
+			message = httpResp.Status
+		}
+		st := status.New(httpStatusCode(httpResp.StatusCode), message)
+		if delay := parseRetryAfter(httpResp.Header.Get("Retry-After"), time.Now()); delay > 0 {
+			st, _ = st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+		}
+		return st.Err()
+	}
+
+	if _, ok := req.(*api.AnalyticsMetrics); ok {
+		t.stats.record(len(payload), len(body))
+	}
+	if err := t.unmarshal(respBody, resp); err != nil {
+		return status.Errorf(codes.Internal, "could not decode response: %s", err.Error())
+	}
+	return nil
+}
+
+func (t *httpTransport) Hello(ctx context.Context, req *api.ReqHello) (*api.RespHello, error) {
+	resp := &api.RespHello{}
+	if err := t.call(ctx, "hello", nil, req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *httpTransport) Login(ctx context.Context, req *api.ReqLogin) (*api.RespLogin, error) {
+	resp := &api.RespLogin{}
+	if err := t.call(ctx, "login", nil, req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *httpTransport) PushMetrics(ctx context.Context, session *Session, metrics *api.AnalyticsMetrics) (*api.RespPush, error) {
+	resp := &api.RespPush{}
+	if err := t.call(ctx, "push", session, metrics, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *httpTransport) Name() string {
+	return TransportHTTPS
+}
+
+func (t *httpTransport) Close() error {
+	t.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// The transports the client can use
+const (
+	// Use gRPC, falling back to HTTPS when it's blocked
+	TransportAuto = ""
+	// Use only gRPC
+	TransportGRPC = "grpc"
+	// Use only HTTPS (eg. behind proxies that strip HTTP/2)
+	TransportHTTPS = "https"
+)
+
+// The transport carrying the calls to the analytics server
+type Transport interface {
+	Hello(ctx context.Context, req *api.ReqHello) (*api.RespHello, error)
+	Login(ctx context.Context, req *api.ReqLogin) (*api.RespLogin, error)
+	// Pushes the metrics in a session that was logged in
+	PushMetrics(ctx context.Context, session *Session, metrics *api.AnalyticsMetrics) (*api.RespPush, error)
+	// Returns the name of the transport (eg. 'grpc')
+	Name() string
+	Close() error
+}
+
+// Opens a transport to the given endpoint
+type TransportDialer func(ctx context.Context, address string) (Transport, error)
+
+// The session with the analytics server, as negotiated when logging in
+type Session struct {
+	// The access token for the calls
+	Token string
+	// The compression to use for the pushes (empty for none)
+	Compression string
+	// The largest message the server accepts (0 if not known)
+	MaxMessageSize int
+}
+
+// Remembers the endpoints where gRPC is blocked, so they are reached over
+// HTTPS without waiting for the gRPC connection to time-out every time
+type blockedEndpoints struct {
+	lock    sync.Mutex
+	blocked map[string]time.Time
+}
+
+func (b *blockedEndpoints) isBlocked(address string, expiry time.Duration) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	at, ok := b.blocked[address]
+	return ok && time.Since(at) < expiry
+}
+
+func (b *blockedEndpoints) set(address string, blocked bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.blocked == nil {
+		b.blocked = make(map[string]time.Time)
+	}
+	if blocked {
+		b.blocked[address] = time.Now()
+	} else {
+		delete(b.blocked, address)
+	}
+}
+
+// Opens the configured transport to the endpoint
+func (c *Client) openTransport(address string) (Transport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.connTimeout)
+	defer cancel()
+
+	if c.config.Dialer != nil {
+		return c.config.Dialer(ctx, address)
+	}
+
+	switch c.config.Transport {
+	case TransportGRPC:
+		return c.dialGRPC(ctx, address)
+	case TransportHTTPS:
+		return c.dialHTTPS(ctx, address)
+	case TransportAuto:
+		if !c.grpcBlocked.isBlocked(address, c.healthInterval) {
+			transport, err := c.dialGRPC(ctx, address)
+			if err == nil {
+				c.grpcBlocked.set(address, false)
+				return transport, nil
+			}
+			c.grpcBlocked.set(address, true)
+		}
+		return c.dialHTTPS(ctx, address)
+	}
+	return nil, fmt.Errorf("unknown transport '%s'", c.config.Transport)
+}
+
+// A transport using the gRPC service
+type grpcTransport struct {
+	conn   *grpc.ClientConn
+	client api.AnalyticsServerClient
+}
+
+// Dials the endpoint, making sure that it's serving
+func (c *Client) dialGRPC(ctx context.Context, address string) (Transport, error) {
+	tlsCredentials, err := loadTLSCredentials(&c.config)
+	if err != nil {
+		return nil, fmt.Errorf("could not load CA certificate: %w", err)
+	}
+
+	conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(tlsCredentials), grpc.WithBlock(),
+		grpc.WithStatsHandler(&c.stats))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", address, err)
+	}
+
+	err = checkHealth(ctx, conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("%s is not healthy: %w", address, err)
+	}
+	return &grpcTransport{conn: conn, client: api.NewAnalyticsServerClient(conn)}, nil
+}
+
+// Checks the health of the server using the gRPC health protocol, where servers
+// that don't implement it are considered healthy
+func checkHealth(ctx context.Context, conn *grpc.ClientConn) error {
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return status.Errorf(codes.Unavailable, "server is %s", resp.Status)
+	}
+	return nil
+}
+
+func (t *grpcTransport) Hello(ctx context.Context, req *api.ReqHello) (*api.RespHello, error) {
+	return t.client.Hello(ctx, req)
+}
+
+func (t *grpcTransport) Login(ctx context.Context, req *api.ReqLogin) (*api.RespLogin, error) {
+	return t.client.Login(ctx, req)
+}
+
+func (t *grpcTransport) PushMetrics(ctx context.Context, session *Session, metrics *api.AnalyticsMetrics) (*api.RespPush, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, "token", session.Token)
+
+	var opts []grpc.CallOption
+	if session.Compression != "" {
+		opts = append(opts, grpc.UseCompressor(session.Compression))
+	}
+	if session.MaxMessageSize > 0 {
+		opts = append(opts, grpc.MaxCallSendMsgSize(session.MaxMessageSize))
+	}
+	return t.client.PushMetrics(ctx, metrics, opts...)
+}
+
+func (t *grpcTransport) Name() string {
+	return TransportGRPC
+}
+
+func (t *grpcTransport) Close() error {
+	return t.conn.Close()
+}
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A transport that records the pushes in memory
+type fakeTransport struct {
+	address string
+	pushes  []*api.AnalyticsMetrics
+	tokens  []string
+	closed  bool
+}
+
+func (t *fakeTransport) Hello(ctx context.Context, req *api.ReqHello) (*api.RespHello, error) {
+	return &api.RespHello{Challenge: []byte("challenge"), Compression: "gzip"}, nil
+}
+
+func (t *fakeTransport) Login(ctx context.Context, req *api.ReqLogin) (*api.RespLogin, error) {
+	return &api.RespLogin{AccessToken: "fake-token"}, nil
+}
+
+func (t *fakeTransport) PushMetrics(ctx context.Context, session *Session, metrics *api.AnalyticsMetrics) (*api.RespPush, error) {
+	t.pushes = append(t.pushes, metrics)
+	t.tokens = append(t.tokens, session.Token)
+	return &api.RespPush{}, nil
+}
+
+func (t *fakeTransport) Name() string {
+	return "fake"
+}
+
+func (t *fakeTransport) Close() error {
+	t.closed = true
+	return nil
+}
+
+func TestCustomTransport(t *testing.T) {
+	transport := &fakeTransport{}
+	c := CreateAnalyticsClient(AnalyticsClientConfig{
+		ClientId:  "1122334455667788",
+		ClientKey: "11223344556677889900aabbccddeeff",
+		Endpoint:  "fake:1234",
+		Dialer: func(ctx context.Context, address string) (Transport, error) {
+			transport.address = address
+			return transport, nil
+		},
+	})
+	assert.NoError(t, c.Connect())
+	assert.Equal(t, "fake:1234", transport.address)
+	assert.Equal(t, "gzip", c.Compression())
+
+	metrics := testMetrics(1)
+	assert.NoError(t, c.PushMetrics(metrics))
+	assert.Equal(t, []*api.AnalyticsMetrics{metrics}, transport.pushes)
+	assert.Equal(t, []string{"fake-token"}, transport.tokens)
+
+	assert.NoError(t, c.Disconnect())
+	assert.True(t, transport.closed)
+}
+
+func TestHTTPTransport(t *testing.T) {
+	for _, tc := range []struct {
+		encoding    string
+		contentType string
+	}{
+		{"", "application/x-protobuf"},
+		{HTTPEncodingJSON, "application/json"},
+	} {
+		server := startFakeServer(t, &fakeServer{compression: []string{"zstd"}})
+		startFakeHTTPServer(t, server)
+		config := server.clientConfig()
+		config.Transport = TransportHTTPS
+		config.HTTPPort = server.httpPort
+		config.HTTPEncoding = tc.encoding
+		c := CreateAnalyticsClient(config)
+		assert.NoError(t, c.Connect())
+		assert.Equal(t, TransportHTTPS, c.transport.Name())
+		assert.Equal(t, "zstd", c.Compression())
+
+		metrics := testMetrics(100)
+		assert.NoError(t, c.PushMetrics(metrics))
+		c.Disconnect()
+
+		assert.Equal(t, []string{
+			"POST /api/v1/analytics/hello " + tc.contentType,
+			"POST /api/v1/analytics/login " + tc.contentType,
+			"POST /api/v1/analytics/push " + tc.contentType,
+		}, server.httpReqs)
+		assert.Equal(t, []string{"zstd"}, server.encoding)
+		assert.Len(t, server.pushes, 1)
+		assert.Equal(t, metrics.GatewayEui, server.pushes[0].GatewayEui)
+		assert.Len(t, server.pushes[0].Uplinks, 100)
+		assert.Greater(t, c.Stats().BytesSaved(), int64(0))
+	}
+}
+
+func TestHTTPTransportErrors(t *testing.T) {
+	assert.Equal(t, codes.Unavailable, httpStatusCode(http.StatusServiceUnavailable))
+	assert.Equal(t, codes.ResourceExhausted, httpStatusCode(http.StatusTooManyRequests))
+	assert.Equal(t, codes.Unauthenticated, httpStatusCode(http.StatusUnauthorized))
+
+	server := startFakeServer(t, &fakeServer{})
+	startFakeHTTPServer(t, server)
+	config := server.clientConfig()
+	config.Transport = TransportHTTPS
+	config.HTTPPort = server.httpPort
+	c := CreateAnalyticsClient(config)
+	assert.NoError(t, c.Connect())
+	defer c.Disconnect()
+
+	// The errors are reported as gRPC status errors
+	c.session.Token = "expired"
+	err := c.PushMetrics(testMetrics(1))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestTransportFallback(t *testing.T) {
+	server := startFakeServer(t, &fakeServer{})
+	startFakeHTTPServer(t, server)
+
+	// gRPC is blocked
+	server.server.Stop()
+	config := server.clientConfig()
+	config.HTTPPort = server.httpPort
+	c := CreateAnalyticsClient(config)
+	c.connTimeout = 200 * time.Millisecond
+	assert.NoError(t, c.Connect())
+	assert.Equal(t, TransportHTTPS, c.transport.Name())
+	assert.NoError(t, c.PushMetrics(testMetrics(1)))
+	assert.Equal(t, 1, server.pushCount())
+
+	// The gRPC connection is not attempted again for a while
+	assert.True(t, c.grpcBlocked.isBlocked(server.addr, c.healthInterval))
+	start := time.Now()
+	assert.NoError(t, c.Connect())
+	assert.Less(t, int64(time.Since(start)), int64(c.connTimeout))
+	c.Disconnect()
+
+	// Only gRPC was requested
+	config.Transport = TransportGRPC
+	c = CreateAnalyticsClient(config)
+	c.connTimeout = 200 * time.Millisecond
+	assert.Error(t, c.Connect())
+
+	// Without a server at all
+	config.Transport = TransportAuto
+	config.HTTPPort = int32(closedPort(t))
+	c = CreateAnalyticsClient(config)
+	c.connTimeout = 200 * time.Millisecond
+	assert.Error(t, c.Connect())
+}
+
+// Returns a port where nothing is listening
+func closedPort(t *testing.T) int {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	return lis.Addr().(*net.TCPAddr).Port
+}
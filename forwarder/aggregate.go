@@ -0,0 +1,357 @@
+package main
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+)
+
+// The bins of the signal quality histograms
+const (
+	// -140 to -20 dBm
+	rssiHistStart = -140
+	rssiHistWidth = 2
+	rssiHistBins  = 60
+	// -25 to +15 dB
+	snrHistStart = -25
+	snrHistWidth = 1
+	snrHistBins  = 40
+)
+
+// The preamble length used by LoRaWAN, when not reported by the gateway
+const defaultPreambleLength = 8
+
+func newHistogram(start float32, width float32, bins int) *api.AnalyticsHistogram {
+	return &api.AnalyticsHistogram{
+		Start:  start,
+		Width:  width,
+		Counts: make([]uint32, bins),
+	}
+}
+
+// Counts the value in its bin, with the values out of range in the first or the last one
+func addToHistogram(h *api.AnalyticsHistogram, value float32) {
+	bin := int(math.Floor(float64((value - h.Start) / h.Width)))
+	if bin < 0 {
+		bin = 0
+	} else if bin >= len(h.Counts) {
+		bin = len(h.Counts) - 1
+	}
+	h.Counts[bin] += 1
+}
+
+// Drops the empty bins at both ends of the histogram, before it's sent
+func compactHistogram(h *api.AnalyticsHistogram) {
+	first, last := 0, len(h.Counts)
+	for first < last && h.Counts[first] == 0 {
+		first++
+	}
+	for last > first && h.Counts[last-1] == 0 {
+		last--
+	}
+	if first == last {
+		h.Counts = nil
+		return
+	}
+	h.Start += float32(first) * h.Width
+	h.Counts = h.Counts[first:last]
+}
+
+func loraSFValue(sf api.LoRaSF) int {
+	if sf == api.LoRaSF_SF_UNKNOWN {
+		return 0
+	}
+	return 13 - int(sf)
+}
+
+func loraBWValue(bw api.LoRaBW) float64 {
+	switch bw {
+	case api.LoRaBW_BW_125k:
+		return 125000
+	case api.LoRaBW_BW_250k:
+		return 250000
+	case api.LoRaBW_BW_500k:
+		return 500000
+	case api.LoRaBW_BW_203k:
+		return 203125
+	case api.LoRaBW_BW_406k:
+		return 406250
+	case api.LoRaBW_BW_812k:
+		return 812500
+	case api.LoRaBW_BW_1625k:
+		return 1625000
+	}
+	return 0
+}
+
+// The time on air of a LoRa frame with explicit header, as given in the
+// Semtech SX126x datasheet
+func loraAirtime(dr *api.LoRaDataRate, cr api.LoRaCodingRate, size int, preamble int, crc bool) time.Duration {
+	sf := loraSFValue(dr.SpreadingFactor)
+	bw := loraBWValue(dr.Bandwidth)
+	if sf == 0 || bw == 0 {
+		return 0
+	}
+
+	// 4/5 - 4/8, assuming 4/5 when unknown
+	codingRate := 1
+	if cr >= api.LoRaCodingRate_CR_4_5 && cr <= api.LoRaCodingRate_CR_4_8 {
+		codingRate = int(cr-api.LoRaCodingRate_CR_4_5) + 1
+	}
+
+	symbolTime := math.Pow(2, float64(sf)) / bw
+	lowDataRate := 0
+	if symbolTime >= 0.016 {
+		lowDataRate = 1
+	}
+	crcBits := 0
+	if crc {
+		crcBits = 16
+	}
+
+	var symbols float64
+	if sf < 7 {
+		bits := math.Max(float64(8*size+crcBits-4*sf+20), 0)
+		symbols = float64(preamble) + 6.25 + 8 +
+			math.Ceil(bits/float64(4*(sf-2*lowDataRate)))*float64(codingRate+4)
+	} else {
+		bits := math.Max(float64(8*size+crcBits-4*sf+28), 0)
+		symbols = float64(preamble) + 4.25 + 8 +
+			math.Ceil(bits/float64(4*(sf-2*lowDataRate)))*float64(codingRate+4)
+	}
+	return time.Duration(math.Round(symbols * symbolTime * float64(time.Second)))
+}
+
+// The time on air of an FSK frame, with 5 bytes of preamble, 3 of sync word,
+// a length byte and the CRC
+func fskAirtime(bitRate uint32, size int) time.Duration {
+	if bitRate == 0 {
+		return 0
+	}
+	bits := (5 + 3 + 1 + size + 2) * 8
+	return time.Duration(math.Round(float64(bits) / float64(bitRate) * float64(time.Second)))
+}
+
+// The time on air of an LR-FHSS frame, as given in the LoRaWAN regional
+// parameters (RP002)
+func lrfhssAirtime(cr api.LoRaCodingRate, size int) time.Duration {
+	headers, bytesPerFragment := 3, 2
+	if cr == api.LoRaCodingRate_CR_2_3 {
+		headers, bytesPerFragment = 2, 4
+	}
+	fragments := (size + 3 + bytesPerFragment - 1) / bytesPerFragment
+	return time.Duration(headers)*233472*time.Microsecond +
+		time.Duration(fragments)*102400*time.Microsecond
+}
+
+func uplinkAirtime(up *api.AnalyticsUplink) time.Duration {
+	switch dr := up.DataRate.(type) {
+	case *api.AnalyticsUplink_DataRateLoRa:
+		return loraAirtime(dr.DataRateLoRa, up.CodingRate, int(up.Size), defaultPreambleLength, true)
+	case *api.AnalyticsUplink_DataRateFSK:
+		return fskAirtime(dr.DataRateFSK, int(up.Size))
+	case *api.AnalyticsUplink_DataRateLRFHSS:
+		return lrfhssAirtime(up.CodingRate, int(up.Size))
+	}
+	return 0
+}
+
+func downlinkAirtime(dn *api.AnalyticsDownlink) time.Duration {
+	switch dr := dn.DataRate.(type) {
+	case *api.AnalyticsDownlink_DataRateLoRa:
+		preamble := int(dn.RfPreamble)
+		if preamble == 0 {
+			preamble = defaultPreambleLength
+		}
+		return loraAirtime(dr.DataRateLoRa, dn.CodingRate, int(dn.Size), preamble, !dn.NoCrc)
+	case *api.AnalyticsDownlink_DataRateFSK:
+		return fskAirtime(dr.DataRateFSK, int(dn.Size))
+	}
+	return 0
+}
+
+func sameUplinkDataRate(a *api.AnalyticsUplinkAggregate, b *api.AnalyticsUplink) bool {
+	switch dr := b.DataRate.(type) {
+	case *api.AnalyticsUplink_DataRateLoRa:
+		other := a.GetDataRateLoRa()
+		return other != nil && other.SpreadingFactor == dr.DataRateLoRa.SpreadingFactor &&
+			other.Bandwidth == dr.DataRateLoRa.Bandwidth
+	case *api.AnalyticsUplink_DataRateFSK:
+		other, ok := a.DataRate.(*api.AnalyticsUplinkAggregate_DataRateFSK)
+		return ok && other.DataRateFSK == dr.DataRateFSK
+	case *api.AnalyticsUplink_DataRateLRFHSS:
+		other := a.GetDataRateLRFHSS()
+		return other != nil && other.ModulationType == dr.DataRateLRFHSS.ModulationType &&
+			other.ChannelWidth == dr.DataRateLRFHSS.ChannelWidth
+	}
+	return a.DataRate == nil
+}
+
+func sameDownlinkDataRate(a *api.AnalyticsDownlinkAggregate, b *api.AnalyticsDownlink) bool {
+	switch dr := b.DataRate.(type) {
+	case *api.AnalyticsDownlink_DataRateLoRa:
+		other := a.GetDataRateLoRa()
+		return other != nil && other.SpreadingFactor == dr.DataRateLoRa.SpreadingFactor &&
+			other.Bandwidth == dr.DataRateLoRa.Bandwidth
+	case *api.AnalyticsDownlink_DataRateFSK:
+		other, ok := a.DataRate.(*api.AnalyticsDownlinkAggregate_DataRateFSK)
+		return ok && other.DataRateFSK == dr.DataRateFSK
+	}
+	return a.DataRate == nil
+}
+
+func newUplinkAggregate(up *api.AnalyticsUplink) *api.AnalyticsUplinkAggregate {
+	out := &api.AnalyticsUplinkAggregate{
+		Modulation: up.Modulation,
+		Frequency:  up.Frequency,
+		Crc:        up.Crc,
+	}
+	switch dr := up.DataRate.(type) {
+	case *api.AnalyticsUplink_DataRateLoRa:
+		out.DataRate = &api.AnalyticsUplinkAggregate_DataRateLoRa{
+			DataRateLoRa: &api.LoRaDataRate{
+				SpreadingFactor: dr.DataRateLoRa.SpreadingFactor,
+				Bandwidth:       dr.DataRateLoRa.Bandwidth,
+			},
+		}
+	case *api.AnalyticsUplink_DataRateFSK:
+		out.DataRate = &api.AnalyticsUplinkAggregate_DataRateFSK{
+			DataRateFSK: dr.DataRateFSK,
+		}
+	case *api.AnalyticsUplink_DataRateLRFHSS:
+		out.DataRate = &api.AnalyticsUplinkAggregate_DataRateLRFHSS{
+			DataRateLRFHSS: &api.LRFHSSDataRate{
+				ModulationType: dr.DataRateLRFHSS.ModulationType,
+				ChannelWidth:   dr.DataRateLRFHSS.ChannelWidth,
+			},
+		}
+	}
+	return out
+}
+
+func newDownlinkAggregate(dn *api.AnalyticsDownlink) *api.AnalyticsDownlinkAggregate {
+	out := &api.AnalyticsDownlinkAggregate{
+		Modulation: dn.Modulation,
+		Frequency:  dn.Frequency,
+	}
+	switch dr := dn.DataRate.(type) {
+	case *api.AnalyticsDownlink_DataRateLoRa:
+		out.DataRate = &api.AnalyticsDownlinkAggregate_DataRateLoRa{
+			DataRateLoRa: &api.LoRaDataRate{
+				SpreadingFactor: dr.DataRateLoRa.SpreadingFactor,
+				Bandwidth:       dr.DataRateLoRa.Bandwidth,
+			},
+		}
+	case *api.AnalyticsDownlink_DataRateFSK:
+		out.DataRate = &api.AnalyticsDownlinkAggregate_DataRateFSK{
+			DataRateFSK: dr.DataRateFSK,
+		}
+	}
+	return out
+}
+
+// Extends the time window of the aggregate with the current time
+func touchAggregate(agg *api.AnalyticsAggregate, now int64) {
+	if agg.StartTime == 0 || now < agg.StartTime {
+		agg.StartTime = now
+	}
+	if now > agg.EndTime {
+		agg.EndTime = now
+	}
+}
+
+// Adds the uplink to the aggregate, in the group with the same data rate,
+// frequency and CRC status
+func aggregateUplink(agg *api.AnalyticsAggregate, up *api.AnalyticsUplink) {
+	var group *api.AnalyticsUplinkAggregate
+	for _, g := range agg.Uplinks {
+		if g.Frequency == up.Frequency && g.Crc == up.Crc && g.Modulation == up.Modulation && sameUplinkDataRate(g, up) {
+			group = g
+			break
+		}
+	}
+	if group == nil {
+		group = newUplinkAggregate(up)
+		agg.Uplinks = append(agg.Uplinks, group)
+	}
+
+	group.Count += 1
+	group.Airtime += uint64(uplinkAirtime(up).Microseconds())
+	group.Size += uint64(up.Size)
+
+	for _, ant := range up.Ant {
+		var antGroup *api.AnalyticsAntennaAggregate
+		for _, a := range group.Ant {
+			if a.Antenna == ant.Antenna {
+				antGroup = a
+				break
+			}
+		}
+		if antGroup == nil {
+			antGroup = &api.AnalyticsAntennaAggregate{
+				Antenna: ant.Antenna,
+				Rssi:    newHistogram(rssiHistStart, rssiHistWidth, rssiHistBins),
+			}
+			if up.Modulation == api.Modulation_LORA {
+				antGroup.Snr = newHistogram(snrHistStart, snrHistWidth, snrHistBins)
+			}
+			group.Ant = append(group.Ant, antGroup)
+		}
+
+		addToHistogram(antGroup.Rssi, float32(ant.RSSIC))
+		if antGroup.Snr != nil {
+			addToHistogram(antGroup.Snr, ant.LSNR)
+		}
+	}
+}
+
+// Adds the downlink to the aggregate, in the group with the same data rate and frequency
+func aggregateDownlink(agg *api.AnalyticsAggregate, dn *api.AnalyticsDownlink) {
+	var group *api.AnalyticsDownlinkAggregate
+	for _, g := range agg.Downlinks {
+		if g.Frequency == dn.Frequency && g.Modulation == dn.Modulation && sameDownlinkDataRate(g, dn) {
+			group = g
+			break
+		}
+	}
+	if group == nil {
+		group = newDownlinkAggregate(dn)
+		agg.Downlinks = append(agg.Downlinks, group)
+	}
+
+	group.Count += 1
+	group.Airtime += uint64(downlinkAirtime(dn).Microseconds())
+	group.Size += uint64(dn.Size)
+}
+
+// Shrinks the histograms of the aggregate, once no more data will be added
+func compactAggregate(agg *api.AnalyticsAggregate) {
+	for _, group := range agg.Uplinks {
+		for _, ant := range group.Ant {
+			compactHistogram(ant.Rssi)
+			if ant.Snr != nil {
+				compactHistogram(ant.Snr)
+			}
+		}
+	}
+}
+
+// Decides if an aggregated frame is also sent individually. The decision is
+// based on the unique ID, so the same frame is sampled on all the gateways.
+func sampleFrame(uniqueId []byte, percent int) bool {
+	if percent <= 0 {
+		return false
+	}
+	if percent >= 100 {
+		return true
+	}
+	if len(uniqueId) == 0 {
+		return rand.Intn(100) < percent
+	}
+	h := fnv.New32a()
+	h.Write(uniqueId)
+	return int(h.Sum32()%100) < percent
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func loraUplink(sf api.LoRaSF, freq float32, size uint32, ant ...*api.AnalyticsUplinkAntenna) *api.AnalyticsUplink {
+	return &api.AnalyticsUplink{
+		Frequency:  freq,
+		Crc:        api.CRCStatus_OK,
+		Modulation: api.Modulation_LORA,
+		CodingRate: api.LoRaCodingRate_CR_4_5,
+		DataRate: &api.AnalyticsUplink_DataRateLoRa{
+			DataRateLoRa: &api.LoRaDataRate{SpreadingFactor: sf, Bandwidth: api.LoRaBW_BW_125k},
+		},
+		Size: size,
+		Ant:  ant,
+	}
+}
+
+func TestAirtime(t *testing.T) {
+	assert.Equal(t, 46336*time.Microsecond, uplinkAirtime(loraUplink(api.LoRaSF_SF7, 868.1, 13)))
+	assert.Equal(t, 1155072*time.Microsecond, uplinkAirtime(loraUplink(api.LoRaSF_SF12, 868.1, 13)))
+	assert.Greater(t, uplinkAirtime(loraUplink(api.LoRaSF_SF7, 868.1, 13)), uplinkAirtime(loraUplink(api.LoRaSF_SF5, 868.1, 13)))
+	assert.Equal(t, time.Duration(0), uplinkAirtime(loraUplink(api.LoRaSF_SF_UNKNOWN, 868.1, 13)))
+
+	assert.Equal(t, 3840*time.Microsecond, uplinkAirtime(&api.AnalyticsUplink{
+		DataRate: &api.AnalyticsUplink_DataRateFSK{DataRateFSK: 50000},
+		Size:     13,
+	}))
+	assert.Equal(t, 1417216*time.Microsecond, uplinkAirtime(&api.AnalyticsUplink{
+		CodingRate: api.LoRaCodingRate_CR_1_3,
+		DataRate:   &api.AnalyticsUplink_DataRateLRFHSS{DataRateLRFHSS: &api.LRFHSSDataRate{}},
+		Size:       10,
+	}))
+
+	// Downlinks without CRC and with a longer preamble
+	dn := &api.AnalyticsDownlink{
+		CodingRate: api.LoRaCodingRate_CR_4_5,
+		DataRate: &api.AnalyticsDownlink_DataRateLoRa{
+			DataRateLoRa: &api.LoRaDataRate{SpreadingFactor: api.LoRaSF_SF7, Bandwidth: api.LoRaBW_BW_125k},
+		},
+		Size:  13,
+		NoCrc: true,
+	}
+	assert.Equal(t, 41216*time.Microsecond, downlinkAirtime(dn))
+	dn.RfPreamble = 10
+	assert.Equal(t, 43264*time.Microsecond, downlinkAirtime(dn))
+}
+
+func TestHistogram(t *testing.T) {
+	h := newHistogram(rssiHistStart, rssiHistWidth, rssiHistBins)
+	addToHistogram(h, -120)
+	addToHistogram(h, -119)
+	addToHistogram(h, -115)
+	addToHistogram(h, -200)
+	addToHistogram(h, 0)
+	assert.Equal(t, uint32(1), h.Counts[0])
+	assert.Equal(t, uint32(2), h.Counts[10])
+	assert.Equal(t, uint32(1), h.Counts[12])
+	assert.Equal(t, uint32(1), h.Counts[59])
+
+	h = newHistogram(rssiHistStart, rssiHistWidth, rssiHistBins)
+	addToHistogram(h, -120)
+	addToHistogram(h, -115)
+	compactHistogram(h)
+	assert.Equal(t, float32(-120), h.Start)
+	assert.Equal(t, []uint32{1, 0, 1}, h.Counts)
+
+	h = newHistogram(snrHistStart, snrHistWidth, snrHistBins)
+	compactHistogram(h)
+	assert.Nil(t, h.Counts)
+}
+
+func TestAggregateUplinks(t *testing.T) {
+	var agg api.AnalyticsAggregate
+	ant := func(n int32, rssi int32, snr float32) *api.AnalyticsUplinkAntenna {
+		return &api.AnalyticsUplinkAntenna{Antenna: n, RSSIC: rssi, LSNR: snr}
+	}
+
+	aggregateUplink(&agg, loraUplink(api.LoRaSF_SF7, 868.1, 13, ant(0, -100, 5), ant(1, -110, -2)))
+	aggregateUplink(&agg, loraUplink(api.LoRaSF_SF7, 868.1, 13, ant(0, -101, 6)))
+	aggregateUplink(&agg, loraUplink(api.LoRaSF_SF12, 868.1, 13, ant(0, -130, -15)))
+	aggregateUplink(&agg, loraUplink(api.LoRaSF_SF7, 868.3, 20, ant(0, -90, 9)))
+	bad := loraUplink(api.LoRaSF_SF7, 868.1, 13, ant(0, -120, -10))
+	bad.Crc = api.CRCStatus_FAIL
+	aggregateUplink(&agg, bad)
+	compactAggregate(&agg)
+
+	assert.Len(t, agg.Uplinks, 4)
+	sf7 := agg.Uplinks[0]
+	assert.Equal(t, api.LoRaSF_SF7, sf7.GetDataRateLoRa().SpreadingFactor)
+	assert.Equal(t, uint32(2), sf7.Count)
+	assert.Equal(t, uint64(26), sf7.Size)
+	assert.Equal(t, uint64(2*46336), sf7.Airtime)
+	assert.Len(t, sf7.Ant, 2)
+	assert.Equal(t, float32(-102), sf7.Ant[0].Rssi.Start)
+	assert.Equal(t, []uint32{1, 1}, sf7.Ant[0].Rssi.Counts)
+	assert.Equal(t, float32(5), sf7.Ant[0].Snr.Start)
+	assert.Equal(t, []uint32{1, 1}, sf7.Ant[0].Snr.Counts)
+	assert.Equal(t, int32(1), sf7.Ant[1].Antenna)
+	assert.Equal(t, []uint32{1}, sf7.Ant[1].Rssi.Counts)
+
+	assert.Equal(t, api.LoRaSF_SF12, agg.Uplinks[1].GetDataRateLoRa().SpreadingFactor)
+	assert.Equal(t, float32(868.3), agg.Uplinks[2].Frequency)
+	assert.Equal(t, api.CRCStatus_FAIL, agg.Uplinks[3].Crc)
+
+	// FSK uplinks have no SNR
+	aggregateUplink(&agg, &api.AnalyticsUplink{
+		Modulation: api.Modulation_FSK,
+		DataRate:   &api.AnalyticsUplink_DataRateFSK{DataRateFSK: 50000},
+		Ant:        []*api.AnalyticsUplinkAntenna{ant(0, -80, 0)},
+	})
+	assert.Nil(t, agg.Uplinks[4].Ant[0].Snr)
+}
+
+func TestSampleFrame(t *testing.T) {
+	assert.False(t, sampleFrame([]byte{1, 2, 3}, 0))
+	assert.True(t, sampleFrame([]byte{1, 2, 3}, 100))
+
+	sampled := 0
+	for i := 0; i < 10000; i++ {
+		id := []byte(fmt.Sprintf("frame-%d", i))
+		if sampleFrame(id, 10) {
+			sampled++
+		}
+		// The same frame is always sampled the same way
+		assert.Equal(t, sampleFrame(id, 10), sampleFrame(id, 10))
+	}
+	assert.InDelta(t, 1000, sampled, 100)
+}
+
+func TestForwarderAggregation(t *testing.T) {
+	pkt, _ := base64.StdEncoding.DecodeString(PacketPushDataUp)
+	pusher := &mockPusher{}
+	config := defaultConf
+	config.MaxUDPStreams = 16
+	config.Aggregate = true
+	fw := CreateAnalyticsForwarder(config, pusher, nil)
+
+	addr := &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1700}
+	fw.UpLocalData(pkt, addr)
+	fw.UpLocalData(pkt, addr)
+	fw.flushData()
+
+	assert.Len(t, pusher.frames, 1)
+	frame := pusher.frames[0]
+	assert.Len(t, frame.Uplinks, 0)
+	if assert.NotNil(t, frame.Aggregate) {
+		assert.Len(t, frame.Aggregate.Uplinks, 1)
+		assert.Equal(t, uint32(2), frame.Aggregate.Uplinks[0].Count)
+		assert.NotZero(t, frame.Aggregate.Uplinks[0].Airtime)
+		assert.NotZero(t, frame.Aggregate.StartTime)
+		assert.Len(t, frame.Aggregate.Uplinks[0].Ant[0].Rssi.Counts, 1)
+	}
+
+	// Sampling every frame still sends them individually
+	sampled := *fw.conf()
+	sampled.AggregateSample = 100
+	fw.config.Store(&sampled)
+	fw.UpLocalData(pkt, addr)
+	fw.flushData()
+	assert.Len(t, pusher.frames, 2)
+	assert.Len(t, pusher.frames[1].Uplinks, 1)
+	assert.Equal(t, uint32(1), pusher.frames[1].Aggregate.Uplinks[0].Count)
+}
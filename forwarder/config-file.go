@@ -0,0 +1,312 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/namsral/flag"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// The version of the schema of the structured configuration files
+const configSchemaVersion = 1
+
+// The options that are comma-separated lists, which can also be given as
+// lists in the structured configuration files
+var listOptions = map[string]bool{
+	"analytics-compression":        true,
+	"analytics-fallback-endpoints": true,
+	"gateway-allow":                true,
+	"gateway-deny":                 true,
+	"gateway-location":             true,
+	"gateway-pin":                  true,
+	"source-allow":                 true,
+	"source-deny":                  true,
+}
+
+// The `--config` flag. The structured files are hidden from the flag package,
+// which would read them as flat `key=value` options, and are loaded by
+// `loadConfigFile` instead.
+type configFileValue struct {
+	path string
+}
+
+func (v *configFileValue) Set(path string) error {
+	v.path = path
+	return nil
+}
+
+func (v *configFileValue) String() string {
+	if v.structured() {
+		return ""
+	}
+	return v.path
+}
+
+// Returns true if the configuration file is in YAML or JSON, rather than
+// flat `key=value` options
+func (v *configFileValue) structured() bool {
+	return isStructuredConfig(v.path)
+}
+
+func isStructuredConfig(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// Registers the `--config` flag in the given flag set
+func registerConfigFileFlag(fs *flag.FlagSet) *configFileValue {
+	configFile := &configFileValue{}
+	fs.Var(configFile, flag.DefaultConfigFlagname, "path to the configuration file (flat options, YAML or JSON)")
+	return configFile
+}
+
+// Loads the configuration file of the flag set when it's structured, after
+// the flag set is parsed. The options given as arguments or environment
+// variables take precedence, like with the flat configuration files.
+func loadConfigFile(fs *flag.FlagSet, configFile *configFileValue) {
+	if !configFile.structured() {
+		return
+	}
+	if err := loadStructuredConfig(fs, configFile.path); err != nil {
+		log.Fatalf("Invalid configuration file %s: %s", configFile.path, err.Error())
+	}
+}
+
+// Applies the options of a structured configuration file to the flag set,
+// except the ones that are already set
+func loadStructuredConfig(fs *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	options, err := parseStructuredConfig(data, fs)
+	if err != nil {
+		return err
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	for name, value := range options {
+		if set[name] {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value '%s' for option '%s': %w", value, name, err)
+		}
+	}
+	return nil
+}
+
+// Returns the error of a node of a structured configuration file, with its
+// location in the file
+func configNodeError(node *yaml.Node, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", node.Line, fmt.Sprintf(format, args...))
+}
+
+// Returns the text of a scalar value, as it appears in the file. Using the
+// text rather than the decoded value keeps eg. the leading zeros of the
+// gateway EUIs, that would otherwise be parsed as numbers.
+func configScalar(name string, node *yaml.Node) (string, error) {
+	if node.Kind != yaml.ScalarNode {
+		return "", configNodeError(node, "'%s' must be a single value", name)
+	}
+	if node.ShortTag() == "!!null" {
+		return "", configNodeError(node, "'%s' has no value", name)
+	}
+	return node.Value, nil
+}
+
+// Returns the keys and values of a mapping, rejecting the repeated keys
+func configMapping(name string, node *yaml.Node) ([]*yaml.Node, []*yaml.Node, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, nil, configNodeError(node, "'%s' must be a mapping", name)
+	}
+	var keys, values []*yaml.Node
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if seen[key.Value] {
+			return nil, nil, configNodeError(key, "'%s' is repeated", key.Value)
+		}
+		seen[key.Value] = true
+		keys = append(keys, key)
+		values = append(values, node.Content[i+1])
+	}
+	return keys, values, nil
+}
+
+// Parses a structured (YAML or JSON) configuration file, and returns the
+// flat options it contains. The options are named like the flags, and are
+// checked against the ones of the given flag set.
+//
+// Besides the options, the file contains the `version` of its schema, and
+// can configure each gateway under `gateways`:
+//
+//	version: 1
+//	client-id: 1122334455667788
+//	gateway-allow: [0102030405060708, 0807060504030201]
+//	gateways:
+//	  0102030405060708:
+//	    location: {latitude: 37.98, longitude: 23.72}
+//	    network: 10.0.0.0/8
+func parseStructuredConfig(data []byte, fs *flag.FlagSet) (map[string]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, fmt.Errorf("missing schema version (version: %d)", configSchemaVersion)
+	}
+	keys, values, err := configMapping("configuration", doc.Content[0])
+	if err != nil {
+		return nil, err
+	}
+
+	options := make(map[string]string)
+	lists := make(map[string][]string)
+	version := ""
+	for i, key := range keys {
+		name, node := key.Value, values[i]
+		switch {
+		case name == "version":
+			if version, err = configScalar(name, node); err != nil {
+				return nil, err
+			}
+			if version != fmt.Sprint(configSchemaVersion) {
+				return nil, configNodeError(node, "unsupported schema version '%s' (expected %d)", version, configSchemaVersion)
+			}
+
+		case name == "gateways":
+			if err := parseGatewaysConfig(node, lists); err != nil {
+				return nil, err
+			}
+
+		case name == flag.DefaultConfigFlagname || fs.Lookup(name) == nil:
+			return nil, configNodeError(key, "unknown option '%s'", name)
+
+		case node.Kind == yaml.SequenceNode:
+			if !listOptions[name] {
+				return nil, configNodeError(node, "'%s' must be a single value", name)
+			}
+			for _, item := range node.Content {
+				value, err := configScalar(name, item)
+				if err != nil {
+					return nil, err
+				}
+				lists[name] = append(lists[name], value)
+			}
+
+		default:
+			value, err := configScalar(name, node)
+			if err != nil {
+				return nil, err
+			}
+			if listOptions[name] {
+				lists[name] = append(lists[name], splitList(value)...)
+			} else {
+				options[name] = value
+			}
+		}
+	}
+	if version == "" {
+		return nil, fmt.Errorf("missing schema version (version: %d)", configSchemaVersion)
+	}
+
+	for name, items := range lists {
+		options[name] = strings.Join(items, ",")
+	}
+	return options, nil
+}
+
+// Parses the settings of each gateway, adding them to the list options
+func parseGatewaysConfig(node *yaml.Node, lists map[string][]string) error {
+	euis, settings, err := configMapping("gateways", node)
+	if err != nil {
+		return err
+	}
+
+	for i, key := range euis {
+		eui, err := parseGatewayEUI(key.Value)
+		if err != nil {
+			return configNodeError(key, "%s", err.Error())
+		}
+		names, values, err := configMapping(key.Value, settings[i])
+		if err != nil {
+			return err
+		}
+
+		for j, name := range names {
+			value := values[j]
+			switch name.Value {
+			case "allow", "deny":
+				enabled, err := configScalar(name.Value, value)
+				if err != nil {
+					return err
+				}
+				switch enabled {
+				case "true":
+					lists["gateway-"+name.Value] = append(lists["gateway-"+name.Value], eui)
+				case "false":
+				default:
+					return configNodeError(value, "'%s' must be true or false", name.Value)
+				}
+
+			case "location":
+				location, err := parseGatewayLocationConfig(value)
+				if err != nil {
+					return err
+				}
+				lists["gateway-location"] = append(lists["gateway-location"], eui+"="+location)
+
+			case "network":
+				network, err := configScalar(name.Value, value)
+				if err != nil {
+					return err
+				}
+				lists["gateway-pin"] = append(lists["gateway-pin"], eui+"@"+network)
+
+			default:
+				return configNodeError(name, "unknown gateway setting '%s'", name.Value)
+			}
+		}
+	}
+	return nil
+}
+
+// Parses the location of a gateway, and returns it like in `gateway-location`
+func parseGatewayLocationConfig(node *yaml.Node) (string, error) {
+	names, values, err := configMapping("location", node)
+	if err != nil {
+		return "", err
+	}
+
+	coords := make(map[string]string)
+	for i, name := range names {
+		switch name.Value {
+		case "latitude", "longitude", "altitude":
+			if coords[name.Value], err = configScalar(name.Value, values[i]); err != nil {
+				return "", err
+			}
+		default:
+			return "", configNodeError(name, "unknown location setting '%s'", name.Value)
+		}
+	}
+	if coords["latitude"] == "" || coords["longitude"] == "" {
+		return "", configNodeError(node, "the location needs a latitude and a longitude")
+	}
+
+	location := coords["latitude"] + ":" + coords["longitude"]
+	if coords["altitude"] != "" {
+		location += ":" + coords["altitude"]
+	}
+	return location, nil
+}
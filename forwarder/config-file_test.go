@@ -0,0 +1,162 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/namsral/flag"
+	"github.com/stretchr/testify/assert"
+)
+
+const testStructuredConfig = `# The credentials from the platform
+version: 1
+client-id: "1122334455667788"
+client-key: secret
+connect-host: eu1.cloud.thethings.network
+flush-interval: 30
+aggregate: true
+gateway-allow:
+  - 0102030405060708
+  - eui-0807060504030201
+analytics-fallback-endpoints: eu2.example.com, eu3.example.com
+gateways:
+  0102030405060708:
+    location: {latitude: 37.98, longitude: 23.72, altitude: 90}
+    network: 10.0.0.0/8
+  0a0b0c0d0e0f0102:
+    deny: true
+`
+
+func createTestConfigFlags() (*flag.FlagSet, *ForwarderConfig, *configFileValue) {
+	var config ForwarderConfig
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configFile := registerConfigFileFlag(fs)
+	registerConfigFlags(fs, &config)
+	return fs, &config, configFile
+}
+
+func TestParseStructuredConfig(t *testing.T) {
+	fs, _, _ := createTestConfigFlags()
+
+	options, err := parseStructuredConfig([]byte(testStructuredConfig), fs)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"client-id":                    "1122334455667788",
+		"client-key":                   "secret",
+		"connect-host":                 "eu1.cloud.thethings.network",
+		"flush-interval":               "30",
+		"aggregate":                    "true",
+		"gateway-allow":                "0102030405060708,eui-0807060504030201",
+		"gateway-deny":                 "0a0b0c0d0e0f0102",
+		"gateway-location":             "0102030405060708=37.98:23.72:90",
+		"gateway-pin":                  "0102030405060708@10.0.0.0/8",
+		"analytics-fallback-endpoints": "eu2.example.com,eu3.example.com",
+	}, options)
+
+	// The same in JSON
+	options, err = parseStructuredConfig([]byte(`{
+		"version": 1,
+		"listen-port-up": 1900,
+		"source-allow": ["10.0.0.0/8", "192.168.0.0/16"],
+		"gateways": {"0102030405060708": {"allow": true, "deny": false}}
+	}`), fs)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"listen-port-up": "1900",
+		"source-allow":   "10.0.0.0/8,192.168.0.0/16",
+		"gateway-allow":  "0102030405060708",
+	}, options)
+}
+
+func TestParseStructuredConfigErrors(t *testing.T) {
+	fs, _, _ := createTestConfigFlags()
+
+	tests := []struct {
+		config string
+		err    string
+	}{
+		{"", "missing schema version (version: 1)"},
+		{"client-id: abc\n", "missing schema version (version: 1)"},
+		{"version: 2\n", "line 1: unsupported schema version '2' (expected 1)"},
+		{"- version: 1\n", "line 1: 'configuration' must be a mapping"},
+		{"version: 1\nno-such-option: 1\n", "line 2: unknown option 'no-such-option'"},
+		{"version: 1\nconfig: other.yaml\n", "line 2: unknown option 'config'"},
+		{"version: 1\ngateway: a\ngateway: b\n", "line 3: 'gateway' is repeated"},
+		{"version: 1\nlisten-port-up: [1, 2]\n", "line 2: 'listen-port-up' must be a single value"},
+		{"version: 1\nclient-id: {a: b}\n", "line 2: 'client-id' must be a single value"},
+		{"version: 1\nclient-id:\n", "line 2: 'client-id' has no value"},
+		{"version: 1\ngateways: [a]\n", "line 2: 'gateways' must be a mapping"},
+		{"version: 1\ngateways:\n  nope: {allow: true}\n", "line 3: invalid gateway EUI"},
+		{"version: 1\ngateways:\n  0102030405060708: {allow: yes}\n", "line 3: 'allow' must be true or false"},
+		{"version: 1\ngateways:\n  0102030405060708: {pinned: true}\n", "line 3: unknown gateway setting 'pinned'"},
+		{"version: 1\ngateways:\n  0102030405060708:\n    location: {latitude: 1}\n", "line 4: the location needs a latitude and a longitude"},
+		{"version: 1\ngateways:\n  0102030405060708:\n    location: {lat: 1}\n", "line 4: unknown location setting 'lat'"},
+		{"version: 1\n  bad: indent\n", "yaml: line 2"},
+	}
+	for _, test := range tests {
+		_, err := parseStructuredConfig([]byte(test.config), fs)
+		assert.ErrorContains(t, err, test.err, test.config)
+	}
+}
+
+func TestLoadStructuredConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forwarder.yaml")
+	assert.Nil(t, os.WriteFile(path, []byte(testStructuredConfig), 0644))
+
+	// The arguments take precedence over the file
+	fs, config, configFile := createTestConfigFlags()
+	assert.Nil(t, fs.Parse([]string{"--config=" + path, "--flush-interval=5"}))
+	assert.True(t, configFile.structured())
+	// The flag package does not read the file as flat options
+	assert.Equal(t, "", configFile.String())
+	assert.Equal(t, "", config.ClientId)
+
+	assert.Nil(t, loadStructuredConfig(fs, path))
+	assert.Equal(t, "1122334455667788", config.ClientId)
+	assert.Equal(t, "secret", config.ClientKey)
+	assert.True(t, config.Aggregate)
+	assert.Equal(t, 5, config.FlushInterval)
+	assert.Equal(t, "0102030405060708@10.0.0.0/8", config.GatewayPin)
+	// The rest keep their defaults
+	assert.Equal(t, defaultConf.ListenPortUp, config.ListenPortUp)
+
+	// Values of the wrong type
+	assert.Nil(t, os.WriteFile(path, []byte("version: 1\nflush-interval: soon\n"), 0644))
+	fs, _, _ = createTestConfigFlags()
+	assert.ErrorContains(t, loadStructuredConfig(fs, path), "invalid value 'soon' for option 'flush-interval'")
+
+	assert.True(t, isStructuredConfig("forwarder.YML"))
+	assert.True(t, isStructuredConfig("forwarder.json"))
+	assert.False(t, isStructuredConfig("/etc/kudzu-forwarder.conf"))
+}
+
+func TestValidateConfig(t *testing.T) {
+	config := defaultConf
+	applyConfigDefaults(&config)
+	assert.Nil(t, validateConfig(&config))
+
+	tests := []struct {
+		change func(c *ForwarderConfig)
+		err    string
+	}{
+		{func(c *ForwarderConfig) { c.ListenPortUp = 70000 }, "listen-port-up must be between 1 and 65535 (got 70000)"},
+		{func(c *ForwarderConfig) { c.ConnectPortDown = 0 }, "connect-port-down must be between 1 and 65535 (got 0)"},
+		{func(c *ForwarderConfig) { c.FlushInterval = -1 }, "flush-interval must be greater than 0 (got -1)"},
+		{func(c *ForwarderConfig) { c.QueueSize = 0 }, "queue-size must be greater than 0 (got 0)"},
+		{func(c *ForwarderConfig) { c.InfoInterval = -5 }, "info-interval must not be negative (got -5)"},
+		{func(c *ForwarderConfig) { c.AggregateSample = 101 }, "aggregate-sample must be between 0 and 100 (got 101)"},
+		{func(c *ForwarderConfig) {
+			c.GatewayAllow = "0102030405060708"
+			c.UnknownGatewayRate = 10
+		}, "unknown-gateway-rate can't be used together with gateway-allow"},
+		{func(c *ForwarderConfig) { c.LogLevel = "chatty" }, "log-level must be 'error', 'warn', 'info' or 'debug' (got 'chatty')"},
+	}
+	for _, test := range tests {
+		invalid := config
+		test.change(&invalid)
+		assert.EqualError(t, validateConfig(&invalid), test.err)
+	}
+}
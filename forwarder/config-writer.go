@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/namsral/flag"
+	"gopkg.in/yaml.v3"
+)
+
+// Renders configuration options in the format of the configuration file,
+// sorted by name
+func renderConfigOptions(options map[string]string) string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ret := ""
+	for _, name := range names {
+		ret += fmt.Sprintf("%s=%s\n", name, options[name])
+	}
+	return ret
+}
+
+// Returns the option name of a line of the configuration file, and the
+// separator between the name and the value (following `flag.ParseFile`)
+func parseConfigLine(line string) (string, string) {
+	if len(line) == 0 || line[0] == '#' {
+		return "", ""
+	}
+	if i := strings.IndexAny(line, "= "); i >= 0 {
+		return line[:i], line[i : i+1]
+	}
+	return line, "="
+}
+
+// Merges the options into the contents of a configuration file. The options
+// already in the file are updated in place, keeping the comments, the order
+// and the other options as they are, while the new ones are appended.
+func mergeConfigFile(contents []byte, options map[string]string) []byte {
+	remaining := make(map[string]string)
+	for name, value := range options {
+		remaining[name] = value
+	}
+
+	text := string(contents)
+	var lines []string
+	if text != "" {
+		lines = strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	}
+	for i, line := range lines {
+		// Keep the line endings of files edited on Windows
+		ending := ""
+		if strings.HasSuffix(line, "\r") {
+			line, ending = strings.TrimSuffix(line, "\r"), "\r"
+		}
+
+		name, separator := parseConfigLine(line)
+		value, ok := remaining[name]
+		if name == "" || !ok {
+			continue
+		}
+		// Only the first occurrence of an option is used
+		lines[i] = name + separator + value + ending
+		delete(remaining, name)
+	}
+
+	ret := ""
+	for _, line := range lines {
+		ret += line + "\n"
+	}
+	return []byte(ret + renderConfigOptions(remaining))
+}
+
+// Merges the options into the contents of a structured configuration file
+// (YAML or JSON) like `mergeConfigFile`. The comments of the YAML files are
+// kept, while the JSON files are formatted again.
+func mergeStructuredConfigFile(contents []byte, options map[string]string, asJSON bool) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(contents, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{
+			Kind: yaml.MappingNode,
+			Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Value: "version"},
+				{Kind: yaml.ScalarNode, Value: fmt.Sprint(configSchemaVersion)},
+			},
+		}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("the configuration is not a mapping")
+	}
+
+	remaining := make(map[string]string)
+	for name, value := range options {
+		remaining[name] = value
+	}
+	// Skip the options the file already sets to the same value, that would
+	// otherwise duplicate the lists and the settings of the gateways
+	var config ForwarderConfig
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	registerConfigFlags(fs, &config)
+	if current, err := parseStructuredConfig(contents, fs); err == nil {
+		for name, value := range current {
+			if remaining[name] == value {
+				delete(remaining, name)
+			}
+		}
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		name := root.Content[i].Value
+		if value, ok := remaining[name]; ok {
+			// Only the value is replaced, to keep its comments
+			setConfigValueNode(root.Content[i+1], value)
+			delete(remaining, name)
+		}
+	}
+	names := make([]string, 0, len(remaining))
+	for name := range remaining {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := &yaml.Node{}
+		setConfigValueNode(value, remaining[name])
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, value)
+	}
+
+	var buf bytes.Buffer
+	if asJSON {
+		writeJSONNode(&buf, root, "")
+		buf.WriteString("\n")
+		return buf.Bytes(), nil
+	}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Sets the value of an option in a structured configuration file
+func setConfigValueNode(node *yaml.Node, value string) {
+	node.Kind = yaml.ScalarNode
+	node.Tag = ""
+	node.Style = 0
+	node.Content = nil
+	node.Value = value
+	// Empty values would otherwise be written as nulls
+	switch strings.ToLower(value) {
+	case "", "~", "null":
+		node.Style = yaml.DoubleQuotedStyle
+	}
+}
+
+// Writes a node of a structured configuration file as JSON. The values that
+// are not quoted are kept as-is when they are valid JSON numbers or booleans.
+func writeJSONNode(buf *bytes.Buffer, node *yaml.Node, indent string) {
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		start, end, step := "{", "}", 2
+		if node.Kind == yaml.SequenceNode {
+			start, end, step = "[", "]", 1
+		}
+		if len(node.Content) == 0 {
+			buf.WriteString(start + end)
+			return
+		}
+		buf.WriteString(start + "\n")
+		for i := 0; i < len(node.Content); i += step {
+			if i > 0 {
+				buf.WriteString(",\n")
+			}
+			buf.WriteString(indent + "  ")
+			if step == 2 {
+				key, _ := json.Marshal(node.Content[i].Value)
+				buf.Write(key)
+				buf.WriteString(": ")
+			}
+			writeJSONNode(buf, node.Content[i+step-1], indent+"  ")
+		}
+		buf.WriteString("\n" + indent + end)
+
+	case yaml.AliasNode:
+		writeJSONNode(buf, node.Alias, indent)
+
+	default:
+		var decoded interface{}
+		if node.Style == 0 && json.Unmarshal([]byte(node.Value), &decoded) == nil {
+			if _, ok := decoded.(string); !ok {
+				buf.WriteString(node.Value)
+				return
+			}
+		}
+		value, _ := json.Marshal(node.Value)
+		buf.Write(value)
+	}
+}
+
+// Writes the file atomically, through a temporary file in the same directory
+// that replaces it once written to disk
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Persist the rename too (not supported on every platform)
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// Merges the options into the configuration file (flat or structured),
+// keeping the previous one as a backup (`<path>.bak`). When the path is a
+// symbolic link, the file it points to is updated.
+func writeConfigFile(path string, options map[string]string) error {
+	// The format follows the name given with `--config`, like when loading
+	structured := isStructuredConfig(path)
+	asJSON := strings.EqualFold(filepath.Ext(path), ".json")
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	// New files are only readable by their owner, since they contain the
+	// client key, while the existing ones keep their permissions
+	perm := os.FileMode(0600)
+	contents, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	exists := err == nil
+
+	merged := mergeConfigFile(contents, options)
+	if structured {
+		if merged, err = mergeStructuredConfigFile(contents, options, asJSON); err != nil {
+			return fmt.Errorf("could not update configuration file: %w", err)
+		}
+	}
+
+	if exists {
+		if info, err := os.Stat(path); err == nil {
+			perm = info.Mode().Perm()
+		}
+		if err := writeFileAtomic(path+".bak", contents, perm); err != nil {
+			return fmt.Errorf("could not back up configuration file: %w", err)
+		}
+	}
+	return writeFileAtomic(path, merged, perm)
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfigFile = `# Credentials from the platform
+client-id=old-client
+client-key old-key
+
+# The LoRaWAN server
+connect-host=eu1.cloud.thethings.network
+# gateway=commented-out
+listen-port-up=1800
+`
+
+func TestRenderConfigOptions(t *testing.T) {
+	assert.Equal(t, "", renderConfigOptions(nil))
+	assert.Equal(t, "a=1\nb=2\nc=\n", renderConfigOptions(map[string]string{"c": "", "b": "2", "a": "1"}))
+}
+
+func TestMergeConfigFile(t *testing.T) {
+	merged := mergeConfigFile([]byte(testConfigFile), map[string]string{
+		"client-id":  "new-client",
+		"client-key": "new-key",
+		"gateway":    "gw-1",
+		"aggregate":  "true",
+	})
+
+	// Updated in place, with the new options appended
+	assert.Equal(t, `# Credentials from the platform
+client-id=new-client
+client-key new-key
+
+# The LoRaWAN server
+connect-host=eu1.cloud.thethings.network
+# gateway=commented-out
+listen-port-up=1800
+aggregate=true
+gateway=gw-1
+`, string(merged))
+
+	// Empty files, files without a trailing new line, and repeated options
+	assert.Equal(t, "gateway=gw-1\n", string(mergeConfigFile(nil, map[string]string{"gateway": "gw-1"})))
+	assert.Equal(t, "server-side\ngateway=gw-1\n", string(mergeConfigFile([]byte("server-side"), map[string]string{"gateway": "gw-1"})))
+	assert.Equal(t, "server-side=false\n", string(mergeConfigFile([]byte("server-side"), map[string]string{"server-side": "false"})))
+	assert.Equal(t, "gateway=gw-2\ngateway=gw-1\n", string(mergeConfigFile([]byte("gateway=gw-0\ngateway=gw-1\n"), map[string]string{"gateway": "gw-2"})))
+
+	// Windows line endings
+	assert.Equal(t, "# comment\r\ngateway=gw-2\r\n", string(mergeConfigFile([]byte("# comment\r\ngateway=gw-1\r\n"), map[string]string{"gateway": "gw-2"})))
+}
+
+func TestWriteConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kudzu-forwarder.conf")
+
+	// A new file
+	assert.Nil(t, writeConfigFile(path, map[string]string{"gateway": "gw-1"}))
+	contents, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "gateway=gw-1\n", string(contents))
+	_, err = os.Stat(path + ".bak")
+	assert.True(t, os.IsNotExist(err))
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		assert.Nil(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	// Updating it keeps the previous version and its permissions
+	assert.Nil(t, os.WriteFile(path, []byte(testConfigFile), 0640))
+	assert.Nil(t, os.Chmod(path, 0640))
+	assert.Nil(t, writeConfigFile(path, map[string]string{"client-id": "new-client"}))
+
+	contents, err = os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Contains(t, string(contents), "client-id=new-client\n")
+	assert.Contains(t, string(contents), "# The LoRaWAN server\n")
+	backup, err := os.ReadFile(path + ".bak")
+	assert.Nil(t, err)
+	assert.Equal(t, testConfigFile, string(backup))
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		assert.Nil(t, err)
+		assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	}
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestWriteConfigFileSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need privileges on windows")
+	}
+	dir := t.TempDir()
+	target := filepath.Join(dir, "forwarder.conf")
+	link := filepath.Join(dir, "kudzu-forwarder.conf")
+	assert.Nil(t, os.WriteFile(target, []byte("gateway=gw-1\n"), 0644))
+	assert.Nil(t, os.Symlink(target, link))
+
+	assert.Nil(t, writeConfigFile(link, map[string]string{"gateway": "gw-2"}))
+
+	// The link is kept, pointing to the updated file
+	resolved, err := os.Readlink(link)
+	assert.Nil(t, err)
+	assert.Equal(t, target, resolved)
+	contents, err := os.ReadFile(target)
+	assert.Nil(t, err)
+	assert.Equal(t, "gateway=gw-2\n", string(contents))
+	backup, err := os.ReadFile(target + ".bak")
+	assert.Nil(t, err)
+	assert.Equal(t, "gateway=gw-1\n", string(backup))
+}
+
+func TestMergeStructuredConfigFile(t *testing.T) {
+	options := map[string]string{
+		"client-id": "new-client",
+		"gateway":   "0102030405060708",
+		"aggregate": "true",
+		"log-file":  "",
+	}
+
+	// The comments and the layout of YAML files are kept
+	merged, err := mergeStructuredConfigFile([]byte(`# Forwarder configuration
+version: 1
+# Credentials from the platform
+client-id: old-client # replaced when pairing
+gateway-allow:
+  - 0807060504030201
+`), options, false)
+	assert.Nil(t, err)
+	assert.Equal(t, `# Forwarder configuration
+version: 1
+# Credentials from the platform
+client-id: new-client # replaced when pairing
+gateway-allow:
+  - 0807060504030201
+aggregate: true
+gateway: 0102030405060708
+log-file: ""
+`, string(merged))
+
+	// The result reads back the same
+	fs, _, _ := createTestConfigFlags()
+	fs.String("log-file", "", "")
+	parsed, err := parseStructuredConfig(merged, fs)
+	assert.Nil(t, err)
+	assert.Equal(t, "0102030405060708", parsed["gateway"])
+	assert.Equal(t, "", parsed["log-file"])
+
+	// JSON files are formatted again
+	merged, err = mergeStructuredConfigFile([]byte(`{"version": 1, "client-id": "old-client", "listen-port-up": 1900, "source-allow": ["10.0.0.0/8"], "gateways": {}}`), options, true)
+	assert.Nil(t, err)
+	assert.Equal(t, `{
+  "version": 1,
+  "client-id": "new-client",
+  "listen-port-up": 1900,
+  "source-allow": [
+    "10.0.0.0/8"
+  ],
+  "gateways": {},
+  "aggregate": true,
+  "gateway": "0102030405060708",
+  "log-file": ""
+}
+`, string(merged))
+
+	// New files start with the schema version
+	merged, err = mergeStructuredConfigFile(nil, map[string]string{"gateway": "gw-1"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "version: 1\ngateway: gw-1\n", string(merged))
+	merged, err = mergeStructuredConfigFile(nil, map[string]string{"gateway": "gw-1"}, true)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\n  \"version\": 1,\n  \"gateway\": \"gw-1\"\n}\n", string(merged))
+
+	// The options loaded from the file are not repeated
+	gateways := "version: 1\ngateways:\n  0102030405060708:\n    network: 10.0.0.0/8\n"
+	merged, err = mergeStructuredConfigFile([]byte(gateways), map[string]string{
+		"gateway-pin": "0102030405060708@10.0.0.0/8",
+		"gateway":     "gw-1",
+	}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, gateways+"gateway: gw-1\n", string(merged))
+
+	_, err = mergeStructuredConfigFile([]byte("- a\n"), options, false)
+	assert.ErrorContains(t, err, "not a mapping")
+}
+
+func TestWriteStructuredConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forwarder.yaml")
+	assert.Nil(t, os.WriteFile(path, []byte("version: 1\ngateway: gw-1\n"), 0644))
+
+	assert.Nil(t, writeConfigFile(path, map[string]string{"gateway": "gw-2"}))
+	contents, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "version: 1\ngateway: gw-2\n", string(contents))
+	backup, err := os.ReadFile(path + ".bak")
+	assert.Nil(t, err)
+	assert.Equal(t, "version: 1\ngateway: gw-1\n", string(backup))
+
+	// Nothing is written when the file can't be merged
+	assert.Nil(t, os.WriteFile(path, []byte("- gateway\n"), 0644))
+	assert.ErrorContains(t, writeConfigFile(path, map[string]string{"gateway": "gw-3"}), "could not update configuration file")
+	backup, err = os.ReadFile(path + ".bak")
+	assert.Nil(t, err)
+	assert.Equal(t, "version: 1\ngateway: gw-1\n", string(backup))
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"runtime/debug"
 
+	"github.com/kudzutechnologies/analytics/client"
 	"github.com/namsral/flag"
 	log "github.com/sirupsen/logrus"
 )
@@ -13,9 +14,14 @@ import (
 const MajorVersion = "0.1.11"
 
 type ForwarderConfig struct {
+	Aggregate            bool   `json:"aggregate,omitempty"`
+	AggregateSample      int    `json:"aggregate-sample,omitempty"`
+	BatchSize            int    `json:"batch-size,omitempty"`
 	BufferSize           int    `json:"buffer-size,omitempty"`
+	CaptureDir           string `json:"capture-dir,omitempty"`
 	ClientId             string `json:"client-id,omitempty"`
 	ClientKey            string `json:"client-key,omitempty"`
+	Compression          string `json:"analytics-compression,omitempty"`
 	ConnectHost          string `json:"connect-host,omitempty"`
 	ConnectInterface     string `json:"connect-interface,omitempty"`
 	ConnectPortDown      int    `json:"connect-port-down,omitempty"`
@@ -23,45 +29,94 @@ type ForwarderConfig struct {
 	ConnectRetryInterval int    `json:"connect-retry-interval,omitempty"`
 	ConnectTimeout       int    `json:"connect-timeout,omitempty"`
 	DebugDump            string `json:"debug-dump,omitempty"`
+	DumpFormat           string `json:"dump-format,omitempty"`
+	DumpMaxFiles         int    `json:"dump-max-files,omitempty"`
+	DumpMaxSize          int    `json:"dump-max-size,omitempty"`
+	DumpRotateInterval   int    `json:"dump-rotate-interval,omitempty"`
 	Endpoint             string `json:"analytics-endpoint,omitempty"`
+	EndpointSRV          string `json:"analytics-endpoint-srv,omitempty"`
+	Endpoints            string `json:"analytics-fallback-endpoints,omitempty"`
 	FlushInterval        int    `json:"flush-interval,omitempty"`
+	GatewayAllow         string `json:"gateway-allow,omitempty"`
+	GatewayDeny          string `json:"gateway-deny,omitempty"`
 	GatewayId            string `json:"gateway,omitempty"`
+	GatewayLocation      string `json:"gateway-location,omitempty"`
+	GatewayMoveDistance  int    `json:"gateway-move-distance,omitempty"`
+	GatewayPin           string `json:"gateway-pin,omitempty"`
 	GaugeStat            bool   `json:"gauge-stat,omitempty"`
+	HealthCheckInterval  int    `json:"analytics-health-interval,omitempty"`
+	HTTPEncoding         string `json:"analytics-http-encoding,omitempty"`
+	InfoInterval         int    `json:"info-interval,omitempty"`
 	ListenHost           string `json:"listen-host,omitempty"`
 	ListenPortDown       int    `json:"listen-port-down,omitempty"`
 	ListenPortUp         int    `json:"listen-port-up,omitempty"`
 	LogLevel             string `json:"log-level,omitempty"`
+	MaxMessageSize       int    `json:"analytics-max-message-size,omitempty"`
 	MaxReconnectBackoff  int    `json:"analytics-max-backoff,omitempty"`
 	MaxUDPStreams        int    `json:"max-udp-streams,omitempty"`
 	QueueSize            int    `json:"queue-size,omitempty"`
+	RateLimit            int    `json:"analytics-rate-limit,omitempty"`
+	RemoteControl        bool   `json:"remote-control,omitempty"`
 	RequestTimeout       int    `json:"analytics-request-timeout,omitempty"`
 	ServerSide           bool   `json:"server-side,omitempty"`
+	SocketWorkers        int    `json:"socket-workers,omitempty"`
+	SourceAllow          string `json:"source-allow,omitempty"`
+	SourceDeny           string `json:"source-deny,omitempty"`
+	Transport            string `json:"analytics-transport,omitempty"`
+	UnknownGatewayRate   int    `json:"unknown-gateway-rate,omitempty"`
 }
 
 var defaultConf = ForwarderConfig{
+	Aggregate:            false,
+	AggregateSample:      0,
+	BatchSize:            32,
 	BufferSize:           1500,
+	CaptureDir:           "",
 	ClientId:             "",
 	ClientKey:            "",
+	Compression:          "",
 	ConnectHost:          "",
-	ConnectInterface:     "0.0.0.0",
+	ConnectInterface:     "",
 	ConnectPortDown:      1700,
 	ConnectPortUp:        1700,
 	ConnectRetryInterval: 1,
 	ConnectTimeout:       0,
 	DebugDump:            "",
+	DumpFormat:           "base64",
+	DumpMaxFiles:         0,
+	DumpMaxSize:          0,
+	DumpRotateInterval:   0,
 	Endpoint:             "",
+	EndpointSRV:          "",
+	Endpoints:            "",
 	FlushInterval:        0,
+	GatewayAllow:         "",
+	GatewayDeny:          "",
 	GatewayId:            "",
+	GatewayLocation:      "",
+	GatewayMoveDistance:  100,
+	GatewayPin:           "",
 	GaugeStat:            false,
+	HealthCheckInterval:  0,
+	HTTPEncoding:         "",
+	InfoInterval:         300,
 	ListenHost:           "127.0.0.1",
 	ListenPortDown:       1801,
 	ListenPortUp:         1800,
 	LogLevel:             "info",
+	MaxMessageSize:       0,
 	MaxReconnectBackoff:  0,
 	MaxUDPStreams:        0,
 	QueueSize:            100,
+	RateLimit:            0,
+	RemoteControl:        false,
 	RequestTimeout:       0,
 	ServerSide:           false,
+	SocketWorkers:        1,
+	SourceAllow:          "",
+	SourceDeny:           "",
+	Transport:            "",
+	UnknownGatewayRate:   0,
 }
 
 func Version() string {
@@ -75,51 +130,250 @@ func Version() string {
 	return "unknown"
 }
 
-func ParseConfigFromEnv() ForwarderConfig {
-	var config ForwarderConfig
-	flag.String(flag.DefaultConfigFlagname, "", "path to the configuration file")
-
+// Registers the flags of all the configuration options in the given flag set
+func registerConfigFlags(fs *flag.FlagSet, config *ForwarderConfig) {
 	// UDP forwarder config
-	flag.IntVar(&config.QueueSize, "queue-size", defaultConf.QueueSize, "how many items to keep in the queue")
-	flag.IntVar(&config.BufferSize, "buffer-size", defaultConf.BufferSize, "how much memory to allocate for the UDP packets")
-	flag.StringVar(&config.ListenHost, "listen-host", defaultConf.ListenHost, "the hostname where to listen (UDP forwarder connects here)")
-	flag.IntVar(&config.ListenPortUp, "listen-port-up", defaultConf.ListenPortUp, "the (local) port where to receive uplink datagrams from the UDP forwarder")
-	flag.IntVar(&config.ListenPortDown, "listen-port-down", defaultConf.ListenPortDown, "the UDP forwarder port where to send downlink datagrams to")
-	flag.StringVar(&config.ConnectHost, "connect-host", defaultConf.ConnectHost, "the hostname where to connect to (the LoRa Server)")
-	flag.IntVar(&config.ConnectPortUp, "connect-port-up", defaultConf.ConnectPortUp, "the server port where to send uplink datagrams to")
-	flag.IntVar(&config.ConnectPortDown, "connect-port-down", defaultConf.ConnectPortDown, "the (local) port where to receive downlink datagrams from")
-	flag.StringVar(&config.ConnectInterface, "connect-interface", defaultConf.ConnectInterface, "the interface to bind when connecting to remote host")
-	flag.IntVar(&config.MaxUDPStreams, "max-udp-streams", defaultConf.MaxUDPStreams, "how many distinct UDP streams to maintain. Only useful on server-side mode")
-	flag.IntVar(&config.ConnectRetryInterval, "connect-retry-interval", defaultConf.ConnectRetryInterval, "how many seconds to wait before re-connecting to the remote server if the connection is severed")
+	fs.IntVar(&config.QueueSize, "queue-size", defaultConf.QueueSize, "how many received datagrams to keep in the queue for analytics processing")
+	fs.IntVar(&config.BufferSize, "buffer-size", defaultConf.BufferSize, "how much memory to allocate for the UDP packets")
+	fs.IntVar(&config.BatchSize, "batch-size", defaultConf.BatchSize, "how many datagrams to read or write with a single system call (linux only)")
+	fs.IntVar(&config.SocketWorkers, "socket-workers", defaultConf.SocketWorkers, "how many sockets to open on each listen port using SO_REUSEPORT (linux only)")
+	fs.StringVar(&config.ListenHost, "listen-host", defaultConf.ListenHost, "the hostname where to listen (UDP forwarder connects here), use '::' to listen on both IPv4 and IPv6")
+	fs.IntVar(&config.ListenPortUp, "listen-port-up", defaultConf.ListenPortUp, "the (local) port where to receive uplink datagrams from the UDP forwarder")
+	fs.IntVar(&config.ListenPortDown, "listen-port-down", defaultConf.ListenPortDown, "the UDP forwarder port where to send downlink datagrams to")
+	fs.StringVar(&config.ConnectHost, "connect-host", defaultConf.ConnectHost, "the hostname where to connect to (the LoRa Server)")
+	fs.IntVar(&config.ConnectPortUp, "connect-port-up", defaultConf.ConnectPortUp, "the server port where to send uplink datagrams to")
+	fs.IntVar(&config.ConnectPortDown, "connect-port-down", defaultConf.ConnectPortDown, "the (local) port where to receive downlink datagrams from")
+	fs.StringVar(&config.ConnectInterface, "connect-interface", defaultConf.ConnectInterface, "the interface name or address to bind when connecting to remote host")
+	fs.IntVar(&config.MaxUDPStreams, "max-udp-streams", defaultConf.MaxUDPStreams, "how many distinct UDP streams to maintain. Only useful on server-side mode")
+	fs.IntVar(&config.ConnectRetryInterval, "connect-retry-interval", defaultConf.ConnectRetryInterval, "how many seconds to wait before re-connecting to the remote server if the connection is severed")
+
+	// Gateway policy config
+	fs.StringVar(&config.GatewayAllow, "gateway-allow", defaultConf.GatewayAllow, "comma-separated list of gateway EUIs that are allowed to use the forwarder")
+	fs.StringVar(&config.GatewayDeny, "gateway-deny", defaultConf.GatewayDeny, "comma-separated list of gateway EUIs that are rejected")
+	fs.StringVar(&config.SourceAllow, "source-allow", defaultConf.SourceAllow, "comma-separated list of networks (CIDR) the gateways are allowed to connect from")
+	fs.StringVar(&config.SourceDeny, "source-deny", defaultConf.SourceDeny, "comma-separated list of networks (CIDR) that are rejected")
+	fs.StringVar(&config.GatewayPin, "gateway-pin", defaultConf.GatewayPin, "comma-separated list of <eui>@<network> pairs that pin a gateway to the given network")
+	fs.IntVar(&config.UnknownGatewayRate, "unknown-gateway-rate", defaultConf.UnknownGatewayRate, "how many new gateways to accept per minute, without an allow list (0 for unlimited)")
 
 	// Analytics client config
-	flag.StringVar(&config.ClientId, "client-id", defaultConf.ClientId, "the client ID to use for connecting to Kudzu Analytics")
-	flag.StringVar(&config.ClientKey, "client-key", defaultConf.ClientKey, "the private client key to use for connecting to Kudzu Analytics")
-	flag.StringVar(&config.Endpoint, "analytics-endpoint", defaultConf.Endpoint, "the analytics endpoint to push the data to")
-	flag.IntVar(&config.ConnectTimeout, "analytics-connect-timeout", defaultConf.ConnectTimeout, "how long to wait for analytics connection")
-	flag.IntVar(&config.RequestTimeout, "analytics-request-timeout", defaultConf.RequestTimeout, "how long to wait for analytics to be pushed")
-	flag.IntVar(&config.MaxReconnectBackoff, "analytics-max-backoff", defaultConf.MaxReconnectBackoff, "the maximum time to wait for reconnecting")
+	fs.StringVar(&config.ClientId, "client-id", defaultConf.ClientId, "the client ID to use for connecting to Kudzu Analytics")
+	fs.StringVar(&config.ClientKey, "client-key", defaultConf.ClientKey, "the private client key to use for connecting to Kudzu Analytics")
+	fs.StringVar(&config.Endpoint, "analytics-endpoint", defaultConf.Endpoint, "the analytics endpoint to push the data to")
+	fs.StringVar(&config.Endpoints, "analytics-fallback-endpoints", defaultConf.Endpoints, "comma-separated list of analytics endpoints to fail over to, in order of preference")
+	fs.StringVar(&config.EndpointSRV, "analytics-endpoint-srv", defaultConf.EndpointSRV, "the DNS SRV name listing the analytics endpoints, preferred over the static ones")
+	fs.IntVar(&config.HealthCheckInterval, "analytics-health-interval", defaultConf.HealthCheckInterval, "how often to check if a preferred analytics endpoint recovered while failed over (in seconds, 0 for 60)")
+	fs.IntVar(&config.ConnectTimeout, "analytics-connect-timeout", defaultConf.ConnectTimeout, "how long to wait for analytics connection")
+	fs.IntVar(&config.RequestTimeout, "analytics-request-timeout", defaultConf.RequestTimeout, "how long to wait for analytics to be pushed")
+	fs.IntVar(&config.MaxReconnectBackoff, "analytics-max-backoff", defaultConf.MaxReconnectBackoff, "the maximum time to wait for reconnecting")
+	fs.StringVar(&config.Compression, "analytics-compression", defaultConf.Compression, "comma-separated compressions to offer to the analytics endpoint, can be 'zstd', 'gzip' or 'none' (empty for all)")
+	fs.IntVar(&config.MaxMessageSize, "analytics-max-message-size", defaultConf.MaxMessageSize, "the largest push to the analytics endpoint (in bytes, 0 for 1 MiB), larger ones are split")
+	fs.IntVar(&config.RateLimit, "analytics-rate-limit", defaultConf.RateLimit, "the largest sustained rate of the pushes to the analytics endpoint (in bytes per second before compression, 0 for no limit)")
+	fs.StringVar(&config.Transport, "analytics-transport", defaultConf.Transport, "the transport to the analytics endpoint, can be 'grpc', 'https' or empty for gRPC with HTTPS as a fall-back")
+	fs.StringVar(&config.HTTPEncoding, "analytics-http-encoding", defaultConf.HTTPEncoding, "the encoding of the HTTPS transport, can be 'protobuf' or 'json'")
 
 	// Forwarder component config
-	flag.IntVar(&config.FlushInterval, "flush-interval", defaultConf.FlushInterval, "how frequently to flush collected metrics to analytics")
-	flag.StringVar(&config.GatewayId, "gateway", defaultConf.GatewayId, "the ID of the gateway the forwarder is pushing data for")
-	flag.BoolVar(&config.GaugeStat, "gauge-stat", defaultConf.GaugeStat, "the statistics are gauge values")
-	flag.BoolVar(&config.ServerSide, "server-side", defaultConf.ServerSide, "the forwarder runs on the server-side")
+	fs.IntVar(&config.FlushInterval, "flush-interval", defaultConf.FlushInterval, "how frequently to flush collected metrics to analytics")
+	fs.IntVar(&config.InfoInterval, "info-interval", defaultConf.InfoInterval, "how frequently to report the health of the forwarder with the metrics (in seconds, 0 to disable)")
+	fs.StringVar(&config.GatewayId, "gateway", defaultConf.GatewayId, "the ID of the gateway the forwarder is pushing data for")
+	fs.StringVar(&config.GatewayLocation, "gateway-location", defaultConf.GatewayLocation, "comma-separated list of <eui>=<lat>:<lon>[:<alt>] positions to report for gateways without GPS")
+	fs.IntVar(&config.GatewayMoveDistance, "gateway-move-distance", defaultConf.GatewayMoveDistance, "how far a gateway must move (in meters) before reporting a position change")
+	fs.BoolVar(&config.GaugeStat, "gauge-stat", defaultConf.GaugeStat, "the statistics are gauge values")
+	fs.BoolVar(&config.Aggregate, "aggregate", defaultConf.Aggregate, "send histograms of the uplinks and downlinks of each flush window instead of every frame")
+	fs.IntVar(&config.AggregateSample, "aggregate-sample", defaultConf.AggregateSample, "the percentage of the frames to also send individually when aggregating (0 - 100)")
+	fs.BoolVar(&config.ServerSide, "server-side", defaultConf.ServerSide, "the forwarder runs on the server-side")
+	fs.BoolVar(&config.RemoteControl, "remote-control", defaultConf.RemoteControl, "accept configuration changes and commands from the analytics service")
+
+	fs.StringVar(&config.DebugDump, "debug-dump", defaultConf.DebugDump, "the filename where to write the traffic for debugging")
+	fs.StringVar(&config.CaptureDir, "capture-dir", defaultConf.CaptureDir, "the directory of the captures started by the remote control (empty to only capture to debug-dump)")
+	fs.StringVar(&config.DumpFormat, "dump-format", defaultConf.DumpFormat, "the format of the traffic dump, can be 'base64' or 'pcapng'")
+	fs.IntVar(&config.DumpMaxSize, "dump-max-size", defaultConf.DumpMaxSize, "rotate the traffic dump after it reaches this many megabytes (0 to disable)")
+	fs.IntVar(&config.DumpRotateInterval, "dump-rotate-interval", defaultConf.DumpRotateInterval, "rotate the traffic dump after this many seconds (0 to disable)")
+	fs.IntVar(&config.DumpMaxFiles, "dump-max-files", defaultConf.DumpMaxFiles, "how many rotated traffic dumps to keep, deleting the oldest (0 to keep all)")
+	fs.StringVar(&config.LogLevel, "log-level", defaultConf.LogLevel, "selects the verbosity of logging, can be 'error', 'warn', 'info', 'debug'")
+}
+
+// Fills-in the options whose defaults depend on other options, and applies the log level
+func applyConfigDefaults(config *ForwarderConfig) {
+	// Adjust MaxUDPStreams defaults
+	if config.MaxUDPStreams == 0 {
+		if config.ServerSide {
+			// On server-side environments, accept streams from many gaateways
+			config.MaxUDPStreams = 256
+		} else {
+			// In low-resource environments we shouldn't create too many streams
+			config.MaxUDPStreams = 2
+		}
+	}
+
+	// Adjust flush interval defaults
+	if config.FlushInterval == 0 {
+		if config.ServerSide {
+			config.FlushInterval = 5
+		} else {
+			config.FlushInterval = 10
+		}
+	}
 
-	flag.StringVar(&config.DebugDump, "debug-dump", defaultConf.DebugDump, "the filename where to write the traffic for debugging")
-	flag.StringVar(&config.LogLevel, "log-level", defaultConf.LogLevel, "selects the verbosity of logging, can be 'error', 'warn', 'info', 'debug'")
+	// Apply log level
+	level, err := parseLogLevel(config.LogLevel)
+	if err != nil {
+		log.Fatalf("Unknown log level: %s", config.LogLevel)
+	}
+	log.SetLevel(level)
+}
+
+// Checks that the values of the options are within range, once the defaults
+// are applied
+func validateConfig(config *ForwarderConfig) error {
+	ports := []struct {
+		name  string
+		value int
+	}{
+		{"listen-port-up", config.ListenPortUp},
+		{"listen-port-down", config.ListenPortDown},
+		{"connect-port-up", config.ConnectPortUp},
+		{"connect-port-down", config.ConnectPortDown},
+	}
+	for _, port := range ports {
+		if port.value < 1 || port.value > 65535 {
+			return fmt.Errorf("%s must be between 1 and 65535 (got %d)", port.name, port.value)
+		}
+	}
+
+	positive := []struct {
+		name  string
+		value int
+	}{
+		{"queue-size", config.QueueSize},
+		{"buffer-size", config.BufferSize},
+		{"batch-size", config.BatchSize},
+		{"socket-workers", config.SocketWorkers},
+		{"max-udp-streams", config.MaxUDPStreams},
+		{"flush-interval", config.FlushInterval},
+	}
+	for _, option := range positive {
+		if option.value <= 0 {
+			return fmt.Errorf("%s must be greater than 0 (got %d)", option.name, option.value)
+		}
+	}
+
+	nonNegative := []struct {
+		name  string
+		value int
+	}{
+		{"connect-retry-interval", config.ConnectRetryInterval},
+		{"unknown-gateway-rate", config.UnknownGatewayRate},
+		{"analytics-health-interval", config.HealthCheckInterval},
+		{"analytics-connect-timeout", config.ConnectTimeout},
+		{"analytics-request-timeout", config.RequestTimeout},
+		{"analytics-max-backoff", config.MaxReconnectBackoff},
+		{"analytics-max-message-size", config.MaxMessageSize},
+		{"analytics-rate-limit", config.RateLimit},
+		{"info-interval", config.InfoInterval},
+		{"gateway-move-distance", config.GatewayMoveDistance},
+		{"dump-max-size", config.DumpMaxSize},
+		{"dump-rotate-interval", config.DumpRotateInterval},
+		{"dump-max-files", config.DumpMaxFiles},
+	}
+	for _, option := range nonNegative {
+		if option.value < 0 {
+			return fmt.Errorf("%s must not be negative (got %d)", option.name, option.value)
+		}
+	}
+
+	// Only the listed gateways get through when there is an allow list, so
+	// there are no unknown gateways left to rate-limit
+	if config.UnknownGatewayRate > 0 && len(splitList(config.GatewayAllow)) > 0 {
+		return fmt.Errorf("unknown-gateway-rate can't be used together with gateway-allow")
+	}
+
+	if config.AggregateSample < 0 || config.AggregateSample > 100 {
+		return fmt.Errorf("aggregate-sample must be between 0 and 100 (got %d)", config.AggregateSample)
+	}
+	if _, err := parseLogLevel(config.LogLevel); err != nil {
+		return fmt.Errorf("log-level must be 'error', 'warn', 'info' or 'debug' (got '%s')", config.LogLevel)
+	}
+	return nil
+}
+
+func parseLogLevel(level string) (log.Level, error) {
+	switch level {
+	case "debug":
+		return log.DebugLevel, nil
+	case "info":
+		return log.InfoLevel, nil
+	case "error":
+		return log.ErrorLevel, nil
+	case "warn":
+		return log.WarnLevel, nil
+	}
+	return log.InfoLevel, fmt.Errorf("unknown log level '%s'", level)
+}
+
+// Returns the configuration of the analytics client
+func analyticsClientConfig(config *ForwarderConfig) client.AnalyticsClientConfig {
+	return client.AnalyticsClientConfig{
+		ClientId:            config.ClientId,
+		ClientKey:           config.ClientKey,
+		Endpoint:            config.Endpoint,
+		Endpoints:           splitList(config.Endpoints),
+		EndpointSRV:         config.EndpointSRV,
+		HealthCheckInterval: int32(config.HealthCheckInterval),
+		ConnectTimeout:      int32(config.ConnectTimeout),
+		RequestTimeout:      int32(config.RequestTimeout),
+		MaxReconnectBackoff: int32(config.MaxReconnectBackoff),
+		ServerSide:          &config.ServerSide,
+		Compression:         config.Compression,
+		MaxMessageSize:      int32(config.MaxMessageSize),
+		RateLimit:           int32(config.RateLimit),
+		Transport:           config.Transport,
+		HTTPEncoding:        config.HTTPEncoding,
+	}
+}
+
+// Returns the path of the configuration file given with `--config`
+func configFilePath() string {
+	return flag.Lookup(flag.DefaultConfigFlagname).Value.(*configFileValue).path
+}
+
+// Merges the options obtained by pairing into the configuration file when
+// `--write` is given, or prints them otherwise
+func outputPairedConfig(options map[string]string, writeConfig bool) {
+	if !writeConfig {
+		fmt.Print(renderConfigOptions(options))
+		return
+	}
+
+	configFile := configFilePath()
+	if configFile == "" {
+		log.Fatalf("You must specify the configuration file to write (--config=)")
+	}
+	log.Infof("Writing changes to configuration file: %s", configFile)
+	if err := writeConfigFile(configFile, options); err != nil {
+		log.Fatalf("Could not write configuration file: %s", err.Error())
+	}
+}
+
+func ParseConfigFromEnv() ForwarderConfig {
+	var config ForwarderConfig
+	configFile := registerConfigFileFlag(flag.CommandLine)
+
+	registerConfigFlags(flag.CommandLine, &config)
 
 	// Local flags
 	var version bool
 	var writeConfig bool
 	var logFile string
 	var pairPin string
+	var pairBundle string
 	flag.BoolVar(&version, "version", false, "show the package version and exit")
 	flag.BoolVar(&writeConfig, "write", false, "write any changes to the configuration file")
 	flag.StringVar(&logFile, "log-file", "", "writes the program output to the specified logfile")
 	flag.StringVar(&pairPin, "pair-pin", "", "if specified, tries to download a configuration from the server using this PIN and exits")
+	flag.StringVar(&pairBundle, "pair-bundle", "", "if specified, reads the configuration from a signed pairing bundle (file, '-' for stdin or the text of a QR code) and exits")
 
 	flag.Parse()
+	loadConfigFile(flag.CommandLine, configFile)
 
 	// Check if only version is requested
 	if version {
@@ -129,21 +383,21 @@ func ParseConfigFromEnv() ForwarderConfig {
 
 	// If we only need to pair, download pair config and write config file now
 	if pairPin != "" {
-		config, err := getRenderedPairConfig(pairPin, config)
+		options, err := getPairConfigOptions(pairPin, config)
 		if err != nil {
 			log.Fatalf("Could not pair with server: %s", err.Error())
 		}
+		outputPairedConfig(options, writeConfig)
+		os.Exit(0)
+	}
 
-		if writeConfig {
-			// Write the configuration to the file
-			log.Infof("Writing changes to configuration file: %s", flag.DefaultConfigFlagname)
-			err = os.WriteFile(flag.DefaultConfigFlagname, []byte(config), 0644)
-			if err != nil {
-				log.Fatalf("Could not write configuration file: %s", err.Error())
-			}
-		} else {
-			fmt.Println(config)
+	// Same when pairing offline with a signed bundle
+	if pairBundle != "" {
+		options, err := getBundleConfigOptions(pairBundle, os.Stdin, config)
+		if err != nil {
+			log.Fatalf("Could not pair with bundle: %s", err.Error())
 		}
+		outputPairedConfig(options, writeConfig)
 		os.Exit(0)
 	}
 
@@ -160,38 +414,13 @@ func ParseConfigFromEnv() ForwarderConfig {
 		log.Fatalf("You must specify a gateway ID (--gateway=) when running on the client-side")
 	}
 
-	// Adjust MaxUDPStreams defaults
-	if config.MaxUDPStreams == 0 {
-		if config.ServerSide {
-			// On server-side environments, accept streams from many gaateways
-			config.MaxUDPStreams = 256
-		} else {
-			// In low-resource environments we shouldn't create too many streams
-			config.MaxUDPStreams = 2
-		}
-	}
-
-	// Adjust flush interval defaults
-	if config.FlushInterval == 0 {
-		if config.ServerSide {
-			config.FlushInterval = 5
-		} else {
-			config.FlushInterval = 10
-		}
+	if _, err := parseGatewayLocations(config.GatewayLocation); err != nil {
+		log.Fatalf("Invalid gateway location: %s", err.Error())
 	}
 
-	// Apply log level
-	switch config.LogLevel {
-	case "debug":
-		log.SetLevel(log.DebugLevel)
-	case "info":
-		log.SetLevel(log.InfoLevel)
-	case "error":
-		log.SetLevel(log.ErrorLevel)
-	case "warn":
-		log.SetLevel(log.WarnLevel)
-	default:
-		log.Fatalf("Unknown log level: %s", config.LogLevel)
+	applyConfigDefaults(&config)
+	if err := validateConfig(&config); err != nil {
+		log.Fatalf("Invalid configuration: %s", err.Error())
 	}
 
 	// If we have a logfile specified, redirect output now
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"github.com/kudzutechnologies/analytics/client"
+	"github.com/namsral/flag"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// How the options changed by the remote control are applied
+const (
+	// Read on every use
+	optionLive = iota
+	// Re-creates the gateway policy
+	optionPolicy
+	// Re-opens the traffic dump
+	optionDump
+)
+
+// The exit status after pairing again, which is not zero so that the service
+// manager restarts the forwarder (eg. with `Restart=on-failure`)
+const restartExitCode = 3
+
+// The options that can be changed while running, where the rest need a restart
+var liveOptions = map[string]int{
+	"aggregate":            optionLive,
+	"aggregate-sample":     optionLive,
+	"flush-interval":       optionLive,
+	"gauge-stat":           optionLive,
+	"info-interval":        optionLive,
+	"log-level":            optionLive,
+	"gateway-allow":        optionPolicy,
+	"gateway-deny":         optionPolicy,
+	"gateway-pin":          optionPolicy,
+	"source-allow":         optionPolicy,
+	"source-deny":          optionPolicy,
+	"unknown-gateway-rate": optionPolicy,
+	"dump-format":          optionDump,
+	"dump-max-files":       optionDump,
+	"dump-max-size":        optionDump,
+	"dump-rotate-interval": optionDump,
+}
+
+// Returns a copy of the configuration with the given options, that are parsed
+// the same way as the ones of the configuration file
+func patchConfig(config ForwarderConfig, options map[string]string) (ForwarderConfig, error) {
+	var patched ForwarderConfig
+	fs := flag.NewFlagSet("patch", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	registerConfigFlags(fs, &patched)
+
+	// Registering the flags resets the options to their defaults
+	patched = config
+	for name, value := range options {
+		if fs.Lookup(name) == nil {
+			return config, fmt.Errorf("unknown option '%s'", name)
+		}
+		if _, ok := liveOptions[name]; !ok {
+			return config, fmt.Errorf("option '%s' can't be changed while running", name)
+		}
+		if err := fs.Set(name, value); err != nil {
+			return config, fmt.Errorf("invalid value '%s' for option '%s': %w", value, name, err)
+		}
+	}
+	return patched, nil
+}
+
+// Changes the options of the running configuration, re-creating the components
+// that depend on them. Nothing is changed if any of the options is invalid.
+func (f *AnalyticsForwarder) applyConfig(options map[string]string) error {
+	prev := f.conf()
+	next, err := patchConfig(*prev, options)
+	if err != nil {
+		return err
+	}
+
+	if err := validateConfig(&next); err != nil {
+		return err
+	}
+	level, err := parseLogLevel(next.LogLevel)
+	if err != nil {
+		return err
+	}
+
+	var (
+		changedPolicy, changedDump bool
+		policy                     *GatewayPolicy
+		dump                       DumpWriter
+	)
+	for name := range options {
+		switch liveOptions[name] {
+		case optionPolicy:
+			changedPolicy = true
+		case optionDump:
+			changedDump = true
+		}
+	}
+	if changedPolicy {
+		if policy, err = createGatewayPolicy(next); err != nil {
+			return fmt.Errorf("invalid gateway policy: %w", err)
+		}
+	}
+
+	// The capture is re-opened with the new options if it's running
+	restartDump := changedDump && f.proxy != nil && f.proxy.IsDumping()
+	if restartDump {
+		if dump, err = createConfigDumpWriter(next, f.captureFile(&next)); err != nil {
+			return err
+		}
+	}
+
+	// Everything is valid, so apply the changes
+	log.SetLevel(level)
+	if f.proxy != nil {
+		if changedPolicy {
+			f.proxy.SetPolicy(policy)
+		}
+		if restartDump {
+			f.proxy.SetDumpWriter(dump)
+		}
+	}
+	f.config.Store(&next)
+	return nil
+}
+
+// Returns the file of the running capture, which is the one started by the
+// remote control or the one of the configuration
+func (f *AnalyticsForwarder) captureFile(config *ForwarderConfig) string {
+	if f.capture != "" {
+		return f.capture
+	}
+	return config.DebugDump
+}
+
+// Returns the path of a capture started by the remote control. The service
+// can only name a file in the `capture-dir`, or use the `debug-dump` when it
+// doesn't give one.
+func captureFilePath(config *ForwarderConfig, name string) (string, error) {
+	if name == "" {
+		if config.DebugDump == "" {
+			return "", fmt.Errorf("no file to capture to")
+		}
+		return config.DebugDump, nil
+	}
+	if config.CaptureDir == "" {
+		return "", fmt.Errorf("no capture directory configured")
+	}
+	if name != filepath.Base(name) || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid capture file '%s'", name)
+	}
+	return filepath.Join(config.CaptureDir, name), nil
+}
+
+// Runs a command of the remote control
+func (f *AnalyticsForwarder) runCommand(cmd *api.ControlCommand) error {
+	switch cmd.Type {
+	case api.ControlCommandType_COMMAND_FLUSH:
+		if f.hasData() {
+			f.flushData()
+		}
+		return nil
+	case api.ControlCommandType_COMMAND_ROTATE_DUMP, api.ControlCommandType_COMMAND_START_CAPTURE,
+		api.ControlCommandType_COMMAND_STOP_CAPTURE:
+		if f.proxy == nil {
+			return fmt.Errorf("no traffic to capture")
+		}
+	}
+
+	switch cmd.Type {
+	case api.ControlCommandType_COMMAND_ROTATE_DUMP:
+		return f.proxy.RotateDump()
+	case api.ControlCommandType_COMMAND_START_CAPTURE:
+		filename, err := captureFilePath(f.conf(), cmd.Args["file"])
+		if err != nil {
+			return err
+		}
+		writer, err := createConfigDumpWriter(*f.conf(), filename)
+		if err != nil {
+			return err
+		}
+		log.Infof("Writing all traffic to %s", filename)
+		f.capture = filename
+		f.proxy.SetDumpWriter(writer)
+		return nil
+	case api.ControlCommandType_COMMAND_STOP_CAPTURE:
+		if !f.proxy.IsDumping() {
+			return fmt.Errorf("not capturing the traffic")
+		}
+		log.Infof("Stopped writing the traffic")
+		f.capture = ""
+		f.proxy.SetDumpWriter(nil)
+		return nil
+	}
+	return fmt.Errorf("unknown command %s", cmd.Type)
+}
+
+// The control channel of the analytics endpoint, implemented by `client.ControlChannel`
+type controlChannel interface {
+	Recv() (*api.ControlMessage, error)
+	Report(report *api.ControlReport) error
+	Close()
+}
+
+// Receives the configuration changes and the commands of the analytics
+// service, and reports the version of the configuration that is running
+type remoteControl struct {
+	fw *AnalyticsForwarder
+	// Opens the control channel, sending the initial report
+	open func(ctx context.Context, report *api.ControlReport) (controlChannel, error)
+	// Pairs again with the given pin, and restarts after reporting success
+	repair  func(pin string) error
+	restart func()
+	// How long to wait before opening the channel again
+	retryInterval time.Duration
+}
+
+func (r *remoteControl) report(id uint64, err error) *api.ControlReport {
+	report := &api.ControlReport{
+		Id:               id,
+		ConfigVersion:    r.fw.configVersion.Load(),
+		ForwarderVersion: MajorVersion,
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return report
+}
+
+// Keeps the control channel open until the context is done, or the server
+// does not support it
+func (r *remoteControl) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		err := r.serve(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if status.Code(err) == codes.Unimplemented {
+			log.Infof("The analytics endpoint does not support remote control")
+			return
+		}
+		log.Warnf("Remote control channel closed: %v", err)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(r.retryInterval):
+		}
+	}
+}
+
+// Handles the messages of a control channel until it's closed
+func (r *remoteControl) serve(ctx context.Context) error {
+	ch, err := r.open(ctx, r.report(0, nil))
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+	log.Infof("Remote control channel opened (configuration version %d)", r.fw.configVersion.Load())
+
+	for {
+		msg, err := ch.Recv()
+		if err != nil {
+			return err
+		}
+
+		restart := false
+		err = r.handle(msg)
+		if err != nil {
+			log.Warnf("Could not apply remote control message %d: %s", msg.Id, err.Error())
+		} else if msg.GetCommand().GetType() == api.ControlCommandType_COMMAND_REPAIR {
+			restart = true
+		}
+		if err := ch.Report(r.report(msg.Id, err)); err != nil {
+			return err
+		}
+		if restart {
+			r.restart()
+			return nil
+		}
+	}
+}
+
+func (r *remoteControl) handle(msg *api.ControlMessage) error {
+	if patch := msg.GetConfig(); patch != nil {
+		if err := r.fw.applyConfig(patch.Options); err != nil {
+			return err
+		}
+		log.Infof("Applied remote configuration version %d: %v", patch.Version, patch.Options)
+		r.fw.configVersion.Store(patch.Version)
+		return nil
+	}
+
+	if cmd := msg.GetCommand(); cmd != nil {
+		log.Infof("Running remote command %s", cmd.Type)
+		if cmd.Type == api.ControlCommandType_COMMAND_REPAIR {
+			if cmd.Args["pin"] == "" {
+				return fmt.Errorf("no pin to pair with")
+			}
+			return r.repair(cmd.Args["pin"])
+		}
+		return r.fw.runCommand(cmd)
+	}
+	return fmt.Errorf("unknown message")
+}
+
+// Creates the remote control of the forwarder, using a client of its own since
+// the control channel can't share the client with the pushes
+func createRemoteControl(config ForwarderConfig, fw *AnalyticsForwarder, configFile string) *remoteControl {
+	clientConfig := analyticsClientConfig(&config)
+	// The control channel is only available over gRPC
+	clientConfig.Transport = client.TransportGRPC
+	c := client.CreateAnalyticsClient(clientConfig)
+
+	return &remoteControl{
+		fw: fw,
+		open: func(ctx context.Context, report *api.ControlReport) (controlChannel, error) {
+			c.Disconnect()
+			if err := c.Connect(); err != nil {
+				return nil, err
+			}
+			ch, err := c.OpenControl(ctx, report)
+			if err != nil {
+				return nil, err
+			}
+			return ch, nil
+		},
+		repair: func(pin string) error {
+			if configFile == "" {
+				return fmt.Errorf("no configuration file to write")
+			}
+			// The pairing starts from the configuration file, without the remote changes
+			options, err := getPairConfigOptions(pin, config)
+			if err != nil {
+				return err
+			}
+			return writeConfigFile(configFile, options)
+		},
+		restart: func() {
+			log.Infof("Paired again, exiting to restart with the new configuration")
+			fw.Stop()
+			os.Exit(restartExitCode)
+		},
+		retryInterval: 10 * time.Second,
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func createControlForwarder(t *testing.T) (*AnalyticsForwarder, *mockPusher) {
+	client := CreateSocket(t)
+	server := CreateSocket(t)
+	proxy, err := CreateUDPProxy(&UDPProxyConfig{
+		UpListenAddr:      client.remote,
+		UpConnectAddr:     server.local,
+		BufferSize:        1024,
+		SocketStreams:     16,
+		ReconnectInterval: 1,
+	})
+	assert.NoError(t, err)
+	t.Cleanup(proxy.Close)
+
+	config := defaultConf
+	config.FlushInterval = 10
+	config.MaxUDPStreams = 16
+	pusher := &mockPusher{}
+	return CreateAnalyticsForwarder(config, pusher, proxy), pusher
+}
+
+func TestPatchConfig(t *testing.T) {
+	config := defaultConf
+	config.FlushInterval = 10
+
+	patched, err := patchConfig(config, map[string]string{
+		"flush-interval": "30",
+		"aggregate":      "true",
+		"gateway-allow":  "0102030405060708",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 30, patched.FlushInterval)
+	assert.True(t, patched.Aggregate)
+	assert.Equal(t, "0102030405060708", patched.GatewayAllow)
+	// The rest of the options are kept, not reset to their defaults
+	assert.Equal(t, 10, config.FlushInterval)
+	assert.Equal(t, config.ListenPortUp, patched.ListenPortUp)
+
+	_, err = patchConfig(config, map[string]string{"flush-interval": "soon"})
+	assert.ErrorContains(t, err, "invalid value 'soon' for option 'flush-interval'")
+	_, err = patchConfig(config, map[string]string{"listen-port-up": "1234"})
+	assert.ErrorContains(t, err, "can't be changed while running")
+	_, err = patchConfig(config, map[string]string{"no-such-option": "1"})
+	assert.ErrorContains(t, err, "unknown option")
+}
+
+func TestApplyConfig(t *testing.T) {
+	defer log.SetLevel(log.GetLevel())
+	fw, _ := createControlForwarder(t)
+
+	assert.NoError(t, fw.applyConfig(map[string]string{
+		"flush-interval":   "30",
+		"log-level":        "debug",
+		"aggregate-sample": "50",
+		"gateway-deny":     "0102030405060708",
+	}))
+	assert.Equal(t, 30, fw.conf().FlushInterval)
+	assert.Equal(t, 50, fw.conf().AggregateSample)
+	assert.Equal(t, log.DebugLevel, log.GetLevel())
+	assert.NotNil(t, fw.proxy.policy.Load())
+
+	// Nothing changes when any of the options is invalid
+	for _, options := range []map[string]string{
+		{"flush-interval": "5", "log-level": "chatty"},
+		{"flush-interval": "5", "gateway-pin": "0102030405060708"},
+		{"flush-interval": "5", "aggregate-sample": "101"},
+		{"flush-interval": "0"},
+	} {
+		assert.Error(t, fw.applyConfig(options))
+		assert.Equal(t, 30, fw.conf().FlushInterval)
+	}
+
+	// Removing the rules removes the policy
+	assert.NoError(t, fw.applyConfig(map[string]string{"gateway-deny": ""}))
+	assert.Nil(t, fw.proxy.policy.Load())
+}
+
+func TestApplyDumpConfig(t *testing.T) {
+	fw, _ := createControlForwarder(t)
+	dir := t.TempDir()
+
+	// The file can't be changed while running
+	assert.ErrorContains(t, fw.applyConfig(map[string]string{"debug-dump": filepath.Join(dir, "dump.pcapng")}), "can't be changed while running")
+
+	// The options of the dump don't start it
+	assert.NoError(t, fw.applyConfig(map[string]string{"dump-max-files": "10"}))
+	assert.False(t, fw.proxy.IsDumping())
+
+	// While they re-open the running capture
+	fw.config.Load().CaptureDir = dir
+	assert.NoError(t, fw.runCommand(&api.ControlCommand{
+		Type: api.ControlCommandType_COMMAND_START_CAPTURE,
+		Args: map[string]string{"file": "dump.pcapng"},
+	}))
+	assert.Error(t, fw.applyConfig(map[string]string{"dump-format": "xml"}))
+	assert.True(t, fw.proxy.IsDumping())
+	assert.NoError(t, fw.applyConfig(map[string]string{"dump-format": "base64"}))
+	assert.True(t, fw.proxy.IsDumping())
+	fw.proxy.SetDumpWriter(nil)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Equal(t, []string{filepath.Join(dir, "dump.pcapng")}, files)
+}
+
+func TestCaptureCommands(t *testing.T) {
+	fw, _ := createControlForwarder(t)
+	dir := t.TempDir()
+	command := func(kind api.ControlCommandType, args map[string]string) error {
+		return fw.runCommand(&api.ControlCommand{Type: kind, Args: args})
+	}
+
+	assert.ErrorContains(t, command(api.ControlCommandType_COMMAND_START_CAPTURE, nil), "no file")
+	assert.ErrorContains(t, command(api.ControlCommandType_COMMAND_STOP_CAPTURE, nil), "not capturing")
+	assert.ErrorContains(t, command(api.ControlCommandType_COMMAND_ROTATE_DUMP, nil), "not dumping")
+
+	// The files are only written in the capture directory
+	start := func(file string) error {
+		return command(api.ControlCommandType_COMMAND_START_CAPTURE, map[string]string{"file": file})
+	}
+	assert.ErrorContains(t, start("capture.pcapng"), "no capture directory")
+	fw.config.Load().CaptureDir = dir
+	for _, file := range []string{filepath.Join(dir, "capture.pcapng"), "/etc/passwd", "..", "../capture.pcapng", "sub/capture.pcapng", `..\capture.pcapng`} {
+		assert.ErrorContains(t, start(file), "invalid capture file", file)
+	}
+	assert.False(t, fw.proxy.IsDumping())
+
+	assert.NoError(t, fw.applyConfig(map[string]string{"dump-rotate-interval": "3600"}))
+	assert.NoError(t, start("capture.pcapng"))
+	assert.True(t, fw.proxy.IsDumping())
+	assert.NoError(t, command(api.ControlCommandType_COMMAND_ROTATE_DUMP, nil))
+	assert.NoError(t, command(api.ControlCommandType_COMMAND_STOP_CAPTURE, nil))
+	assert.False(t, fw.proxy.IsDumping())
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Equal(t, []string{filepath.Join(dir, "capture-0001.pcapng"), filepath.Join(dir, "capture-0002.pcapng")}, files)
+
+	// Without a file, the capture is written to debug-dump
+	fw.config.Load().DebugDump = filepath.Join(dir, "dump.pcapng")
+	assert.NoError(t, command(api.ControlCommandType_COMMAND_START_CAPTURE, nil))
+	assert.NoError(t, command(api.ControlCommandType_COMMAND_STOP_CAPTURE, nil))
+	files, _ = filepath.Glob(filepath.Join(dir, "dump-*"))
+	assert.Equal(t, []string{filepath.Join(dir, "dump-0001.pcapng")}, files)
+
+	assert.Error(t, command(api.ControlCommandType_COMMAND_UNKNOWN, nil))
+}
+
+// A control channel that delivers the scripted messages and records the reports
+type fakeControlChannel struct {
+	lock     sync.Mutex
+	messages chan *api.ControlMessage
+	reports  []*api.ControlReport
+	closed   bool
+}
+
+func (c *fakeControlChannel) Recv() (*api.ControlMessage, error) {
+	msg, ok := <-c.messages
+	if !ok {
+		return nil, io.EOF
+	}
+	return msg, nil
+}
+
+func (c *fakeControlChannel) Report(report *api.ControlReport) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.reports = append(c.reports, report)
+	return nil
+}
+
+func (c *fakeControlChannel) Close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.closed = true
+}
+
+func TestRemoteControl(t *testing.T) {
+	fw, pusher := createControlForwarder(t)
+	pkt, _ := base64.StdEncoding.DecodeString(PacketPushDataUp)
+	fw.UpLocalData(pkt, &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1700})
+
+	ch := &fakeControlChannel{messages: make(chan *api.ControlMessage, 8)}
+	var opened *api.ControlReport
+	var repaired string
+	restarted := false
+	rc := &remoteControl{
+		fw: fw,
+		open: func(ctx context.Context, report *api.ControlReport) (controlChannel, error) {
+			opened = report
+			return ch, nil
+		},
+		repair: func(pin string) error {
+			repaired = pin
+			return nil
+		},
+		restart: func() {
+			restarted = true
+		},
+	}
+
+	ch.messages <- &api.ControlMessage{Id: 1, Message: &api.ControlMessage_Config{Config: &api.ConfigPatch{
+		Version: 4,
+		Options: map[string]string{"flush-interval": "60"},
+	}}}
+	ch.messages <- &api.ControlMessage{Id: 2, Message: &api.ControlMessage_Config{Config: &api.ConfigPatch{
+		Version: 5,
+		Options: map[string]string{"connect-host": "example.com"},
+	}}}
+	ch.messages <- &api.ControlMessage{Id: 3, Message: &api.ControlMessage_Command{Command: &api.ControlCommand{
+		Type: api.ControlCommandType_COMMAND_FLUSH,
+	}}}
+	ch.messages <- &api.ControlMessage{Id: 4, Message: &api.ControlMessage_Command{Command: &api.ControlCommand{
+		Type: api.ControlCommandType_COMMAND_REPAIR,
+	}}}
+	ch.messages <- &api.ControlMessage{Id: 5, Message: &api.ControlMessage_Command{Command: &api.ControlCommand{
+		Type: api.ControlCommandType_COMMAND_REPAIR,
+		Args: map[string]string{"pin": "123456"},
+	}}}
+
+	// The channel ends after pairing again, to restart
+	assert.NoError(t, rc.serve(context.Background()))
+	assert.Equal(t, uint64(0), opened.ConfigVersion)
+	assert.Equal(t, MajorVersion, opened.ForwarderVersion)
+	assert.True(t, ch.closed)
+
+	assert.Len(t, ch.reports, 5)
+	assert.Equal(t, uint64(4), ch.reports[0].ConfigVersion)
+	assert.Empty(t, ch.reports[0].Error)
+	assert.Equal(t, 60, fw.conf().FlushInterval)
+
+	// The version only changes when the patch is applied
+	assert.Equal(t, uint64(2), ch.reports[1].Id)
+	assert.Equal(t, uint64(4), ch.reports[1].ConfigVersion)
+	assert.Contains(t, ch.reports[1].Error, "can't be changed while running")
+
+	assert.Empty(t, ch.reports[2].Error)
+	assert.Len(t, pusher.frames, 1)
+
+	assert.Equal(t, "no pin to pair with", ch.reports[3].Error)
+	assert.Empty(t, ch.reports[4].Error)
+	assert.Equal(t, "123456", repaired)
+	assert.True(t, restarted)
+}
+
+func TestRemoteControlRetry(t *testing.T) {
+	fw, _ := createControlForwarder(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	attempts := 0
+	rc := &remoteControl{
+		fw: fw,
+		open: func(ctx context.Context, report *api.ControlReport) (controlChannel, error) {
+			attempts++
+			if attempts == 3 {
+				cancel()
+			}
+			return nil, errors.New("unavailable")
+		},
+		retryInterval: time.Millisecond,
+	}
+
+	// Opening is retried until the context is done
+	rc.run(ctx)
+	assert.Equal(t, 3, attempts)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+)
+
+// How many of the latest errors to report
+const diagnosticsMaxErrors = 5
+
+// The options that are not reported with the configuration
+var redactedOptions = map[string]bool{
+	"client-key": true,
+}
+
+// Collects the health of the forwarder, that is reported periodically with
+// the metrics so the service can tell why a forwarder is not sending data
+type diagnostics struct {
+	lock       sync.Mutex
+	started    time.Time
+	lastReport time.Time
+	pushes     uint64
+	pushErrors uint64
+	lastErrors []string
+	// The datagrams that could not be decoded, by reason
+	malformed map[error]uint64
+}
+
+func newDiagnostics(now time.Time) *diagnostics {
+	return &diagnostics{started: now, malformed: make(map[error]uint64)}
+}
+
+// Records the outcome of a push to the analytics endpoint
+func (d *diagnostics) recordPush(err error, now time.Time) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if err == nil {
+		d.pushes += 1
+		return
+	}
+	d.pushErrors += 1
+	d.addError(err, now)
+}
+
+// Records an error that is not related to a push
+func (d *diagnostics) recordError(err error, now time.Time) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.addError(err, now)
+}
+
+func (d *diagnostics) addError(err error, now time.Time) {
+	d.lastErrors = append(d.lastErrors, fmt.Sprintf("%s: %s", now.UTC().Format(time.RFC3339), err.Error()))
+	if len(d.lastErrors) > diagnosticsMaxErrors {
+		d.lastErrors = d.lastErrors[len(d.lastErrors)-diagnosticsMaxErrors:]
+	}
+}
+
+// Counts a datagram that could not be decoded, by the reason of the error
+func (d *diagnostics) recordMalformed(err error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.malformed[DecodeErrorReason(err)] += 1
+}
+
+// Returns true if it's time to report again, which is right away after starting
+func (d *diagnostics) due(interval time.Duration, now time.Time) bool {
+	if interval <= 0 {
+		return false
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.lastReport.IsZero() || now.Sub(d.lastReport) >= interval
+}
+
+// Returns the report of the process and the counters of the forwarder
+func (d *diagnostics) report(now time.Time) *api.ForwarderInfo {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.lastReport = now
+
+	return &api.ForwarderInfo{
+		Version:    MajorVersion,
+		Revision:   Version(),
+		Uptime:     uint64(now.Sub(d.started).Seconds()),
+		Os:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		GoVersion:  runtime.Version(),
+		Pushes:     d.pushes,
+		PushErrors: d.pushErrors,
+		LastErrors: append([]string(nil), d.lastErrors...),
+
+		MalformedTruncated:   d.malformed[ErrTruncated],
+		MalformedBadVersion:  d.malformed[ErrBadVersion],
+		MalformedUnknownKind: d.malformed[ErrUnknownKind],
+		MalformedBadJSON:     d.malformed[ErrBadJSON],
+	}
+}
+
+// Returns the options of the configuration in the notation of the
+// configuration file, without the secrets
+func redactConfig(config *ForwarderConfig) map[string]string {
+	ret := make(map[string]string)
+	for name, value := range toFlatMap(config) {
+		if redactedOptions[name] {
+			value = "<redacted>"
+		}
+		ret[name] = formatConfigValue(value)
+	}
+	return ret
+}
+
+// Returns the health of the forwarder
+func (f *AnalyticsForwarder) forwarderInfo(now time.Time) *api.ForwarderInfo {
+	info := f.diagnostics.report(now)
+	info.Config = redactConfig(f.conf())
+	info.ConfigVersion = f.configVersion.Load()
+
+	if f.proxy != nil {
+		up, dn := f.proxy.StreamCounts()
+		info.UpStreams = uint32(up)
+		info.DnStreams = uint32(dn)
+		info.StreamEvictions = f.proxy.EvictedStreams()
+		info.QueueDepth = uint32(f.proxy.QueueDepth())
+		info.DroppedDatagrams = f.proxy.DroppedEvents()
+	}
+	return info
+}
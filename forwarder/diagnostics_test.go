@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnosticsErrors(t *testing.T) {
+	start := time.Date(2023, 2, 22, 1, 53, 31, 0, time.UTC)
+	d := newDiagnostics(start)
+
+	d.recordPush(nil, start)
+	for i := 0; i < 7; i++ {
+		d.recordPush(errors.New("unavailable"), start.Add(time.Duration(i)*time.Second))
+	}
+	d.recordError(errors.New("could not connect"), start.Add(time.Minute))
+
+	info := d.report(start.Add(time.Hour))
+	assert.Equal(t, uint64(3600), info.Uptime)
+	assert.Equal(t, uint64(1), info.Pushes)
+	assert.Equal(t, uint64(7), info.PushErrors)
+	// Only the latest errors are kept
+	assert.Len(t, info.LastErrors, diagnosticsMaxErrors)
+	assert.Equal(t, "2023-02-22T01:53:34Z: unavailable", info.LastErrors[0])
+	assert.Equal(t, "2023-02-22T01:54:31Z: could not connect", info.LastErrors[4])
+}
+
+func TestDiagnosticsDue(t *testing.T) {
+	start := time.Date(2023, 2, 22, 1, 53, 31, 0, time.UTC)
+	d := newDiagnostics(start)
+
+	assert.False(t, d.due(0, start))
+	assert.True(t, d.due(time.Minute, start))
+	d.report(start)
+	assert.False(t, d.due(time.Minute, start.Add(59*time.Second)))
+	assert.True(t, d.due(time.Minute, start.Add(time.Minute)))
+}
+
+func TestRedactConfig(t *testing.T) {
+	config := defaultConf
+	config.ClientId = "1122334455667788"
+	config.ClientKey = "11223344556677889900aabbccddeeff"
+	config.MaxMessageSize = 4000000
+
+	redacted := redactConfig(&config)
+	assert.Equal(t, "1122334455667788", redacted["client-id"])
+	assert.Equal(t, "<redacted>", redacted["client-key"])
+	assert.Equal(t, "4000000", redacted["analytics-max-message-size"])
+	assert.Equal(t, "info", redacted["log-level"])
+}
+
+func TestForwarderInfo(t *testing.T) {
+	pkt, _ := base64.StdEncoding.DecodeString(PacketPushDataUp)
+	fw, pusher := createControlForwarder(t)
+	fw.configVersion.Store(3)
+
+	// The first flush reports the health even without traffic
+	fw.flushData()
+	assert.Len(t, pusher.frames, 1)
+	info := pusher.frames[0].Forwarder
+	if assert.NotNil(t, info) {
+		assert.Equal(t, MajorVersion, info.Version)
+		assert.Equal(t, runtime.GOOS, info.Os)
+		assert.Equal(t, runtime.Version(), info.GoVersion)
+		assert.Equal(t, uint64(3), info.ConfigVersion)
+		assert.Equal(t, "10", info.Config["flush-interval"])
+		assert.Empty(t, pusher.frames[0].Uplinks)
+	}
+
+	// Then only when it's due again
+	fw.UpLocalData(pkt, &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1700})
+	fw.flushData()
+	assert.Len(t, pusher.frames, 2)
+	assert.Nil(t, pusher.frames[1].Forwarder)
+	assert.Equal(t, uint64(2), fw.forwarderInfo(time.Now()).Pushes)
+}
@@ -0,0 +1,450 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reads back the datagrams from a capture file
+type DumpReader interface {
+	// Returns the next record, or io.EOF when there are no more records
+	Next() (*DumpRecord, error)
+	Close() error
+}
+
+// The link types of the captures we can decode
+// (see https://www.tcpdump.org/linktypes.html)
+const (
+	linkTypeNull     = 0
+	linkTypeEthernet = 1
+	linkTypeRawAlt1  = 12
+	linkTypeRawAlt2  = 14
+	linkTypeRaw      = 101
+	linkTypeLoop     = 108
+	linkTypeLinuxSLL = 113
+	linkTypeIPv4     = 228
+	linkTypeIPv6     = 229
+	linkTypeSLL2     = 276
+)
+
+// Opens the capture file, detecting its format from the contents. Both the
+// pcapng and the classic pcap formats are supported, as well as the legacy
+// base64 dumps.
+func OpenDumpReader(filename string) (DumpReader, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	rd := bufio.NewReaderSize(file, 64*1024)
+	magic, err := rd.Peek(4)
+	if err != nil && err != io.EOF {
+		file.Close()
+		return nil, err
+	}
+
+	if len(magic) == 4 {
+		switch binary.LittleEndian.Uint32(magic) {
+		case pcapngBlockSHB:
+			return &pcapngReader{file: file, rd: rd}, nil
+		case 0xa1b2c3d4, 0xd4c3b2a1, 0xa1b23c4d, 0x4d3cb2a1:
+			return createPcapReader(file, rd)
+		}
+	}
+
+	return &base64Reader{file: file, scanner: bufio.NewScanner(rd)}, nil
+}
+
+///////////////////////////////////////
+// Legacy base64 dumps
+///////////////////////////////////////
+
+type base64Reader struct {
+	file    *os.File
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *base64Reader) Next() (*DumpRecord, error) {
+	for r.scanner.Scan() {
+		r.line += 1
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: expecting <stream>:<base64>", r.line)
+		}
+		stream, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid stream: %w", r.line, err)
+		}
+		data, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid data: %w", r.line, err)
+		}
+
+		// The legacy dumps do not contain timestamps or addresses
+		return &DumpRecord{
+			Stream:  stream,
+			Inbound: stream%2 == 0,
+			Data:    data,
+		}, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (r *base64Reader) Close() error {
+	return r.file.Close()
+}
+
+///////////////////////////////////////
+// Classic pcap captures
+///////////////////////////////////////
+
+type pcapReader struct {
+	file     *os.File
+	rd       *bufio.Reader
+	order    binary.ByteOrder
+	nanosec  bool
+	linkType uint32
+}
+
+func createPcapReader(file *os.File, rd *bufio.Reader) (*pcapReader, error) {
+	var hdr [24]byte
+	if _, err := io.ReadFull(rd, hdr[:]); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("truncated pcap header: %w", err)
+	}
+
+	r := &pcapReader{file: file, rd: rd, order: binary.LittleEndian}
+	magic := binary.LittleEndian.Uint32(hdr[0:])
+	if magic == 0xd4c3b2a1 || magic == 0x4d3cb2a1 {
+		r.order = binary.BigEndian
+		magic = binary.BigEndian.Uint32(hdr[0:])
+	}
+	r.nanosec = magic == 0xa1b23c4d
+	r.linkType = r.order.Uint32(hdr[20:]) & 0xffff
+	return r, nil
+}
+
+func (r *pcapReader) Next() (*DumpRecord, error) {
+	for {
+		var hdr [16]byte
+		if _, err := io.ReadFull(r.rd, hdr[:]); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				return nil, fmt.Errorf("truncated pcap record")
+			}
+			return nil, err
+		}
+
+		sec := int64(r.order.Uint32(hdr[0:]))
+		frac := int64(r.order.Uint32(hdr[4:]))
+		capLen := r.order.Uint32(hdr[8:])
+		if capLen > 256*1024 {
+			return nil, fmt.Errorf("invalid pcap record length %d", capLen)
+		}
+
+		data := make([]byte, capLen)
+		if _, err := io.ReadFull(r.rd, data); err != nil {
+			return nil, fmt.Errorf("truncated pcap record")
+		}
+
+		if !r.nanosec {
+			frac *= 1000
+		}
+		if rec := decodeLinkPacket(r.linkType, data); rec != nil {
+			rec.Time = time.Unix(sec, frac)
+			return rec, nil
+		}
+	}
+}
+
+func (r *pcapReader) Close() error {
+	return r.file.Close()
+}
+
+///////////////////////////////////////
+// pcapng captures
+///////////////////////////////////////
+
+type pcapngInterface struct {
+	linkType uint32
+	// The timestamp resolution, as a negative power of 10 (or 2 if binary)
+	tsResol  uint8
+	tsBinary bool
+}
+
+// Converts the timestamp into time, without losing precision
+func (i *pcapngInterface) timestamp(ts uint64) time.Time {
+	if i.tsBinary {
+		sec := ts >> i.tsResol
+		frac := ts & (1<<i.tsResol - 1)
+		return time.Unix(int64(sec), int64(float64(frac)*1e9/math.Pow(2, float64(i.tsResol))))
+	}
+
+	unit := uint64(1)
+	for j := uint8(0); j < i.tsResol; j++ {
+		unit *= 10
+	}
+	sec := ts / unit
+	frac := ts % unit
+	for unit > 1e9 {
+		unit /= 10
+		frac /= 10
+	}
+	for unit < 1e9 {
+		unit *= 10
+		frac *= 10
+	}
+	return time.Unix(int64(sec), int64(frac))
+}
+
+type pcapngReader struct {
+	file       *os.File
+	rd         *bufio.Reader
+	order      binary.ByteOrder
+	interfaces []pcapngInterface
+}
+
+// Iterates over the options of a block, calling `fn` with each one of them
+func pcapngOptions(order binary.ByteOrder, b []byte, fn func(code uint16, value []byte)) {
+	for len(b) >= 4 {
+		code := order.Uint16(b[0:])
+		size := int(order.Uint16(b[2:]))
+		if code == pcapngOptEnd || 4+size > len(b) {
+			return
+		}
+		fn(code, b[4:4+size])
+		b = b[4+size+pcapngPad(size):]
+	}
+}
+
+func (r *pcapngReader) readBlock() (uint32, []byte, error) {
+	var hdr [8]byte
+	if _, err := io.ReadFull(r.rd, hdr[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, nil, fmt.Errorf("truncated pcapng block")
+		}
+		return 0, nil, err
+	}
+
+	// The byte order is defined by each section header
+	if binary.LittleEndian.Uint32(hdr[0:]) == pcapngBlockSHB {
+		bom, err := r.rd.Peek(4)
+		if err != nil {
+			return 0, nil, fmt.Errorf("truncated pcapng section header")
+		}
+		if binary.LittleEndian.Uint32(bom) == pcapngByteOrder {
+			r.order = binary.LittleEndian
+		} else {
+			r.order = binary.BigEndian
+		}
+	}
+	if r.order == nil {
+		return 0, nil, fmt.Errorf("missing pcapng section header")
+	}
+
+	kind := r.order.Uint32(hdr[0:])
+	total := r.order.Uint32(hdr[4:])
+	if total < 12 || total%4 != 0 || total > 256*1024 {
+		return 0, nil, fmt.Errorf("invalid pcapng block length %d", total)
+	}
+
+	body := make([]byte, total-8)
+	if _, err := io.ReadFull(r.rd, body); err != nil {
+		return 0, nil, fmt.Errorf("truncated pcapng block")
+	}
+	return kind, body[:len(body)-4], nil
+}
+
+func (r *pcapngReader) Next() (*DumpRecord, error) {
+	for {
+		kind, body, err := r.readBlock()
+		if err != nil {
+			return nil, err
+		}
+
+		switch kind {
+		case pcapngBlockSHB:
+			// A new section resets the interfaces
+			r.interfaces = nil
+
+		case pcapngBlockIDB:
+			if len(body) < 8 {
+				return nil, fmt.Errorf("truncated pcapng interface block")
+			}
+			iface := pcapngInterface{
+				linkType: uint32(r.order.Uint16(body[0:])),
+				tsResol:  6,
+			}
+			pcapngOptions(r.order, body[8:], func(code uint16, value []byte) {
+				// if_tsresol is either a power of 10 or of 2
+				if code == 9 && len(value) == 1 {
+					iface.tsBinary = value[0]&0x80 != 0
+					iface.tsResol = value[0] & 0x7f
+					if (iface.tsBinary && iface.tsResol > 63) || (!iface.tsBinary && iface.tsResol > 19) {
+						iface.tsResol = 6
+						iface.tsBinary = false
+					}
+				}
+			})
+			r.interfaces = append(r.interfaces, iface)
+
+		case pcapngBlockEPB:
+			if len(body) < 20 {
+				return nil, fmt.Errorf("truncated pcapng packet block")
+			}
+			ifIdx := int(r.order.Uint32(body[0:]))
+			ts := uint64(r.order.Uint32(body[4:]))<<32 | uint64(r.order.Uint32(body[8:]))
+			capLen := int(r.order.Uint32(body[12:]))
+			if ifIdx >= len(r.interfaces) || 20+capLen > len(body) {
+				return nil, fmt.Errorf("invalid pcapng packet block")
+			}
+
+			iface := r.interfaces[ifIdx]
+			rec := decodeLinkPacket(iface.linkType, body[20:20+capLen])
+			if rec == nil {
+				continue
+			}
+
+			rec.Time = iface.timestamp(ts)
+			pcapngOptions(r.order, body[20+capLen+pcapngPad(capLen):], func(code uint16, value []byte) {
+				if code == pcapngOptEpbFlags && len(value) == 4 {
+					switch r.order.Uint32(value) & 3 {
+					case pcapngFlagInbound:
+						rec.Inbound = true
+					case pcapngFlagOutbound:
+						rec.Inbound = false
+					}
+				}
+			})
+			return rec, nil
+		}
+	}
+}
+
+func (r *pcapngReader) Close() error {
+	return r.file.Close()
+}
+
+///////////////////////////////////////
+// Packet decoding
+///////////////////////////////////////
+
+// Decodes the UDP datagram from a captured packet, returning nil if the packet
+// does not contain a UDP datagram
+func decodeLinkPacket(linkType uint32, data []byte) *DumpRecord {
+	var etherType uint16
+	switch linkType {
+	case linkTypeRaw, linkTypeRawAlt1, linkTypeRawAlt2, linkTypeIPv4, linkTypeIPv6:
+		return decodeIPPacket(data)
+
+	case linkTypeNull, linkTypeLoop:
+		// The address family in host (null) or network (loop) byte order
+		if len(data) < 4 {
+			return nil
+		}
+		return decodeIPPacket(data[4:])
+
+	case linkTypeEthernet:
+		if len(data) < 14 {
+			return nil
+		}
+		etherType = binary.BigEndian.Uint16(data[12:])
+		data = data[14:]
+		for etherType == 0x8100 && len(data) >= 4 {
+			// Skip the VLAN tags
+			etherType = binary.BigEndian.Uint16(data[2:])
+			data = data[4:]
+		}
+
+	case linkTypeLinuxSLL:
+		if len(data) < 16 {
+			return nil
+		}
+		etherType = binary.BigEndian.Uint16(data[14:])
+		data = data[16:]
+
+	case linkTypeSLL2:
+		if len(data) < 20 {
+			return nil
+		}
+		etherType = binary.BigEndian.Uint16(data[0:])
+		data = data[20:]
+
+	default:
+		return nil
+	}
+
+	if etherType != 0x0800 && etherType != 0x86dd {
+		return nil
+	}
+	return decodeIPPacket(data)
+}
+
+// Decodes the UDP datagram from an IPv4 or IPv6 packet
+func decodeIPPacket(data []byte) *DumpRecord {
+	var src, dst net.IP
+	if len(data) < 1 {
+		return nil
+	}
+
+	switch data[0] >> 4 {
+	case 4:
+		if len(data) < 20 {
+			return nil
+		}
+		ihl := int(data[0]&0x0f) * 4
+		// Fragmented datagrams are not re-assembled
+		fragmented := binary.BigEndian.Uint16(data[6:])&0x3fff != 0
+		if ihl < 20 || len(data) < ihl || data[9] != 17 || fragmented {
+			return nil
+		}
+		if total := int(binary.BigEndian.Uint16(data[2:])); total >= ihl && total < len(data) {
+			data = data[:total]
+		}
+		src, dst = net.IP(data[12:16]), net.IP(data[16:20])
+		data = data[ihl:]
+
+	case 6:
+		// Extension headers are not supported
+		if len(data) < 40 || data[6] != 17 {
+			return nil
+		}
+		src, dst = net.IP(data[8:24]), net.IP(data[24:40])
+		data = data[40:]
+
+	default:
+		return nil
+	}
+
+	if len(data) < 8 {
+		return nil
+	}
+	length := int(binary.BigEndian.Uint16(data[4:]))
+	if length < 8 || length > len(data) {
+		length = len(data)
+	}
+
+	return &DumpRecord{
+		Src:  &net.UDPAddr{IP: append(net.IP(nil), src...), Port: int(binary.BigEndian.Uint16(data[0:]))},
+		Dst:  &net.UDPAddr{IP: append(net.IP(nil), dst...), Port: int(binary.BigEndian.Uint16(data[2:]))},
+		Data: append([]byte(nil), data[8:length]...),
+	}
+}
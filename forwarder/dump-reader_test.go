@@ -0,0 +1,119 @@
+package main
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadPcapngDump(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "dump.pcapng")
+	writer, _ := CreateDumpWriter(&DumpWriterConfig{Filename: filename, Format: "pcapng"})
+
+	gateway := &net.UDPAddr{IP: net.ParseIP("10.0.0.1").To4(), Port: 40000}
+	local := &net.UDPAddr{IP: net.ParseIP("2001:db8::2"), Port: 1700}
+	tm := time.Date(2023, 2, 22, 1, 53, 31, 306224000, time.UTC)
+	writer.WriteRecord(&DumpRecord{Time: tm, Inbound: true, Src: gateway, Dst: local, Data: []byte{1, 2, 3}})
+	writer.WriteRecord(&DumpRecord{Time: tm.Add(time.Second), Inbound: false, Src: local, Dst: gateway, Data: []byte{4, 5}})
+	writer.Close()
+
+	reader, err := OpenDumpReader(filename)
+	assert.NoError(t, err)
+	defer reader.Close()
+
+	rec, err := reader.Next()
+	assert.NoError(t, err)
+	assert.True(t, rec.Time.Equal(tm))
+	assert.True(t, rec.Inbound)
+	assert.Equal(t, "10.0.0.1:40000", rec.Src.String())
+	assert.Equal(t, "[2001:db8::2]:1700", rec.Dst.String())
+	assert.Equal(t, []byte{1, 2, 3}, rec.Data)
+
+	rec, err = reader.Next()
+	assert.NoError(t, err)
+	assert.False(t, rec.Inbound)
+	assert.Equal(t, []byte{4, 5}, rec.Data)
+
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReadPcapFile(t *testing.T) {
+	// A classic pcap with an ethernet frame and a non-UDP packet
+	var file []byte
+	file = appendLE32(file, 0xa1b2c3d4)
+	file = appendLE16(file, 2)
+	file = appendLE16(file, 4)
+	file = append(file, make([]byte, 8)...)
+	file = appendLE32(file, 65535)
+	file = appendLE32(file, linkTypeEthernet)
+
+	addRecord := func(sec, usec uint32, frame []byte) {
+		file = appendLE32(file, sec)
+		file = appendLE32(file, usec)
+		file = appendLE32(file, uint32(len(frame)))
+		file = appendLE32(file, uint32(len(frame)))
+		file = append(file, frame...)
+	}
+
+	ip := encodeIPPacket(
+		&net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 40000},
+		&net.UDPAddr{IP: net.ParseIP("10.0.0.2"), Port: 1700},
+		[]byte{2, 0, 1, 0},
+	)
+	eth := append(make([]byte, 12), 0x08, 0x00)
+	arp := append(make([]byte, 12), 0x08, 0x06)
+	addRecord(1677030811, 500, append(arp, make([]byte, 28)...))
+	addRecord(1677030811, 1000, append(eth, ip...))
+
+	filename := filepath.Join(t.TempDir(), "capture.pcap")
+	os.WriteFile(filename, file, 0600)
+
+	reader, err := OpenDumpReader(filename)
+	assert.NoError(t, err)
+	defer reader.Close()
+
+	rec, err := reader.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Unix(1677030811, 1000000), rec.Time)
+	assert.Equal(t, "10.0.0.1:40000", rec.Src.String())
+	assert.Equal(t, "10.0.0.2:1700", rec.Dst.String())
+	assert.Equal(t, []byte{2, 0, 1, 0}, rec.Data)
+
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReadBase64Dump(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "dump.txt")
+	os.WriteFile(filename, []byte("0:AgABAA==\n1:AgABAQ==\n\n"), 0600)
+
+	reader, err := OpenDumpReader(filename)
+	assert.NoError(t, err)
+	defer reader.Close()
+
+	rec, err := reader.Next()
+	assert.NoError(t, err)
+	assert.True(t, rec.Inbound)
+	assert.True(t, rec.Time.IsZero())
+	assert.Equal(t, []byte{2, 0, 1, 0}, rec.Data)
+
+	rec, err = reader.Next()
+	assert.NoError(t, err)
+	assert.False(t, rec.Inbound)
+	assert.Equal(t, 1, rec.Stream)
+
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+
+	os.WriteFile(filename, []byte("garbage\n"), 0600)
+	reader, _ = OpenDumpReader(filename)
+	_, err = reader.Next()
+	assert.Error(t, err)
+	reader.Close()
+}
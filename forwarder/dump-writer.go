@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// A datagram that went through the proxy, as seen on the gateway-facing socket
+type DumpRecord struct {
+	// When the datagram was received
+	Time time.Time
+	// The index of the stream (even numbers are from the gateway, odd to the gateway)
+	Stream int
+	// True if the datagram was received from the gateway
+	Inbound bool
+	// The original source and destination of the datagram
+	Src *net.UDPAddr
+	Dst *net.UDPAddr
+	// The datagram contents
+	Data []byte
+}
+
+// Writes the datagrams that went through the proxy to a capture file
+type DumpWriter interface {
+	WriteRecord(rec *DumpRecord) error
+	Close() error
+}
+
+// Configures the output of the dump writers
+type DumpWriterConfig struct {
+	// The file where to write the capture
+	Filename string
+	// The capture format, 'base64' (the default) or 'pcapng'
+	Format string
+	// Rotate the file after it exceeds this many bytes (0 = never)
+	MaxSize int64
+	// Rotate the file after this much time (0 = never)
+	RotateInterval time.Duration
+	// How many rotated files to keep, deleting the oldest ones (0 = keep all)
+	MaxFiles int
+	// How frequently to flush the buffered data to disk
+	FlushInterval time.Duration
+}
+
+func CreateDumpWriter(config *DumpWriterConfig) (DumpWriter, error) {
+	var encoder dumpEncoder
+	switch config.Format {
+	case "base64", "":
+		encoder = &base64Encoder{}
+	case "pcapng":
+		encoder = &pcapngEncoder{}
+	default:
+		return nil, fmt.Errorf("unknown dump format '%s'", config.Format)
+	}
+
+	writer, err := createRotatingWriter(config, encoder)
+	if err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// Encodes the records of a specific file format
+type dumpEncoder interface {
+	// Writes the header that must be found at the beginning of every file
+	WriteHeader(w io.Writer) error
+	// Writes a single record
+	WriteRecord(w io.Writer, rec *DumpRecord) error
+}
+
+// The default format, that writes `stream:base64` lines
+type base64Encoder struct{}
+
+func (e *base64Encoder) WriteHeader(w io.Writer) error {
+	return nil
+}
+
+func (e *base64Encoder) WriteRecord(w io.Writer, rec *DumpRecord) error {
+	_, err := fmt.Fprintf(w, "%d:%s\n", rec.Stream, base64.StdEncoding.EncodeToString(rec.Data))
+	return err
+}
+
+// A buffered dump writer that rotates the output file by size or time
+type rotatingWriter struct {
+	config  *DumpWriterConfig
+	encoder dumpEncoder
+	lock    sync.Mutex
+	file    *os.File
+	buf     *bufio.Writer
+	size    int64
+	opened  time.Time
+	seq     int
+	files   []string
+	closed  bool
+	done    chan bool
+	doneOne sync.Once
+	flushWg sync.WaitGroup
+}
+
+func createRotatingWriter(config *DumpWriterConfig, encoder dumpEncoder) (*rotatingWriter, error) {
+	inst := &rotatingWriter{
+		config:  config,
+		encoder: encoder,
+		done:    make(chan bool),
+	}
+	if inst.isRotating() {
+		inst.resumeSequence()
+	}
+	if err := inst.open(); err != nil {
+		return nil, err
+	}
+
+	flushInterval := config.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+	inst.flushWg.Add(1)
+	go inst.flushThread(flushInterval)
+	return inst, nil
+}
+
+func (w *rotatingWriter) isRotating() bool {
+	return w.config.MaxSize > 0 || w.config.RotateInterval > 0
+}
+
+// Returns the name of the next file, inserting a sequence number before the
+// extension when rotating (eg. 'dump.pcapng' becomes 'dump-0001.pcapng')
+func (w *rotatingWriter) nextFilename() string {
+	if !w.isRotating() {
+		return w.config.Filename
+	}
+
+	w.seq += 1
+	ext := filepath.Ext(w.config.Filename)
+	base := strings.TrimSuffix(w.config.Filename, ext)
+	return fmt.Sprintf("%s-%04d%s", base, w.seq, ext)
+}
+
+// Continues after the rotated files of a previous run, so that they are not
+// written over, and the oldest of them are deleted like the new ones
+func (w *rotatingWriter) resumeSequence() {
+	dir := filepath.Dir(w.config.Filename)
+	ext := filepath.Ext(w.config.Filename)
+	prefix := strings.TrimSuffix(filepath.Base(w.config.Filename), ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	seqs := make(map[string]int)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		digits := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		seq, err := strconv.Atoi(digits)
+		if err != nil || seq <= 0 || len(digits) < 4 {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		seqs[path] = seq
+		w.files = append(w.files, path)
+		if seq > w.seq {
+			w.seq = seq
+		}
+	}
+	sort.Slice(w.files, func(i, j int) bool {
+		return seqs[w.files[i]] < seqs[w.files[j]]
+	})
+}
+
+func (w *rotatingWriter) open() error {
+	name := w.nextFilename()
+	file, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("could not open %s: %w", name, err)
+	}
+
+	w.file = file
+	w.buf = bufio.NewWriterSize(file, 64*1024)
+	w.size = 0
+	w.opened = time.Now()
+	w.files = append(w.files, name)
+
+	// In ring-buffer mode delete the oldest files
+	if w.config.MaxFiles > 0 && w.isRotating() {
+		for len(w.files) > w.config.MaxFiles {
+			if err := os.Remove(w.files[0]); err != nil {
+				log.Warnf("Could not remove old dump file %s: %s", w.files[0], err.Error())
+			}
+			w.files = w.files[1:]
+		}
+	}
+
+	return w.encoder.WriteHeader(w)
+}
+
+func (w *rotatingWriter) closeFile() error {
+	if err := w.buf.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// Counts the bytes written, so the rotation can be decided
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	n, err := w.buf.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotatingWriter) WriteRecord(rec *DumpRecord) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+
+	if (w.config.MaxSize > 0 && w.size >= w.config.MaxSize) ||
+		(w.config.RotateInterval > 0 && time.Since(w.opened) >= w.config.RotateInterval) {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	return w.encoder.WriteRecord(w, rec)
+}
+
+// Starts the next file right away, which is only possible when rotating
+func (w *rotatingWriter) Rotate() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	if !w.isRotating() {
+		return fmt.Errorf("the dump is not rotating (see dump-max-size and dump-rotate-interval)")
+	}
+	return w.rotate()
+}
+
+func (w *rotatingWriter) rotate() error {
+	if err := w.closeFile(); err != nil {
+		log.Warnf("Error closing dump file: %s", err.Error())
+	}
+	if err := w.open(); err != nil {
+		w.closed = true
+		return err
+	}
+	return nil
+}
+
+func (w *rotatingWriter) flushThread(interval time.Duration) {
+	defer w.flushWg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.lock.Lock()
+			if !w.closed {
+				if err := w.buf.Flush(); err != nil {
+					log.Warnf("Error writing to dump file: %s", err.Error())
+				}
+			}
+			w.lock.Unlock()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *rotatingWriter) Close() error {
+	var err error
+	w.lock.Lock()
+	if !w.closed {
+		w.closed = true
+		err = w.closeFile()
+	}
+	w.lock.Unlock()
+
+	w.doneOne.Do(func() { close(w.done) })
+	w.flushWg.Wait()
+	return err
+}
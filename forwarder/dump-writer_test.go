@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type pcapngTestBlock struct {
+	kind uint32
+	body []byte
+}
+
+func readPcapngBlocks(t *testing.T, filename string) []pcapngTestBlock {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Could not read %s: %s", filename, err.Error())
+	}
+
+	var ret []pcapngTestBlock
+	for len(data) > 0 {
+		if len(data) < 12 {
+			t.Fatalf("Truncated block")
+		}
+		kind := binary.LittleEndian.Uint32(data[0:])
+		total := binary.LittleEndian.Uint32(data[4:])
+		if total%4 != 0 || int(total) > len(data) {
+			t.Fatalf("Invalid block length %d", total)
+		}
+		assert.Equal(t, total, binary.LittleEndian.Uint32(data[total-4:]))
+		ret = append(ret, pcapngTestBlock{kind, data[8 : total-4]})
+		data = data[total:]
+	}
+	return ret
+}
+
+func TestPcapngDump(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "dump.pcapng")
+	writer, err := CreateDumpWriter(&DumpWriterConfig{Filename: filename, Format: "pcapng"})
+	assert.NoError(t, err)
+
+	gateway := &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 40000}
+	local := &net.UDPAddr{IP: net.ParseIP("10.0.0.2"), Port: 1700}
+	payload := []byte{0x02, 0x12, 0x34, 0x02, 1, 2, 3, 4, 5, 6, 7, 8}
+	tm := time.Date(2023, 2, 22, 1, 53, 31, 306224000, time.UTC)
+
+	assert.NoError(t, writer.WriteRecord(&DumpRecord{Time: tm, Inbound: true, Src: gateway, Dst: local, Data: payload}))
+	assert.NoError(t, writer.WriteRecord(&DumpRecord{Time: tm, Inbound: false, Src: local, Dst: gateway, Data: payload[0:4]}))
+	assert.NoError(t, writer.Close())
+
+	blocks := readPcapngBlocks(t, filename)
+	assert.Len(t, blocks, 4)
+	assert.Equal(t, uint32(pcapngBlockSHB), blocks[0].kind)
+	assert.Equal(t, uint32(pcapngByteOrder), binary.LittleEndian.Uint32(blocks[0].body))
+	assert.Equal(t, uint32(pcapngBlockIDB), blocks[1].kind)
+	assert.Equal(t, uint16(pcapngLinkTypeRaw), binary.LittleEndian.Uint16(blocks[1].body))
+
+	// Check the first packet
+	epb := blocks[2].body
+	assert.Equal(t, uint32(pcapngBlockEPB), blocks[2].kind)
+	ts := uint64(binary.LittleEndian.Uint32(epb[4:]))<<32 | uint64(binary.LittleEndian.Uint32(epb[8:]))
+	assert.Equal(t, uint64(tm.UnixMicro()), ts)
+	capLen := binary.LittleEndian.Uint32(epb[12:])
+	assert.Equal(t, uint32(20+8+len(payload)), capLen)
+
+	pkt := epb[20 : 20+capLen]
+	assert.Equal(t, byte(0x45), pkt[0])
+	assert.Equal(t, uint16(0), ipChecksumFold(ipChecksum(0, pkt[0:20])))
+	assert.Equal(t, net.ParseIP("10.0.0.1").To4(), net.IP(pkt[12:16]))
+	assert.Equal(t, net.ParseIP("10.0.0.2").To4(), net.IP(pkt[16:20]))
+	assert.Equal(t, uint16(40000), binary.BigEndian.Uint16(pkt[20:]))
+	assert.Equal(t, uint16(1700), binary.BigEndian.Uint16(pkt[22:]))
+	assert.Equal(t, payload, pkt[28:])
+
+	// The UDP checksum must validate over the pseudo-header
+	sum := ipChecksum(0, pkt[12:20])
+	sum += 17 + uint32(8+len(payload))
+	assert.Equal(t, uint16(0), ipChecksumFold(ipChecksum(sum, pkt[20:])))
+
+	// The direction is stored in the flags option
+	opts := epb[20+capLen+uint32(pcapngPad(int(capLen))):]
+	assert.Equal(t, uint16(pcapngOptEpbFlags), binary.LittleEndian.Uint16(opts[0:]))
+	assert.Equal(t, uint32(pcapngFlagInbound), binary.LittleEndian.Uint32(opts[4:]))
+
+	epb = blocks[3].body
+	capLen = binary.LittleEndian.Uint32(epb[12:])
+	opts = epb[20+capLen+uint32(pcapngPad(int(capLen))):]
+	assert.Equal(t, uint32(pcapngFlagOutbound), binary.LittleEndian.Uint32(opts[4:]))
+}
+
+func TestPcapngIPv6Packet(t *testing.T) {
+	gateway := &net.UDPAddr{IP: net.ParseIP("2001:db8::1"), Port: 40000}
+	local := &net.UDPAddr{IP: net.ParseIP("::"), Port: 1700}
+	payload := []byte{1, 2, 3}
+
+	pkt := encodeIPPacket(gateway, local, payload)
+	assert.Len(t, pkt, 40+8+3)
+	assert.Equal(t, byte(0x60), pkt[0])
+	assert.Equal(t, gateway.IP, net.IP(pkt[8:24]))
+
+	sum := ipChecksum(0, pkt[8:40])
+	sum += 17 + uint32(8+len(payload))
+	assert.Equal(t, uint16(0), ipChecksumFold(ipChecksum(sum, pkt[40:])))
+
+	// IPv4 gateways on dual-stack sockets are mapped into IPv6
+	pkt = encodeIPPacket(&net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 40000}, local, payload)
+	assert.Equal(t, byte(0x60), pkt[0])
+	assert.Equal(t, net.ParseIP("::ffff:10.0.0.1"), net.IP(pkt[8:24]))
+}
+
+func TestBase64Dump(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "dump.txt")
+	writer, err := CreateDumpWriter(&DumpWriterConfig{Filename: filename, Format: "base64"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.WriteRecord(&DumpRecord{Stream: 3, Data: []byte{1, 2, 3}}))
+	assert.NoError(t, writer.Close())
+
+	data, _ := os.ReadFile(filename)
+	assert.Equal(t, "3:AQID\n", string(data))
+
+	// It's the default format
+	assert.Nil(t, os.Remove(filename))
+	writer, err = CreateDumpWriter(&DumpWriterConfig{Filename: filename})
+	assert.NoError(t, err)
+	assert.NoError(t, writer.WriteRecord(&DumpRecord{Stream: 1, Data: []byte{4}}))
+	assert.NoError(t, writer.Close())
+	data, _ = os.ReadFile(filename)
+	assert.Equal(t, "1:BA==\n", string(data))
+	assert.Equal(t, "base64", defaultConf.DumpFormat)
+
+	_, err = CreateDumpWriter(&DumpWriterConfig{Filename: filename, Format: "xml"})
+	assert.Error(t, err)
+}
+
+func TestDumpRotation(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := CreateDumpWriter(&DumpWriterConfig{
+		Filename: filepath.Join(dir, "dump.pcapng"),
+		Format:   "pcapng",
+		MaxSize:  512,
+		MaxFiles: 2,
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, writer.WriteRecord(&DumpRecord{Time: time.Now(), Data: make([]byte, 100)}))
+	}
+	assert.NoError(t, writer.Close())
+
+	// Only the last files of the ring are kept
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Len(t, files, 2)
+	for _, file := range files {
+		blocks := readPcapngBlocks(t, file)
+		assert.Equal(t, uint32(pcapngBlockSHB), blocks[0].kind)
+	}
+	_, err = os.Stat(filepath.Join(dir, "dump-0001.pcapng"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDumpRotationResume(t *testing.T) {
+	dir := t.TempDir()
+	config := &DumpWriterConfig{
+		Filename: filepath.Join(dir, "dump.txt"),
+		MaxSize:  1024,
+		MaxFiles: 3,
+	}
+	for _, name := range []string{"dump-0002.txt", "dump-0010.txt", "dump-0009.txt", "dump-1.txt", "other-0050.txt", "dump-0050.log"} {
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte("0:AA==\n"), 0600))
+	}
+
+	// The files of the previous run are kept, and the oldest are deleted
+	writer, err := CreateDumpWriter(config)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.WriteRecord(&DumpRecord{Data: []byte{1}}))
+	assert.NoError(t, writer.Close())
+
+	files, _ := filepath.Glob(filepath.Join(dir, "dump-*.txt"))
+	assert.Equal(t, []string{
+		filepath.Join(dir, "dump-0009.txt"),
+		filepath.Join(dir, "dump-0010.txt"),
+		filepath.Join(dir, "dump-0011.txt"),
+		filepath.Join(dir, "dump-1.txt"),
+	}, files)
+	data, _ := os.ReadFile(filepath.Join(dir, "dump-0010.txt"))
+	assert.Equal(t, "0:AA==\n", string(data))
+	data, _ = os.ReadFile(filepath.Join(dir, "dump-0011.txt"))
+	assert.Equal(t, "0:AQ==\n", string(data))
+}
+
+// Collects the dump records in memory
+type memDumpWriter struct {
+	lock    sync.Mutex
+	records []DumpRecord
+}
+
+func (w *memDumpWriter) WriteRecord(rec *DumpRecord) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	r := *rec
+	r.Data = append([]byte(nil), rec.Data...)
+	w.records = append(w.records, r)
+	return nil
+}
+
+func (w *memDumpWriter) Close() error {
+	return nil
+}
+
+func TestDumpOnProxy(t *testing.T) {
+	client := CreateSocket(t)
+	server := CreateSocket(t)
+
+	dump := &memDumpWriter{}
+	proxy, _ := CreateUDPProxy(&UDPProxyConfig{
+		UpListenAddr:      client.remote,
+		UpConnectAddr:     server.local,
+		BufferSize:        1024,
+		SocketStreams:     16,
+		ReconnectInterval: 1,
+		DumpWriter:        dump,
+	})
+
+	up := randBuf(1024)
+	client.Send(up)
+	expectToReceive(t, server, up)
+	dn := randBuf(1024)
+	server.Reply(dn)
+	expectToReceive(t, client, dn)
+	proxy.Close()
+
+	// Both directions are recorded on the gateway-facing leg
+	dump.lock.Lock()
+	defer dump.lock.Unlock()
+	assert.Len(t, dump.records, 2)
+	assert.True(t, dump.records[0].Inbound)
+	assert.Equal(t, up, dump.records[0].Data)
+	assert.Equal(t, client.local.String(), dump.records[0].Src.String())
+	assert.Equal(t, client.remote.String(), dump.records[0].Dst.String())
+
+	assert.False(t, dump.records[1].Inbound)
+	assert.Equal(t, dn, dump.records[1].Data)
+	assert.Equal(t, client.remote.String(), dump.records[1].Src.String())
+	assert.Equal(t, client.local.String(), dump.records[1].Dst.String())
+	assert.Equal(t, dump.records[0].Stream+1, dump.records[1].Stream)
+}
+
+func TestGatewayLocalAddr(t *testing.T) {
+	sock, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	assert.NoError(t, err)
+	defer sock.Close()
+	port := sock.LocalAddr().(*net.UDPAddr).Port
+
+	// The sockets on all the addresses record the one that reaches the gateway
+	gateway := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 40000}
+	assert.Equal(t, fmt.Sprintf("127.0.0.1:%d", port), gatewayLocalAddr(sock, gateway).String())
+
+	bound, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+	defer bound.Close()
+	assert.Equal(t, bound.LocalAddr().String(), gatewayLocalAddr(bound, gateway).String())
+}
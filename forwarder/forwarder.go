@@ -3,35 +3,66 @@ package main
 import (
 	"encoding/base64"
 	"encoding/hex"
-	"encoding/json"
+	"fmt"
 	"net"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/kudzutechnologies/analytics/api"
 	"github.com/kudzutechnologies/analytics/client"
 	log "github.com/sirupsen/logrus"
-	"google.golang.org/protobuf/proto"
 )
 
+// The interface to the analytics endpoint, implemented by `client.Client`
+type metricsPusher interface {
+	Connect() error
+	PushMetrics(metrics *api.AnalyticsMetrics) error
+}
+
+// Implemented by `client.Client`, for reporting the traffic to the analytics endpoint
+type pushStatsReporter interface {
+	Stats() client.ClientStats
+}
+
 type AnalyticsForwarder struct {
-	client       *client.Client
-	config       ForwarderConfig
-	proxy        *UDPProxy
-	metricsFrame *lru.Cache[string, *api.AnalyticsMetrics]
-	isSending    bool
+	client      metricsPusher
+	config      atomic.Pointer[ForwarderConfig]
+	proxy       *UDPProxy
+	metrics     *metricsAggregator
+	clocks      *gatewayClocks
+	positions   *gatewayPositions
+	flushLock   sync.Mutex
+	lastDropped uint64
+	diagnostics *diagnostics
+	// The version of the remote configuration changes applied
+	configVersion atomic.Uint64
+	// The file of the capture started by the remote control
+	capture string
+
+	// The clock used for timestamping the received data (replaced when replaying)
+	now func() time.Time
 }
 
-func CreateAnalyticsForwarder(config ForwarderConfig, client *client.Client, proxy *UDPProxy) *AnalyticsForwarder {
+func CreateAnalyticsForwarder(config ForwarderConfig, client metricsPusher, proxy *UDPProxy) *AnalyticsForwarder {
 	inst := &AnalyticsForwarder{
-		config:    config,
-		client:    client,
-		proxy:     proxy,
-		isSending: false,
+		client: client,
+		proxy:  proxy,
+		now:    time.Now,
+	}
+	inst.config.Store(&config)
+	inst.diagnostics = newDiagnostics(time.Now())
+	inst.metrics = newMetricsAggregator(config.MaxUDPStreams, config.ServerSide)
+	inst.clocks = newGatewayClocks(config.MaxUDPStreams)
+
+	// The locations are validated when the configuration is parsed
+	locations, err := parseGatewayLocations(config.GatewayLocation)
+	if err != nil {
+		log.Warnf("Ignoring gateway locations: %s", err.Error())
 	}
-	inst.metricsFrame, _ = lru.NewWithEvict(config.MaxUDPStreams, inst.handleEvict)
+	inst.positions = newGatewayPositions(config.MaxUDPStreams, float64(config.GatewayMoveDistance), locations)
 	return inst
 }
 
@@ -45,35 +76,13 @@ func (f *AnalyticsForwarder) StartAndWait() {
 	<-ch
 }
 
-func (f *AnalyticsForwarder) handleEvict(key string, frame *api.AnalyticsMetrics) {
-	f.flushDataFrame(frame)
-}
-
-func (f *AnalyticsForwarder) getMetricsFrame(localEp *net.UDPAddr) *api.AnalyticsMetrics {
-	key := localEp.IP.String()
-	if found, ok := f.metricsFrame.Get(key); ok {
-		return found
-	}
-
-	found := &api.AnalyticsMetrics{}
-
-	// Include stats only on the server-side
-	if f.config.ServerSide {
-		found.Metrics = &api.AnalyticsInternalMetrics{
-			GatewayIp: localEp.String(),
-		}
-	}
-
-	f.metricsFrame.Add(key, found)
-	return found
-}
-
 func (f *AnalyticsForwarder) connect() {
 	// Keep trying until the analytics client is connected
 	for {
 		err := f.client.Connect()
 		if err != nil {
 			log.Warnf("Could not connect to analytics endpoint: %s", err.Error())
+			f.diagnostics.recordError(fmt.Errorf("could not connect: %w", err), time.Now())
 		} else {
 			// We are ready, start main thread
 			go f.main()
@@ -93,132 +102,159 @@ func (f *AnalyticsForwarder) main() {
 
 	// Periodically flush data waiting in the egress queue
 	for {
-		log.Debugf("Sleeping for %d sec", f.config.FlushInterval)
-		time.Sleep(time.Second * time.Duration(f.config.FlushInterval))
-		log.Debugf("Queue size=%d, isSending=%v", f.queueSize(), f.isSending)
-		if !f.isSending && f.hasData() {
+		flushInterval := f.conf().FlushInterval
+		log.Debugf("Sleeping for %d sec", flushInterval)
+		time.Sleep(time.Second * time.Duration(flushInterval))
+		log.Debugf("Queue size=%d", f.queueSize())
+		if rejected := f.proxy.RejectedPackets(); rejected > 0 {
+			log.Infof("Rejected %d datagrams due to gateway policy", rejected)
+		}
+		if dropped := f.proxy.DroppedEvents(); dropped > f.lastDropped {
+			log.Warnf("Dropped %d datagrams from analytics because the queue is full", dropped-f.lastDropped)
+			f.lastDropped = dropped
+		}
+		if f.hasData() || f.infoDue() {
 			f.flushData()
 		}
 	}
 }
 
-func (f *AnalyticsForwarder) hasData() bool {
-	return f.queueSize() > 0
+// Stops receiving traffic and pushes the data still in the queue, waiting for
+// a flush in progress to finish
+func (f *AnalyticsForwarder) Stop() {
+	if f.proxy != nil {
+		f.proxy.Close()
+	}
+	f.flushLock.Lock()
+	f.flushLock.Unlock()
+	if f.hasData() {
+		f.flushData()
+	}
 }
 
-func (f *AnalyticsForwarder) queueSize() int {
-	var total int = 0
-	for _, f := range f.metricsFrame.Values() {
-		sum := len(f.Uplinks) +
-			len(f.Downlinks) +
-			len(f.Stats)
-
-		if f.Metrics != nil {
-			if f.Metrics.DnRxPackets > 0 || f.Metrics.DnTxPackets > 0 ||
-				f.Metrics.UpRxPackets > 0 || f.Metrics.UpTxPackets > 0 {
-				if sum == 0 {
-					sum++
-				}
-			}
-		}
-
-		total += sum
-	}
+// Returns the configuration in effect, that may be replaced by the remote control
+func (f *AnalyticsForwarder) conf() *ForwarderConfig {
+	return f.config.Load()
+}
 
-	return total
+// Returns true if the health of the forwarder should be reported with the next flush
+func (f *AnalyticsForwarder) infoDue() bool {
+	return f.diagnostics.due(time.Second*time.Duration(f.conf().InfoInterval), time.Now())
 }
 
-func (f *AnalyticsForwarder) flushDataFrame(frame *api.AnalyticsMetrics) {
-	// Copy frame to allow it to be re-used while sending
-	frameCopy := proto.Clone(frame).(*api.AnalyticsMetrics)
+func (f *AnalyticsForwarder) hasData() bool {
+	return f.queueSize() > 0
+}
 
-	// Reset frame
-	frame.Downlinks = nil
-	frame.Uplinks = nil
-	frame.Stats = nil
+func (f *AnalyticsForwarder) queueSize() int {
+	return f.metrics.Pending()
+}
 
-	// Reset metrics counters
-	if frame.Metrics != nil {
-		frame.Metrics.DnRxPackets = 0
-		frame.Metrics.DnTxPackets = 0
-		frame.Metrics.UpRxPackets = 0
-		frame.Metrics.UpTxPackets = 0
-		frame.Metrics.PktPULL_ACK = 0
-		frame.Metrics.PktPULL_DATA = 0
-		frame.Metrics.PktPULL_RESP = 0
-		frame.Metrics.PktPUSH_ACK = 0
-		frame.Metrics.PktPUSH_DATA = 0
-		frame.Metrics.PktTX_ACK = 0
+func (f *AnalyticsForwarder) pushFrame(frame *api.AnalyticsMetrics) {
+	if frame.Aggregate != nil {
+		compactAggregate(frame.Aggregate)
 	}
-
-	// Push a copy
-	err := f.client.PushMetrics(frameCopy)
+	err := f.client.PushMetrics(frame)
+	f.diagnostics.recordPush(err, time.Now())
 	if err != nil {
 		log.Warnf("Unable to push metrics: %s", err.Error())
 	}
 }
 
 func (f *AnalyticsForwarder) flushData() {
-	f.isSending = true
-	log.Debugf("Flushing %d frames in %d gateways", f.queueSize(), f.metricsFrame.Len())
-
-	// Flush data
-	for _, sendFrame := range f.metricsFrame.Values() {
-		f.flushDataFrame(sendFrame)
+	// Skip this flush if the previous one is still sending
+	if !f.flushLock.TryLock() {
+		log.Debugf("Previous flush is still in progress")
+		return
 	}
+	defer f.flushLock.Unlock()
 
-	f.isSending = false
-}
+	// The frames are swapped with empty ones, so they can be sent without
+	// blocking the threads that collect new data
+	frames := f.metrics.Swap()
+	log.Debugf("Flushing %d gateways", len(frames))
 
-func (f *AnalyticsForwarder) UpLocalData(data []byte, localEp *net.UDPAddr) {
-	frame := f.getMetricsFrame(localEp)
-	if frame.Metrics != nil {
-		frame.Metrics.UpTxPackets += 1
+	// Report the health with the first frame, or on its own if there is no
+	// traffic (which is when it's needed the most)
+	if f.infoDue() {
+		if len(frames) == 0 {
+			frames = append(frames, &api.AnalyticsMetrics{GatewayId: f.conf().GatewayId})
+		}
+		frames[0].Forwarder = f.forwarderInfo(time.Now())
 	}
-	if SemtechUDPIsUplink(data) {
-		f.handleUplink(data, localEp, frame)
-	} else if SemtechUDPIsDownlink(data) {
-		f.handleDownlink(data, localEp, frame)
+	for _, frame := range frames {
+		f.pushFrame(frame)
 	}
-}
 
-func (f *AnalyticsForwarder) UpRemoteData(data []byte, localEp *net.UDPAddr) {
-	frame := f.getMetricsFrame(localEp)
-	if frame.Metrics != nil {
-		frame.Metrics.UpRxPackets += 1
+	if reporter, ok := f.client.(pushStatsReporter); ok {
+		s := reporter.Stats()
+		log.Debugf("Pushed %d bytes in %d pushes, %d bytes saved by compression, throttled for %s", s.WireBytes, s.Pushes, s.BytesSaved(), s.Throttled)
 	}
+}
 
-	if SemtechUDPIsUplink(data) {
-		f.handleUplink(data, localEp, frame)
-	} else if SemtechUDPIsDownlink(data) {
-		f.handleDownlink(data, localEp, frame)
+func (f *AnalyticsForwarder) handleData(data []byte, localEp *net.UDPAddr, counter func(m *api.AnalyticsInternalMetrics)) {
+	// Decode outside of the gateway lock, since it also parses the JSON payload
+	msg, err := DecodeMessage(data, len(data), localEp, f.now(), []string{})
+	if err != nil {
+		// Only counted, since a misbehaving gateway would flood the log. The
+		// totals are reported with the health of the forwarder in any mode.
+		if log.IsLevelEnabled(log.DebugLevel) {
+			log.Debugf("Could not handle datagram from %s: %s", localEp.String(), err.Error())
+			log.Debugf("Malformed datagram: %s", hex.EncodeToString(data))
+		}
+		f.diagnostics.recordMalformed(err)
 	}
+
+	f.metrics.Update(localEp, func(frame *api.AnalyticsMetrics) {
+		if frame.Metrics != nil {
+			counter(frame.Metrics)
+		}
+		if err != nil {
+			countMalformed(frame.Metrics, err)
+			return
+		}
+
+		if SemtechUDPIsUplink(data) {
+			f.handleUplink(msg, localEp, frame)
+		} else if SemtechUDPIsDownlink(data) {
+			f.handleDownlink(msg, localEp, frame)
+		}
+	})
 }
 
-func (f *AnalyticsForwarder) DnLocalData(data []byte, localEp *net.UDPAddr) {
-	frame := f.getMetricsFrame(localEp)
-	if frame.Metrics != nil {
-		frame.Metrics.DnTxPackets += 1
+// Counts a datagram that could not be decoded in the metrics of its gateway, by
+// the reason of the error
+func countMalformed(m *api.AnalyticsInternalMetrics, err error) {
+	if m == nil {
+		return
 	}
 
-	if SemtechUDPIsUplink(data) {
-		f.handleUplink(data, localEp, frame)
-	} else if SemtechUDPIsDownlink(data) {
-		f.handleDownlink(data, localEp, frame)
+	switch DecodeErrorReason(err) {
+	case ErrTruncated:
+		m.MalformedTruncated += 1
+	case ErrBadVersion:
+		m.MalformedBadVersion += 1
+	case ErrUnknownKind:
+		m.MalformedUnknownKind += 1
+	case ErrBadJSON:
+		m.MalformedBadJSON += 1
 	}
 }
 
-func (f *AnalyticsForwarder) DnRemoteData(data []byte, localEp *net.UDPAddr) {
-	frame := f.getMetricsFrame(localEp)
-	if frame.Metrics != nil {
-		frame.Metrics.DnRxPackets += 1
-	}
+func (f *AnalyticsForwarder) UpLocalData(data []byte, localEp *net.UDPAddr) {
+	f.handleData(data, localEp, func(m *api.AnalyticsInternalMetrics) { m.UpTxPackets += 1 })
+}
 
-	if SemtechUDPIsUplink(data) {
-		f.handleUplink(data, localEp, frame)
-	} else if SemtechUDPIsDownlink(data) {
-		f.handleDownlink(data, localEp, frame)
-	}
+func (f *AnalyticsForwarder) UpRemoteData(data []byte, localEp *net.UDPAddr) {
+	f.handleData(data, localEp, func(m *api.AnalyticsInternalMetrics) { m.UpRxPackets += 1 })
+}
+
+func (f *AnalyticsForwarder) DnLocalData(data []byte, localEp *net.UDPAddr) {
+	f.handleData(data, localEp, func(m *api.AnalyticsInternalMetrics) { m.DnTxPackets += 1 })
+}
+
+func (f *AnalyticsForwarder) DnRemoteData(data []byte, localEp *net.UDPAddr) {
+	f.handleData(data, localEp, func(m *api.AnalyticsInternalMetrics) { m.DnRxPackets += 1 })
 }
 
 func (f *AnalyticsForwarder) incPktStat(frame *SemtechUDPMessage, metricsFrame *api.AnalyticsMetrics) {
@@ -244,72 +280,131 @@ func (f *AnalyticsForwarder) incPktStat(frame *SemtechUDPMessage, metricsFrame *
 	}
 }
 
-func (f *AnalyticsForwarder) handleUplink(data []byte, localEp *net.UDPAddr, metricsFrame *api.AnalyticsMetrics) {
-	log.Debugf("Handling uplink frame from %s: %s", localEp.String(), hex.EncodeToString(data))
-	frame, err := DecodeMessage(data, len(data), localEp, time.Now(), []string{})
-	if err != nil {
-		log.Warnf("Could not handle uplink: %s", err.Error())
-	} else {
-		f.incPktStat(frame, metricsFrame)
-		eui := frame.GatewayEUI()
-		if eui != nil {
-			log.Debugf("Gateway EUI: %s, Token: %04x", hex.EncodeToString(eui), frame.Token)
-
-			// Configure gateway
-			metricsFrame.GatewayEui = eui
-			if !f.config.ServerSide {
-				metricsFrame.GatewayId = f.config.GatewayId
-			}
+func (f *AnalyticsForwarder) handleUplink(frame *SemtechUDPMessage, localEp *net.UDPAddr, metricsFrame *api.AnalyticsMetrics) {
+	log.Debugf("Handling uplink frame from %s: %s", localEp.String(), hex.EncodeToString(frame.Encode()))
+	f.incPktStat(frame, metricsFrame)
+	conf := f.conf()
+	eui := frame.GatewayEUI()
+	if eui != nil {
+		log.Debugf("Gateway EUI: %s, Token: %04x", hex.EncodeToString(eui), frame.Token)
+
+		// Configure gateway (copy the EUI, since the datagram buffer is re-used)
+		metricsFrame.GatewayEui = append([]byte(nil), eui...)
+		if !conf.ServerSide {
+			metricsFrame.GatewayId = conf.GatewayId
+		}
 
-			// Convert uplinks
-			rx, err := frame.GetAllRxPkt()
-			if err == nil && rx != nil {
-				for _, r := range rx {
-					pkt := f.convertRxPkt(&r)
-					log.Debugf("Got uplink: %+v", pkt)
+		// Convert uplinks
+		clock := f.clocks.Get(eui)
+		rx, err := frame.GetAllRxPkt()
+		if err == nil && rx != nil {
+			rxAt := f.now()
+			for _, r := range rx {
+				pkt := f.convertRxPkt(&r)
+				pkt.RxTimestamp = f.unwrapTmst(clock, uint32(r.Tmst), rxAt, metricsFrame)
+				log.Debugf("Got uplink: %+v", pkt)
+				if conf.Aggregate {
+					aggregateUplink(f.frameAggregate(metricsFrame, rxAt), pkt)
+				}
+				if !conf.Aggregate || sampleFrame(pkt.UniqueId, conf.AggregateSample) {
 					metricsFrame.Uplinks = append(metricsFrame.Uplinks, pkt)
 				}
 			}
+		}
 
-			// Convert stat
-			stat, err := frame.GetStatMsg()
-			if err == nil && stat != nil {
-				pkt := f.convertStatPkt(stat)
-				log.Debugf("Got stat: %+v", pkt)
-				metricsFrame.Stats = append(metricsFrame.Stats, pkt)
-			}
-		} else {
-			log.Debugf("No EUI in the frame")
+		// Convert stat
+		stat, err := frame.GetStatMsg()
+		if err == nil && stat != nil {
+			pkt := f.convertStatPkt(stat)
+			pkt.GwClockDrift = clock.Drift()
+			f.trackPosition(eui, pkt, metricsFrame)
+			log.Debugf("Got stat: %+v", pkt)
+			metricsFrame.Stats = append(metricsFrame.Stats, pkt)
 		}
+	} else {
+		log.Debugf("No EUI in the frame")
 	}
 
-	log.Debugf("Queue size=%d", f.queueSize())
+	log.Debugf("Gateway queue size=%d", frameSize(metricsFrame))
 }
 
-func (f *AnalyticsForwarder) handleDownlink(data []byte, localEp *net.UDPAddr, metricsFrame *api.AnalyticsMetrics) {
-	log.Debugf("Handling downlink frame from %s: %s", localEp.String(), hex.EncodeToString(data))
-	frame, err := DecodeMessage(data, len(data), localEp, time.Now(), []string{})
-	if err != nil {
-		log.Warnf("Could not handle downlink: %s", err.Error())
-	} else {
-		f.incPktStat(frame, metricsFrame)
+func (f *AnalyticsForwarder) handleDownlink(frame *SemtechUDPMessage, localEp *net.UDPAddr, metricsFrame *api.AnalyticsMetrics) {
+	log.Debugf("Handling downlink frame from %s: %s", localEp.String(), hex.EncodeToString(frame.Encode()))
+	f.incPktStat(frame, metricsFrame)
 
-		eui := frame.GatewayEUI()
-		log.Debugf("Gateway EUI: %s, Token: %04x", hex.EncodeToString(eui), frame.Token)
-		log.Debugf("Pair Gateway EUI: %s", hex.EncodeToString(metricsFrame.GatewayEui))
-		if eui != nil {
-			metricsFrame.GatewayEui = eui
+	eui := frame.GatewayEUI()
+	log.Debugf("Gateway EUI: %s, Token: %04x", hex.EncodeToString(eui), frame.Token)
+	log.Debugf("Pair Gateway EUI: %s", hex.EncodeToString(metricsFrame.GatewayEui))
+	if eui != nil {
+		metricsFrame.GatewayEui = append([]byte(nil), eui...)
+	}
+
+	// Convert downlinks
+	tx, err := frame.GetTxPacket()
+	if err == nil && tx != nil {
+		log.Debugf("Got downlink: %+v", tx)
+		pkt := f.convertTxPkt(tx)
+		if metricsFrame.GatewayEui != nil && tx.Tmst != 0 {
+			pkt.TxTimestamp = f.clocks.Get(metricsFrame.GatewayEui).Extend(uint32(tx.Tmst))
+		}
+		conf := f.conf()
+		if conf.Aggregate {
+			aggregateDownlink(f.frameAggregate(metricsFrame, f.now()), pkt)
 		}
+		if !conf.Aggregate || sampleFrame(pkt.UniqueId, conf.AggregateSample) {
+			metricsFrame.Downlinks = append(metricsFrame.Downlinks, pkt)
+		}
+	}
+
+	log.Debugf("Gateway queue size=%d", frameSize(metricsFrame))
+}
+
+// Returns the aggregate of the frame, extending its window to the given time
+func (f *AnalyticsForwarder) frameAggregate(metricsFrame *api.AnalyticsMetrics, now time.Time) *api.AnalyticsAggregate {
+	if metricsFrame.Aggregate == nil {
+		metricsFrame.Aggregate = &api.AnalyticsAggregate{}
+	}
+	touchAggregate(metricsFrame.Aggregate, now.UnixMilli())
+	return metricsFrame.Aggregate
+}
 
-		// Convert downlinks
-		tx, err := frame.GetTxPacket()
-		if err == nil && tx != nil {
-			log.Debugf("Got downlink: %+v", tx)
-			metricsFrame.Downlinks = append(metricsFrame.Downlinks, f.convertTxPkt(tx))
+// Unwraps the concentrator counter of an uplink, counting the wrap-arounds and
+// the restarts of the gateway
+func (f *AnalyticsForwarder) unwrapTmst(clock *gatewayClock, tmst uint32, rxAt time.Time, metricsFrame *api.AnalyticsMetrics) int64 {
+	value, event := clock.Observe(tmst, rxAt)
+	if metricsFrame.Metrics != nil {
+		switch event {
+		case tmstRollover:
+			metricsFrame.Metrics.TmstRollovers += 1
+		case tmstReset:
+			metricsFrame.Metrics.TmstResets += 1
 		}
 	}
+	return value
+}
+
+// Detects the position changes of the gateway from its status report, or fills-in
+// the configured position for gateways without GPS
+func (f *AnalyticsForwarder) trackPosition(eui []byte, stat *api.AnalyticsStat, metricsFrame *api.AnalyticsMetrics) {
+	pos := GatewayPosition{
+		Latitude:  stat.GwLatitudePrecise,
+		Longitude: stat.GwLongitudePrecise,
+		Altitude:  stat.GwAltitude,
+	}
+	if fixed, ok := f.positions.Fixed(eui); ok && !pos.IsValid() {
+		stat.GwLatitude = float32(fixed.Latitude)
+		stat.GwLongitude = float32(fixed.Longitude)
+		stat.GwLatitudePrecise = fixed.Latitude
+		stat.GwLongitudePrecise = fixed.Longitude
+		stat.GwAltitude = fixed.Altitude
+		return
+	}
 
-	log.Debugf("Queue size=%d", f.queueSize())
+	events := f.positions.Update(eui, pos, f.now().UnixMilli())
+	for _, event := range events {
+		log.Infof("Gateway %s: %s at %f,%f", hex.EncodeToString(eui), event.Type.String(), event.Latitude, event.Longitude)
+	}
+	metricsFrame.Events = append(metricsFrame.Events, events...)
 }
 
 ////////////////////////////////////////////////////////////////////////////////////
@@ -344,6 +439,16 @@ func parseCodingRate(cr string) api.LoRaCodingRate {
 		return api.LoRaCodingRate_CR_4_15
 	case "4/16":
 		return api.LoRaCodingRate_CR_4_16
+
+	// LR-FHSS
+	case "1/3":
+		return api.LoRaCodingRate_CR_1_3
+	case "2/3":
+		return api.LoRaCodingRate_CR_2_3
+	case "1/2":
+		return api.LoRaCodingRate_CR_1_2
+	case "5/6":
+		return api.LoRaCodingRate_CR_5_6
 	}
 
 	return api.LoRaCodingRate_CR_UNKNOWN
@@ -366,6 +471,8 @@ func parseModu(modu string) api.Modulation {
 		return api.Modulation_LORA
 	case "FSK":
 		return api.Modulation_FSK
+	case "LR-FHSS":
+		return api.Modulation_LR_FHSS
 	}
 
 	return api.Modulation_UNKNOWN
@@ -385,6 +492,10 @@ func parseSF(sf string) api.LoRaSF {
 		return api.LoRaSF_SF11
 	case "12":
 		return api.LoRaSF_SF12
+	case "5":
+		return api.LoRaSF_SF5
+	case "6":
+		return api.LoRaSF_SF6
 	}
 
 	return api.LoRaSF_SF_UNKNOWN
@@ -398,17 +509,29 @@ func parseBW(bw string) api.LoRaBW {
 		return api.LoRaBW_BW_250k
 	case "500":
 		return api.LoRaBW_BW_500k
+
+	// 2.4 GHz
+	case "203":
+		return api.LoRaBW_BW_203k
+	case "406":
+		return api.LoRaBW_BW_406k
+	case "812":
+		return api.LoRaBW_BW_812k
+	case "1625":
+		return api.LoRaBW_BW_1625k
 	}
 
 	return api.LoRaBW_BW_UNKNOWN
 }
 
+// Parses the data rate of a LoRa packet. The data rate comes from the gateway,
+// so the unparsable ones are only logged at debug level.
 func parseDataLoRaRate(dataRate string) *api.LoRaDataRate {
 	var lora api.LoRaDataRate
 	// EG. 'SF7BW125'
 	bw := strings.Index(dataRate, "BW")
-	if bw == -1 {
-		log.Warnf("Unparsable data rate '%s'", dataRate)
+	if bw < 2 || !strings.HasPrefix(dataRate, "SF") {
+		log.Debugf("Unparsable data rate '%s'", dataRate)
 	} else {
 		lora.SpreadingFactor = parseSF(dataRate[2:bw])
 		lora.Bandwidth = parseBW(dataRate[bw+2:])
@@ -416,13 +539,31 @@ func parseDataLoRaRate(dataRate string) *api.LoRaDataRate {
 	return &lora
 }
 
+func parseDataLRFHSSRate(dataRate string) *api.LRFHSSDataRate {
+	var lrfhss api.LRFHSSDataRate
+	// EG. 'M0CW137'
+	cw := strings.Index(dataRate, "CW")
+	if cw < 1 || !strings.HasPrefix(dataRate, "M") {
+		log.Debugf("Unparsable data rate '%s'", dataRate)
+	} else {
+		if val, err := strconv.ParseUint(dataRate[1:cw], 10, 32); err == nil {
+			lrfhss.ModulationType = uint32(val)
+		}
+		if val, err := strconv.ParseUint(dataRate[cw+2:], 10, 32); err == nil {
+			lrfhss.ChannelWidth = uint32(val)
+		}
+	}
+	return &lrfhss
+}
+
 func (f *AnalyticsForwarder) convertRxPkt(in *SemtechUDPRxPkt) *api.AnalyticsUplink {
 	var out api.AnalyticsUplink
-	var tm time.Time
 
-	err := json.Unmarshal([]byte(in.Time), &tm)
-	if err == nil {
+	// Prefer the time reported by the gateway, or derive it from the GPS time
+	if tm, err := parseSemtechTime(in.Time); err == nil {
 		out.RxWallTime = tm.UnixMicro()
+	} else if in.Tmms != 0 {
+		out.RxWallTime = gpsToUTC(in.Tmms).UnixMicro()
 	}
 
 	out.RxFinishedTime = in.Tmst
@@ -436,15 +577,19 @@ func (f *AnalyticsForwarder) convertRxPkt(in *SemtechUDPRxPkt) *api.AnalyticsUpl
 	switch in.Modulation {
 	case "LORA":
 		out.DataRate = &api.AnalyticsUplink_DataRateLoRa{
-			DataRateLoRa: parseDataLoRaRate(in.DataRate),
+			DataRateLoRa: parseDataLoRaRate(string(in.DataRate)),
 		}
 	case "FSK":
-		val, err := strconv.Atoi(in.DataRate)
+		val, err := strconv.Atoi(string(in.DataRate))
 		if err == nil {
 			out.DataRate = &api.AnalyticsUplink_DataRateFSK{
 				DataRateFSK: uint32(val),
 			}
 		}
+	case "LR-FHSS":
+		out.DataRate = &api.AnalyticsUplink_DataRateLRFHSS{
+			DataRateLRFHSS: parseDataLRFHSSRate(string(in.DataRate)),
+		}
 	}
 
 	if len(in.RSig) > 0 {
@@ -471,15 +616,29 @@ func (f *AnalyticsForwarder) convertRxPkt(in *SemtechUDPRxPkt) *api.AnalyticsUpl
 			out.Ant = append(out.Ant, oAnt)
 		}
 	} else {
-		out.Ant = append(out.Ant, &api.AnalyticsUplinkAntenna{
+		oAnt := &api.AnalyticsUplinkAntenna{
 			Antenna: 0,
 			IfChan:  int32(in.Channel),
 			RSSIC:   int32(in.Rssi),
 			LSNR:    in.Lsnr,
-		})
+			FTime:   in.FTime,
+			Foff:    in.FOff,
+		}
+		if in.RSSIS != nil {
+			var value int32 = int32(*in.RSSIS)
+			oAnt.RSSIS = &value
+		}
+		out.Ant = append(out.Ant, oAnt)
 	}
 	out.Size = uint32(in.Size)
 
+	if len(in.Custom) > 0 {
+		out.CustomFields = make(map[string]string, len(in.Custom))
+		for name, value := range in.Custom {
+			out.CustomFields[name] = string(value)
+		}
+	}
+
 	data, err := base64.StdEncoding.DecodeString(in.Data)
 	if err == nil {
 		fhdrLen := GetLoRaWANHeaderLen(data)
@@ -492,15 +651,15 @@ func (f *AnalyticsForwarder) convertRxPkt(in *SemtechUDPRxPkt) *api.AnalyticsUpl
 
 func (f *AnalyticsForwarder) convertStatPkt(in *SemtechUDPStat) *api.AnalyticsStat {
 	var out api.AnalyticsStat
-	var tm time.Time
 
-	err := json.Unmarshal([]byte(in.Time), &tm)
-	if err == nil {
+	if tm, err := parseSemtechTime(in.Time); err == nil {
 		out.GwTime = tm.UnixMilli()
 	}
 
-	out.GwLatitude = in.Lati
-	out.GwLongitude = in.Long
+	out.GwLatitude = float32(in.Lati)
+	out.GwLongitude = float32(in.Long)
+	out.GwLatitudePrecise = in.Lati
+	out.GwLongitudePrecise = in.Long
 	out.GwAltitude = in.Alti
 	out.RxPackets = uint32(in.RxNb)
 	out.RxWithValidPhyCRC = uint32(in.RxOk)
@@ -508,8 +667,9 @@ func (f *AnalyticsForwarder) convertStatPkt(in *SemtechUDPStat) *api.AnalyticsSt
 	out.RxAckr = in.Ackr
 	out.TxReceived = uint32(in.DwnB)
 	out.TxEmitted = uint32(in.TxNb)
+	out.GwTemp = in.Temp
 
-	out.IsGauge = f.config.GaugeStat
+	out.IsGauge = f.conf().GaugeStat
 
 	return &out
 }
@@ -530,10 +690,10 @@ func (f *AnalyticsForwarder) convertTxPkt(in *SemtechUDPTxPkt) *api.AnalyticsDow
 	switch in.Modulation {
 	case "LORA":
 		out.DataRate = &api.AnalyticsDownlink_DataRateLoRa{
-			DataRateLoRa: parseDataLoRaRate(in.DataRate),
+			DataRateLoRa: parseDataLoRaRate(string(in.DataRate)),
 		}
 	case "FSK":
-		val, err := strconv.Atoi(in.DataRate)
+		val, err := strconv.Atoi(string(in.DataRate))
 		if err == nil {
 			out.DataRate = &api.AnalyticsDownlink_DataRateFSK{
 				DataRateFSK: uint32(val),
@@ -546,7 +706,7 @@ func (f *AnalyticsForwarder) convertTxPkt(in *SemtechUDPTxPkt) *api.AnalyticsDow
 	out.RfPreamble = uint32(in.Prea)
 	out.Size = uint32(in.Size)
 	out.NoCrc = in.NoCRC
-	out.RxWallTime = time.Now().UnixMilli()
+	out.RxWallTime = f.now().UnixMilli()
 
 	data, err := base64.StdEncoding.DecodeString(in.Data)
 	if err == nil {
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"github.com/kudzutechnologies/analytics/simulator"
+	"github.com/stretchr/testify/assert"
+)
+
+// Collects the pushed metrics instead of sending them to the analytics endpoint
+type mockPusher struct {
+	lock   sync.Mutex
+	frames []*api.AnalyticsMetrics
+}
+
+func (m *mockPusher) Connect() error {
+	return nil
+}
+
+func (m *mockPusher) PushMetrics(metrics *api.AnalyticsMetrics) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.frames = append(m.frames, metrics)
+	return nil
+}
+
+func (m *mockPusher) totals() (uplinks int, upTx uint32, pushData uint32) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, f := range m.frames {
+		uplinks += len(f.Uplinks)
+		if f.Metrics != nil {
+			upTx += f.Metrics.UpTxPackets
+			pushData += f.Metrics.PktPUSH_DATA
+		}
+	}
+	return
+}
+
+func TestForwarderFlush(t *testing.T) {
+	pkt, _ := base64.StdEncoding.DecodeString(PacketPushDataUp)
+	pusher := &mockPusher{}
+	config := defaultConf
+	config.ServerSide = true
+	config.MaxUDPStreams = 16
+	fw := CreateAnalyticsForwarder(config, pusher, nil)
+
+	addr := &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1700}
+	fw.UpLocalData(pkt, addr)
+	fw.UpLocalData(pkt, addr)
+	assert.True(t, fw.hasData())
+
+	fw.flushData()
+	assert.False(t, fw.hasData())
+	assert.Len(t, pusher.frames, 1)
+	assert.Len(t, pusher.frames[0].Uplinks, 2)
+	assert.Equal(t, uint32(2), pusher.frames[0].Metrics.UpTxPackets)
+	assert.Equal(t, "10.0.0.1:1700", pusher.frames[0].Metrics.GatewayIp)
+
+	// The gateway details are kept for the next frame
+	fw.UpLocalData(pkt, addr)
+	fw.flushData()
+	assert.Len(t, pusher.frames, 2)
+	assert.Equal(t, pusher.frames[0].GatewayEui, pusher.frames[1].GatewayEui)
+	assert.Equal(t, "10.0.0.1:1700", pusher.frames[1].Metrics.GatewayIp)
+}
+
+func TestForwarderEvictedFlush(t *testing.T) {
+	pkt, _ := base64.StdEncoding.DecodeString(PacketPushDataUp)
+	pusher := &mockPusher{}
+	config := defaultConf
+	config.ServerSide = true
+	config.MaxUDPStreams = 1
+	fw := CreateAnalyticsForwarder(config, pusher, nil)
+
+	// The frame of the evicted gateway waits for the flush, instead of being
+	// pushed by the thread receiving the traffic
+	fw.UpLocalData(pkt, &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1700})
+	fw.UpLocalData(pkt, &net.UDPAddr{IP: net.ParseIP("10.0.0.2"), Port: 1700})
+	assert.Len(t, pusher.frames, 0)
+	assert.Equal(t, 2, fw.queueSize())
+
+	fw.flushData()
+	assert.False(t, fw.hasData())
+	assert.Len(t, pusher.frames, 2)
+	assert.Equal(t, "10.0.0.1:1700", pusher.frames[0].Metrics.GatewayIp)
+	assert.Equal(t, "10.0.0.2:1700", pusher.frames[1].Metrics.GatewayIp)
+}
+
+func TestForwarderStop(t *testing.T) {
+	pkt, _ := base64.StdEncoding.DecodeString(PacketPushDataUp)
+	fw, pusher := createControlForwarder(t)
+
+	// The data still in the queue is pushed when stopping
+	fw.UpLocalData(pkt, &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1700})
+	fw.Stop()
+	assert.False(t, fw.hasData())
+	uplinks, _, _ := pusher.totals()
+	assert.Equal(t, 1, uplinks)
+}
+
+func TestForwarderMalformedCounters(t *testing.T) {
+	pkt, _ := base64.StdEncoding.DecodeString(PacketPushDataUp)
+	for _, serverSide := range []bool{true, false} {
+		pusher := &mockPusher{}
+		config := defaultConf
+		config.ServerSide = serverSide
+		config.MaxUDPStreams = 16
+		fw := CreateAnalyticsForwarder(config, pusher, nil)
+
+		addr := &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1700}
+		fw.UpLocalData(pkt, addr)
+		fw.UpLocalData(pkt[0:10], addr)
+		fw.UpLocalData(pkt[0:20], addr)
+		fw.UpLocalData(pkt[0:20], addr)
+		fw.UpLocalData([]byte{1, 0, 0, PUSH_DATA}, addr)
+		fw.DnRemoteData([]byte{PROTOCOL_VERSION, 0, 0, 0x42}, addr)
+		fw.flushData()
+
+		// The totals are reported with the health of the forwarder in any mode
+		info := fw.forwarderInfo(time.Now())
+		assert.Equal(t, uint64(1), info.MalformedTruncated)
+		assert.Equal(t, uint64(2), info.MalformedBadJSON)
+		assert.Equal(t, uint64(1), info.MalformedBadVersion)
+		assert.Equal(t, uint64(1), info.MalformedUnknownKind)
+
+		assert.Len(t, pusher.frames, 1)
+		assert.Len(t, pusher.frames[0].Uplinks, 1)
+		m := pusher.frames[0].Metrics
+		if !serverSide {
+			assert.Nil(t, m)
+			continue
+		}
+
+		// And per gateway on the server-side
+		assert.Equal(t, uint32(5), m.UpTxPackets)
+		assert.Equal(t, uint32(1), m.PktPUSH_DATA)
+		assert.Equal(t, uint32(1), m.MalformedTruncated)
+		assert.Equal(t, uint32(2), m.MalformedBadJSON)
+		assert.Equal(t, uint32(1), m.MalformedBadVersion)
+		assert.Equal(t, uint32(1), m.MalformedUnknownKind)
+	}
+}
+
+func TestConvertModernRxPkt(t *testing.T) {
+	fw := CreateAnalyticsForwarder(defaultConf, &mockPusher{}, nil)
+	convert := func(rxpk string) *api.AnalyticsUplink {
+		var in SemtechUDPRxPkt
+		assert.NoError(t, json.Unmarshal([]byte(rxpk), &in))
+		return fw.convertRxPkt(&in)
+	}
+
+	// SX1302 single-antenna details and custom fields
+	up := convert(`{"jver":1,"tmst":1133298212,"ftime":520153458,"chan":0,"rfch":0,"freq":868.1,"mid":8,"stat":1,
+		"modu":"LORA","datr":"SF5BW125","codr":"4/5","rssis":-104,"rssi":-98,"lsnr":-3.5,"foff":-2113,"size":3,"data":"QAEC"}`)
+	assert.Equal(t, api.LoRaSF_SF5, up.GetDataRateLoRa().SpreadingFactor)
+	assert.Len(t, up.Ant, 1)
+	assert.Equal(t, int32(-98), up.Ant[0].RSSIC)
+	assert.Equal(t, int32(-104), *up.Ant[0].RSSIS)
+	assert.Equal(t, int32(-2113), *up.Ant[0].Foff)
+	assert.Equal(t, int64(520153458), *up.Ant[0].FTime)
+	assert.Equal(t, map[string]string{"jver": "1", "mid": "8"}, up.CustomFields)
+
+	// 2.4 GHz
+	up = convert(`{"modu":"LORA","datr":"SF6BW812","codr":"4/8","freq":2425.8125}`)
+	assert.Equal(t, api.LoRaSF_SF6, up.GetDataRateLoRa().SpreadingFactor)
+	assert.Equal(t, api.LoRaBW_BW_812k, up.GetDataRateLoRa().Bandwidth)
+	up = convert(`{"modu":"LORA","datr":"SF12BW1625"}`)
+	assert.Equal(t, api.LoRaBW_BW_1625k, up.GetDataRateLoRa().Bandwidth)
+
+	// LR-FHSS
+	up = convert(`{"modu":"LR-FHSS","datr":"M0CW137","codr":"2/3","freq":868.1}`)
+	assert.Equal(t, api.Modulation_LR_FHSS, up.Modulation)
+	assert.Equal(t, api.LoRaCodingRate_CR_2_3, up.CodingRate)
+	assert.Equal(t, uint32(0), up.GetDataRateLRFHSS().ModulationType)
+	assert.Equal(t, uint32(137), up.GetDataRateLRFHSS().ChannelWidth)
+
+	// FSK data rates are numbers
+	up = convert(`{"modu":"FSK","datr":50000,"rssi":-75}`)
+	assert.Equal(t, uint32(50000), up.GetDataRateFSK())
+}
+
+func TestConvertStatPkt(t *testing.T) {
+	fw := CreateAnalyticsForwarder(defaultConf, &mockPusher{}, nil)
+
+	var in SemtechUDPStat
+	assert.NoError(t, json.Unmarshal([]byte(`{"time":"2023-03-01 10:12:44 GMT","lati":46.2412345,"long":3.2523456,
+		"alti":145,"rxnb":2,"rxok":2,"rxfw":2,"ackr":100.0,"dwnb":0,"txnb":0,"temp":38.5}`), &in))
+	stat := fw.convertStatPkt(&in)
+
+	assert.Equal(t, time.Date(2023, 3, 1, 10, 12, 44, 0, time.UTC).UnixMilli(), stat.GwTime)
+	assert.Equal(t, float32(38.5), *stat.GwTemp)
+	assert.Equal(t, 46.2412345, stat.GwLatitudePrecise)
+	assert.Equal(t, 3.2523456, stat.GwLongitudePrecise)
+	assert.Equal(t, float32(46.2412345), stat.GwLatitude)
+	assert.Equal(t, uint32(2), stat.RxPackets)
+
+	// The temperature is optional
+	in = SemtechUDPStat{}
+	assert.Nil(t, fw.convertStatPkt(&in).GwTemp)
+}
+
+func TestForwarderTiming(t *testing.T) {
+	pusher := &mockPusher{}
+	config := defaultConf
+	config.ServerSide = true
+	config.MaxUDPStreams = 16
+	fw := CreateAnalyticsForwarder(config, pusher, nil)
+	now := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	fw.now = func() time.Time { return now }
+
+	eui := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	addr := &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1700}
+	send := func(rxpk string) {
+		msg := SemtechUDPMessage{Version: PROTOCOL_VERSION, Kind: PUSH_DATA, Data: append(append([]byte(nil), eui...), `{"rxpk":[`+rxpk+`]}`...)}
+		fw.UpLocalData(msg.Encode(), addr)
+	}
+	send(`{"time":"2023-03-01T09:59:59.528002Z","tmst":4294000000,"freq":868.1,"stat":1,"modu":"LORA","datr":"SF7BW125","size":0,"data":""}`)
+	now = now.Add(2 * time.Second)
+	send(`{"tmms":1361700019000,"tmst":1032704,"freq":868.1,"stat":1,"modu":"LORA","datr":"SF7BW125","size":0,"data":""}`)
+
+	tx := SemtechUDPMessage{Version: PROTOCOL_VERSION, Kind: PULL_RESP, Data: []byte(`{"txpk":{"tmst":2032704,"freq":868.1,"powe":14,"modu":"LORA","datr":"SF7BW125","size":0,"data":""}}`)}
+	fw.DnRemoteData(tx.Encode(), addr)
+	fw.flushData()
+
+	assert.Len(t, pusher.frames, 1)
+	frame := pusher.frames[0]
+	assert.Len(t, frame.Uplinks, 2)
+	assert.Equal(t, time.Date(2023, 3, 1, 9, 59, 59, 528002000, time.UTC).UnixMicro(), frame.Uplinks[0].RxWallTime)
+	assert.Equal(t, int64(4294000000), frame.Uplinks[0].RxTimestamp)
+
+	// GPS time is converted to UTC, and the counter continues after the wrap-around
+	assert.Equal(t, time.Date(2023, 3, 1, 10, 0, 1, 0, time.UTC).UnixMicro(), frame.Uplinks[1].RxWallTime)
+	assert.Equal(t, int64(4294000000+2000000), frame.Uplinks[1].RxTimestamp)
+	assert.Equal(t, uint32(1), frame.Metrics.TmstRollovers)
+	assert.Equal(t, uint32(0), frame.Metrics.TmstResets)
+
+	assert.Len(t, frame.Downlinks, 1)
+	assert.Equal(t, int64(4294000000+3000000), frame.Downlinks[0].TxTimestamp)
+}
+
+// Feeds many gateways from parallel threads while flushing, and checks that
+// nothing is lost. Run with `-race` to detect unsafe accesses.
+func TestForwarderConcurrentLoad(t *testing.T) {
+	const gateways = 32
+	const threads = 4
+	const packets = 100
+
+	pkt, _ := base64.StdEncoding.DecodeString(PacketPushDataUp)
+	pusher := &mockPusher{}
+	config := defaultConf
+	config.ServerSide = true
+	config.MaxUDPStreams = gateways / 2 // Force evictions as well
+	fw := CreateAnalyticsForwarder(config, pusher, nil)
+
+	done := make(chan bool)
+	flushed := make(chan bool)
+	go func() {
+		for {
+			select {
+			case <-done:
+				close(flushed)
+				return
+			default:
+				fw.flushData()
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for gw := 0; gw < gateways; gw++ {
+		addr := &net.UDPAddr{IP: net.ParseIP(fmt.Sprintf("10.0.0.%d", gw+1)), Port: 1700}
+		data := append([]byte(nil), pkt...)
+		data[11] = byte(gw)
+
+		for th := 0; th < threads; th++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < packets; i++ {
+					fw.UpLocalData(data, addr)
+				}
+			}()
+		}
+	}
+
+	wg.Wait()
+	close(done)
+	<-flushed
+	fw.flushData()
+
+	uplinks, upTx, pushData := pusher.totals()
+	assert.Equal(t, gateways*threads*packets, uplinks)
+	assert.Equal(t, uint32(gateways*threads*packets), upTx)
+	assert.Equal(t, uint32(gateways*threads*packets), pushData)
+}
+
+// Runs simulated gateways and LNS through the proxy, and checks that all the
+// traffic is recorded in the analytics
+func TestForwarderSimulatedTraffic(t *testing.T) {
+	lns, err := simulator.CreateLNS(simulator.LNSConfig{
+		UpListenAddr:   &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)},
+		DownListenAddr: &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)},
+		DownlinkRatio:  0.5,
+	})
+	assert.NoError(t, err)
+	defer lns.Close()
+
+	pusher := &mockPusher{}
+	config := defaultConf
+	config.ServerSide = true
+	config.MaxUDPStreams = 16
+	fw := CreateAnalyticsForwarder(config, pusher, nil)
+
+	up := CreateSocket(t)
+	dn := CreateSocket(t)
+	proxy, err := CreateUDPProxy(&UDPProxyConfig{
+		UpListenAddr:      up.remote,
+		UpConnectAddr:     lns.UpAddr(),
+		DownListenAddr:    dn.remote,
+		DownConnectAddr:   lns.DownAddr(),
+		BufferSize:        4096,
+		SocketStreams:     16,
+		ReconnectInterval: 1,
+		Events:            fw,
+		QueueSize:         1000,
+	})
+	assert.NoError(t, err)
+
+	gateways, err := simulator.StartGateways(simulator.GatewayConfig{
+		EUI:               []byte{0, 0x16, 0xc0, 0x01, 0, 0, 0, 0},
+		UpAddr:            up.remote,
+		DownAddr:          dn.remote,
+		UplinkInterval:    20 * time.Millisecond,
+		StatInterval:      100 * time.Millisecond,
+		KeepaliveInterval: 50 * time.Millisecond,
+		Antennas:          2,
+		Seed:              1,
+	}, 4)
+	assert.NoError(t, err)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for simulator.TotalStats(gateways).TxAcks < 20 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, gw := range gateways {
+		gw.Close()
+	}
+	time.Sleep(50 * time.Millisecond)
+	lns.Close()
+	proxy.Close()
+	fw.flushData()
+
+	stats := simulator.TotalStats(gateways)
+	assert.GreaterOrEqual(t, stats.TxAcks, uint64(20))
+
+	pusher.lock.Lock()
+	defer pusher.lock.Unlock()
+	var uplinks, downlinks uint64
+	for _, f := range pusher.frames {
+		uplinks += uint64(len(f.Uplinks))
+		downlinks += uint64(len(f.Downlinks))
+		for _, up := range f.Uplinks {
+			assert.Len(t, up.Ant, 2)
+		}
+	}
+	assert.Equal(t, stats.Uplinks, uplinks)
+	assert.Equal(t, lns.Stats().Downlinks, downlinks)
+}
+
+func FuzzParseDataLoRaRate(f *testing.F) {
+	sfNames := map[api.LoRaSF]string{
+		api.LoRaSF_SF5: "5", api.LoRaSF_SF6: "6", api.LoRaSF_SF7: "7", api.LoRaSF_SF8: "8",
+		api.LoRaSF_SF9: "9", api.LoRaSF_SF10: "10", api.LoRaSF_SF11: "11", api.LoRaSF_SF12: "12",
+	}
+	bwNames := map[api.LoRaBW]string{
+		api.LoRaBW_BW_125k: "125", api.LoRaBW_BW_250k: "250", api.LoRaBW_BW_500k: "500",
+		api.LoRaBW_BW_203k: "203", api.LoRaBW_BW_406k: "406", api.LoRaBW_BW_812k: "812", api.LoRaBW_BW_1625k: "1625",
+	}
+
+	f.Fuzz(func(t *testing.T, dataRate string) {
+		lora := parseDataLoRaRate(dataRate)
+		sf, sfOk := sfNames[lora.SpreadingFactor]
+		bw, bwOk := bwNames[lora.Bandwidth]
+
+		// A fully recognized data rate must format back to the input
+		if sfOk && bwOk && "SF"+sf+"BW"+bw != dataRate {
+			t.Fatalf("Data rate '%s' parsed as SF%sBW%s", dataRate, sf, bw)
+		}
+	})
+}
+
+func FuzzConvertRxPkt(f *testing.F) {
+	fw := CreateAnalyticsForwarder(defaultConf, &mockPusher{}, nil)
+
+	f.Fuzz(func(t *testing.T, rxpk []byte) {
+		var in SemtechUDPRxPkt
+		if json.Unmarshal(rxpk, &in) != nil {
+			return
+		}
+
+		out := fw.convertRxPkt(&in)
+		if out.Size != uint32(in.Size) {
+			t.Fatalf("Size %d converted to %d", in.Size, out.Size)
+		}
+		if len(out.Ant) == 0 || (len(in.RSig) > 0 && len(out.Ant) != len(in.RSig)) {
+			t.Fatalf("Got %d antennas for %d rsig entries", len(out.Ant), len(in.RSig))
+		}
+
+		// The header is always a prefix of the frame
+		data, err := base64.StdEncoding.DecodeString(in.Data)
+		if err == nil {
+			if !bytes.HasPrefix(data, out.Fhdr) {
+				t.Fatalf("Header %x is not a prefix of %x", out.Fhdr, data)
+			}
+			if len(out.UniqueId) != 20 {
+				t.Fatalf("Missing unique ID")
+			}
+		}
+	})
+}
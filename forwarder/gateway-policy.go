@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+var (
+	ErrPolicyDeniedSource    = fmt.Errorf("denied-source")
+	ErrPolicyUnlistedSource  = fmt.Errorf("unlisted-source")
+	ErrPolicyNoGateway       = fmt.Errorf("no-gateway")
+	ErrPolicyDeniedGateway   = fmt.Errorf("denied-gateway")
+	ErrPolicyUnlistedGateway = fmt.Errorf("unlisted-gateway")
+	ErrPolicyPinnedAddress   = fmt.Errorf("pinned-address")
+	ErrPolicyRateLimited     = fmt.Errorf("rate-limited")
+)
+
+// The rules that decide which gateways are allowed to use the proxy
+type GatewayPolicyConfig struct {
+	// Gateway EUIs that are explicitly allowed (empty allows everything)
+	AllowGateways []string
+	// Gateway EUIs that are always rejected
+	DenyGateways []string
+	// Source networks that are allowed (empty allows everything)
+	AllowSources []*net.IPNet
+	// Source networks that are always rejected
+	DenySources []*net.IPNet
+	// Gateway EUIs that can only be used from the given networks
+	PinnedSources map[string][]*net.IPNet
+	// How many new gateways are admitted per minute, without an allow list (0 = unlimited)
+	UnknownGatewayRate int
+	// How many admitted gateways to remember
+	MaxGateways int
+}
+
+type GatewayPolicy struct {
+	config       *GatewayPolicyConfig
+	allow        map[string]bool
+	deny         map[string]bool
+	needsEUI     bool
+	known        *lru.Cache[string, bool]
+	lock         sync.Mutex
+	tokens       float64
+	lastRefill   time.Time
+	rejected     map[string]uint64
+	lastRejected uint64
+}
+
+// Normalizes the EUI notation used in the configuration (eg. '0102030405060708',
+// '01:02:03:04:05:06:07:08' or 'eui-0102030405060708') into a lowercase hex string
+func parseGatewayEUI(eui string) (string, error) {
+	str := strings.ToLower(strings.TrimSpace(eui))
+	str = strings.TrimPrefix(str, "eui-")
+	str = strings.NewReplacer(":", "", "-", "", " ", "").Replace(str)
+
+	b, err := hex.DecodeString(str)
+	if err != nil || len(b) != 8 {
+		return "", fmt.Errorf("invalid gateway EUI '%s'", eui)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Parses a CIDR, or a single IP address that is converted into a host network
+func parseSourceNet(cidr string) (*net.IPNet, error) {
+	cidr = strings.TrimSpace(cidr)
+	if !strings.Contains(cidr, "/") {
+		ip := net.ParseIP(cidr)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid address '%s'", cidr)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid network '%s'", cidr)
+	}
+	return ipNet, nil
+}
+
+func splitList(list string) []string {
+	var ret []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			ret = append(ret, item)
+		}
+	}
+	return ret
+}
+
+// Parses the comma-separated policy rules as found in the configuration file
+func ParseGatewayPolicyConfig(allow, deny, sourceAllow, sourceDeny, pins string, unknownRate int) (*GatewayPolicyConfig, error) {
+	config := &GatewayPolicyConfig{
+		PinnedSources:      make(map[string][]*net.IPNet),
+		UnknownGatewayRate: unknownRate,
+	}
+
+	for _, item := range splitList(allow) {
+		eui, err := parseGatewayEUI(item)
+		if err != nil {
+			return nil, err
+		}
+		config.AllowGateways = append(config.AllowGateways, eui)
+	}
+	for _, item := range splitList(deny) {
+		eui, err := parseGatewayEUI(item)
+		if err != nil {
+			return nil, err
+		}
+		config.DenyGateways = append(config.DenyGateways, eui)
+	}
+	for _, item := range splitList(sourceAllow) {
+		ipNet, err := parseSourceNet(item)
+		if err != nil {
+			return nil, err
+		}
+		config.AllowSources = append(config.AllowSources, ipNet)
+	}
+	for _, item := range splitList(sourceDeny) {
+		ipNet, err := parseSourceNet(item)
+		if err != nil {
+			return nil, err
+		}
+		config.DenySources = append(config.DenySources, ipNet)
+	}
+
+	// Pins are in the form <eui>@<cidr>
+	for _, item := range splitList(pins) {
+		parts := strings.SplitN(item, "@", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid gateway pin '%s', expecting <eui>@<network>", item)
+		}
+		eui, err := parseGatewayEUI(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		ipNet, err := parseSourceNet(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		config.PinnedSources[eui] = append(config.PinnedSources[eui], ipNet)
+	}
+
+	return config, nil
+}
+
+// Returns true if the configuration contains at least one rule
+func (c *GatewayPolicyConfig) HasRules() bool {
+	return len(c.AllowGateways) > 0 || len(c.DenyGateways) > 0 ||
+		len(c.AllowSources) > 0 || len(c.DenySources) > 0 ||
+		len(c.PinnedSources) > 0 || c.UnknownGatewayRate > 0
+}
+
+func CreateGatewayPolicy(config *GatewayPolicyConfig) (*GatewayPolicy, error) {
+	var err error
+	inst := &GatewayPolicy{
+		config:   config,
+		allow:    make(map[string]bool),
+		deny:     make(map[string]bool),
+		rejected: make(map[string]uint64),
+	}
+
+	for _, eui := range config.AllowGateways {
+		inst.allow[eui] = true
+	}
+	for _, eui := range config.DenyGateways {
+		inst.deny[eui] = true
+	}
+	inst.needsEUI = len(inst.allow) > 0 || len(inst.deny) > 0 ||
+		len(config.PinnedSources) > 0 || config.UnknownGatewayRate > 0
+
+	maxGateways := config.MaxGateways
+	if maxGateways <= 0 {
+		maxGateways = 1024
+	}
+	inst.known, err = lru.New[string, bool](maxGateways)
+	if err != nil {
+		return nil, fmt.Errorf("could not allocate gateway cache: %w", err)
+	}
+
+	inst.tokens = float64(config.UnknownGatewayRate)
+	inst.lastRefill = time.Now()
+	return inst, nil
+}
+
+func netsContain(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Extracts the gateway EUI from the datagrams sent by the gateways
+func policyGatewayEUI(data []byte) string {
+	if len(data) < 12 || data[0] != PROTOCOL_VERSION {
+		return ""
+	}
+	switch data[3] {
+	case PUSH_DATA, PULL_DATA, TX_ACK:
+		return hex.EncodeToString(data[4:12])
+	}
+	return ""
+}
+
+// Consumes one admission token for an unknown gateway
+func (p *GatewayPolicy) takeToken(now time.Time) bool {
+	rate := float64(p.config.UnknownGatewayRate)
+	p.tokens += now.Sub(p.lastRefill).Minutes() * rate
+	if p.tokens > rate {
+		p.tokens = rate
+	}
+	p.lastRefill = now
+
+	if p.tokens < 1 {
+		return false
+	}
+	p.tokens -= 1
+	return true
+}
+
+func (p *GatewayPolicy) reject(err error) error {
+	p.lock.Lock()
+	p.rejected[err.Error()] += 1
+	p.lock.Unlock()
+	return err
+}
+
+// Checks if the datagram received from the given address is allowed to go
+// through the proxy. Rejected datagrams are counted per reason.
+func (p *GatewayPolicy) Check(data []byte, addr *net.UDPAddr) error {
+	if p == nil {
+		return nil
+	}
+
+	if netsContain(p.config.DenySources, addr.IP) {
+		return p.reject(ErrPolicyDeniedSource)
+	}
+	if len(p.config.AllowSources) > 0 && !netsContain(p.config.AllowSources, addr.IP) {
+		return p.reject(ErrPolicyUnlistedSource)
+	}
+	if !p.needsEUI {
+		return nil
+	}
+
+	eui := policyGatewayEUI(data)
+	if eui == "" {
+		return p.reject(ErrPolicyNoGateway)
+	}
+	if p.deny[eui] {
+		return p.reject(ErrPolicyDeniedGateway)
+	}
+	if len(p.allow) > 0 && !p.allow[eui] {
+		return p.reject(ErrPolicyUnlistedGateway)
+	}
+	if pins, ok := p.config.PinnedSources[eui]; ok && !netsContain(pins, addr.IP) {
+		return p.reject(ErrPolicyPinnedAddress)
+	}
+
+	// Gateways that are not explicitly allowed are admitted at a limited rate,
+	// to avoid exhausting the proxy streams with forged EUIs
+	if p.config.UnknownGatewayRate > 0 && !p.allow[eui] && !p.known.Contains(eui) {
+		p.lock.Lock()
+		ok := p.takeToken(time.Now())
+		p.lock.Unlock()
+		if !ok {
+			return p.reject(ErrPolicyRateLimited)
+		}
+		p.known.Add(eui, true)
+	}
+
+	return nil
+}
+
+// Returns the number of rejected datagrams per reason
+func (p *GatewayPolicy) RejectedCounts() map[string]uint64 {
+	ret := make(map[string]uint64)
+	if p == nil {
+		return ret
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for k, v := range p.rejected {
+		ret[k] = v
+	}
+	return ret
+}
+
+// Returns the number of datagrams rejected since the last call
+func (p *GatewayPolicy) RejectedSinceLast() uint64 {
+	if p == nil {
+		return 0
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	var total uint64 = 0
+	for _, v := range p.rejected {
+		total += v
+	}
+	diff := total - p.lastRejected
+	p.lastRejected = total
+	return diff
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func policyPacket(t *testing.T, eui []byte) []byte {
+	d, err := base64.StdEncoding.DecodeString(PacketPullReq)
+	if err != nil {
+		t.Fatalf("Could not decode constant: %s", err.Error())
+	}
+	if eui != nil {
+		copy(d[4:12], eui)
+	}
+	return d
+}
+
+func createTestPolicy(t *testing.T, allow, deny, sourceAllow, sourceDeny, pins string, rate int) *GatewayPolicy {
+	config, err := ParseGatewayPolicyConfig(allow, deny, sourceAllow, sourceDeny, pins, rate)
+	if err != nil {
+		t.Fatalf("Could not parse policy: %s", err.Error())
+	}
+	policy, err := CreateGatewayPolicy(config)
+	if err != nil {
+		t.Fatalf("Could not create policy: %s", err.Error())
+	}
+	return policy
+}
+
+func TestGatewayPolicyParsing(t *testing.T) {
+	config, err := ParseGatewayPolicyConfig(
+		"7076ff00560603e5, 00:16:c0:01:ff:10:a2:35",
+		"eui-0102030405060708",
+		"10.0.0.0/8, 192.168.1.1",
+		"fd00::/8",
+		"7076ff00560603e5@10.1.1.1",
+		5,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"7076ff00560603e5", "0016c001ff10a235"}, config.AllowGateways)
+	assert.Equal(t, []string{"0102030405060708"}, config.DenyGateways)
+	assert.Len(t, config.AllowSources, 2)
+	assert.Equal(t, "192.168.1.1/32", config.AllowSources[1].String())
+	assert.Len(t, config.DenySources, 1)
+	assert.Len(t, config.PinnedSources["7076ff00560603e5"], 1)
+	assert.True(t, config.HasRules())
+
+	empty, err := ParseGatewayPolicyConfig("", "", "", "", "", 0)
+	assert.NoError(t, err)
+	assert.False(t, empty.HasRules())
+
+	_, err = ParseGatewayPolicyConfig("7076ff", "", "", "", "", 0)
+	assert.Error(t, err)
+	_, err = ParseGatewayPolicyConfig("", "", "10.0.0.0/33", "", "", 0)
+	assert.Error(t, err)
+	_, err = ParseGatewayPolicyConfig("", "", "", "", "7076ff00560603e5", 0)
+	assert.Error(t, err)
+}
+
+func TestGatewayPolicyRules(t *testing.T) {
+	allowed := []byte{0x70, 0x76, 0xff, 0x00, 0x56, 0x06, 0x03, 0xe5}
+	denied := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+	other := []byte{0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18}
+	addr := &net.UDPAddr{IP: net.ParseIP("10.1.1.1"), Port: 1700}
+	outside := &net.UDPAddr{IP: net.ParseIP("172.16.0.1"), Port: 1700}
+
+	// A nil policy accepts everything
+	var none *GatewayPolicy
+	assert.NoError(t, none.Check([]byte{0x00}, addr))
+
+	policy := createTestPolicy(t, "7076ff00560603e5", "0102030405060708", "10.0.0.0/8", "10.9.0.0/16", "", 0)
+	assert.NoError(t, policy.Check(policyPacket(t, allowed), addr))
+	assert.ErrorIs(t, policy.Check(policyPacket(t, denied), addr), ErrPolicyDeniedGateway)
+	assert.ErrorIs(t, policy.Check(policyPacket(t, other), addr), ErrPolicyUnlistedGateway)
+	assert.ErrorIs(t, policy.Check(policyPacket(t, allowed), outside), ErrPolicyUnlistedSource)
+	assert.ErrorIs(t, policy.Check(policyPacket(t, allowed), &net.UDPAddr{IP: net.ParseIP("10.9.1.1")}), ErrPolicyDeniedSource)
+	assert.ErrorIs(t, policy.Check([]byte{0x02, 0x00, 0x00, 0x00}, addr), ErrPolicyNoGateway)
+
+	assert.Equal(t, map[string]uint64{
+		"denied-gateway":   1,
+		"unlisted-gateway": 1,
+		"unlisted-source":  1,
+		"denied-source":    1,
+		"no-gateway":       1,
+	}, policy.RejectedCounts())
+	assert.Equal(t, uint64(5), policy.RejectedSinceLast())
+	assert.Equal(t, uint64(0), policy.RejectedSinceLast())
+}
+
+func TestGatewayPolicyPinning(t *testing.T) {
+	eui := []byte{0x70, 0x76, 0xff, 0x00, 0x56, 0x06, 0x03, 0xe5}
+	policy := createTestPolicy(t, "", "", "", "", "7076ff00560603e5@10.1.1.1", 0)
+
+	assert.NoError(t, policy.Check(policyPacket(t, eui), &net.UDPAddr{IP: net.ParseIP("10.1.1.1")}))
+	assert.ErrorIs(t, policy.Check(policyPacket(t, eui), &net.UDPAddr{IP: net.ParseIP("10.1.1.2")}), ErrPolicyPinnedAddress)
+
+	// Other gateways are not affected by the pin
+	assert.NoError(t, policy.Check(policyPacket(t, []byte{1, 2, 3, 4, 5, 6, 7, 8}), &net.UDPAddr{IP: net.ParseIP("10.1.1.2")}))
+}
+
+func TestGatewayPolicyRateLimit(t *testing.T) {
+	addr := &net.UDPAddr{IP: net.ParseIP("10.1.1.1"), Port: 1700}
+	policy := createTestPolicy(t, "", "", "", "", "", 2)
+
+	gw1 := policyPacket(t, []byte{1, 1, 1, 1, 1, 1, 1, 1})
+	gw2 := policyPacket(t, []byte{2, 2, 2, 2, 2, 2, 2, 2})
+	gw3 := policyPacket(t, []byte{3, 3, 3, 3, 3, 3, 3, 3})
+
+	assert.NoError(t, policy.Check(gw1, addr))
+	assert.NoError(t, policy.Check(gw2, addr))
+	assert.ErrorIs(t, policy.Check(gw3, addr), ErrPolicyRateLimited)
+
+	// Already admitted gateways are not limited
+	assert.NoError(t, policy.Check(gw1, addr))
+	assert.NoError(t, policy.Check(gw2, addr))
+}
+
+func TestGatewayPolicyOnProxy(t *testing.T) {
+	client1 := CreateSocket(t)
+	server := CreateSocket(t)
+
+	bufs := &BufHandler{}
+	proxy, _ := CreateUDPProxy(&UDPProxyConfig{
+		UpListenAddr:        client1.remote,
+		UpConnectAddr:       server.local,
+		UpConnectBindAddr:   nil,
+		DownListenAddr:      nil,
+		DownConnectAddr:     nil,
+		DownConnectBindAddr: nil,
+		BufferSize:          1024,
+		SocketStreams:       16,
+		ReconnectInterval:   1,
+		Events:              bufs,
+		Policy:              createTestPolicy(t, "7076ff00560603e5", "", "", "", "", 0),
+	})
+
+	// Rejected datagrams are neither forwarded nor recorded
+	rejected := policyPacket(t, []byte{1, 2, 3, 4, 5, 6, 7, 8})
+	client1.Send(rejected)
+
+	// Allowed datagrams go through
+	allowed := policyPacket(t, nil)
+	client1.Send(allowed)
+	expectToReceive(t, server, allowed)
+	assert.Eventually(t, func() bool { return bytes.Equal(allowed, bufs.upLocal()) }, time.Second, time.Millisecond)
+	assert.Equal(t, uint64(1), proxy.RejectedPackets())
+
+	proxy.Close()
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/kudzutechnologies/analytics/api"
+)
+
+// The mean radius of the earth (in meters)
+const earthRadius = 6371008.8
+
+// A position of a gateway
+type GatewayPosition struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  float32
+}
+
+// Returns false for the positions that packet forwarders report when they have
+// no GPS fix: missing coordinates, the 0,0 "null island" and out-of-range values
+func (p GatewayPosition) IsValid() bool {
+	if math.IsNaN(p.Latitude) || math.IsNaN(p.Longitude) {
+		return false
+	}
+	if p.Latitude == 0 && p.Longitude == 0 {
+		return false
+	}
+	return p.Latitude >= -90 && p.Latitude <= 90 && p.Longitude >= -180 && p.Longitude <= 180
+}
+
+// The great-circle distance to another position (in meters), ignoring the altitude
+func (p GatewayPosition) DistanceTo(other GatewayPosition) float64 {
+	lat1 := p.Latitude * math.Pi / 180
+	lat2 := other.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (other.Longitude - p.Longitude) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Parses the fixed gateway positions as found in the configuration file, in
+// the form <eui>=<lat>:<lon>[:<alt>],...
+func parseGatewayLocations(value string) (map[string]GatewayPosition, error) {
+	ret := make(map[string]GatewayPosition)
+	for _, item := range splitList(value) {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid gateway location '%s', expecting <eui>=<lat>:<lon>[:<alt>]", item)
+		}
+		eui, err := parseGatewayEUI(parts[0])
+		if err != nil {
+			return nil, err
+		}
+
+		coords := strings.Split(parts[1], ":")
+		if len(coords) < 2 || len(coords) > 3 {
+			return nil, fmt.Errorf("invalid gateway location '%s', expecting <eui>=<lat>:<lon>[:<alt>]", item)
+		}
+		var pos GatewayPosition
+		if pos.Latitude, err = strconv.ParseFloat(strings.TrimSpace(coords[0]), 64); err != nil {
+			return nil, fmt.Errorf("invalid latitude in '%s'", item)
+		}
+		if pos.Longitude, err = strconv.ParseFloat(strings.TrimSpace(coords[1]), 64); err != nil {
+			return nil, fmt.Errorf("invalid longitude in '%s'", item)
+		}
+		if len(coords) == 3 {
+			alt, err := strconv.ParseFloat(strings.TrimSpace(coords[2]), 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid altitude in '%s'", item)
+			}
+			pos.Altitude = float32(alt)
+		}
+		if !pos.IsValid() {
+			return nil, fmt.Errorf("gateway location out of range in '%s'", item)
+		}
+		ret[eui] = pos
+	}
+	return ret, nil
+}
+
+// The last known position of a gateway
+type gatewayPositionState struct {
+	lock     sync.Mutex
+	position GatewayPosition
+	known    bool
+	locked   bool
+}
+
+// Keeps the last known position of every gateway, and detects when they move
+// or lose their GPS lock
+type gatewayPositions struct {
+	moveDistance float64
+	fixed        map[string]GatewayPosition
+	states       *lru.Cache[string, *gatewayPositionState]
+}
+
+func newGatewayPositions(size int, moveDistance float64, fixed map[string]GatewayPosition) *gatewayPositions {
+	inst := &gatewayPositions{
+		moveDistance: moveDistance,
+		fixed:        fixed,
+	}
+	inst.states, _ = lru.New[string, *gatewayPositionState](size)
+	return inst
+}
+
+func (g *gatewayPositions) getState(key string) *gatewayPositionState {
+	if found, ok := g.states.Get(key); ok {
+		return found
+	}
+
+	// Another thread might have created the state in the meantime
+	state := &gatewayPositionState{}
+	if found, ok, _ := g.states.PeekOrAdd(key, state); ok {
+		return found
+	}
+	return state
+}
+
+// Returns the position configured for a gateway without GPS
+func (g *gatewayPositions) Fixed(eui []byte) (GatewayPosition, bool) {
+	pos, ok := g.fixed[fmt.Sprintf("%x", eui)]
+	return pos, ok
+}
+
+// Updates the position of the gateway from a status report, and returns the
+// events it caused (if any)
+func (g *gatewayPositions) Update(eui []byte, pos GatewayPosition, now int64) []*api.AnalyticsGatewayEvent {
+	state := g.getState(string(eui))
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	newEvent := func(kind api.GatewayEventType, pos GatewayPosition) *api.AnalyticsGatewayEvent {
+		return &api.AnalyticsGatewayEvent{
+			Time:      now,
+			Type:      kind,
+			Latitude:  pos.Latitude,
+			Longitude: pos.Longitude,
+			Altitude:  pos.Altitude,
+		}
+	}
+
+	if !pos.IsValid() {
+		if state.locked {
+			state.locked = false
+			return []*api.AnalyticsGatewayEvent{newEvent(api.GatewayEventType_GPS_LOCK_LOST, state.position)}
+		}
+		return nil
+	}
+
+	var events []*api.AnalyticsGatewayEvent
+	if !state.known {
+		events = append(events, newEvent(api.GatewayEventType_POSITION_ACQUIRED, pos))
+		state.position = pos
+		state.known = true
+	} else {
+		if !state.locked {
+			events = append(events, newEvent(api.GatewayEventType_GPS_LOCK_RESTORED, pos))
+		}
+
+		// Keep the previous position as reference, so that the GPS jitter does
+		// not add up to a movement
+		if dist := state.position.DistanceTo(pos); dist > g.moveDistance {
+			event := newEvent(api.GatewayEventType_POSITION_MOVED, pos)
+			event.PreviousLatitude = state.position.Latitude
+			event.PreviousLongitude = state.position.Longitude
+			event.Distance = float32(dist)
+			events = append(events, event)
+			state.position = pos
+		}
+	}
+	state.locked = true
+	return events
+}
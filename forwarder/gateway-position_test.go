@@ -0,0 +1,139 @@
+package main
+
+import (
+	"math"
+	"net"
+	"testing"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGatewayPositionValid(t *testing.T) {
+	assert.True(t, GatewayPosition{Latitude: 46.24, Longitude: 3.25}.IsValid())
+	assert.True(t, GatewayPosition{Latitude: 0, Longitude: 3.25}.IsValid())
+	assert.False(t, GatewayPosition{}.IsValid())
+	assert.False(t, GatewayPosition{Altitude: 120}.IsValid())
+	assert.False(t, GatewayPosition{Latitude: 91, Longitude: 3}.IsValid())
+	assert.False(t, GatewayPosition{Latitude: 46, Longitude: -181}.IsValid())
+	assert.False(t, GatewayPosition{Latitude: math.NaN(), Longitude: 3}.IsValid())
+}
+
+func TestGatewayPositionDistance(t *testing.T) {
+	paris := GatewayPosition{Latitude: 48.8566, Longitude: 2.3522}
+	london := GatewayPosition{Latitude: 51.5074, Longitude: -0.1278}
+	assert.InDelta(t, 343500, paris.DistanceTo(london), 1000)
+	assert.InDelta(t, 343500, london.DistanceTo(paris), 1000)
+	assert.Equal(t, float64(0), paris.DistanceTo(paris))
+
+	// About 111 meters per 0.001 degrees of latitude
+	assert.InDelta(t, 111, paris.DistanceTo(GatewayPosition{Latitude: 48.8576, Longitude: 2.3522}), 1)
+}
+
+func TestParseGatewayLocations(t *testing.T) {
+	locations, err := parseGatewayLocations("7076ff00560603e5=46.2412:3.2523:145, eui-0016C001FF10A235=-33.9:18.4")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]GatewayPosition{
+		"7076ff00560603e5": {Latitude: 46.2412, Longitude: 3.2523, Altitude: 145},
+		"0016c001ff10a235": {Latitude: -33.9, Longitude: 18.4},
+	}, locations)
+
+	locations, err = parseGatewayLocations("")
+	assert.NoError(t, err)
+	assert.Len(t, locations, 0)
+
+	for _, invalid := range []string{
+		"7076ff00560603e5",
+		"7076ff00560603e5=46.2",
+		"7076ff00560603e5=46.2:3:1:2",
+		"7076ff00560603e5=north:3",
+		"7076ff00560603e5=46:3:high",
+		"7076ff00560603e5=0:0",
+		"7076ff=46:3",
+	} {
+		_, err = parseGatewayLocations(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestGatewayPositionEvents(t *testing.T) {
+	positions := newGatewayPositions(4, 100, nil)
+	eui := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	home := GatewayPosition{Latitude: 46.2412, Longitude: 3.2523, Altitude: 145}
+	types := func(events []*api.AnalyticsGatewayEvent) []api.GatewayEventType {
+		var ret []api.GatewayEventType
+		for _, e := range events {
+			ret = append(ret, e.Type)
+		}
+		return ret
+	}
+
+	// Nothing happens until the first fix
+	assert.Len(t, positions.Update(eui, GatewayPosition{}, 1), 0)
+	events := positions.Update(eui, home, 2)
+	assert.Equal(t, []api.GatewayEventType{api.GatewayEventType_POSITION_ACQUIRED}, types(events))
+	assert.Equal(t, int64(2), events[0].Time)
+	assert.Equal(t, home.Latitude, events[0].Latitude)
+
+	// The GPS jitter does not add up to a movement
+	for i := 1; i <= 10; i++ {
+		jitter := home
+		jitter.Latitude += float64(i) * 0.00005
+		assert.Len(t, positions.Update(eui, jitter, 3), 0)
+	}
+
+	// Losing and restoring the GPS lock
+	events = positions.Update(eui, GatewayPosition{}, 4)
+	assert.Equal(t, []api.GatewayEventType{api.GatewayEventType_GPS_LOCK_LOST}, types(events))
+	assert.Equal(t, home.Longitude, events[0].Longitude)
+	assert.Len(t, positions.Update(eui, GatewayPosition{Latitude: 100, Longitude: 100}, 5), 0)
+	events = positions.Update(eui, home, 6)
+	assert.Equal(t, []api.GatewayEventType{api.GatewayEventType_GPS_LOCK_RESTORED}, types(events))
+
+	// Moving further than the threshold
+	moved := GatewayPosition{Latitude: 46.2512, Longitude: 3.2523}
+	events = positions.Update(eui, moved, 7)
+	assert.Equal(t, []api.GatewayEventType{api.GatewayEventType_POSITION_MOVED}, types(events))
+	assert.Equal(t, home.Latitude, events[0].PreviousLatitude)
+	assert.Equal(t, moved.Latitude, events[0].Latitude)
+	assert.InDelta(t, 1112, events[0].Distance, 5)
+	assert.Len(t, positions.Update(eui, moved, 8), 0)
+
+	// Other gateways are tracked independently
+	events = positions.Update([]byte{8, 7, 6, 5, 4, 3, 2, 1}, home, 9)
+	assert.Equal(t, []api.GatewayEventType{api.GatewayEventType_POSITION_ACQUIRED}, types(events))
+}
+
+func TestForwarderGatewayPosition(t *testing.T) {
+	pusher := &mockPusher{}
+	config := defaultConf
+	config.MaxUDPStreams = 16
+	config.GatewayLocation = "0102030405060708=46.2412:3.2523:145"
+	fw := CreateAnalyticsForwarder(config, pusher, nil)
+
+	addr := &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1700}
+	send := func(eui []byte, stat string) {
+		msg := SemtechUDPMessage{Version: PROTOCOL_VERSION, Kind: PUSH_DATA, Data: append(append([]byte(nil), eui...), `{"stat":`+stat+`}`...)}
+		fw.UpLocalData(msg.Encode(), addr)
+	}
+
+	// The configured position replaces the missing one
+	send([]byte{1, 2, 3, 4, 5, 6, 7, 8}, `{"time":"2023-03-01 10:12:44 GMT","rxnb":1}`)
+	fw.flushData()
+	assert.Len(t, pusher.frames, 1)
+	stat := pusher.frames[0].Stats[0]
+	assert.Equal(t, 46.2412, stat.GwLatitudePrecise)
+	assert.Equal(t, 3.2523, stat.GwLongitudePrecise)
+	assert.Equal(t, float32(145), stat.GwAltitude)
+	assert.Len(t, pusher.frames[0].Events, 0)
+
+	// A gateway with GPS reports events
+	send([]byte{8, 7, 6, 5, 4, 3, 2, 1}, `{"lati":46.2412,"long":3.2523,"alti":145,"rxnb":1}`)
+	send([]byte{8, 7, 6, 5, 4, 3, 2, 1}, `{"lati":0,"long":0,"alti":0,"rxnb":1}`)
+	fw.flushData()
+	assert.Len(t, pusher.frames, 2)
+	events := pusher.frames[1].Events
+	assert.Len(t, events, 2)
+	assert.Equal(t, api.GatewayEventType_POSITION_ACQUIRED, events[0].Type)
+	assert.Equal(t, api.GatewayEventType_GPS_LOCK_LOST, events[1].Type)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoRaWANHeaderLen(t *testing.T) {
+	// Unconfirmed data up with FPort, from a real capture
+	up, _ := base64.StdEncoding.DecodeString("QKydCyYAQwMB7iu5QD6sH5u1C+Yh")
+	assert.Equal(t, 9, GetLoRaWANHeaderLen(up))
+
+	// Unconfirmed data down with 2 bytes of FOpts
+	dn := []byte{0x60, 1, 2, 3, 4, 0x02, 0, 0, 0xaa, 0xbb, 1, 9, 9, 9, 9}
+	assert.Equal(t, 11, GetLoRaWANHeaderLen(dn))
+
+	// Join request and proprietary frames
+	assert.Equal(t, 19, GetLoRaWANHeaderLen(make([]byte, 23)))
+	assert.Equal(t, 5, GetLoRaWANHeaderLen([]byte{0xe0, 1, 2, 3, 4}))
+
+	// Truncated frames are collected as a whole
+	assert.Equal(t, 0, GetLoRaWANHeaderLen(nil))
+	assert.Equal(t, 6, GetLoRaWANHeaderLen(dn[0:6]))
+	assert.Equal(t, 9, GetLoRaWANHeaderLen([]byte{0x40, 1, 2, 3, 4, 0x0f, 0, 0, 0}))
+}
+
+func FuzzGetLoRaWANHeaderLen(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		n := GetLoRaWANHeaderLen(data)
+		if n < 0 || n > len(data) {
+			t.Fatalf("Header length %d out of bounds (frame is %d bytes)", n, len(data))
+		}
+
+		// The header of data frames includes the FOpts and the FPort
+		mtype := byte(0)
+		if len(data) > 0 {
+			mtype = data[0] >> 5
+		}
+		if len(data) >= 8 && mtype >= MTYpeUnconfirmedUp && mtype <= MTypeConfirmedDown {
+			expected := 8 + int(data[5]&0x0f) + 1
+			if expected <= len(data) && n != expected {
+				t.Fatalf("Expected header length %d, got %d", expected, n)
+			}
+		}
+	})
+}
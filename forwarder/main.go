@@ -1,28 +1,37 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"net"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/kudzutechnologies/analytics/client"
 	log "github.com/sirupsen/logrus"
 )
 
 func main() {
+	// Replay captured traffic instead of running the proxy
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		runReplay(os.Args[2:])
+		return
+	}
+
+	// Print the live traffic of the service instead of running the proxy
+	if len(os.Args) > 1 && os.Args[1] == "tail" {
+		runTail(os.Args[2:])
+		return
+	}
+
 	// Parse configuration from environment
 	config := ParseConfigFromEnv()
 
 	// Connect to the analytics endpoint
-	client := client.CreateAnalyticsClient(client.AnalyticsClientConfig{
-		ClientId:            config.ClientId,
-		ClientKey:           config.ClientKey,
-		Endpoint:            config.Endpoint,
-		ConnectTimeout:      int32(config.ConnectTimeout),
-		RequestTimeout:      int32(config.RequestTimeout),
-		MaxReconnectBackoff: int32(config.MaxReconnectBackoff),
-		ServerSide:          &config.ServerSide,
-	})
+	client := client.CreateAnalyticsClient(analyticsClientConfig(&config))
 
 	// Create the UDP proxy
 	proxy, err := CreateUDPProxy(CreateUDPProxyConfig(config))
@@ -30,62 +39,173 @@ func main() {
 		log.Fatalf("Could not start forwarder: %s", err.Error())
 	}
 
+	// Close the proxy on exit, so the traffic dump is flushed to disk
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		log.Infof("Shutting down")
+		proxy.Close()
+		os.Exit(0)
+	}()
+
 	// Try to connect to the analytics endpoint
 	fw := CreateAnalyticsForwarder(config, client, proxy)
+
+	// Accept configuration changes and commands from the analytics service
+	if config.RemoteControl {
+		go createRemoteControl(config, fw, configFilePath()).run(context.Background())
+	}
 	fw.StartAndWait()
 }
 
 func parseEndpoint(name string, host string, port int) *net.UDPAddr {
-	log.Debugf("Using %s endpoint: %s:%d", name, host, port)
-	ep, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", host, port))
+	// Accept IPv6 literals both with and without brackets
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	hostPort := net.JoinHostPort(host, strconv.Itoa(port))
+
+	log.Debugf("Using %s endpoint: %s", name, hostPort)
+	ep, err := net.ResolveUDPAddr("udp", hostPort)
 	if err != nil {
-		log.Fatalf("Invalid %s endpoint: %s:%d: %s", name, host, port, err.Error())
+		log.Fatalf("Invalid %s endpoint: %s: %s", name, hostPort, err.Error())
 	}
 	return ep
 }
 
+// Resolves the local address to bind to when connecting to the remote endpoint.
+// The interface can either be an IP address or the name of a network interface,
+// in which case the first address matching the family of the remote is used.
+func parseBindAddr(name string, iface string, remote *net.UDPAddr) *net.UDPAddr {
+	if iface == "" {
+		return nil
+	}
+
+	wantV4 := remote.IP.To4() != nil
+	iface = strings.TrimSuffix(strings.TrimPrefix(iface, "["), "]")
+	if ip := net.ParseIP(iface); ip != nil {
+		if ip.IsUnspecified() {
+			// Any address of the remote family, same as not binding at all
+			return nil
+		}
+		if (ip.To4() != nil) != wantV4 {
+			log.Fatalf("Invalid %s address %s: does not match the family of %s", name, iface, remote.String())
+		}
+		return &net.UDPAddr{IP: ip}
+	}
+
+	netIf, err := net.InterfaceByName(iface)
+	if err != nil {
+		log.Fatalf("Invalid %s interface %s: %s", name, iface, err.Error())
+	}
+	addrs, err := netIf.Addrs()
+	if err != nil {
+		log.Fatalf("Could not list addresses of %s: %s", iface, err.Error())
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || (ipNet.IP.To4() != nil) != wantV4 {
+			continue
+		}
+		// Link-local IPv6 addresses are only usable with their zone
+		zone := ""
+		if ipNet.IP.IsLinkLocalUnicast() {
+			zone = netIf.Name
+		}
+		log.Debugf("Using %s address: %s (%s)", name, ipNet.IP.String(), iface)
+		return &net.UDPAddr{IP: ipNet.IP, Zone: zone}
+	}
+
+	log.Fatalf("Interface %s has no address usable for connecting to %s", iface, remote.String())
+	return nil
+}
+
+func parseGatewayPolicy(config ForwarderConfig) *GatewayPolicy {
+	policy, err := createGatewayPolicy(config)
+	if err != nil {
+		log.Fatalf("Invalid gateway policy: %s", err.Error())
+	}
+	if policy != nil {
+		log.Infof("Enforcing gateway policy on incoming datagrams")
+	}
+	return policy
+}
+
+// Creates the gateway policy of the configuration, or nil if it has no rules
+func createGatewayPolicy(config ForwarderConfig) (*GatewayPolicy, error) {
+	policyConfig, err := ParseGatewayPolicyConfig(config.GatewayAllow, config.GatewayDeny,
+		config.SourceAllow, config.SourceDeny, config.GatewayPin, config.UnknownGatewayRate)
+	if err != nil {
+		return nil, err
+	}
+	if !policyConfig.HasRules() {
+		return nil, nil
+	}
+
+	policyConfig.MaxGateways = config.MaxUDPStreams * 4
+	return CreateGatewayPolicy(policyConfig)
+}
+
+// Creates the writer of the traffic dump to the given file, with the dump
+// options of the configuration
+func createConfigDumpWriter(config ForwarderConfig, filename string) (DumpWriter, error) {
+	return CreateDumpWriter(&DumpWriterConfig{
+		Filename:       filename,
+		Format:         config.DumpFormat,
+		MaxSize:        int64(config.DumpMaxSize) * 1024 * 1024,
+		RotateInterval: time.Second * time.Duration(config.DumpRotateInterval),
+		MaxFiles:       config.DumpMaxFiles,
+	})
+}
+
 func CreateUDPProxyConfig(config ForwarderConfig) *UDPProxyConfig {
 	var err error
 	var dnListen *net.UDPAddr = nil
 	var dnConnect *net.UDPAddr = nil
-	var dmpFile *os.File = nil
+	var dmpWriter DumpWriter = nil
 
 	upListen := parseEndpoint("local uplink", config.ListenHost, config.ListenPortUp)
 	if config.ListenPortDown != config.ListenPortUp {
 		dnListen = parseEndpoint("local downlink", config.ListenHost, config.ListenPortDown)
 	} else {
-		log.Debugf("Using same endpoint for downlink: %s:%d", config.ListenHost, config.ListenPortUp)
+		log.Debugf("Using same endpoint for downlink: %s", upListen.String())
 	}
 
 	upConnect := parseEndpoint("remote uplink", config.ConnectHost, config.ConnectPortUp)
-	if config.ListenPortDown != config.ListenPortUp {
-		dnListen = parseEndpoint("remote downlink", config.ConnectHost, config.ConnectPortDown)
+	if config.ConnectPortDown != config.ConnectPortUp {
+		dnConnect = parseEndpoint("remote downlink", config.ConnectHost, config.ConnectPortDown)
 	} else {
-		log.Debugf("Using same endpoint for downlink: %s:%d", config.ConnectHost, config.ConnectPortUp)
+		log.Debugf("Using same endpoint for downlink: %s", upConnect.String())
+		dnConnect = upConnect
 	}
 
-	bindAddr := parseEndpoint("remote bind", config.ConnectInterface, 0)
+	upBindAddr := parseBindAddr("remote uplink bind", config.ConnectInterface, upConnect)
+	dnBindAddr := parseBindAddr("remote downlink bind", config.ConnectInterface, dnConnect)
+	policy := parseGatewayPolicy(config)
 
 	if config.DebugDump != "" {
-		dmpFile, err = os.OpenFile(config.DebugDump, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+		dmpWriter, err = createConfigDumpWriter(config, config.DebugDump)
 		if err != nil {
 			log.Warnf("Could not open %s: %s", config.DebugDump, err.Error())
 		} else {
-			log.Infof("Writing all traffic to %s", config.DebugDump)
+			log.Infof("Writing all traffic to %s (%s)", config.DebugDump, config.DumpFormat)
 		}
 	}
 
 	ret := &UDPProxyConfig{
 		UpListenAddr:        upListen,
 		UpConnectAddr:       upConnect,
-		UpConnectBindAddr:   bindAddr,
+		UpConnectBindAddr:   upBindAddr,
 		DownListenAddr:      dnListen,
 		DownConnectAddr:     dnConnect,
-		DownConnectBindAddr: bindAddr,
+		DownConnectBindAddr: dnBindAddr,
 		BufferSize:          config.BufferSize,
 		SocketStreams:       config.MaxUDPStreams,
 		ReconnectInterval:   config.RequestTimeout,
-		DumpFile:            dmpFile,
+		DumpWriter:          dmpWriter,
+		Policy:              policy,
+		QueueSize:           config.QueueSize,
+		BatchSize:           config.BatchSize,
+		Workers:             config.SocketWorkers,
 	}
 
 	return ret
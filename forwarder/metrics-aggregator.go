@@ -0,0 +1,167 @@
+package main
+
+import (
+	"net"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/kudzutechnologies/analytics/api"
+)
+
+// The metrics frame of a single gateway, guarded by its own lock so that
+// different gateways can be updated in parallel
+type metricsShard struct {
+	lock    sync.Mutex
+	frame   *api.AnalyticsMetrics
+	evicted bool
+}
+
+// Collects the metrics frames of all gateways, from any number of threads
+type metricsAggregator struct {
+	serverSide bool
+	shards     *lru.Cache[string, *metricsShard]
+	// The frames of the evicted gateways, kept until the next swap so that
+	// they are pushed with the others instead of by the receiving threads
+	evictLock sync.Mutex
+	evicted   []*api.AnalyticsMetrics
+}
+
+func newMetricsAggregator(size int, serverSide bool) *metricsAggregator {
+	inst := &metricsAggregator{
+		serverSide: serverSide,
+	}
+	inst.shards, _ = lru.NewWithEvict(size, inst.handleEvict)
+	return inst
+}
+
+func (a *metricsAggregator) handleEvict(key string, shard *metricsShard) {
+	shard.lock.Lock()
+	frame := shard.frame
+	shard.frame = nil
+	shard.evicted = true
+	shard.lock.Unlock()
+
+	if frame != nil {
+		a.evictLock.Lock()
+		a.evicted = append(a.evicted, frame)
+		a.evictLock.Unlock()
+	}
+}
+
+func (a *metricsAggregator) newFrame(localEp *net.UDPAddr) *api.AnalyticsMetrics {
+	frame := &api.AnalyticsMetrics{}
+
+	// Include stats only on the server-side
+	if a.serverSide {
+		frame.Metrics = &api.AnalyticsInternalMetrics{
+			GatewayIp: localEp.String(),
+		}
+	}
+	return frame
+}
+
+func (a *metricsAggregator) getShard(localEp *net.UDPAddr) *metricsShard {
+	key := localEp.IP.String()
+	if found, ok := a.shards.Get(key); ok {
+		return found
+	}
+
+	// Another thread might have created the shard in the meantime
+	shard := &metricsShard{frame: a.newFrame(localEp)}
+	if found, ok, _ := a.shards.PeekOrAdd(key, shard); ok {
+		return found
+	}
+	return shard
+}
+
+// Calls `fn` with the frame of the gateway at the given endpoint, while holding
+// the lock of that gateway
+func (a *metricsAggregator) Update(localEp *net.UDPAddr, fn func(frame *api.AnalyticsMetrics)) {
+	for {
+		shard := a.getShard(localEp)
+		shard.lock.Lock()
+		if shard.evicted {
+			// The shard was flushed while we were waiting, use a new one
+			shard.lock.Unlock()
+			continue
+		}
+		fn(shard.frame)
+		shard.lock.Unlock()
+		return
+	}
+}
+
+// Replaces the frames of all gateways with empty ones, returning the previous
+// frames and the ones of the evicted gateways. Each frame is swapped atomically,
+// so no update is lost.
+func (a *metricsAggregator) Swap() []*api.AnalyticsMetrics {
+	a.evictLock.Lock()
+	ret := a.evicted
+	a.evicted = nil
+	a.evictLock.Unlock()
+
+	for _, shard := range a.shards.Values() {
+		shard.lock.Lock()
+		if !shard.evicted {
+			frame := shard.frame
+			shard.frame = &api.AnalyticsMetrics{
+				GatewayId:  frame.GatewayId,
+				GatewayEui: frame.GatewayEui,
+			}
+			if frame.Metrics != nil {
+				shard.frame.Metrics = &api.AnalyticsInternalMetrics{
+					GatewayIp: frame.Metrics.GatewayIp,
+				}
+			}
+			ret = append(ret, frame)
+		}
+		shard.lock.Unlock()
+	}
+	return ret
+}
+
+// Returns the number of items waiting to be sent
+func (a *metricsAggregator) Pending() int {
+	var total int = 0
+	a.evictLock.Lock()
+	for _, frame := range a.evicted {
+		total += frameSize(frame)
+	}
+	a.evictLock.Unlock()
+
+	for _, shard := range a.shards.Values() {
+		shard.lock.Lock()
+		if !shard.evicted {
+			total += frameSize(shard.frame)
+		}
+		shard.lock.Unlock()
+	}
+	return total
+}
+
+// Returns the number of gateways being tracked
+func (a *metricsAggregator) Len() int {
+	return a.shards.Len()
+}
+
+// Returns the number of items in the frame, counting the metrics as one item
+func frameSize(f *api.AnalyticsMetrics) int {
+	sum := len(f.Uplinks) +
+		len(f.Downlinks) +
+		len(f.Stats) +
+		len(f.Events)
+	if f.Aggregate != nil {
+		sum += len(f.Aggregate.Uplinks) + len(f.Aggregate.Downlinks)
+	}
+
+	if f.Metrics != nil {
+		if f.Metrics.DnRxPackets > 0 || f.Metrics.DnTxPackets > 0 ||
+			f.Metrics.UpRxPackets > 0 || f.Metrics.UpTxPackets > 0 {
+			if sum == 0 {
+				sum++
+			}
+		}
+	}
+
+	return sum
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/client"
+)
+
+// The prefix of the pairing bundles that are encoded in QR codes
+const pairBundleQRPrefix = "KZPAIR1:"
+
+// The extended key usage of the certificates that sign the pairing bundles,
+// so that the other certificates of the root CA (eg. of the TLS servers)
+// can't provision a forwarder
+var pairBundleSignerUsage = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 62024, 1, 1}
+
+// A pairing bundle, which provisions a forwarder without reaching the
+// service. The payload is signed by a certificate that chains up to the
+// Kudzu root CA.
+type pairBundle struct {
+	// The encoded `pairBundlePayload` (base64)
+	Payload string `json:"payload"`
+	// The signature of the encoded payload (base64)
+	Signature string `json:"signature"`
+	// The DER-encoded certificates (base64), the signer first followed by
+	// any intermediates
+	Certificates []string `json:"certificates"`
+}
+
+// The signed contents of a pairing bundle
+type pairBundlePayload struct {
+	Config pairConfig `json:"config"`
+	// When the bundle was issued, and until when it can be used (unix seconds)
+	Issued  int64 `json:"issued"`
+	Expires int64 `json:"expires,omitempty"`
+}
+
+// Decodes a pairing bundle, either in its JSON form or as the text of a QR code
+func decodePairBundle(data []byte) (*pairBundle, error) {
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, pairBundleQRPrefix) {
+		raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(text, pairBundleQRPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid QR code: %w", err)
+		}
+		text = string(raw)
+	}
+
+	var bundle pairBundle
+	if err := json.Unmarshal([]byte(text), &bundle); err != nil {
+		return nil, fmt.Errorf("invalid pairing bundle: %w", err)
+	}
+	return &bundle, nil
+}
+
+// Returns the signature algorithm the bundles are signed with, for the
+// given type of key
+func pairBundleSignatureAlgorithm(key crypto.PublicKey) (x509.SignatureAlgorithm, error) {
+	switch key.(type) {
+	case *ecdsa.PublicKey:
+		return x509.ECDSAWithSHA256, nil
+	case *rsa.PublicKey:
+		return x509.SHA256WithRSA, nil
+	case ed25519.PublicKey:
+		return x509.PureEd25519, nil
+	}
+	return x509.UnknownSignatureAlgorithm, fmt.Errorf("unsupported key type %T", key)
+}
+
+// Verifies the signature of the bundle against the given root CAs, and
+// returns the pairing configuration it carries. The signer must be a
+// certificate dedicated to the pairing bundles (`pairBundleSignerUsage`).
+//
+// The bundle must be issued while its signer is valid. The certificates are
+// validated at the current time, unless the clock is before the start of
+// the signer's validity (the gateways being provisioned offline often have
+// no clock set), in which case they are validated at the time of issue and
+// the expiry of the bundle is not checked.
+func (b *pairBundle) verify(roots *x509.CertPool, now time.Time) (*pairConfig, error) {
+	if len(b.Certificates) == 0 {
+		return nil, fmt.Errorf("the bundle has no signer certificate")
+	}
+	var certs []*x509.Certificate
+	for i, encoded := range b.Certificates {
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate #%d: %w", i, err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate #%d: %w", i, err)
+		}
+		certs = append(certs, cert)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(b.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(b.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	signer := certs[0]
+	algorithm, err := pairBundleSignatureAlgorithm(signer.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := signer.CheckSignature(algorithm, payload, signature); err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	var contents pairBundlePayload
+	if err := json.Unmarshal(payload, &contents); err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+
+	issued := time.Unix(contents.Issued, 0)
+	if issued.Before(signer.NotBefore) || issued.After(signer.NotAfter) {
+		return nil, fmt.Errorf("the bundle was issued outside the validity of its signer")
+	}
+	clockSet := !now.Before(signer.NotBefore)
+	verifyTime := issued
+	if clockSet {
+		verifyTime = now
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = signer.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   verifyTime,
+		// The usage for the pairing bundles is checked below, since it's not
+		// one known to the x509 package
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("untrusted signer: %w", err)
+	}
+	if !hasExtKeyUsage(signer, pairBundleSignerUsage) {
+		return nil, fmt.Errorf("the signer is not allowed to sign pairing bundles")
+	}
+
+	if contents.Expires != 0 && clockSet && now.Unix() > contents.Expires {
+		return nil, fmt.Errorf("the bundle expired on %s", time.Unix(contents.Expires, 0).UTC().Format(time.RFC3339))
+	}
+	if contents.Config.ClientID == "" || contents.Config.ClientKey == "" {
+		return nil, fmt.Errorf("the bundle has no client credentials")
+	}
+
+	return &contents.Config, nil
+}
+
+// Returns true if the certificate has the given extended key usage
+func hasExtKeyUsage(cert *x509.Certificate, usage asn1.ObjectIdentifier) bool {
+	for _, oid := range cert.UnknownExtKeyUsage {
+		if oid.Equal(usage) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reads a pairing bundle from a file, from stdin (`-`) or from the text of
+// a QR code given as-is
+func readPairBundle(source string, stdin io.Reader) ([]byte, error) {
+	if strings.HasPrefix(source, pairBundleQRPrefix) {
+		return []byte(source), nil
+	}
+	if source == "-" {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(source)
+}
+
+// Verifies the given pairing bundle against the root CA of the client, and
+// returns the options to write to the configuration file like
+// `getPairConfigOptions`
+func getBundleConfigOptions(source string, stdin io.Reader, config ForwarderConfig) (map[string]string, error) {
+	data, err := readPairBundle(source, stdin)
+	if err != nil {
+		return nil, fmt.Errorf("could not read pairing bundle: %w", err)
+	}
+	bundle, err := decodePairBundle(data)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := client.RootCAs()
+	if err != nil {
+		return nil, err
+	}
+	pairConfig, err := bundle.verify(roots, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return pairConfigOptions(pairConfig, config), nil
+}
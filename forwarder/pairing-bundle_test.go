@@ -0,0 +1,262 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testSigner struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// Creates a certificate valid between the given times, signed by the parent
+// (or self-signed when there is none). The ones that are not CAs are allowed
+// to sign pairing bundles.
+func createTestSigner(t *testing.T, name string, parent *testSigner, isCA bool, notBefore, notAfter time.Time) *testSigner {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if !isCA {
+		template.UnknownExtKeyUsage = []asn1.ObjectIdentifier{pairBundleSignerUsage}
+	}
+	return createTestCert(t, template, parent)
+}
+
+// Creates a certificate from the template, signed by the parent (or
+// self-signed when there is none)
+func createTestCert(t *testing.T, template *x509.Certificate, parent *testSigner) *testSigner {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	issuer, signKey := template, crypto.Signer(key)
+	if parent != nil {
+		issuer, signKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, key.Public(), signKey)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+
+	return &testSigner{cert: cert, key: key}
+}
+
+func signTestBundle(t *testing.T, signer *testSigner, payload pairBundlePayload) *pairBundle {
+	encoded, err := json.Marshal(payload)
+	assert.Nil(t, err)
+	digest := sha256.Sum256(encoded)
+	signature, err := ecdsa.SignASN1(rand.Reader, signer.key, digest[:])
+	assert.Nil(t, err)
+
+	return &pairBundle{
+		Payload:      base64.StdEncoding.EncodeToString(encoded),
+		Signature:    base64.StdEncoding.EncodeToString(signature),
+		Certificates: []string{base64.StdEncoding.EncodeToString(signer.cert.Raw)},
+	}
+}
+
+func testPairBundlePayload(issued time.Time) pairBundlePayload {
+	return pairBundlePayload{
+		Config: pairConfig{
+			GatewayID: "gw-1",
+			ClientID:  "client",
+			ClientKey: "secret",
+			Extras:    map[string]interface{}{"connect-host": "lns.example.com"},
+		},
+		Issued: issued.Unix(),
+	}
+}
+
+func TestPairBundleVerify(t *testing.T) {
+	issued := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	root := createTestSigner(t, "root", nil, true, issued.AddDate(-1, 0, 0), issued.AddDate(10, 0, 0))
+	signer := createTestSigner(t, "pairing", root, false, issued.AddDate(0, -1, 0), issued.AddDate(0, 1, 0))
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+
+	bundle := signTestBundle(t, signer, testPairBundlePayload(issued))
+	config, err := bundle.verify(roots, issued.Add(time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, "gw-1", config.GatewayID)
+	assert.Equal(t, "client", config.ClientID)
+	assert.Equal(t, "secret", config.ClientKey)
+	assert.Equal(t, "lns.example.com", config.Extras["connect-host"])
+
+	// The signer is checked at the current time, or at the time of issue when
+	// the clock of the gateway is not set
+	_, err = bundle.verify(roots, issued.AddDate(1, 0, 0))
+	assert.ErrorContains(t, err, "untrusted signer")
+	_, err = bundle.verify(roots, time.Unix(0, 0))
+	assert.Nil(t, err)
+
+	// Not signed by the root
+	other := createTestSigner(t, "other", nil, true, issued.AddDate(-1, 0, 0), issued.AddDate(10, 0, 0))
+	otherRoots := x509.NewCertPool()
+	otherRoots.AddCert(other.cert)
+	_, err = bundle.verify(otherRoots, issued)
+	assert.ErrorContains(t, err, "untrusted signer")
+
+	// Issued while the signer was not valid, eg. back-dated by an expired
+	// signer, even when the clock is not set
+	payload := testPairBundlePayload(issued.AddDate(0, 2, 0))
+	_, err = signTestBundle(t, signer, payload).verify(roots, issued)
+	assert.ErrorContains(t, err, "issued outside the validity of its signer")
+	expired := createTestSigner(t, "expired", root, false, issued.AddDate(-1, 0, 0), issued.AddDate(0, -6, 0))
+	payload = testPairBundlePayload(issued.AddDate(0, -7, 0))
+	_, err = signTestBundle(t, expired, payload).verify(roots, issued)
+	assert.ErrorContains(t, err, "untrusted signer")
+	_, err = signTestBundle(t, expired, testPairBundlePayload(issued)).verify(roots, time.Unix(0, 0))
+	assert.ErrorContains(t, err, "issued outside the validity of its signer")
+
+	// Other certificates of the root CA can't sign bundles
+	server := createTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "analytics.kudzu.gr"},
+		NotBefore:    issued.AddDate(0, -1, 0),
+		NotAfter:     issued.AddDate(0, 1, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, root)
+	_, err = signTestBundle(t, server, testPairBundlePayload(issued)).verify(roots, issued)
+	assert.ErrorContains(t, err, "not allowed to sign pairing bundles")
+
+	// Modified payload
+	tampered := *bundle
+	tamperedPayload := testPairBundlePayload(issued)
+	tamperedPayload.Config.GatewayID = "gw-2"
+	encoded, _ := json.Marshal(tamperedPayload)
+	tampered.Payload = base64.StdEncoding.EncodeToString(encoded)
+	_, err = tampered.verify(roots, issued)
+	assert.ErrorContains(t, err, "invalid signature")
+
+	// Expired
+	payload = testPairBundlePayload(issued)
+	payload.Expires = issued.Add(24 * time.Hour).Unix()
+	expiring := signTestBundle(t, signer, payload)
+	_, err = expiring.verify(roots, issued.Add(time.Hour))
+	assert.Nil(t, err)
+	_, err = expiring.verify(roots, issued.Add(48*time.Hour))
+	assert.ErrorContains(t, err, "expired")
+
+	// No credentials
+	payload = testPairBundlePayload(issued)
+	payload.Config.ClientKey = ""
+	_, err = signTestBundle(t, signer, payload).verify(roots, issued)
+	assert.ErrorContains(t, err, "no client credentials")
+
+	_, err = (&pairBundle{}).verify(roots, issued)
+	assert.ErrorContains(t, err, "no signer certificate")
+}
+
+func TestPairBundleIntermediate(t *testing.T) {
+	issued := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	root := createTestSigner(t, "root", nil, true, issued.AddDate(-1, 0, 0), issued.AddDate(10, 0, 0))
+	intermediate := createTestSigner(t, "intermediate", root, true, issued.AddDate(-1, 0, 0), issued.AddDate(5, 0, 0))
+	signer := createTestSigner(t, "pairing", intermediate, false, issued.AddDate(0, -1, 0), issued.AddDate(0, 1, 0))
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+
+	bundle := signTestBundle(t, signer, testPairBundlePayload(issued))
+	_, err := bundle.verify(roots, issued)
+	assert.ErrorContains(t, err, "untrusted signer")
+
+	bundle.Certificates = append(bundle.Certificates, base64.StdEncoding.EncodeToString(intermediate.cert.Raw))
+	_, err = bundle.verify(roots, issued)
+	assert.Nil(t, err)
+}
+
+func TestPairBundleDecode(t *testing.T) {
+	bundle := &pairBundle{Payload: "cGF5bG9hZA==", Signature: "c2ln", Certificates: []string{"Y2VydA=="}}
+	encoded, _ := json.Marshal(bundle)
+
+	decoded, err := decodePairBundle(encoded)
+	assert.Nil(t, err)
+	assert.Equal(t, bundle, decoded)
+
+	qr := pairBundleQRPrefix + base64.RawURLEncoding.EncodeToString(encoded)
+	decoded, err = decodePairBundle([]byte(qr + "\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, bundle, decoded)
+
+	_, err = decodePairBundle([]byte(pairBundleQRPrefix + "!!"))
+	assert.ErrorContains(t, err, "invalid QR code")
+	_, err = decodePairBundle([]byte("{"))
+	assert.ErrorContains(t, err, "invalid pairing bundle")
+}
+
+func TestPairBundleRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"payload":""}`), 0600))
+
+	data, err := readPairBundle(path, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"payload":""}`, string(data))
+
+	data, err = readPairBundle("-", strings.NewReader("from stdin"))
+	assert.Nil(t, err)
+	assert.Equal(t, "from stdin", string(data))
+
+	data, err = readPairBundle(pairBundleQRPrefix+"abc", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, pairBundleQRPrefix+"abc", string(data))
+
+	_, err = readPairBundle(filepath.Join(t.TempDir(), "missing"), nil)
+	assert.NotNil(t, err)
+}
+
+func TestPairConfigOptions(t *testing.T) {
+	config := defaultConf
+	config.ConnectHost = "lns.local"
+
+	options := pairConfigOptions(&pairConfig{
+		GatewayID: "gw-1",
+		ClientID:  "client",
+		ClientKey: "secret",
+		Extras:    map[string]interface{}{"server-side": true, "max-udp-streams": float64(1000000)},
+	}, config)
+
+	assert.Equal(t, map[string]string{
+		"client-id":       "client",
+		"client-key":      "secret",
+		"connect-host":    "lns.local",
+		"gateway":         "gw-1",
+		"max-udp-streams": "1000000",
+		"server-side":     "true",
+	}, options)
+}
+
+func TestGetBundleConfigOptions(t *testing.T) {
+	issued := time.Now()
+	root := createTestSigner(t, "root", nil, true, issued.AddDate(-1, 0, 0), issued.AddDate(10, 0, 0))
+	signer := createTestSigner(t, "pairing", root, false, issued.AddDate(0, -1, 0), issued.AddDate(0, 1, 0))
+	encoded, _ := json.Marshal(signTestBundle(t, signer, testPairBundlePayload(issued)))
+
+	// Only the bundles signed by the Kudzu root CA are accepted
+	_, err := getBundleConfigOptions("-", strings.NewReader(string(encoded)), defaultConf)
+	assert.ErrorContains(t, err, "untrusted signer")
+
+	_, err = getBundleConfigOptions(filepath.Join(t.TempDir(), "missing"), nil, defaultConf)
+	assert.ErrorContains(t, err, "could not read pairing bundle")
+}
@@ -1,12 +1,19 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
+
+	log "github.com/sirupsen/logrus"
 )
 
 type pairConfig struct {
@@ -16,19 +23,119 @@ type pairConfig struct {
 	Extras    map[string]interface{} `json:"extras,omitempty"`
 }
 
-// Retrieves the client configuration from a pairing pin
-func getClientConfigFromPin(pin string, baseUrl string) (*pairConfig, error) {
-	// Make the HTTP GET request to the URL.
-	resp, err := http.Get(fmt.Sprintf("%s/%s", baseUrl, pin))
+// The pairing endpoint of the analytics service, when none is configured
+const defaultPairingUrl = "https://eu1.cluster.kudzu.gr/api/v1/pairing/edge"
+
+var (
+	errPairingPinNotFound = errors.New("unknown pairing PIN")
+	errPairingPinExpired  = errors.New("expired pairing PIN")
+)
+
+// Retrieves the client configuration of a forwarder from a pairing PIN
+type pairingClient struct {
+	baseUrl    string
+	httpClient *http.Client
+	// How long to wait for each attempt, and how many attempts to make
+	timeout  time.Duration
+	attempts int
+	// The delay before retrying, doubled after every failed attempt
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+// An error of a pairing attempt, that can be retried
+type pairingRetryError struct {
+	err error
+	// The delay requested by the service (`Retry-After`), if any
+	after time.Duration
+}
+
+func (e *pairingRetryError) Error() string {
+	return e.err.Error()
+}
+
+func (e *pairingRetryError) Unwrap() error {
+	return e.err
+}
+
+// Creates a pairing client for the given analytics endpoint. The endpoint is
+// used as-is when it has a scheme (eg. `http://localhost:8080/pairing`),
+// otherwise it is the host of the HTTPS pairing API.
+func createPairingClient(endpoint string) *pairingClient {
+	baseUrl := defaultPairingUrl
+	if strings.Contains(endpoint, "://") {
+		baseUrl = strings.TrimSuffix(endpoint, "/")
+	} else if endpoint != "" {
+		baseUrl = fmt.Sprintf("https://%s/api/v1/pairing/edge", endpoint)
+	}
+
+	return &pairingClient{
+		baseUrl:    baseUrl,
+		httpClient: &http.Client{},
+		timeout:    15 * time.Second,
+		attempts:   4,
+		backoff:    time.Second,
+		maxBackoff: 10 * time.Second,
+	}
+}
+
+// Retrieves the client configuration for the given PIN, retrying when the
+// service can't be reached or is unavailable
+func (p *pairingClient) getConfig(ctx context.Context, pin string) (*pairConfig, error) {
+	backoff := p.backoff
+	for attempt := 1; ; attempt++ {
+		config, err := p.fetch(ctx, pin)
+		if err == nil {
+			return config, nil
+		}
+
+		var retryErr *pairingRetryError
+		if !errors.As(err, &retryErr) || attempt >= p.attempts || ctx.Err() != nil {
+			return nil, err
+		}
+
+		delay := backoff
+		if retryErr.after > delay {
+			delay = retryErr.after
+		}
+		log.Warnf("Could not pair (attempt %d/%d), retrying in %s: %s", attempt, p.attempts, delay, err.Error())
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+
+		backoff *= 2
+		if backoff > p.maxBackoff {
+			backoff = p.maxBackoff
+		}
+	}
+}
+
+// Makes a single attempt to retrieve the client configuration
+func (p *pairingClient) fetch(ctx context.Context, pin string) (*pairConfig, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/%s", p.baseUrl, url.PathEscape(pin)), nil)
 	if err != nil {
-		return nil, fmt.Errorf("error making GET request: %w", err)
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("kudzu-forwarder/%s", MajorVersion))
+	req.Header.Set("X-Forwarder-Version", MajorVersion)
+	req.Header.Set("X-Forwarder-Revision", Version())
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, &pairingRetryError{err: fmt.Errorf("error making GET request: %w", err)}
 	}
 	defer resp.Body.Close()
 
 	// Read the response body.
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %w", err)
+		return nil, &pairingRetryError{err: fmt.Errorf("error reading response body: %w", err)}
 	}
 
 	// Parse the JSON data into the Response struct.
@@ -42,15 +149,43 @@ func getClientConfigFromPin(pin string, baseUrl string) (*pairConfig, error) {
 			Config pairConfig `json:"config"`
 		} `json:"data,omitempty"`
 	}
-	if err := json.Unmarshal(body, &responseData); err != nil {
-		return nil, fmt.Errorf("error parsing JSON: %w", err)
+	parseErr := json.Unmarshal(body, &responseData)
+
+	// The description of the error, when the service sent one
+	description := ""
+	if parseErr == nil {
+		if responseData.Details != nil && responseData.Details.Description != "" {
+			description = responseData.Details.Description
+		} else if responseData.ErrorCode != nil {
+			description = *responseData.ErrorCode
+		}
+	}
+	statusErr := func(err error) error {
+		if description != "" {
+			return fmt.Errorf("%w: %s", err, description)
+		}
+		return err
 	}
 
-	if responseData.ErrorCode != nil {
-		if responseData.Details != nil {
-			return nil, fmt.Errorf("%s", responseData.Details.Description)
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, statusErr(errPairingPinNotFound)
+	case resp.StatusCode == http.StatusGone:
+		return nil, statusErr(errPairingPinExpired)
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return nil, &pairingRetryError{
+			err:   statusErr(fmt.Errorf("service unavailable (HTTP %d)", resp.StatusCode)),
+			after: parseRetryAfter(resp.Header.Get("Retry-After")),
 		}
-		return nil, fmt.Errorf("%s", *responseData.ErrorCode)
+	case resp.StatusCode != http.StatusOK:
+		return nil, statusErr(fmt.Errorf("request failed (HTTP %d)", resp.StatusCode))
+	}
+
+	if parseErr != nil {
+		return nil, fmt.Errorf("error parsing JSON: %w", parseErr)
+	}
+	if responseData.ErrorCode != nil {
+		return nil, fmt.Errorf("%s", description)
 	} else if responseData.Data == nil {
 		return nil, fmt.Errorf("error parsing JSON: no data")
 	}
@@ -58,6 +193,15 @@ func getClientConfigFromPin(pin string, baseUrl string) (*pairConfig, error) {
 	return &responseData.Data.Config, nil
 }
 
+// Parses the delay of a `Retry-After` header, given in seconds
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
 func toFlatMap(input interface{}) map[string]interface{} {
 	// Convert config to a flat map
 	rawConfig := make(map[string]interface{})
@@ -72,12 +216,9 @@ func toFlatMap(input interface{}) map[string]interface{} {
 	return rawConfig
 }
 
-func getRenderedPairConfig(pin string, config ForwarderConfig) (string, error) {
-	baseUrl := "https://eu1.cluster.kudzu.gr/api/v1/pairing/edge"
-	if config.Endpoint != "" {
-		baseUrl = fmt.Sprintf("https://%s/api/v1/pairing/edge", config.Endpoint)
-	}
-
+// Retrieves the configuration for the given pairing PIN, and returns the
+// options to write to the configuration file
+func getPairConfigOptions(pin string, config ForwarderConfig) (map[string]string, error) {
 	// Remove whitespaces and everything non-numeric
 	pin = strings.Map(func(r rune) rune {
 		if unicode.IsDigit(r) {
@@ -86,11 +227,17 @@ func getRenderedPairConfig(pin string, config ForwarderConfig) (string, error) {
 		return -1
 	}, pin)
 
-	pairConfig, err := getClientConfigFromPin(pin, baseUrl)
+	pairConfig, err := createPairingClient(config.Endpoint).getConfig(context.Background(), pin)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
+	return pairConfigOptions(pairConfig, config), nil
+}
+
+// Returns the configuration options that differ from the defaults, after
+// applying the given pairing configuration
+func pairConfigOptions(pairConfig *pairConfig, config ForwarderConfig) map[string]string {
 	// Update known config properties
 	config.ClientId = pairConfig.ClientID
 	config.ClientKey = pairConfig.ClientKey
@@ -102,15 +249,24 @@ func getRenderedPairConfig(pin string, config ForwarderConfig) (string, error) {
 		configMap[k] = v
 	}
 
-	// Render to INI format
-	iniConfig := ""
+	options := make(map[string]string)
 	defaultConfig := toFlatMap(defaultConf)
 	for k, v := range configMap {
 		if dv, ok := defaultConfig[k]; ok && dv == v {
 			continue
 		}
-		iniConfig += fmt.Sprintf("%s=%v\n", k, v)
+		options[k] = formatConfigValue(v)
 	}
 
-	return iniConfig, nil
+	return options
+}
+
+// Formats a value of a flattened configuration (see `toFlatMap`) like in
+// the configuration file
+func formatConfigValue(value interface{}) string {
+	if number, ok := value.(float64); ok {
+		// Avoid the exponent notation of large numbers
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", value)
 }
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPairingResponse = `{"data":{"config":{"gateway":"gw-1","client-id":"client","client-key":"secret","extras":{"connect-host":"lns.example.com"}}}}`
+
+// Creates a pairing client for a mock server, that retries without delay
+func createTestPairingClient(server *httptest.Server) *pairingClient {
+	p := createPairingClient(server.URL + "/pairing")
+	p.backoff = time.Millisecond
+	p.maxBackoff = time.Millisecond
+	return p
+}
+
+func TestPairingClientSuccess(t *testing.T) {
+	var req *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		w.Write([]byte(testPairingResponse))
+	}))
+	defer server.Close()
+
+	config, err := createTestPairingClient(server).getConfig(context.Background(), "123456")
+	assert.Nil(t, err)
+	assert.Equal(t, "gw-1", config.GatewayID)
+	assert.Equal(t, "client", config.ClientID)
+	assert.Equal(t, "secret", config.ClientKey)
+	assert.Equal(t, "lns.example.com", config.Extras["connect-host"])
+
+	assert.Equal(t, "/pairing/123456", req.URL.Path)
+	assert.Equal(t, "kudzu-forwarder/"+MajorVersion, req.Header.Get("User-Agent"))
+	assert.Equal(t, MajorVersion, req.Header.Get("X-Forwarder-Version"))
+	assert.Equal(t, Version(), req.Header.Get("X-Forwarder-Revision"))
+}
+
+func TestPairingClientErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		err    error
+		msg    string
+	}{
+		{"unknown pin", http.StatusNotFound, `{"error":"not-found","details":{"description":"No such PIN"}}`, errPairingPinNotFound, "unknown pairing PIN: No such PIN"},
+		{"unknown pin without body", http.StatusNotFound, ``, errPairingPinNotFound, "unknown pairing PIN"},
+		{"expired pin", http.StatusGone, `{"error":"expired"}`, errPairingPinExpired, "expired pairing PIN: expired"},
+		{"malformed json", http.StatusOK, `{"data":`, nil, "error parsing JSON"},
+		{"no data", http.StatusOK, `{}`, nil, "error parsing JSON: no data"},
+		{"error envelope", http.StatusOK, `{"error":"denied","details":{"description":"Pairing disabled"}}`, nil, "Pairing disabled"},
+		{"bad request", http.StatusBadRequest, `{"error":"invalid-pin"}`, nil, "request failed (HTTP 400): invalid-pin"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			_, err := createTestPairingClient(server).getConfig(context.Background(), "123456")
+			assert.ErrorContains(t, err, test.msg)
+			if test.err != nil {
+				assert.True(t, errors.Is(err, test.err))
+			}
+			// These are not retried
+			assert.Equal(t, int32(1), requests.Load())
+		})
+	}
+}
+
+func TestPairingClientRetries(t *testing.T) {
+	// Fails the first requests, alternating between unavailable and rate-limited
+	var requests, failures atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		if n <= failures.Load() {
+			if n%2 == 0 {
+				w.WriteHeader(http.StatusTooManyRequests)
+			} else {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			return
+		}
+		w.Write([]byte(testPairingResponse))
+	}))
+	defer server.Close()
+
+	failures.Store(2)
+	config, err := createTestPairingClient(server).getConfig(context.Background(), "123456")
+	assert.Nil(t, err)
+	assert.Equal(t, "gw-1", config.GatewayID)
+	assert.Equal(t, int32(3), requests.Load())
+
+	// Until the attempts run out
+	requests.Store(0)
+	failures.Store(100)
+	p := createTestPairingClient(server)
+	p.attempts = 2
+	_, err = p.getConfig(context.Background(), "123456")
+	assert.ErrorContains(t, err, "service unavailable (HTTP 429)")
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestPairingClientTimeout(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte(testPairingResponse))
+	}))
+	defer server.Close()
+
+	// A request that takes too long is retried
+	p := createTestPairingClient(server)
+	p.timeout = 50 * time.Millisecond
+	config, err := p.getConfig(context.Background(), "123456")
+	assert.Nil(t, err)
+	assert.Equal(t, "gw-1", config.GatewayID)
+	assert.Equal(t, int32(2), requests.Load())
+
+	// While the context stops the retries
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = p.getConfig(ctx, "123456")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestPairingClientUrl(t *testing.T) {
+	assert.Equal(t, defaultPairingUrl, createPairingClient("").baseUrl)
+	assert.Equal(t, "https://eu2.example.com/api/v1/pairing/edge", createPairingClient("eu2.example.com").baseUrl)
+	assert.Equal(t, "http://localhost:8080/pairing", createPairingClient("http://localhost:8080/pairing/").baseUrl)
+
+	assert.Equal(t, 2*time.Second, parseRetryAfter("2"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(""))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("Wed, 21 Oct 2015 07:28:00 GMT"))
+}
+
+func TestGetPairConfigOptions(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Write([]byte(testPairingResponse))
+	}))
+	defer server.Close()
+
+	config := defaultConf
+	config.Endpoint = server.URL
+	options, err := getPairConfigOptions("123 456", config)
+	assert.Nil(t, err)
+	assert.Equal(t, "/123456", path)
+	assert.Equal(t, map[string]string{
+		"analytics-endpoint": server.URL,
+		"client-id":          "client",
+		"client-key":         "secret",
+		"connect-host":       "lns.example.com",
+		"gateway":            "gw-1",
+	}, options)
+}
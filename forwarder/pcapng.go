@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+)
+
+// The pcapng constants used by the encoder
+// (see https://www.ietf.org/archive/id/draft-ietf-opsawg-pcapng-01.html)
+const (
+	pcapngBlockSHB    = 0x0A0D0D0A
+	pcapngBlockIDB    = 0x00000001
+	pcapngBlockEPB    = 0x00000006
+	pcapngByteOrder   = 0x1A2B3C4D
+	pcapngLinkTypeRaw = 101
+
+	pcapngOptEnd       = 0
+	pcapngOptShbUser   = 4
+	pcapngOptIfName    = 2
+	pcapngOptEpbFlags  = 2
+	pcapngFlagInbound  = 1
+	pcapngFlagOutbound = 2
+)
+
+// Encodes the datagrams as raw IP packets in a pcapng capture, so they can be
+// opened with Wireshark or tcpdump
+type pcapngEncoder struct {
+	buf []byte
+}
+
+func appendLE16(b []byte, v uint16) []byte {
+	return append(b, byte(v), byte(v>>8))
+}
+
+func appendLE32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendLE64(b []byte, v uint64) []byte {
+	return appendLE32(appendLE32(b, uint32(v)), uint32(v>>32))
+}
+
+func appendBE16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func pcapngPad(n int) int {
+	return (4 - n%4) % 4
+}
+
+// Appends a block option, padded to 32 bits
+func pcapngAppendOption(b []byte, code uint16, value []byte) []byte {
+	b = appendLE16(b, code)
+	b = appendLE16(b, uint16(len(value)))
+	b = append(b, value...)
+	return append(b, make([]byte, pcapngPad(len(value)))...)
+}
+
+// Wraps the block body with the type and the length fields
+func pcapngWriteBlock(w io.Writer, blockType uint32, body []byte) error {
+	total := uint32(len(body) + 12)
+	hdr := make([]byte, 0, 8)
+	hdr = appendLE32(hdr, blockType)
+	hdr = appendLE32(hdr, total)
+	if _, err := w.Write(hdr); err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	_, err := w.Write(appendLE32(nil, total))
+	return err
+}
+
+func (e *pcapngEncoder) WriteHeader(w io.Writer) error {
+	// Section header block
+	shb := make([]byte, 0, 64)
+	shb = appendLE32(shb, pcapngByteOrder)
+	shb = appendLE16(shb, 1)
+	shb = appendLE16(shb, 0)
+	shb = appendLE64(shb, 0xFFFFFFFFFFFFFFFF)
+	shb = pcapngAppendOption(shb, pcapngOptShbUser, []byte("kudzu-forwarder"))
+	shb = pcapngAppendOption(shb, pcapngOptEnd, nil)
+	if err := pcapngWriteBlock(w, pcapngBlockSHB, shb); err != nil {
+		return err
+	}
+
+	// Interface description block, carrying raw IPv4/IPv6 packets
+	idb := make([]byte, 0, 32)
+	idb = appendLE16(idb, pcapngLinkTypeRaw)
+	idb = appendLE16(idb, 0)
+	idb = appendLE32(idb, 0)
+	idb = pcapngAppendOption(idb, pcapngOptIfName, []byte("proxy"))
+	idb = pcapngAppendOption(idb, pcapngOptEnd, nil)
+	return pcapngWriteBlock(w, pcapngBlockIDB, idb)
+}
+
+func (e *pcapngEncoder) WriteRecord(w io.Writer, rec *DumpRecord) error {
+	pkt := encodeIPPacket(rec.Src, rec.Dst, rec.Data)
+	ts := uint64(rec.Time.UnixMicro())
+
+	// Enhanced packet block
+	b := e.buf[:0]
+	b = appendLE32(b, 0)
+	b = appendLE32(b, uint32(ts>>32))
+	b = appendLE32(b, uint32(ts))
+	b = appendLE32(b, uint32(len(pkt)))
+	b = appendLE32(b, uint32(len(pkt)))
+	b = append(b, pkt...)
+	b = append(b, make([]byte, pcapngPad(len(pkt)))...)
+
+	var flags uint32 = pcapngFlagOutbound
+	if rec.Inbound {
+		flags = pcapngFlagInbound
+	}
+	b = pcapngAppendOption(b, pcapngOptEpbFlags, appendLE32(nil, flags))
+	b = pcapngAppendOption(b, pcapngOptEnd, nil)
+	e.buf = b
+
+	return pcapngWriteBlock(w, pcapngBlockEPB, b)
+}
+
+// Returns the addresses in the same family, mapping IPv4 into IPv6 if needed
+func dumpAddrFamily(src, dst net.IP) (net.IP, net.IP) {
+	if src4, dst4 := src.To4(), dst.To4(); src4 != nil && dst4 != nil {
+		return src4, dst4
+	}
+	return src.To16(), dst.To16()
+}
+
+// Adds the data to the running sum of the internet checksum
+func ipChecksum(sum uint32, data []byte) uint32 {
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(data[i])<<8 | uint32(data[i+1])
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	return sum
+}
+
+// Folds the running sum into the final checksum
+func ipChecksumFold(sum uint32) uint16 {
+	for sum > 0xffff {
+		sum = (sum >> 16) + (sum & 0xffff)
+	}
+	return ^uint16(sum)
+}
+
+// Encodes the datagram in a UDP over IP packet, with valid checksums
+func encodeIPPacket(src, dst *net.UDPAddr, data []byte) []byte {
+	var srcIP, dstIP net.IP
+	var srcPort, dstPort int
+	if src != nil {
+		srcIP, srcPort = src.IP, src.Port
+	}
+	if dst != nil {
+		dstIP, dstPort = dst.IP, dst.Port
+	}
+	if srcIP == nil && dstIP == nil {
+		srcIP, dstIP = net.IPv4zero, net.IPv4zero
+	} else if srcIP == nil {
+		srcIP = net.IPv4zero
+	} else if dstIP == nil {
+		dstIP = net.IPv4zero
+	}
+	srcIP, dstIP = dumpAddrFamily(srcIP, dstIP)
+
+	udpLen := 8 + len(data)
+	udp := make([]byte, 0, udpLen)
+	udp = appendBE16(udp, uint16(srcPort))
+	udp = appendBE16(udp, uint16(dstPort))
+	udp = appendBE16(udp, uint16(udpLen))
+	udp = appendBE16(udp, 0)
+	udp = append(udp, data...)
+
+	// The UDP checksum covers the pseudo-header with the addresses
+	sum := ipChecksum(0, srcIP)
+	sum = ipChecksum(sum, dstIP)
+	sum += 17 + uint32(udpLen)
+	csum := ipChecksumFold(ipChecksum(sum, udp))
+	if csum == 0 {
+		csum = 0xffff
+	}
+	binary.BigEndian.PutUint16(udp[6:], csum)
+
+	if len(srcIP) == net.IPv4len {
+		hdr := make([]byte, 20, 20+udpLen)
+		hdr[0] = 0x45
+		binary.BigEndian.PutUint16(hdr[2:], uint16(20+udpLen))
+		hdr[6] = 0x40 // Don't fragment
+		hdr[8] = 64
+		hdr[9] = 17
+		copy(hdr[12:16], srcIP)
+		copy(hdr[16:20], dstIP)
+		binary.BigEndian.PutUint16(hdr[10:], ipChecksumFold(ipChecksum(0, hdr)))
+		return append(hdr, udp...)
+	}
+
+	hdr := make([]byte, 40, 40+udpLen)
+	hdr[0] = 0x60
+	binary.BigEndian.PutUint16(hdr[4:], uint16(udpLen))
+	hdr[6] = 17
+	hdr[7] = 64
+	copy(hdr[8:24], srcIP)
+	copy(hdr[24:40], dstIP)
+	return append(hdr, udp...)
+}
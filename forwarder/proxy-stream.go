@@ -19,7 +19,10 @@ type ProxyStreamConfig struct {
 	Name              string
 	Index             int
 	BufferSize        int
+	BatchSize         int
 	Local             *net.UDPConn
+	LocalBatch        batchConn
+	LocalAddress      *net.UDPAddr
 	LocalReplyAddress *net.UDPAddr
 	RemoteAddress     *net.UDPAddr
 	RemoteBindAddress *net.UDPAddr
@@ -31,6 +34,8 @@ type ProxyStream struct {
 	closed          bool
 	closeWg         sync.WaitGroup
 	remote          *net.UDPConn
+	remoteWriter    *udpWriter
+	writeLock       sync.Mutex
 	remoteBoundAddr *net.UDPAddr
 	remoteReplyAddr *net.UDPAddr
 	conf            *ProxyStreamConfig
@@ -45,6 +50,17 @@ func strAddr(addr *net.UDPAddr) string {
 	return addr.String()
 }
 
+// Returns the network name to use for the address family of the given address
+func udpNetwork(addr *net.UDPAddr) string {
+	if addr == nil || addr.IP == nil {
+		return "udp"
+	}
+	if addr.IP.To4() != nil {
+		return "udp4"
+	}
+	return "udp6"
+}
+
 func CreateProxyStream(config *ProxyStreamConfig) *ProxyStream {
 	inst := &ProxyStream{
 		closed: false,
@@ -55,6 +71,11 @@ func CreateProxyStream(config *ProxyStreamConfig) *ProxyStream {
 }
 
 func (s *ProxyStream) HandleLocalData(data []byte) error {
+	return s.HandleLocalBatch([][]byte{data})
+}
+
+// Forwards one or more datagrams received from the local side to the remote
+func (s *ProxyStream) HandleLocalBatch(data [][]byte) error {
 	if s.closed {
 		return ErrSocketClosed
 	}
@@ -68,11 +89,17 @@ func (s *ProxyStream) HandleLocalData(data []byte) error {
 		}
 	}
 
-	log.Debugf("[%s] Sending %d bytes to %s: %s", s.conf.Name,
-		len(data), s.conf.RemoteAddress.String(), hex.EncodeToString(data))
+	if log.IsLevelEnabled(log.DebugLevel) {
+		for _, d := range data {
+			log.Debugf("[%s] Sending %d bytes to %s: %s", s.conf.Name,
+				len(d), s.conf.RemoteAddress.String(), hex.EncodeToString(d))
+		}
+	}
 
 	// Write to remote
-	_, err := s.remote.Write(data)
+	s.writeLock.Lock()
+	err := s.remoteWriter.Write(data, nil)
+	s.writeLock.Unlock()
 	if err != nil {
 		log.Warnf("[%s] Unable to write to remote (%s): %s", s.conf.Name, s.conf.RemoteAddress.String(), err.Error())
 		s.Close()
@@ -103,11 +130,13 @@ func (s *ProxyStream) remoteToLocal() {
 	defer s.closeWg.Done()
 
 	log.Debugf("[%s] Reading thread started", s.conf.Name)
-	b := make([]byte, s.conf.BufferSize)
+	batch := newUDPBatch(s.remote, s.conf.BatchSize, s.conf.BufferSize)
+	local := newUDPWriter(s.conf.Local, s.conf.LocalBatch)
+	var bufs [][]byte
 
 	for s.connected {
 		log.Debugf("[%s] Reading up to %d bytes from %s", s.conf.Name, s.conf.BufferSize, s.remoteBoundAddr.String())
-		n, addr, err := s.remote.ReadFromUDP(b)
+		n, err := batch.Read()
 
 		// If were intentionally closed in the process, exit the loop
 		if s.closed {
@@ -122,27 +151,34 @@ func (s *ProxyStream) remoteToLocal() {
 			break
 		}
 
-		log.Debugf("[%s] Received %d bytes from %s: %s", s.conf.Name,
-			n, s.remoteBoundAddr.String(), hex.EncodeToString(b[0:n]))
-
-		// Once a message is received from the specified endpoint, we will be using that
-		// for communicating with the upstream from this point onwards
-		s.remoteReplyAddr = addr
+		bufs = bufs[:0]
+		for i := 0; i < n; i++ {
+			data, addr := batch.Datagram(i)
+			if log.IsLevelEnabled(log.DebugLevel) {
+				log.Debugf("[%s] Received %d bytes from %s: %s", s.conf.Name,
+					len(data), s.remoteBoundAddr.String(), hex.EncodeToString(data))
+			}
+
+			// Once a message is received from the specified endpoint, we will be using that
+			// for communicating with the upstream from this point onwards
+			s.remoteReplyAddr = addr
+			bufs = append(bufs, data)
+		}
 
 		// Send data to the local endpoint
-		wb, err := s.conf.Local.WriteToUDP(b[0:n], s.conf.LocalReplyAddress)
+		err = local.Write(bufs, s.conf.LocalReplyAddress)
 		if err != nil {
 			log.Warnf("[%s] Unable to write to local (%s): %s", s.conf.Name, s.conf.LocalReplyAddress.String(), err)
 			s.Close()
 			s.conf.Events.LocalError(err)
 			break
-		} else if wb != n {
-			// We don't expect fragmentation, so just log this as a warning
-			log.Warnf("[%s] Remote-to-local fragmentation (%d != %d)", s.conf.Name, wb, n)
 		}
 
 		// We can now handle data
-		s.conf.Events.DataReceived(b[0:n], addr)
+		for i := 0; i < n; i++ {
+			data, addr := batch.Datagram(i)
+			s.conf.Events.DataReceived(data, addr)
+		}
 	}
 
 	log.Debugf("[%s] Reading thread exited", s.conf.Name)
@@ -157,8 +193,8 @@ func (s *ProxyStream) connect() error {
 	log.Debugf("[%s] Dialing %s (from %s)", s.conf.Name, s.conf.RemoteAddress.String(),
 		strAddr(s.conf.RemoteBindAddress))
 
-	// Connect on the remote socket
-	s.remote, err = net.DialUDP("udp4", s.conf.RemoteBindAddress, s.conf.RemoteAddress)
+	// Connect on the remote socket, using the address family of the remote
+	s.remote, err = net.DialUDP(udpNetwork(s.conf.RemoteAddress), s.conf.RemoteBindAddress, s.conf.RemoteAddress)
 	if err != nil {
 		return fmt.Errorf(
 			"could not connect to %s: %s",
@@ -169,6 +205,8 @@ func (s *ProxyStream) connect() error {
 
 	log.Infof("[%s] Connected to %s", s.conf.Name, s.conf.RemoteAddress.String())
 
+	s.remoteWriter = newUDPWriter(s.remote, nil)
+
 	rlAddr := s.remote.LocalAddr()
 	if addr, ok := rlAddr.(*net.UDPAddr); ok {
 		s.remoteBoundAddr = addr
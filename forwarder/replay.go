@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"github.com/kudzutechnologies/analytics/client"
+	"github.com/namsral/flag"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+type ReplayConfig struct {
+	// How fast to replay relative to the original timing (0 = as fast as possible)
+	Speed float64
+	// How frequently to flush the metrics, in capture time
+	FlushInterval time.Duration
+}
+
+// Re-feeds captured traffic through the analytics forwarder
+type Replayer struct {
+	config    ReplayConfig
+	fw        *AnalyticsForwarder
+	sleep     func(time.Duration)
+	clock     func() time.Time
+	recTime   time.Time
+	firstTime time.Time
+	startTime time.Time
+	lastFlush time.Time
+
+	// Statistics of the replayed traffic
+	Replayed int
+	Skipped  int
+}
+
+func CreateReplayer(config ReplayConfig, fw *AnalyticsForwarder) *Replayer {
+	inst := &Replayer{
+		config: config,
+		fw:     fw,
+		sleep:  time.Sleep,
+		clock:  time.Now,
+	}
+
+	// Timestamp the data with the time they were captured
+	fw.now = func() time.Time {
+		if inst.recTime.IsZero() {
+			return time.Now()
+		}
+		return inst.recTime
+	}
+	return inst
+}
+
+// Waits until it's time to replay the record, according to the speed
+func (r *Replayer) pace(rec *DumpRecord) {
+	if r.config.Speed <= 0 || rec.Time.IsZero() {
+		return
+	}
+	if r.firstTime.IsZero() {
+		r.firstTime = rec.Time
+		r.startTime = r.clock()
+		return
+	}
+
+	offset := time.Duration(float64(rec.Time.Sub(r.firstTime)) / r.config.Speed)
+	if wait := r.startTime.Add(offset).Sub(r.clock()); wait > 0 {
+		r.sleep(wait)
+	}
+}
+
+// Passes the datagram to the forwarder handler that would have received it
+// from the proxy, based on the kind of the Semtech UDP packet
+func (r *Replayer) dispatch(rec *DumpRecord) bool {
+	data := rec.Data
+	if len(data) < 4 || data[0] != PROTOCOL_VERSION {
+		return false
+	}
+
+	// The gateway is the sender of the packets it originates, and the
+	// receiver of the rest
+	gateway := rec.Dst
+	switch data[3] {
+	case PUSH_DATA, PULL_DATA, TX_ACK:
+		gateway = rec.Src
+	}
+	if gateway == nil {
+		gateway = replayGateway(rec.Stream)
+	}
+
+	switch data[3] {
+	case PUSH_DATA:
+		r.fw.UpLocalData(data, gateway)
+	case PUSH_ACK:
+		r.fw.UpRemoteData(data, gateway)
+	case PULL_DATA, TX_ACK:
+		r.fw.DnLocalData(data, gateway)
+	case PULL_RESP, PULL_ACK:
+		r.fw.DnRemoteData(data, gateway)
+	default:
+		return false
+	}
+	return true
+}
+
+// Returns the endpoint of the gateway of a stream, for the captures that do not
+// record addresses. The proxy numbers four streams per gateway (the datagrams it
+// receives and sends, for the uplinks and the downlinks), so each gateway gets
+// its own address in 127.0.0.0/8.
+func replayGateway(stream int) *net.UDPAddr {
+	n := stream/4 + 1
+	return &net.UDPAddr{IP: net.IPv4(127, byte(n>>16), byte(n>>8), byte(n)), Port: 0}
+}
+
+// Replays all the records from the reader, flushing the metrics periodically
+func (r *Replayer) Replay(reader DumpReader) error {
+	for {
+		rec, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		r.pace(rec)
+		r.recTime = rec.Time
+		if r.dispatch(rec) {
+			r.Replayed += 1
+		} else {
+			r.Skipped += 1
+		}
+
+		if r.config.FlushInterval > 0 && !rec.Time.IsZero() {
+			if r.lastFlush.IsZero() {
+				r.lastFlush = rec.Time
+			} else if rec.Time.Sub(r.lastFlush) >= r.config.FlushInterval {
+				r.fw.flushData()
+				r.lastFlush = rec.Time
+			}
+		}
+	}
+
+	return nil
+}
+
+// Flushes the metrics that are still pending
+func (r *Replayer) Finish() {
+	if r.fw.hasData() {
+		r.fw.flushData()
+	}
+}
+
+// Writes the metrics as JSON lines instead of pushing them to analytics
+type jsonMetricsWriter struct {
+	lock sync.Mutex
+	w    *bufio.Writer
+}
+
+func createJSONMetricsWriter(w io.Writer) *jsonMetricsWriter {
+	return &jsonMetricsWriter{w: bufio.NewWriter(w)}
+}
+
+func (j *jsonMetricsWriter) Connect() error {
+	return nil
+}
+
+func (j *jsonMetricsWriter) PushMetrics(metrics *api.AnalyticsMetrics) error {
+	b, err := protojson.Marshal(metrics)
+	if err != nil {
+		return err
+	}
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.w.Write(b)
+	return j.w.WriteByte('\n')
+}
+
+func (j *jsonMetricsWriter) Flush() error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.w.Flush()
+}
+
+// The `replay` command, that pushes the traffic of the given captures to
+// analytics, or writes the resulting metrics to a JSON file
+func runReplay(args []string) {
+	var config ForwarderConfig
+	var speed float64
+	var output string
+	var caFile string
+
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	configFile := registerConfigFileFlag(fs)
+	registerConfigFlags(fs, &config)
+	fs.Float64Var(&speed, "speed", 1, "how fast to replay relative to the original timing (0 for as fast as possible)")
+	fs.StringVar(&output, "output", "", "write the metrics to this JSON file ('-' for stdout) instead of pushing them to analytics")
+	fs.StringVar(&caFile, "ca-file", "", "the CA certificate of the analytics endpoint (eg. for a local test server)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s replay [options] <capture>...\n\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	loadConfigFile(fs, configFile)
+	applyConfigDefaults(&config)
+	// The health of the replaying process says nothing about the captured forwarder
+	config.InfoInterval = 0
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	// Select where the metrics should go
+	var pusher metricsPusher
+	var jsonOut *jsonMetricsWriter
+	switch output {
+	case "":
+		if config.ClientId == "" || config.ClientKey == "" {
+			log.Fatalf("You must specify a client ID and key (--client-id=, --client-key=) or an --output file")
+		}
+		clientConfig := analyticsClientConfig(&config)
+		clientConfig.CAFile = caFile
+		pusher = client.CreateAnalyticsClient(clientConfig)
+	case "-":
+		jsonOut = createJSONMetricsWriter(os.Stdout)
+		pusher = jsonOut
+	default:
+		f, err := os.Create(output)
+		if err != nil {
+			log.Fatalf("Could not create %s: %s", output, err.Error())
+		}
+		defer f.Close()
+		jsonOut = createJSONMetricsWriter(f)
+		pusher = jsonOut
+	}
+
+	if err := pusher.Connect(); err != nil {
+		log.Fatalf("Could not connect to analytics endpoint: %s", err.Error())
+	}
+
+	fw := CreateAnalyticsForwarder(config, pusher, nil)
+	replayer := CreateReplayer(ReplayConfig{
+		Speed:         speed,
+		FlushInterval: time.Second * time.Duration(config.FlushInterval),
+	}, fw)
+
+	for _, filename := range fs.Args() {
+		reader, err := OpenDumpReader(filename)
+		if err != nil {
+			log.Fatalf("Could not open %s: %s", filename, err.Error())
+		}
+
+		log.Infof("Replaying %s", filename)
+		err = replayer.Replay(reader)
+		reader.Close()
+		if err != nil {
+			log.Fatalf("Could not replay %s: %s", filename, err.Error())
+		}
+	}
+
+	replayer.Finish()
+	if jsonOut != nil {
+		if err := jsonOut.Flush(); err != nil {
+			log.Fatalf("Could not write metrics: %s", err.Error())
+		}
+	}
+	log.Infof("Replayed %d datagrams (%d skipped)", replayer.Replayed, replayer.Skipped)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// Writes a capture with an uplink, its acknowledgement and a downlink
+func createReplayCapture(t *testing.T, start time.Time) string {
+	filename := filepath.Join(t.TempDir(), "capture.pcapng")
+	writer, err := CreateDumpWriter(&DumpWriterConfig{Filename: filename, Format: "pcapng"})
+	if err != nil {
+		t.Fatalf("Could not create capture: %s", err.Error())
+	}
+
+	decode := func(s string) []byte {
+		b, _ := base64.StdEncoding.DecodeString(s)
+		return b
+	}
+	gateway := &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 40000}
+	local := &net.UDPAddr{IP: net.ParseIP("10.0.0.2"), Port: 1700}
+
+	writer.WriteRecord(&DumpRecord{Time: start, Inbound: true, Src: gateway, Dst: local, Data: decode(PacketPushDataUp)})
+	writer.WriteRecord(&DumpRecord{Time: start.Add(time.Second), Src: local, Dst: gateway, Data: decode(PacketPushAck)})
+	writer.WriteRecord(&DumpRecord{Time: start.Add(2 * time.Second), Src: local, Dst: gateway, Data: decode(PacketPullResp)})
+	writer.WriteRecord(&DumpRecord{Time: start.Add(3 * time.Second), Src: local, Dst: gateway, Data: []byte{0xff}})
+	writer.Close()
+	return filename
+}
+
+func createTestReplayer(speed float64) (*Replayer, *mockPusher) {
+	pusher := &mockPusher{}
+	config := defaultConf
+	config.ServerSide = true
+	config.MaxUDPStreams = 16
+	fw := CreateAnalyticsForwarder(config, pusher, nil)
+	return CreateReplayer(ReplayConfig{Speed: speed}, fw), pusher
+}
+
+func TestReplayCapture(t *testing.T) {
+	start := time.Date(2023, 2, 22, 1, 53, 31, 0, time.UTC)
+	replayer, pusher := createTestReplayer(0)
+
+	reader, err := OpenDumpReader(createReplayCapture(t, start))
+	assert.NoError(t, err)
+	assert.NoError(t, replayer.Replay(reader))
+	reader.Close()
+	replayer.Finish()
+
+	assert.Equal(t, 3, replayer.Replayed)
+	assert.Equal(t, 1, replayer.Skipped)
+
+	// All the packets are attributed to the gateway
+	assert.Len(t, pusher.frames, 1)
+	frame := pusher.frames[0]
+	assert.Equal(t, "10.0.0.1:40000", frame.Metrics.GatewayIp)
+	assert.Len(t, frame.Uplinks, 1)
+	assert.Len(t, frame.Downlinks, 1)
+	assert.Equal(t, uint32(1), frame.Metrics.UpTxPackets)
+	assert.Equal(t, uint32(1), frame.Metrics.UpRxPackets)
+	assert.Equal(t, uint32(1), frame.Metrics.DnRxPackets)
+
+	// The downlinks are timestamped with the capture time
+	assert.Equal(t, start.Add(2*time.Second).UnixMilli(), frame.Downlinks[0].RxWallTime)
+}
+
+func TestReplayBase64Gateways(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "dump.txt")
+	writer, err := CreateDumpWriter(&DumpWriterConfig{Filename: filename})
+	assert.NoError(t, err)
+
+	// The streams of the first gateway are 0-3 and of the second 4-7, with
+	// the uplinks first
+	decode := func(s string) []byte {
+		b, _ := base64.StdEncoding.DecodeString(s)
+		return b
+	}
+	writer.WriteRecord(&DumpRecord{Stream: 0, Inbound: true, Data: decode(PacketPushDataUp)})
+	writer.WriteRecord(&DumpRecord{Stream: 1, Data: decode(PacketPushAck)})
+	writer.WriteRecord(&DumpRecord{Stream: 3, Data: decode(PacketPullResp)})
+	writer.WriteRecord(&DumpRecord{Stream: 4, Inbound: true, Data: decode(PacketPushDataUp)})
+	writer.WriteRecord(&DumpRecord{Stream: 5, Data: decode(PacketPushAck)})
+	writer.Close()
+
+	replayer, pusher := createTestReplayer(0)
+	reader, err := OpenDumpReader(filename)
+	assert.NoError(t, err)
+	assert.NoError(t, replayer.Replay(reader))
+	reader.Close()
+	replayer.Finish()
+
+	// Each gateway has a frame of its own
+	assert.Equal(t, 5, replayer.Replayed)
+	assert.Len(t, pusher.frames, 2)
+	first, second := pusher.frames[0], pusher.frames[1]
+	assert.Equal(t, "127.0.0.1:0", first.Metrics.GatewayIp)
+	assert.Len(t, first.Uplinks, 1)
+	assert.Len(t, first.Downlinks, 1)
+	assert.Equal(t, uint32(1), first.Metrics.UpRxPackets)
+	assert.Equal(t, "127.0.0.2:0", second.Metrics.GatewayIp)
+	assert.Len(t, second.Uplinks, 1)
+	assert.Len(t, second.Downlinks, 0)
+	assert.Equal(t, uint32(1), second.Metrics.UpRxPackets)
+}
+
+func TestReplayTiming(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	replayer, _ := createTestReplayer(2)
+
+	var slept time.Duration
+	clock := time.Now()
+	replayer.clock = func() time.Time { return clock }
+	replayer.sleep = func(d time.Duration) {
+		slept += d
+		clock = clock.Add(d)
+	}
+
+	reader, _ := OpenDumpReader(createReplayCapture(t, start))
+	assert.NoError(t, replayer.Replay(reader))
+	reader.Close()
+
+	// The 3 seconds of the capture are replayed in 1.5 seconds
+	assert.Equal(t, 1500*time.Millisecond, slept)
+}
+
+func TestReplayToJSON(t *testing.T) {
+	var out bytes.Buffer
+	writer := createJSONMetricsWriter(&out)
+	writer.PushMetrics(&api.AnalyticsMetrics{GatewayId: "gw1"})
+	writer.PushMetrics(&api.AnalyticsMetrics{GatewayId: "gw2"})
+	assert.NoError(t, writer.Flush())
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var m map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &m))
+	assert.Equal(t, "gw2", m["gatewayId"])
+}
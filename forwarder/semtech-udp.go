@@ -3,11 +3,13 @@ package main
 import (
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"strconv"
 	"strings"
 
 	"fmt"
 	"net"
+	"reflect"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -44,34 +46,57 @@ type SemtechUDPJsonPayload struct {
 }
 
 type SemtechUDPStat struct {
-	Time string  `json:"time,omitempty"`
-	Lati float32 `json:"lati,omitempty"`
-	Long float32 `json:"long,omitempty"`
-	Alti float32 `json:"alti,omitempty"`
-	RxNb int     `json:"rxnb,omitempty"`
-	RxOk int     `json:"rxok,omitempty"`
-	RxFw int     `json:"rxfw,omitempty"`
-	Ackr float32 `json:"ackr,omitempty"`
-	DwnB int     `json:"dwnb,omitempty"`
-	TxNb int     `json:"txnb,omitempty"`
+	Time string   `json:"time,omitempty"`
+	Lati float64  `json:"lati,omitempty"`
+	Long float64  `json:"long,omitempty"`
+	Alti float32  `json:"alti,omitempty"`
+	RxNb int      `json:"rxnb,omitempty"`
+	RxOk int      `json:"rxok,omitempty"`
+	RxFw int      `json:"rxfw,omitempty"`
+	Ackr float32  `json:"ackr,omitempty"`
+	DwnB int      `json:"dwnb,omitempty"`
+	TxNb int      `json:"txnb,omitempty"`
+	Temp *float32 `json:"temp,omitempty"` // Concentrator temperature in °C (SX1302/SX1303)
+}
+
+// The `datr` field is a string for LoRa and LR-FHSS (eg. "SF7BW125" or
+// "M0CW137"), but a number in bits per second for FSK
+type SemtechUDPDataRate string
+
+func (d *SemtechUDPDataRate) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*d = SemtechUDPDataRate(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*d = SemtechUDPDataRate(n)
+	return nil
 }
 
 type SemtechUDPTxPkt struct {
-	Imme       bool    `json:"imme,omitempty"`
-	Tmst       int64   `json:"tmst,omitempty"`
-	Tmms       int64   `json:"tmms,omitempty"`
-	Frequency  float32 `json:"freq,omitempty"`
-	RfChain    int     `json:"rfch"`
-	Power      float32 `json:"powe,omitempty"`
-	Modulation string  `json:"modu,omitempty"`
-	DataRate   string  `json:"datr,omitempty"`
-	CodingRate string  `json:"codr,omitempty"`
-	Fdev       float32 `json:"fdev,omitempty"`
-	Ipol       bool    `json:"ipol,omitempty"`
-	Prea       int     `json:"prea,omitempty"`
-	NoCRC      bool    `json:"ncrc,omitempty"`
-	Size       int     `json:"size,omitempty"`
-	Data       string  `json:"data,omitempty"`
+	Imme       bool               `json:"imme,omitempty"`
+	Tmst       int64              `json:"tmst,omitempty"`
+	Tmms       int64              `json:"tmms,omitempty"`
+	Frequency  float32            `json:"freq,omitempty"`
+	RfChain    int                `json:"rfch"`
+	Power      float32            `json:"powe,omitempty"`
+	Modulation string             `json:"modu,omitempty"`
+	DataRate   SemtechUDPDataRate `json:"datr,omitempty"`
+	CodingRate string             `json:"codr,omitempty"`
+	Fdev       float32            `json:"fdev,omitempty"`
+	Ipol       bool               `json:"ipol,omitempty"`
+	Prea       int                `json:"prea,omitempty"`
+	NoCRC      bool               `json:"ncrc,omitempty"`
+	Size       int                `json:"size,omitempty"`
+	Data       string             `json:"data,omitempty"`
 }
 
 // RSig contains the metadata associated with the received signal
@@ -89,24 +114,32 @@ type SemtechUDPRxPktRsig struct {
 }
 
 type SemtechUDPRxPkt struct {
-	Time       string  `json:"time,omitempty"`
-	Tmms       int64   `json:"tmms,omitempty"`
-	Tmst       int64   `json:"tmst,omitempty"`
-	Frequency  float32 `json:"freq,omitempty"`
-	Channel    int     `json:"chan,omitempty"`
-	RfChain    int     `json:"rfch,omitempty"`
-	Stat       int     `json:"stat,omitempty"`
-	Modulation string  `json:"modu,omitempty"`
-	DataRate   string  `json:"datr,omitempty"`
-	CodingRate string  `json:"codr,omitempty"`
-	Rssi       float32 `json:"rssi,omitempty"`
-	Lsnr       float32 `json:"lsnr,omitempty"`
-	Size       int     `json:"size,omitempty"`
-	Data       string  `json:"data,omitempty"`
+	Time       string             `json:"time,omitempty"`
+	Tmms       int64              `json:"tmms,omitempty"`
+	Tmst       int64              `json:"tmst,omitempty"`
+	Frequency  float32            `json:"freq,omitempty"`
+	Channel    int                `json:"chan,omitempty"`
+	RfChain    int                `json:"rfch,omitempty"`
+	Stat       int                `json:"stat,omitempty"`
+	Modulation string             `json:"modu,omitempty"`
+	DataRate   SemtechUDPDataRate `json:"datr,omitempty"`
+	CodingRate string             `json:"codr,omitempty"`
+	Rssi       float32            `json:"rssi,omitempty"`
+	Lsnr       float32            `json:"lsnr,omitempty"`
+	Size       int                `json:"size,omitempty"`
+	Data       string             `json:"data,omitempty"`
+
+	// Single-antenna signal details from SX1302/SX1303 concentrators
+	RSSIS *float32 `json:"rssis,omitempty"` // RSSI in dBm of the signal (Optional)
+	FOff  *int32   `json:"foff,omitempty"`  // Frequency offset in Hz (Optional)
+	FTime *int64   `json:"ftime,omitempty"` // Fine timestamp, ns precision [0..999999999] (Optional)
 
 	// Extra fields from kerlink, for per-antenna details
 	RSig []SemtechUDPRxPktRsig `json:"rsig,omitempty"`
 
+	// The vendor-specific fields that are not part of the structure above
+	Custom map[string]json.RawMessage `json:"-"`
+
 	// Additional Meta-Data exposed from more elaborate
 	// internal modules
 	DeviceEUI []byte   `json:"-"`
@@ -115,12 +148,75 @@ type SemtechUDPRxPkt struct {
 	LocAlt    *float32 `json:"-"`
 }
 
+// The JSON names of the known rxpk fields
+var semtechUDPRxPktFields = jsonFieldNames(reflect.TypeOf(SemtechUDPRxPkt{}))
+
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	ret := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			ret[name] = true
+		}
+	}
+	return ret
+}
+
+func (p *SemtechUDPRxPkt) UnmarshalJSON(b []byte) error {
+	type plain SemtechUDPRxPkt
+	if err := json.Unmarshal(b, (*plain)(p)); err != nil {
+		return err
+	}
+
+	// Keep the fields we don't know about, so they can be forwarded as-is
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	p.Custom = nil
+	for name, value := range fields {
+		if !semtechUDPRxPktFields[name] {
+			if p.Custom == nil {
+				p.Custom = make(map[string]json.RawMessage)
+			}
+			p.Custom[name] = value
+		}
+	}
+	return nil
+}
+
+// The reasons a datagram can fail to decode
+var (
+	ErrTruncated   = errors.New("truncated packet")
+	ErrBadVersion  = errors.New("invalid protocol version")
+	ErrUnknownKind = errors.New("unknown packet kind")
+	ErrBadJSON     = errors.New("invalid JSON payload")
+)
+
+// The minimum length of each kind of packet: the header, the gateway EUI when
+// sent by the gateway, and at least the `{}` of the JSON object when required
+var semtechUDPMinLength = map[byte]int{
+	PUSH_DATA: 4 + 8 + 2,
+	PUSH_ACK:  4,
+	PULL_DATA: 4 + 8,
+	PULL_RESP: 4 + 2,
+	PULL_ACK:  4,
+	TX_ACK:    4 + 8,
+}
+
 func DecodeMessage(payload []byte, size int, sender *net.UDPAddr, time time.Time, tags []string) (*SemtechUDPMessage, error) {
-	if size < 4 {
-		return nil, fmt.Errorf("packet too small")
+	if size < 4 || size > len(payload) {
+		return nil, fmt.Errorf("%w (%d bytes)", ErrTruncated, size)
 	}
 	if payload[0] != PROTOCOL_VERSION {
-		return nil, fmt.Errorf("invalid protocol version (%d)", payload[0])
+		return nil, fmt.Errorf("%w (%d)", ErrBadVersion, payload[0])
+	}
+	minLength, ok := semtechUDPMinLength[payload[3]]
+	if !ok {
+		return nil, fmt.Errorf("%w (0x%02x)", ErrUnknownKind, payload[3])
+	}
+	if size < minLength {
+		return nil, fmt.Errorf("%w (%d bytes, expecting at least %d)", ErrTruncated, size, minLength)
 	}
 
 	msg := &SemtechUDPMessage{
@@ -133,25 +229,54 @@ func DecodeMessage(payload []byte, size int, sender *net.UDPAddr, time time.Time
 		parsedPayload: nil,
 	}
 
+	// Validate the JSON payload of the kinds that carry one (it is optional
+	// on TX_ACK)
+	if msg.Kind == PUSH_DATA || msg.Kind == PULL_RESP || (msg.Kind == TX_ACK && len(msg.Data) > 8) {
+		if _, err := msg.parseJsonPayload(); err != nil {
+			return nil, err
+		}
+	}
+
 	return msg, nil
 }
 
+// Returns the reason of a decoding error, as one of the ErrXXX values
+func DecodeErrorReason(err error) error {
+	for _, reason := range []error{ErrTruncated, ErrBadVersion, ErrUnknownKind, ErrBadJSON} {
+		if errors.Is(err, reason) {
+			return reason
+		}
+	}
+	return nil
+}
+
+func (m *SemtechUDPMessage) jsonOffset() int {
+	switch m.Kind {
+	case PUSH_DATA, TX_ACK:
+		return 8
+	case PULL_RESP:
+		return 0
+	}
+	return -1
+}
+
 func (m *SemtechUDPMessage) parseJsonPayload() (*SemtechUDPJsonPayload, error) {
 	var ret SemtechUDPJsonPayload
 	if m.parsedPayload != nil {
 		return m.parsedPayload, nil
 	}
 
-	var ofs int = 8
-	if m.Kind == PULL_RESP {
-		ofs = 0
+	ofs := m.jsonOffset()
+	if ofs < 0 || len(m.Data) < ofs {
+		return nil, fmt.Errorf("%w (no payload)", ErrTruncated)
 	}
 
 	err := json.Unmarshal(m.Data[ofs:], &ret)
 	if err != nil {
-		return nil, fmt.Errorf("could not parse JSON: %s", err.Error())
+		return nil, fmt.Errorf("%w: %s", ErrBadJSON, err.Error())
 	}
 
+	m.parsedPayload = &ret
 	return &ret, nil
 }
 
@@ -167,7 +292,7 @@ func (m *SemtechUDPMessage) GatewayEUI() []byte {
 	if m.customGwEUI != nil {
 		return m.customGwEUI
 	}
-	if m.Kind == PUSH_DATA || m.Kind == PULL_DATA || m.Kind == TX_ACK {
+	if (m.Kind == PUSH_DATA || m.Kind == PULL_DATA || m.Kind == TX_ACK) && len(m.Data) >= 8 {
 		return m.Data[0:8]
 	}
 	return nil
@@ -179,22 +304,19 @@ func (m *SemtechUDPMessage) GatewayID() string {
 	}
 
 	gwIdB := m.GatewayEUI()
+	if len(gwIdB) < 8 {
+		return ""
+	}
 	return fmt.Sprintf("eui-%02x%02x%02x%02x%02x%02x%02x%02x",
 		gwIdB[0], gwIdB[1], gwIdB[2], gwIdB[3], gwIdB[4], gwIdB[5], gwIdB[6], gwIdB[7])
 }
 
 func (m *SemtechUDPMessage) GetBody() []byte {
-	if m.Kind == PUSH_DATA {
-		return m.Data[8:]
+	ofs := m.jsonOffset()
+	if ofs < 0 || len(m.Data) < ofs {
+		return nil
 	}
-	if m.Kind == PULL_RESP {
-		return m.Data
-	}
-	if m.Kind == TX_ACK {
-		return m.Data[8:]
-	}
-
-	return nil
+	return m.Data[ofs:]
 }
 
 func (m *SemtechUDPMessage) GetStatMsg() (*SemtechUDPStat, error) {
@@ -260,64 +382,65 @@ type CodingRate struct {
 	LoRaSF  int
 	LoRaBw  int
 	FskRate int
+	// The modulation type and the occupied channel width of LR-FHSS
+	LRFHSSModulation   int
+	LRFHSSChannelWidth int
 }
 
-func (pkt *SemtechUDPTxPkt) GetCodingRate() (CodingRate, error) {
+// Parses the data rate of the given modulation. The data rate comes from the
+// gateway, so the unparsable ones are only logged at debug level.
+func parseSemtechCodingRate(modulation string, dataRate string) CodingRate {
 	var codr CodingRate
 
-	if pkt.Modulation == "LORA" {
-		bw := strings.Index(pkt.DataRate, "BW")
-		if bw == -1 {
-			log.Warnf("Unparsable data rate '%s'", pkt.DataRate)
+	switch modulation {
+	case "LORA":
+		// EG. 'SF7BW125'
+		bw := strings.Index(dataRate, "BW")
+		if bw < 2 || !strings.HasPrefix(dataRate, "SF") {
+			log.Debugf("Unparsable data rate '%s'", dataRate)
 		} else {
-			val, err := strconv.Atoi(pkt.DataRate[2:bw])
+			val, err := strconv.Atoi(dataRate[2:bw])
 			if err == nil {
 				codr.LoRaSF = val
 			}
-			val, err = strconv.Atoi(pkt.DataRate[bw+2:])
+			val, err = strconv.Atoi(dataRate[bw+2:])
 			if err == nil {
 				codr.LoRaBw = val
 			}
 		}
-	} else if pkt.Modulation == "FSK" {
-		val, err := strconv.Atoi(pkt.DataRate)
-		if err != nil {
+	case "FSK":
+		val, err := strconv.Atoi(dataRate)
+		if err == nil {
 			codr.FskRate = val
 		}
-	} else {
-		log.Warnf("Unknown modulation '%s'", pkt.Modulation)
-	}
-
-	return codr, nil
-}
-
-func (pkt *SemtechUDPRxPkt) GetCodingRate() (CodingRate, error) {
-	var codr CodingRate
-
-	if pkt.Modulation == "LORA" {
-		bw := strings.Index(pkt.DataRate, "BW")
-		if bw == -1 {
-			log.Warnf("Unparsable data rate '%s'", pkt.DataRate)
+	case "LR-FHSS":
+		// EG. 'M0CW137'
+		cw := strings.Index(dataRate, "CW")
+		if cw < 1 || !strings.HasPrefix(dataRate, "M") {
+			log.Debugf("Unparsable data rate '%s'", dataRate)
 		} else {
-			val, err := strconv.Atoi(pkt.DataRate[2:bw])
+			val, err := strconv.Atoi(dataRate[1:cw])
 			if err == nil {
-				codr.LoRaSF = val
+				codr.LRFHSSModulation = val
 			}
-			val, err = strconv.Atoi(pkt.DataRate[bw+2:])
+			val, err = strconv.Atoi(dataRate[cw+2:])
 			if err == nil {
-				codr.LoRaBw = val
+				codr.LRFHSSChannelWidth = val
 			}
 		}
-	} else if pkt.Modulation == "FSK" {
-		val, err := strconv.Atoi(pkt.DataRate)
-		if err != nil {
-			codr.FskRate = val
-		}
-	} else {
-		log.Warnf("Unknown modulation '%s'", pkt.Modulation)
+	default:
+		log.Debugf("Unknown modulation '%s'", modulation)
 	}
 
-	return codr, nil
+	return codr
+}
+
+func (pkt *SemtechUDPTxPkt) GetCodingRate() (CodingRate, error) {
+	return parseSemtechCodingRate(pkt.Modulation, string(pkt.DataRate)), nil
+}
+
+func (pkt *SemtechUDPRxPkt) GetCodingRate() (CodingRate, error) {
+	return parseSemtechCodingRate(pkt.Modulation, string(pkt.DataRate)), nil
 }
 
 ////////////////////////////////////////////////////////////////////////////////////
@@ -325,7 +448,7 @@ func (pkt *SemtechUDPRxPkt) GetCodingRate() (CodingRate, error) {
 ////////////////////////////////////////////////////////////////////////////////////
 
 func SemtechUDPIsDownlink(b []byte) bool {
-	if b[0] == PROTOCOL_VERSION {
+	if len(b) >= 4 && b[0] == PROTOCOL_VERSION {
 		switch b[3] {
 		case PUSH_DATA:
 			return false
@@ -347,7 +470,7 @@ func SemtechUDPIsDownlink(b []byte) bool {
 }
 
 func SemtechUDPIsUplink(b []byte) bool {
-	if b[0] == PROTOCOL_VERSION {
+	if len(b) >= 4 && b[0] == PROTOCOL_VERSION {
 		switch b[3] {
 		case PUSH_DATA:
 			return true
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
@@ -34,7 +36,10 @@ const (
 		"codr": "4/5",
 		"size": 21,
 		"data": "QKydCyYAQwMB7iu5QD6sH5u1C+Yh",
-		"rsig": [{ "ant": 0, "chan": 0, "rssic": -50, "lsnr": 13.2 }]
+		"rsig": [{ "ant": 0, "chan": 0, "rssic": -50, "lsnr": 13.2 }],
+		"aesk": 0,
+		"brd": 0,
+		"jver": 2
 	}`
 
 	PacketPushAck = "Ar43AQ=="
@@ -161,3 +166,128 @@ func TestParser(t *testing.T) {
 	assert.Equal(t, uint16(0x0400), p7.Token, "Unexpected tag")
 	assert.Equal(t, []byte{0x70, 0x76, 0xff, 0x0, 0x56, 0x6, 0x3, 0xe5}, p7.GatewayEUI(), "Unexpected gateway ID")
 }
+
+func TestDecodeErrors(t *testing.T) {
+	eui := []byte{0x70, 0x76, 0xff, 0x0, 0x56, 0x6, 0x3, 0xe5}
+	packet := func(kind byte, body ...[]byte) []byte {
+		ret := []byte{PROTOCOL_VERSION, 0x12, 0x34, kind}
+		for _, b := range body {
+			ret = append(ret, b...)
+		}
+		return ret
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", []byte{}, ErrTruncated},
+		{"short header", []byte{PROTOCOL_VERSION, 0, 0}, ErrTruncated},
+		{"bad version", []byte{1, 0, 0, PUSH_ACK}, ErrBadVersion},
+		{"unknown kind", packet(0x06), ErrUnknownKind},
+		{"push data without EUI", packet(PUSH_DATA, eui[0:4]), ErrTruncated},
+		{"push data without JSON", packet(PUSH_DATA, eui), ErrTruncated},
+		{"push data with bad JSON", packet(PUSH_DATA, eui, []byte("{\"rxpk\":")), ErrBadJSON},
+		{"pull data without EUI", packet(PULL_DATA, eui[0:7]), ErrTruncated},
+		{"pull resp without JSON", packet(PULL_RESP), ErrTruncated},
+		{"pull resp with bad JSON", packet(PULL_RESP, []byte("[1, 2")), ErrBadJSON},
+		{"tx ack without EUI", packet(TX_ACK, eui[0:2]), ErrTruncated},
+		{"tx ack with bad JSON", packet(TX_ACK, eui, []byte("x")), ErrBadJSON},
+		{"tx ack without JSON", packet(TX_ACK, eui), nil},
+		{"push ack", packet(PUSH_ACK), nil},
+	}
+	for _, tt := range tests {
+		msg, err := DecodeMessage(tt.data, len(tt.data), nil, time.Now(), nil)
+		if tt.err == nil {
+			assert.NoError(t, err, tt.name)
+			assert.NotNil(t, msg, tt.name)
+		} else {
+			assert.ErrorIs(t, err, tt.err, tt.name)
+			assert.Equal(t, tt.err, DecodeErrorReason(err), tt.name)
+			assert.Nil(t, msg, tt.name)
+		}
+	}
+
+	// The size can not exceed the buffer
+	_, err := DecodeMessage([]byte{PROTOCOL_VERSION, 0, 0, PUSH_ACK}, 100, nil, time.Now(), nil)
+	assert.ErrorIs(t, err, ErrTruncated)
+
+	// The helpers do not index past the end of short buffers
+	assert.False(t, SemtechUDPIsUplink([]byte{PROTOCOL_VERSION}))
+	assert.False(t, SemtechUDPIsDownlink([]byte{}))
+}
+
+func FuzzDecodeMessage(f *testing.F) {
+	for _, s := range []string{PacketPullReq, PacketPullAck, PacketPushDataStat, PacketPushDataUp, PacketPushAck, PacketPullResp, PacketTxAck} {
+		b, _ := base64.StdEncoding.DecodeString(s)
+		f.Add(b)
+	}
+	f.Add([]byte{PROTOCOL_VERSION, 0, 0, PUSH_DATA, 1, 2, 3, 4, 5, 6, 7, 8, '{', '}'})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		SemtechUDPIsUplink(data)
+		SemtechUDPIsDownlink(data)
+
+		msg, err := DecodeMessage(data, len(data), nil, time.Now(), nil)
+		if err != nil {
+			if DecodeErrorReason(err) == nil {
+				t.Fatalf("Untyped decode error: %s", err.Error())
+			}
+			return
+		}
+
+		// None of the accessors may panic on a decoded message
+		msg.GatewayEUI()
+		msg.GatewayID()
+		msg.GetBody()
+		msg.GetAllRxPkt()
+		msg.GetStatMsg()
+		msg.GetTxPacket()
+		if !bytes.Equal(msg.Encode(), data) {
+			t.Fatalf("Re-encoded message is different: %x", msg.Encode())
+		}
+		if (msg.Kind == PUSH_DATA || msg.Kind == PULL_DATA || msg.Kind == TX_ACK) && len(msg.GatewayEUI()) != 8 {
+			t.Fatalf("Missing gateway EUI")
+		}
+	})
+}
+
+func TestCodingRate(t *testing.T) {
+	rx := SemtechUDPRxPkt{Modulation: "LR-FHSS", DataRate: "M0CW137"}
+	codr, err := rx.GetCodingRate()
+	assert.NoError(t, err)
+	assert.Equal(t, CodingRate{LRFHSSModulation: 0, LRFHSSChannelWidth: 137}, codr)
+
+	tx := SemtechUDPTxPkt{Modulation: "LR-FHSS", DataRate: "M1CW336"}
+	codr, err = tx.GetCodingRate()
+	assert.NoError(t, err)
+	assert.Equal(t, CodingRate{LRFHSSModulation: 1, LRFHSSChannelWidth: 336}, codr)
+
+	rx = SemtechUDPRxPkt{Modulation: "LORA", DataRate: "SF9BW125"}
+	codr, _ = rx.GetCodingRate()
+	assert.Equal(t, CodingRate{LoRaSF: 9, LoRaBw: 125}, codr)
+}
+
+func FuzzGetCodingRate(f *testing.F) {
+	f.Add("LORA", "SF7BW125")
+	f.Add("FSK", "50000")
+	f.Add("LR-FHSS", "M0CW137")
+
+	f.Fuzz(func(t *testing.T, modu string, datr string) {
+		rx := SemtechUDPRxPkt{Modulation: modu, DataRate: SemtechUDPDataRate(datr)}
+		codr, _ := rx.GetCodingRate()
+		if (codr.LoRaSF != 0 || codr.LoRaBw != 0) && (modu != "LORA" || !strings.HasPrefix(datr, "SF")) {
+			t.Fatalf("Unexpected LoRa data rate from '%s'", datr)
+		}
+		if codr.FskRate != 0 && modu != "FSK" {
+			t.Fatalf("Unexpected FSK data rate from '%s'", datr)
+		}
+		if (codr.LRFHSSModulation != 0 || codr.LRFHSSChannelWidth != 0) && (modu != "LR-FHSS" || !strings.HasPrefix(datr, "M")) {
+			t.Fatalf("Unexpected LR-FHSS data rate from '%s'", datr)
+		}
+
+		tx := SemtechUDPTxPkt{Modulation: modu, DataRate: SemtechUDPDataRate(datr)}
+		tx.GetCodingRate()
+	})
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"github.com/kudzutechnologies/analytics/client"
+	"github.com/namsral/flag"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Returns the name of the frame type used on the command line (eg. 'unconfirmed-up')
+func frameTypeName(t api.FrameType) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(t.String(), "FRAME_"), "_", "-"))
+}
+
+// Parses the comma-separated frame types (eg. 'join-request,confirmed-up')
+func parseFrameTypes(list string) ([]api.FrameType, error) {
+	var ret []api.FrameType
+	for _, name := range splitList(list) {
+		found := false
+		for value := range api.FrameType_name {
+			if t := api.FrameType(value); frameTypeName(t) == strings.ToLower(name) {
+				ret = append(ret, t)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown frame type '%s'", name)
+		}
+	}
+	return ret, nil
+}
+
+// Parses the comma-separated device addresses, in hex as they are usually written
+func parseDevAddrs(list string) ([]uint32, error) {
+	var ret []uint32
+	for _, addr := range splitList(list) {
+		value, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(addr), "0x"), 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid device address '%s'", addr)
+		}
+		ret = append(ret, uint32(value))
+	}
+	return ret, nil
+}
+
+// Creates the filter of the subscription, where the gateway is either an EUI
+// or an ID
+func tailFilter(gateway, devAddrs, frameTypes string) (*api.ReqSubscribe, error) {
+	filter := &api.ReqSubscribe{}
+	if gateway != "" {
+		if eui, err := parseGatewayEUI(gateway); err == nil {
+			b, _ := hex.DecodeString(eui)
+			filter.Gateway = &api.GatewaySelector{GatewayEui: b}
+		} else {
+			filter.Gateway = &api.GatewaySelector{GatewayId: gateway}
+		}
+	}
+
+	var err error
+	if filter.DevAddr, err = parseDevAddrs(devAddrs); err != nil {
+		return nil, err
+	}
+	if filter.FrameType, err = parseFrameTypes(frameTypes); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// Describes the LoRaWAN header of a frame (eg. 'unconfirmed-up 26011234 fcnt=12')
+func formatFhdr(fhdr []byte) string {
+	if len(fhdr) == 0 {
+		return "-"
+	}
+	mtype := api.FrameType(fhdr[0] >> 5)
+	ret := frameTypeName(mtype)
+	switch mtype {
+	case api.FrameType_FRAME_JOIN_REQUEST:
+		if len(fhdr) >= 17 {
+			ret += fmt.Sprintf(" join-eui=%016x dev-eui=%016x",
+				binary.LittleEndian.Uint64(fhdr[1:9]), binary.LittleEndian.Uint64(fhdr[9:17]))
+		}
+	case api.FrameType_FRAME_JOIN_ACCEPT, api.FrameType_FRAME_REJOIN_REQUEST, api.FrameType_FRAME_PROPRIETARY:
+	default:
+		if len(fhdr) >= 8 {
+			ret += fmt.Sprintf(" %08x fcnt=%d", binary.LittleEndian.Uint32(fhdr[1:5]), binary.LittleEndian.Uint16(fhdr[6:8]))
+		}
+	}
+	return ret
+}
+
+// Describes the data rate in the notation of the Semtech UDP protocol (eg. 'SF7BW125')
+func formatDataRate(lora *api.LoRaDataRate, fsk uint32, lrfhss *api.LRFHSSDataRate) string {
+	switch {
+	case lora != nil:
+		bw := strings.TrimSuffix(strings.TrimPrefix(lora.Bandwidth.String(), "BW_"), "k")
+		return lora.SpreadingFactor.String() + "BW" + bw
+	case lrfhss != nil:
+		return fmt.Sprintf("M%dCW%d", lrfhss.ModulationType, lrfhss.ChannelWidth)
+	case fsk != 0:
+		return fmt.Sprintf("FSK%d", fsk)
+	}
+	return "-"
+}
+
+// Formats the event as a single line, like:
+//
+//	12:00:00.000 gw-1 ▲ unconfirmed-up 26011234 fcnt=12 868.100MHz SF7BW125 23B rssi=-80 snr=7.5
+func formatTrafficEvent(event *api.TrafficEvent) string {
+	gateway := event.GatewayId
+	if gateway == "" {
+		gateway = hex.EncodeToString(event.GatewayEui)
+	}
+
+	if up := event.GetUplink(); up != nil {
+		line := fmt.Sprintf("%s %s ▲ %s %.3fMHz %s %dB",
+			time.UnixMicro(up.RxWallTime).Format("15:04:05.000"), gateway, formatFhdr(up.Fhdr),
+			up.Frequency, formatDataRate(up.GetDataRateLoRa(), up.GetDataRateFSK(), up.GetDataRateLRFHSS()), up.Size)
+		if len(up.Ant) > 0 {
+			line += fmt.Sprintf(" rssi=%d snr=%.1f", up.Ant[0].RSSIC, up.Ant[0].LSNR)
+		}
+		if up.Crc != api.CRCStatus_OK {
+			line += " crc=" + strings.ToLower(up.Crc.String())
+		}
+		return line
+	}
+	if dn := event.GetDownlink(); dn != nil {
+		return fmt.Sprintf("%s %s ▼ %s %.3fMHz %s %dB power=%.0f",
+			time.UnixMilli(dn.RxWallTime).Format("15:04:05.000"), gateway, formatFhdr(dn.Fhdr),
+			dn.Frequency, formatDataRate(dn.GetDataRateLoRa(), dn.GetDataRateFSK(), nil), dn.Size, dn.Power)
+	}
+	return fmt.Sprintf("%s ? unknown event", gateway)
+}
+
+// Prints the events of the subscription until it ends, returning its error
+func printTraffic(w io.Writer, sub *client.Subscription, asJson bool) error {
+	for event := range sub.Events() {
+		if asJson {
+			b, err := protojson.Marshal(event)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(w, string(b))
+		} else {
+			fmt.Fprintln(w, formatTrafficEvent(event))
+		}
+	}
+	return sub.Err()
+}
+
+// The `tail` command, that prints the live traffic the analytics service is
+// receiving, like `tcpdump` does
+func runTail(args []string) {
+	var config ForwarderConfig
+	var devAddrs, frameTypes, caFile string
+	var asJson bool
+
+	fs := flag.NewFlagSet("tail", flag.ExitOnError)
+	configFile := registerConfigFileFlag(fs)
+	registerConfigFlags(fs, &config)
+	fs.StringVar(&devAddrs, "dev-addr", "", "only show the frames of these (comma-separated) device addresses")
+	fs.StringVar(&frameTypes, "frame-type", "", "only show these (comma-separated) frame types (eg. 'join-request,unconfirmed-up')")
+	fs.StringVar(&caFile, "ca-file", "", "the CA certificate of the analytics endpoint (eg. for a local test server)")
+	fs.BoolVar(&asJson, "json", false, "print the events as JSON lines")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s tail [options]\n\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	loadConfigFile(fs, configFile)
+	applyConfigDefaults(&config)
+
+	if config.ClientId == "" || config.ClientKey == "" {
+		log.Fatalf("You must specify a client ID and key (--client-id=, --client-key=)")
+	}
+	// The gateway of the configuration (if any) is the one to follow
+	filter, err := tailFilter(config.GatewayId, devAddrs, frameTypes)
+	if err != nil {
+		log.Fatalf("Invalid filter: %s", err.Error())
+	}
+
+	clientConfig := analyticsClientConfig(&config)
+	clientConfig.CAFile = caFile
+	// Queries are only available over gRPC
+	clientConfig.Transport = client.TransportGRPC
+	c := client.CreateAnalyticsClient(clientConfig)
+	if err := c.Connect(); err != nil {
+		log.Fatalf("Could not connect to analytics endpoint: %s", err.Error())
+	}
+	defer c.Disconnect()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	// Subscribe again when the server goes away, until interrupted
+	for ctx.Err() == nil {
+		sub, err := c.Subscribe(ctx, filter)
+		if err == nil {
+			err = printTraffic(os.Stdout, sub, asJson)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil && status.Code(err) != codes.Unavailable {
+			log.Fatalf("Could not subscribe to the traffic: %s", err.Error())
+		}
+
+		log.Warnf("Subscription ended, subscribing again: %v", err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/kudzutechnologies/analytics/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTailFilter(t *testing.T) {
+	filter, err := tailFilter("01:02:03:04:05:06:07:08", "26011234, 0x260B5678", "join-request,Confirmed-Up")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8}, filter.Gateway.GatewayEui)
+	assert.Equal(t, "", filter.Gateway.GatewayId)
+	assert.Equal(t, []uint32{0x26011234, 0x260b5678}, filter.DevAddr)
+	assert.Equal(t, []api.FrameType{api.FrameType_FRAME_JOIN_REQUEST, api.FrameType_FRAME_CONFIRMED_UP}, filter.FrameType)
+
+	filter, err = tailFilter("gw-1", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "gw-1", filter.Gateway.GatewayId)
+	assert.Nil(t, filter.DevAddr)
+	assert.Nil(t, filter.FrameType)
+
+	filter, err = tailFilter("", "", "")
+	assert.NoError(t, err)
+	assert.Nil(t, filter.Gateway)
+
+	_, err = tailFilter("", "2601123g", "")
+	assert.Error(t, err)
+	_, err = tailFilter("", "", "confirmed-sideways")
+	assert.Error(t, err)
+}
+
+func TestFormatTrafficEvent(t *testing.T) {
+	up := &api.TrafficEvent{
+		GatewayId: "gw-1",
+		Frame: &api.TrafficEvent_Uplink{Uplink: &api.AnalyticsUplink{
+			RxWallTime: 1676937600123000,
+			Frequency:  868.1,
+			Crc:        api.CRCStatus_OK,
+			DataRate: &api.AnalyticsUplink_DataRateLoRa{DataRateLoRa: &api.LoRaDataRate{
+				SpreadingFactor: api.LoRaSF_SF7,
+				Bandwidth:       api.LoRaBW_BW_125k,
+			}},
+			Size: 23,
+			Fhdr: []byte{0x40, 0x34, 0x12, 0x01, 0x26, 0x00, 0x0c, 0x00, 0x01},
+			Ant:  []*api.AnalyticsUplinkAntenna{{RSSIC: -80, LSNR: 7.5}},
+		}},
+	}
+	line := formatTrafficEvent(up)
+	assert.Contains(t, line, " gw-1 ▲ unconfirmed-up 26011234 fcnt=12 868.100MHz SF7BW125 23B rssi=-80 snr=7.5")
+	assert.NotContains(t, line, "crc=")
+
+	up.GetUplink().Crc = api.CRCStatus_FAIL
+	assert.Contains(t, formatTrafficEvent(up), " crc=fail")
+
+	join := &api.TrafficEvent{
+		GatewayEui: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		Frame: &api.TrafficEvent_Uplink{Uplink: &api.AnalyticsUplink{
+			Fhdr: []byte{0x00, 8, 7, 6, 5, 4, 3, 2, 1, 0x18, 0x17, 0x16, 0x15, 0x14, 0x13, 0x12, 0x11, 0xaa, 0xbb},
+			DataRate: &api.AnalyticsUplink_DataRateLRFHSS{DataRateLRFHSS: &api.LRFHSSDataRate{
+				ModulationType: 0,
+				ChannelWidth:   137,
+			}},
+		}},
+	}
+	assert.Contains(t, formatTrafficEvent(join), " 0102030405060708 ▲ join-request join-eui=0102030405060708 dev-eui=1112131415161718 0.000MHz M0CW137 0B")
+
+	down := &api.TrafficEvent{
+		GatewayId: "gw-1",
+		Frame: &api.TrafficEvent_Downlink{Downlink: &api.AnalyticsDownlink{
+			RxWallTime: 1676937601000,
+			Frequency:  869.525,
+			DataRate:   &api.AnalyticsDownlink_DataRateFSK{DataRateFSK: 50000},
+			Size:       17,
+			Power:      14,
+			Fhdr:       []byte{0x60, 0x34, 0x12, 0x01, 0x26, 0x00, 0x03, 0x00},
+		}},
+	}
+	assert.Contains(t, formatTrafficEvent(down), " gw-1 ▼ unconfirmed-down 26011234 fcnt=3 869.525MHz FSK50000 17B power=14")
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// The start of the GPS time scale, that (unlike UTC) never inserts leap seconds
+var gpsEpoch = time.Date(1980, time.January, 6, 0, 0, 0, 0, time.UTC)
+
+// The UTC days that started after a leap second since the GPS epoch. After the
+// n-th entry, GPS time is n seconds ahead of UTC.
+var gpsLeapSeconds = []time.Time{
+	time.Date(1981, time.July, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1982, time.July, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1983, time.July, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1985, time.July, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1988, time.January, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1991, time.January, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1992, time.July, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1993, time.July, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1994, time.July, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1996, time.January, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1997, time.July, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(1999, time.January, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2006, time.January, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2009, time.January, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2012, time.July, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2015, time.July, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC),
+}
+
+// Converts a GPS time (in milliseconds since the GPS epoch, eg. `tmms`) to UTC
+func gpsToUTC(gpsMillis int64) time.Time {
+	gps := gpsEpoch.Add(time.Duration(gpsMillis) * time.Millisecond)
+	for i := len(gpsLeapSeconds) - 1; i >= 0; i-- {
+		offset := time.Duration(i+1) * time.Second
+		if !gps.Before(gpsLeapSeconds[i].Add(offset)) {
+			return gps.Add(-offset)
+		}
+	}
+	return gps
+}
+
+// Converts a UTC time to GPS time (in milliseconds since the GPS epoch)
+func utcToGPS(tm time.Time) int64 {
+	var offset time.Duration
+	for _, leap := range gpsLeapSeconds {
+		if tm.Before(leap) {
+			break
+		}
+		offset += time.Second
+	}
+	return tm.Add(offset).Sub(gpsEpoch).Milliseconds()
+}
+
+// The time formats used by the packet forwarders: RFC3339 with fractional
+// seconds in `rxpk`, and a plain date-time in `stat` (some forwarders use
+// RFC3339 in both)
+var semtechTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05 MST",
+}
+
+// Parses the `time` field of an `rxpk` or a `stat` object
+func parseSemtechTime(value string) (time.Time, error) {
+	for _, layout := range semtechTimeLayouts {
+		if tm, err := time.Parse(layout, value); err == nil {
+			return tm.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unparsable time '%s'", value)
+}
+
+const (
+	// The range of the 32-bit concentrator counter (tmst), that wraps every ~71 minutes
+	tmstRange = 1 << 32
+	// How far the counter can be from where the forwarder clock predicts it,
+	// before assuming that the concentrator has restarted
+	tmstResetThreshold = 10 * time.Second
+	// The length of the windows used for estimating the clock drift
+	clockDriftWindow = 10 * time.Minute
+	// How long to measure the drift against the same window, before starting over
+	// to follow the changes of the gateway clock (eg. with the temperature)
+	clockDriftMaxSpan = 6 * time.Hour
+)
+
+type tmstEvent int
+
+const (
+	tmstNone tmstEvent = iota
+	// The counter has wrapped around since the previous packet
+	tmstRollover
+	// The counter has jumped, usually because the concentrator was restarted
+	tmstReset
+)
+
+// The smallest offset between the forwarder clock and the gateway counter
+// observed within a window. The network latency only ever adds to the offset,
+// so the minimum is the closest to the true one.
+type clockWindow struct {
+	start     time.Time
+	minOffset int64
+	counter   int64
+	valid     bool
+}
+
+func (w *clockWindow) observe(counter int64, at time.Time) {
+	offset := at.UnixMicro() - counter
+	if !w.valid {
+		*w = clockWindow{start: at, minOffset: offset, counter: counter, valid: true}
+	} else if offset < w.minOffset {
+		w.minOffset = offset
+		w.counter = counter
+	}
+}
+
+// Tracks the concentrator counter of a single gateway, unwrapping it into a
+// monotonic 64-bit value and estimating its drift against the forwarder clock
+type gatewayClock struct {
+	lock     sync.Mutex
+	started  bool
+	lastTmst uint32
+	last     int64
+	lastAt   time.Time
+	anchor   clockWindow
+	curWin   clockWindow
+	drift    float64
+	hasDrift bool
+}
+
+// Unwraps the counter of an uplink received by the forwarder at the given time
+func (c *gatewayClock) Observe(tmst uint32, at time.Time) (int64, tmstEvent) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.started {
+		c.started = true
+		c.lastTmst = tmst
+		c.last = int64(tmst)
+		c.lastAt = at
+		c.curWin.observe(c.last, at)
+		return c.last, tmstNone
+	}
+
+	// Where the counter should be by now, according to the forwarder clock
+	elapsed := float64(at.Sub(c.lastAt).Microseconds())
+	if c.hasDrift {
+		elapsed *= 1 + c.drift/1e6
+	}
+	expected := c.last + int64(elapsed)
+
+	// Pick the number of wrap-arounds that brings the counter closest to that
+	delta := int64(tmst - c.lastTmst)
+	rounded := expected - c.last - delta + tmstRange/2
+	wraps := rounded / tmstRange
+	if rounded%tmstRange < 0 {
+		wraps -= 1
+	}
+	value := c.last + delta + wraps*tmstRange
+
+	event := tmstNone
+	if diff := value - expected; diff > tmstResetThreshold.Microseconds() || diff < -tmstResetThreshold.Microseconds() {
+		// Continue from the predicted value, so the result stays monotonic
+		value = expected
+		if value <= c.last {
+			value = c.last + 1
+		}
+		event = tmstReset
+		c.anchor = clockWindow{}
+		c.curWin = clockWindow{}
+	} else if value <= c.last {
+		// Late packet, that does not move the clock forward
+		return value, tmstNone
+	} else if int64(c.lastTmst)+(value-c.last) >= tmstRange {
+		event = tmstRollover
+	}
+
+	c.lastTmst = tmst
+	c.last = value
+	c.lastAt = at
+	c.updateDrift(value, at)
+	return value, event
+}
+
+// Compares the smallest offsets of the completed windows with the first one, so
+// that the latency noise gets less significant the longer the gateway is seen
+func (c *gatewayClock) updateDrift(counter int64, at time.Time) {
+	if c.curWin.valid && at.Sub(c.curWin.start) >= clockDriftWindow {
+		if !c.anchor.valid {
+			c.anchor = c.curWin
+		} else if span := c.curWin.counter - c.anchor.counter; span >= clockDriftWindow.Microseconds() {
+			// A fast gateway clock makes the offset shrink over time
+			c.drift = float64(c.anchor.minOffset-c.curWin.minOffset) / float64(span) * 1e6
+			c.hasDrift = true
+			if span >= clockDriftMaxSpan.Microseconds() {
+				c.anchor = c.curWin
+			}
+		}
+		c.curWin = clockWindow{}
+	}
+	c.curWin.observe(counter, at)
+}
+
+// Unwraps the counter of a downlink, that is scheduled shortly after the last uplink
+func (c *gatewayClock) Extend(tmst uint32) int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.started {
+		return 0
+	}
+	return c.last + int64(int32(tmst-c.lastTmst))
+}
+
+// Returns the estimated drift of the gateway clock (in ppm), or nil if there
+// are not enough observations yet
+func (c *gatewayClock) Drift() *float32 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.hasDrift {
+		return nil
+	}
+	drift := float32(c.drift)
+	return &drift
+}
+
+// The clocks of the gateways, by EUI
+type gatewayClocks struct {
+	clocks *lru.Cache[string, *gatewayClock]
+}
+
+func newGatewayClocks(size int) *gatewayClocks {
+	inst := &gatewayClocks{}
+	inst.clocks, _ = lru.New[string, *gatewayClock](size)
+	return inst
+}
+
+func (g *gatewayClocks) Get(eui []byte) *gatewayClock {
+	key := string(eui)
+	if found, ok := g.clocks.Get(key); ok {
+		return found
+	}
+
+	// Another thread might have created the clock in the meantime
+	clock := &gatewayClock{}
+	if found, ok, _ := g.clocks.PeekOrAdd(key, clock); ok {
+		return found
+	}
+	return clock
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGPSTime(t *testing.T) {
+	assert.Equal(t, gpsEpoch, gpsToUTC(0))
+	assert.Equal(t, int64(0), utcToGPS(gpsEpoch))
+
+	// 18 leap seconds since 2017
+	utc := time.Date(2023, 3, 1, 10, 0, 1, 0, time.UTC)
+	assert.Equal(t, int64(1361700019000), utcToGPS(utc))
+	assert.Equal(t, utc, gpsToUTC(1361700019000))
+
+	// Around a leap second
+	leap := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, leap, gpsToUTC(utcToGPS(leap)))
+	assert.Equal(t, utcToGPS(leap)-2000, utcToGPS(leap.Add(-time.Second)))
+	before := leap.Add(-time.Millisecond)
+	assert.Equal(t, before, gpsToUTC(utcToGPS(before)))
+}
+
+func TestParseSemtechTime(t *testing.T) {
+	tm, err := parseSemtechTime("2013-03-31T16:21:17.528002Z")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2013, 3, 31, 16, 21, 17, 528002000, time.UTC), tm)
+
+	tm, err = parseSemtechTime("2014-01-12 08:59:28 GMT")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2014, 1, 12, 8, 59, 28, 0, time.UTC), tm)
+
+	_, err = parseSemtechTime("")
+	assert.Error(t, err)
+	_, err = parseSemtechTime(`"2013-03-31T16:21:17Z"`)
+	assert.Error(t, err)
+}
+
+func TestGatewayClockUnwrap(t *testing.T) {
+	var clock gatewayClock
+	at := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	start := int64(tmstRange - 5000000)
+	assert.Equal(t, int64(0), clock.Extend(100))
+
+	// A packet every 10 seconds for 3 hours
+	rollovers := 0
+	last := int64(0)
+	for i := 0; i <= 1080; i++ {
+		expected := start + int64(i)*10000000
+		value, event := clock.Observe(uint32(expected), at.Add(time.Duration(i)*10*time.Second))
+		assert.Equal(t, expected, value)
+		assert.Greater(t, value, last)
+		assert.NotEqual(t, tmstReset, event)
+		if event == tmstRollover {
+			rollovers += 1
+		}
+		last = value
+	}
+	assert.Equal(t, 3, rollovers)
+
+	// Downlinks are scheduled after the last uplink, even across a wrap-around
+	assert.Equal(t, last+1000000, clock.Extend(uint32(last+1000000)))
+
+	// A gap longer than the wrap-around period
+	at = at.Add(10800 * time.Second)
+	last += 2 * 3600 * 1000000
+	value, event := clock.Observe(uint32(last), at.Add(2*time.Hour))
+	assert.Equal(t, last, value)
+	assert.Equal(t, tmstRollover, event)
+	at = at.Add(2 * time.Hour)
+
+	// A late packet does not move the clock
+	value, event = clock.Observe(uint32(last-1000), at)
+	assert.Equal(t, last-1000, value)
+	assert.Equal(t, tmstNone, event)
+
+	// A restarted concentrator continues from the forwarder clock
+	value, event = clock.Observe(1000, at.Add(time.Second))
+	assert.Equal(t, tmstReset, event)
+	assert.Equal(t, last+1000000, value)
+	value, event = clock.Observe(2001000, at.Add(3*time.Second))
+	assert.Equal(t, tmstNone, event)
+	assert.Equal(t, last+3000000, value)
+}
+
+func TestGatewayClockDrift(t *testing.T) {
+	var clock gatewayClock
+	rnd := rand.New(rand.NewSource(1))
+	at := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	assert.Nil(t, clock.Drift())
+
+	// The gateway clock runs 40 ppm fast, and the network adds up to 20 ms of latency
+	for i := 0; i < 720; i++ {
+		elapsed := time.Duration(i) * 5 * time.Second
+		tmst := uint32(int64(float64(elapsed.Microseconds()) * (1 + 40e-6)))
+		latency := time.Duration(rnd.Intn(20000)) * time.Microsecond
+		clock.Observe(tmst, at.Add(elapsed).Add(latency))
+	}
+
+	drift := clock.Drift()
+	if assert.NotNil(t, drift) {
+		assert.InDelta(t, 40, *drift, 2)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"net"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// The batch operations of a socket, implemented by the `ipv4` or the `ipv6`
+// package depending on its address family (their messages are the same type)
+type batchConn interface {
+	ReadBatch(msgs []ipv4.Message, flags int) (int, error)
+	WriteBatch(msgs []ipv4.Message, flags int) (int, error)
+}
+
+// Returns the batch operations of the socket, for its address family. The
+// result is safe for concurrent use, and is meant to be kept with the socket.
+func newBatchConn(conn *net.UDPConn) batchConn {
+	if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok && addr.IP.To4() == nil {
+		return ipv6.NewPacketConn(conn)
+	}
+	return ipv4.NewPacketConn(conn)
+}
+
+// A set of buffers for reading multiple datagrams from a socket with a single
+// system call, on the platforms that support it
+type udpBatch struct {
+	msgs []ipv4.Message
+	conn *net.UDPConn
+	pc   batchConn
+}
+
+func newUDPBatch(conn *net.UDPConn, size int, bufSize int) *udpBatch {
+	if size < 1 {
+		size = 1
+	}
+
+	b := &udpBatch{
+		msgs: make([]ipv4.Message, size),
+		conn: conn,
+		pc:   newBatchConn(conn),
+	}
+	for i := range b.msgs {
+		b.msgs[i].Buffers = [][]byte{make([]byte, bufSize)}
+	}
+	return b
+}
+
+// Blocks until at least one datagram is available and returns the number of
+// datagrams read
+func (b *udpBatch) Read() (int, error) {
+	if len(b.msgs) == 1 {
+		n, addr, err := b.conn.ReadFromUDP(b.msgs[0].Buffers[0])
+		if err != nil {
+			return 0, err
+		}
+		b.msgs[0].N = n
+		b.msgs[0].Addr = addr
+		return 1, nil
+	}
+
+	return readBatch(b.conn, b.pc, b.msgs)
+}
+
+// Returns the contents and the sender of the i-th datagram read
+func (b *udpBatch) Datagram(i int) ([]byte, *net.UDPAddr) {
+	msg := &b.msgs[i]
+	addr, _ := msg.Addr.(*net.UDPAddr)
+	return msg.Buffers[0][0:msg.N], addr
+}
+
+// Sends batches of datagrams on a socket, reusing its messages between the
+// batches. The socket's batch operations can be shared between writers, but
+// a writer is not safe for concurrent use.
+type udpWriter struct {
+	conn *net.UDPConn
+	pc   batchConn
+	msgs []ipv4.Message
+}
+
+// Creates a writer for the socket, using the given batch operations of the
+// socket or new ones when nil
+func newUDPWriter(conn *net.UDPConn, pc batchConn) *udpWriter {
+	if pc == nil {
+		pc = newBatchConn(conn)
+	}
+	return &udpWriter{conn: conn, pc: pc}
+}
+
+// Sends all the given datagrams to the address (or the connected remote if nil)
+// using as few system calls as possible
+func (w *udpWriter) Write(bufs [][]byte, addr *net.UDPAddr) error {
+	if len(bufs) == 1 {
+		var err error
+		if addr == nil {
+			_, err = w.conn.Write(bufs[0])
+		} else {
+			_, err = w.conn.WriteToUDP(bufs[0], addr)
+		}
+		return err
+	}
+
+	return writeBatchMsgs(w, bufs, addr)
+}
+
+// Returns the messages for the given datagrams, re-using the ones of the
+// previous batches
+func (w *udpWriter) messages(bufs [][]byte, addr *net.UDPAddr) []ipv4.Message {
+	var netAddr net.Addr
+	if addr != nil {
+		netAddr = addr
+	}
+
+	for len(w.msgs) < len(bufs) {
+		w.msgs = append(w.msgs, ipv4.Message{Buffers: make([][]byte, 1)})
+	}
+	msgs := w.msgs[:len(bufs)]
+	for i, buf := range bufs {
+		msgs[i].Buffers[0] = buf
+		msgs[i].Addr = netAddr
+	}
+	return msgs
+}
+
+// Drops the references to the datagrams of the last batch, so their buffers
+// can be collected
+func (w *udpWriter) release(msgs []ipv4.Message) {
+	for i := range msgs {
+		msgs[i].Buffers[0] = nil
+		msgs[i].Addr = nil
+	}
+}
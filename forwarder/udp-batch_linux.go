@@ -0,0 +1,55 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"net"
+	"syscall"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/sys/unix"
+)
+
+// Uses recvmmsg to read as many datagrams as available
+func readBatch(conn *net.UDPConn, pc batchConn, msgs []ipv4.Message) (int, error) {
+	return pc.ReadBatch(msgs, 0)
+}
+
+// Uses sendmmsg to send all the datagrams, retrying if only part of the batch was sent
+func writeBatchMsgs(w *udpWriter, bufs [][]byte, addr *net.UDPAddr) error {
+	msgs := w.messages(bufs, addr)
+	defer w.release(msgs)
+
+	for len(msgs) > 0 {
+		n, err := w.pc.WriteBatch(msgs, 0)
+		if err != nil {
+			return err
+		}
+		msgs = msgs[n:]
+	}
+	return nil
+}
+
+// Opens a socket that shares the same address with other worker sockets, letting
+// the kernel distribute the incoming datagrams between them
+func listenUDPReusePort(addr *net.UDPAddr) (*net.UDPConn, error) {
+	lc := net.ListenConfig{
+		Control: func(network, address string, c syscall.RawConn) error {
+			var opErr error
+			err := c.Control(func(fd uintptr) {
+				opErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+			})
+			if err != nil {
+				return err
+			}
+			return opErr
+		},
+	}
+
+	conn, err := lc.ListenPacket(context.Background(), "udp", addr.String())
+	if err != nil {
+		return nil, err
+	}
+	return conn.(*net.UDPConn), nil
+}
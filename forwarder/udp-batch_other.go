@@ -0,0 +1,41 @@
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+	"net"
+
+	"golang.org/x/net/ipv4"
+)
+
+// Batching is not supported, so read a single datagram
+func readBatch(conn *net.UDPConn, pc batchConn, msgs []ipv4.Message) (int, error) {
+	n, addr, err := conn.ReadFromUDP(msgs[0].Buffers[0])
+	if err != nil {
+		return 0, err
+	}
+	msgs[0].N = n
+	msgs[0].Addr = addr
+	return 1, nil
+}
+
+// Batching is not supported, so send the datagrams one by one
+func writeBatchMsgs(w *udpWriter, bufs [][]byte, addr *net.UDPAddr) error {
+	for _, buf := range bufs {
+		var err error
+		if addr == nil {
+			_, err = w.conn.Write(buf)
+		} else {
+			_, err = w.conn.WriteToUDP(buf, addr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func listenUDPReusePort(addr *net.UDPAddr) (*net.UDPConn, error) {
+	return nil, fmt.Errorf("SO_REUSEPORT is not supported on this platform")
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+func TestNewBatchConn(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+	defer conn.Close()
+	assert.IsType(t, &ipv4.PacketConn{}, newBatchConn(conn))
+
+	// Including the dual-stack sockets listening on all addresses
+	requireIPv6(t)
+	conn, err = net.ListenUDP("udp", &net.UDPAddr{})
+	assert.NoError(t, err)
+	defer conn.Close()
+	assert.IsType(t, &ipv6.PacketConn{}, newBatchConn(conn))
+}
+
+func TestUDPBatch(t *testing.T) {
+	for _, host := range []string{"127.0.0.1", "::1"} {
+		t.Run(host, func(t *testing.T) {
+			if host == "::1" {
+				requireIPv6(t)
+			}
+			ip := net.ParseIP(host)
+			receiver, err := net.ListenUDP("udp", &net.UDPAddr{IP: ip})
+			assert.NoError(t, err)
+			defer receiver.Close()
+			sender, err := net.ListenUDP("udp", &net.UDPAddr{IP: ip})
+			assert.NoError(t, err)
+			defer sender.Close()
+
+			// The messages of the writer are re-used between the batches
+			writer := newUDPWriter(sender, nil)
+			var sent []string
+			for _, size := range []int{3, 2, 1, 4} {
+				var bufs [][]byte
+				for i := 0; i < size; i++ {
+					bufs = append(bufs, []byte(fmt.Sprintf("datagram %d", len(sent))))
+					sent = append(sent, string(bufs[i]))
+				}
+				assert.NoError(t, writer.Write(bufs, receiver.LocalAddr().(*net.UDPAddr)))
+			}
+			assert.Len(t, writer.msgs, 4)
+			for _, msg := range writer.msgs {
+				assert.Nil(t, msg.Buffers[0])
+			}
+
+			batch := newUDPBatch(receiver, 8, 1500)
+			receiver.SetReadDeadline(time.Now().Add(time.Second))
+			var received []string
+			for len(received) < len(sent) {
+				n, err := batch.Read()
+				if !assert.NoError(t, err) {
+					return
+				}
+				for i := 0; i < n; i++ {
+					data, addr := batch.Datagram(i)
+					assert.Equal(t, sender.LocalAddr().String(), addr.String())
+					received = append(received, string(data))
+				}
+			}
+			assert.Equal(t, sent, received)
+		})
+	}
+}
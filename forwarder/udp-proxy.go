@@ -1,11 +1,11 @@
 package main
 
 import (
-	"encoding/base64"
 	"fmt"
 	"net"
-	"os"
+	"net/netip"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	lru "github.com/hashicorp/golang-lru/v2"
@@ -13,15 +13,30 @@ import (
 )
 
 type UDPProxy struct {
-	closed       bool
-	closeWg      sync.WaitGroup
-	upSock       *net.UDPConn
-	dnSock       *net.UDPConn
-	config       *UDPProxyConfig
-	upStreams    *lru.Cache[string, *ProxyStream]
-	dnStreams    *lru.Cache[string, *ProxyStream]
-	streamIds    *lru.Cache[string, int]
-	lastStreamId int
+	closed        atomic.Bool
+	closeWg       sync.WaitGroup
+	closeOnce     sync.Once
+	upSock        *net.UDPConn
+	dnSock        *net.UDPConn
+	workerSocks   []*net.UDPConn
+	config        *UDPProxyConfig
+	upStreams     *lru.Cache[string, *ProxyStream]
+	dnStreams     *lru.Cache[string, *ProxyStream]
+	streamIds     *lru.Cache[string, int]
+	lastStreamId  atomic.Int64
+	queue         chan proxyEvent
+	queueDone     chan bool
+	queueWg       sync.WaitGroup
+	bufPool       sync.Pool
+	droppedEvents uint64
+
+	// How many streams were evicted to make room for new ones
+	evictedStreams uint64
+
+	// The gateway policy and the traffic dump, that can be replaced while running
+	policy   atomic.Pointer[GatewayPolicy]
+	dumpLock sync.RWMutex
+	dump     DumpWriter
 }
 
 type UDPProxyConfig struct {
@@ -35,7 +50,16 @@ type UDPProxyConfig struct {
 	SocketStreams       int
 	ReconnectInterval   int
 	Events              UDPProxyEvents
-	DumpFile            *os.File
+	DumpWriter          DumpWriter
+	Policy              *GatewayPolicy
+
+	// How many events to queue for the event handler. When zero, the events
+	// are handled synchronously by the socket threads.
+	QueueSize int
+	// How many datagrams to read or write with a single system call
+	BatchSize int
+	// How many sockets to open on each listen address (using SO_REUSEPORT)
+	Workers int
 }
 
 type UDPProxyEvents interface {
@@ -45,11 +69,27 @@ type UDPProxyEvents interface {
 	DnRemoteData([]byte, *net.UDPAddr)
 }
 
+const (
+	eventUpLocal = iota
+	eventUpRemote
+	eventDnLocal
+	eventDnRemote
+)
+
+// An event waiting in the queue, with a copy of the datagram in a pooled buffer
+type proxyEvent struct {
+	kind int
+	buf  *[]byte
+	addr *net.UDPAddr
+}
+
 func CreateUDPProxy(config *UDPProxyConfig) (*UDPProxy, error) {
 	var err error
 	inst := &UDPProxy{
 		config: config,
+		dump:   config.DumpWriter,
 	}
+	inst.policy.Store(config.Policy)
 
 	inst.upStreams, err = lru.NewWithEvict(config.SocketStreams, inst.evictStream)
 	if err != nil {
@@ -64,6 +104,18 @@ func CreateUDPProxy(config *UDPProxyConfig) (*UDPProxy, error) {
 		return nil, fmt.Errorf("could not allocate indices: %w", err)
 	}
 
+	// Decouple the event handler from the socket threads
+	if config.QueueSize > 0 {
+		inst.bufPool.New = func() any {
+			buf := make([]byte, config.BufferSize)
+			return &buf
+		}
+		inst.queue = make(chan proxyEvent, config.QueueSize)
+		inst.queueDone = make(chan bool)
+		inst.queueWg.Add(1)
+		go inst.eventThread()
+	}
+
 	err = inst.bindLocal()
 	return inst, err
 }
@@ -71,53 +123,201 @@ func CreateUDPProxy(config *UDPProxyConfig) (*UDPProxy, error) {
 func (s *UDPProxy) Close() {
 	s.closeAll()
 	s.joinThreads()
+
+	s.closeOnce.Do(func() {
+		if s.queue != nil {
+			close(s.queueDone)
+			s.queueWg.Wait()
+		}
+		s.SetDumpWriter(nil)
+	})
 }
 
 func (s *UDPProxy) SetEventHandler(events UDPProxyEvents) {
 	s.config.Events = events
 }
 
-func (p *UDPProxy) writeDump(stream int, data []byte) {
-	if p.config.DumpFile == nil {
+// Returns the number of events waiting to be handled
+func (s *UDPProxy) QueueDepth() int {
+	return len(s.queue)
+}
+
+// Returns the number of events dropped because the queue was full
+func (s *UDPProxy) DroppedEvents() uint64 {
+	return atomic.LoadUint64(&s.droppedEvents)
+}
+
+// Returns the number of active up and down streams
+func (s *UDPProxy) StreamCounts() (int, int) {
+	return s.upStreams.Len(), s.dnStreams.Len()
+}
+
+// Returns the number of streams evicted because there were too many gateways
+func (s *UDPProxy) EvictedStreams() uint64 {
+	return atomic.LoadUint64(&s.evictedStreams)
+}
+
+// Returns the number of datagrams rejected by the gateway policy since the last call
+func (s *UDPProxy) RejectedPackets() uint64 {
+	return s.policy.Load().RejectedSinceLast()
+}
+
+// Replaces the gateway policy (nil to accept all the gateways)
+func (s *UDPProxy) SetPolicy(policy *GatewayPolicy) {
+	s.policy.Store(policy)
+}
+
+// Replaces the writer of the traffic dump (nil to stop dumping), closing the
+// previous one
+func (s *UDPProxy) SetDumpWriter(writer DumpWriter) {
+	s.dumpLock.Lock()
+	prev := s.dump
+	s.dump = writer
+	s.dumpLock.Unlock()
+
+	if prev != nil {
+		if err := prev.Close(); err != nil {
+			log.Warnf("Error closing dump file: %s", err.Error())
+		}
+	}
+}
+
+// Returns true if the traffic is being dumped
+func (s *UDPProxy) IsDumping() bool {
+	s.dumpLock.RLock()
+	defer s.dumpLock.RUnlock()
+	return s.dump != nil
+}
+
+// Starts a new file of the traffic dump, if it's rotating
+func (s *UDPProxy) RotateDump() error {
+	s.dumpLock.RLock()
+	defer s.dumpLock.RUnlock()
+	if s.dump == nil {
+		return fmt.Errorf("not dumping the traffic")
+	}
+	rotator, ok := s.dump.(interface{ Rotate() error })
+	if !ok {
+		return fmt.Errorf("the traffic dump can't be rotated")
+	}
+	return rotator.Rotate()
+}
+
+// Returns the address of the local socket that the gateway sends to. The
+// sockets listening on all the addresses only know the unspecified address,
+// so the one that the system uses to reach the gateway is returned instead.
+func gatewayLocalAddr(sock *net.UDPConn, gateway *net.UDPAddr) *net.UDPAddr {
+	local, _ := sock.LocalAddr().(*net.UDPAddr)
+	if local == nil || !local.IP.IsUnspecified() {
+		return local
+	}
+
+	// Connecting a UDP socket only selects the route, without sending anything
+	conn, err := net.DialUDP("udp", nil, gateway)
+	if err != nil {
+		return local
+	}
+	defer conn.Close()
+	routed, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return local
+	}
+	return &net.UDPAddr{IP: routed.IP, Port: local.Port}
+}
+
+// Records a datagram exchanged with the gateway, on the local address it sends to
+func (p *UDPProxy) writeDump(stream int, inbound bool, local *net.UDPAddr, gateway *net.UDPAddr, data []byte) {
+	p.dumpLock.RLock()
+	defer p.dumpLock.RUnlock()
+	if p.dump == nil {
 		return
 	}
 
-	text := fmt.Sprintf("%d:%s\n", stream, base64.StdEncoding.EncodeToString(data))
-	if _, err := p.config.DumpFile.WriteString(text); err != nil {
+	rec := &DumpRecord{
+		Time:    time.Now(),
+		Stream:  stream,
+		Inbound: inbound,
+		Src:     local,
+		Dst:     gateway,
+		Data:    data,
+	}
+	if inbound {
+		rec.Src, rec.Dst = gateway, local
+	}
+
+	if err := p.dump.WriteRecord(rec); err != nil {
 		log.Warnf("Error writing to dump file: %s", err.Error())
-	} else {
-		p.config.DumpFile.Sync()
 	}
 }
 
+// Returns the key that identifies the stream of the given remote address. IPv4
+// addresses received on dual-stack sockets are un-mapped, so that they always
+// resolve to the same stream.
+func streamKey(addr *net.UDPAddr) string {
+	ap := addr.AddrPort()
+	return netip.AddrPortFrom(ap.Addr().Unmap(), ap.Port()).String()
+}
+
 func (s *UDPProxy) evictStream(key string, stream *ProxyStream) {
 	log.Debugf("[%s] Stream evicted", stream.conf.Name)
 	stream.Close()
 }
 
+// Opens the sockets for the given address, one per worker
+func (s *UDPProxy) listenLocal(addr *net.UDPAddr) ([]*net.UDPConn, error) {
+	if s.config.Workers <= 1 {
+		sock, err := net.ListenUDP("udp", addr)
+		if err != nil {
+			return nil, err
+		}
+		return []*net.UDPConn{sock}, nil
+	}
+
+	var socks []*net.UDPConn
+	for i := 0; i < s.config.Workers; i++ {
+		sock, err := listenUDPReusePort(addr)
+		if err != nil {
+			for _, sock := range socks {
+				sock.Close()
+			}
+			return nil, err
+		}
+		socks = append(socks, sock)
+	}
+	return socks, nil
+}
+
 func (s *UDPProxy) bindLocal() error {
-	var err error
-	s.closed = false
+	s.closed.Store(false)
+	s.workerSocks = nil
 
 	// Open first connection
-	s.upSock, err = net.ListenUDP("udp", s.config.UpListenAddr)
+	upSocks, err := s.listenLocal(s.config.UpListenAddr)
 	if err != nil {
 		return fmt.Errorf("could not bind to %s for UP: %w", s.config.UpListenAddr.String(), err)
 	}
 
-	s.closeWg.Add(1)
-	go s.upThread()
+	s.upSock = upSocks[0]
+	s.workerSocks = append(s.workerSocks, upSocks[1:]...)
+	for _, sock := range upSocks {
+		s.closeWg.Add(1)
+		go s.localThread("up", sock, s.upStreams, s.getUpStreamFor, eventUpLocal)
+	}
 	log.Infof("[up] Listening on %s for uplinks", s.config.UpListenAddr.String())
 
 	// Open second connection
 	if s.config.DownListenAddr != nil {
-		s.dnSock, err = net.ListenUDP("udp", s.config.DownListenAddr)
+		dnSocks, err := s.listenLocal(s.config.DownListenAddr)
 		if err != nil {
 			return fmt.Errorf("could not bind to %s for DOWN: %w", s.config.DownListenAddr.String(), err)
 		}
 
-		s.closeWg.Add(1)
-		go s.dnThread()
+		s.dnSock = dnSocks[0]
+		s.workerSocks = append(s.workerSocks, dnSocks[1:]...)
+		for _, sock := range dnSocks {
+			s.closeWg.Add(1)
+			go s.localThread("dn", sock, s.dnStreams, s.getDnStreamFor, eventDnLocal)
+		}
 		log.Infof("[dn] Listening on %s for downlinks", s.config.DownListenAddr.String())
 	} else {
 		log.Infof("[dn] Also listening on %s for downlinks", s.config.UpListenAddr.String())
@@ -135,11 +335,10 @@ func (s *UDPProxy) bindLocalWithBackoff() {
 }
 
 func (s *UDPProxy) closeAll() {
-	if s.closed {
+	if !s.closed.CompareAndSwap(false, true) {
 		return
 	}
 
-	s.closed = true
 	if s.upSock != nil {
 		log.Debugf("[up] Closing socket")
 		s.upSock.Close()
@@ -148,6 +347,9 @@ func (s *UDPProxy) closeAll() {
 		log.Debugf("[dn] Closing socket")
 		s.dnSock.Close()
 	}
+	for _, sock := range s.workerSocks {
+		sock.Close()
+	}
 
 	log.Debugf("[up] Purging %d streams", s.upStreams.Len())
 	s.upStreams.Purge()
@@ -161,7 +363,7 @@ func (s *UDPProxy) joinThreads() {
 }
 
 func (s *UDPProxy) scheduleRestart() {
-	if s.closed {
+	if s.closed.Load() {
 		return
 	}
 
@@ -179,87 +381,160 @@ func (s *UDPProxy) scheduleRestart() {
 	}()
 }
 
-func (s *UDPProxy) upThread() {
+// Reads datagrams from a local socket and forwards them to the stream of each sender
+func (s *UDPProxy) localThread(name string, sock *net.UDPConn, streams *lru.Cache[string, *ProxyStream],
+	getStream func(*net.UDPConn, batchConn, *net.UDPAddr, []byte) *ProxyStream, kind int) {
 	defer s.closeWg.Done()
 
-	b := make([]byte, s.config.BufferSize)
-	log.Debugf("[up] Started thread")
+	type streamBatch struct {
+		stream *ProxyStream
+		addr   *net.UDPAddr
+		data   [][]byte
+	}
+
+	batch := newUDPBatch(sock, s.config.BatchSize, s.config.BufferSize)
+	var pending []streamBatch
+	log.Debugf("[%s] Started thread", name)
 
-	for !s.closed {
-		n, addr, err := s.upSock.ReadFromUDP(b)
-		if s.closed {
+	for !s.closed.Load() {
+		n, err := batch.Read()
+		if s.closed.Load() {
 			break
 		}
 
 		if err != nil {
-			log.Errorf("[up] Unable to read from local socket: %s", err.Error())
+			log.Errorf("[%s] Unable to read from local socket: %s", name, err.Error())
 			s.scheduleRestart()
 			break
 		}
 
-		stream := s.getUpStreamFor(addr, b[0:n])
-		s.writeDump(stream.conf.Index*2+0, b[0:n])
+		// Group the datagrams per stream, so they can be forwarded together
+		pending = pending[:0]
+		for i := 0; i < n; i++ {
+			data, addr := batch.Datagram(i)
 
-		err = stream.HandleLocalData(b[0:n])
-		if err != nil {
-			log.Warnf("[up:%s] Could not write to remote: %s", addr.String(), err.Error())
-			log.Debugf("[up:%s] Evicting due to error", addr.String())
-			s.upStreams.Remove(addr.String())
-			break
-		} else {
-			if s.config.Events != nil {
-				s.config.Events.UpLocalData(b[0:n], addr)
+			// Apply the gateway policy before creating any stream
+			if err := s.policy.Load().Check(data, addr); err != nil {
+				log.Debugf("[%s:%s] Rejected datagram: %s", name, addr.String(), err.Error())
+				continue
+			}
+
+			stream := getStream(sock, batch.pc, addr, data)
+			s.writeDump(stream.conf.Index*2+0, true, stream.conf.LocalAddress, addr, data)
+
+			found := false
+			for j := range pending {
+				if pending[j].stream == stream {
+					pending[j].data = append(pending[j].data, data)
+					found = true
+					break
+				}
+			}
+			if !found {
+				pending = append(pending, streamBatch{stream, addr, [][]byte{data}})
+			}
+		}
+
+		for _, p := range pending {
+			err = p.stream.HandleLocalBatch(p.data)
+			if err != nil {
+				log.Warnf("[%s:%s] Could not write to remote: %s", name, p.addr.String(), err.Error())
+				log.Debugf("[%s:%s] Evicting due to error", name, p.addr.String())
+				streams.Remove(streamKey(p.addr))
+				continue
+			}
+
+			for _, data := range p.data {
+				s.emit(kind, data, p.addr)
 			}
 		}
 	}
 
-	log.Debugf("[up] Exited thread")
+	log.Debugf("[%s] Exited thread", name)
 }
 
-func (s *UDPProxy) dnThread() {
-	defer s.closeWg.Done()
-
-	b := make([]byte, s.config.BufferSize)
-	log.Debugf("[dn] Started thread")
+// Passes the event to the handler, either directly or through the queue
+func (s *UDPProxy) emit(kind int, data []byte, addr *net.UDPAddr) {
+	events := s.config.Events
+	if events == nil {
+		return
+	}
+	if s.queue == nil {
+		dispatchEvent(events, kind, data, addr)
+		return
+	}
 
-	for !s.closed {
-		n, addr, err := s.dnSock.ReadFromUDP(b)
-		if s.closed {
-			break
+	// Copy the datagram, since the socket buffers are re-used on the next read
+	buf := s.bufPool.Get().(*[]byte)
+	if cap(*buf) < len(data) {
+		*buf = make([]byte, len(data))
+	}
+	*buf = (*buf)[0:len(data)]
+	copy(*buf, data)
+
+	select {
+	case s.queue <- proxyEvent{kind: kind, buf: buf, addr: addr}:
+	default:
+		s.bufPool.Put(buf)
+		if atomic.AddUint64(&s.droppedEvents, 1)%1000 == 1 {
+			log.Warnf("Event queue is full, dropping events")
 		}
+	}
+}
 
-		if err != nil {
-			log.Errorf("[dn] Unable to read from local socket: %s", err.Error())
-			s.scheduleRestart()
-			break
-		}
+func dispatchEvent(events UDPProxyEvents, kind int, data []byte, addr *net.UDPAddr) {
+	switch kind {
+	case eventUpLocal:
+		events.UpLocalData(data, addr)
+	case eventUpRemote:
+		events.UpRemoteData(data, addr)
+	case eventDnLocal:
+		events.DnLocalData(data, addr)
+	case eventDnRemote:
+		events.DnRemoteData(data, addr)
+	}
+}
 
-		stream := s.getDnStreamFor(addr, b[0:n])
-		s.writeDump(stream.conf.Index*2+0, b[0:n])
+func (s *UDPProxy) handleQueuedEvent(ev proxyEvent) {
+	if events := s.config.Events; events != nil {
+		dispatchEvent(events, ev.kind, *ev.buf, ev.addr)
+	}
+	s.bufPool.Put(ev.buf)
+}
 
-		err = stream.HandleLocalData(b[0:n])
-		if err != nil {
-			log.Warnf("[up:%s] Could not write to remote: %s", addr.String(), err.Error())
-			log.Debugf("[dn:%s] Evicting due to error", addr.String())
-			s.dnStreams.Remove(addr.String())
-			break
-		} else {
-			if s.config.Events != nil {
-				s.config.Events.DnLocalData(b[0:n], addr)
+// Handles the queued events, until the proxy is closed
+func (s *UDPProxy) eventThread() {
+	defer s.queueWg.Done()
+
+	for {
+		select {
+		case ev := <-s.queue:
+			s.handleQueuedEvent(ev)
+
+		case <-s.queueDone:
+			// Drain what is left in the queue
+			for {
+				select {
+				case ev := <-s.queue:
+					s.handleQueuedEvent(ev)
+				default:
+					return
+				}
 			}
 		}
 	}
-
-	log.Debugf("[dn] Exited thread")
 }
 
 func (s *UDPProxy) getStreamId(ip net.IP, idByes []byte) int {
 	key := ip.String()
 	slot, ok := s.streamIds.Get(key)
 	if !ok {
-		slot = s.lastStreamId
-		s.lastStreamId++
-		s.streamIds.Add(key, slot)
+		// Each gateway takes two IDs, one for each direction. Another thread
+		// might have assigned the IDs in the meantime.
+		slot = int(s.lastStreamId.Add(2) - 2)
+		if found, ok, _ := s.streamIds.PeekOrAdd(key, slot); ok {
+			slot = found
+		}
 	}
 
 	if SemtechUDPIsUplink(idByes) {
@@ -269,27 +544,29 @@ func (s *UDPProxy) getStreamId(ip net.IP, idByes []byte) int {
 	}
 }
 
-func (s *UDPProxy) getUpStreamFor(addr *net.UDPAddr, idbytes []byte) *ProxyStream {
-	key := addr.String()
+func (s *UDPProxy) getUpStreamFor(sock *net.UDPConn, localBatch batchConn, addr *net.UDPAddr, idbytes []byte) *ProxyStream {
+	key := streamKey(addr)
 	if found, ok := s.upStreams.Get(key); ok {
 		return found
 	}
 
 	idx := s.getStreamId(addr.IP, idbytes)
+	local := gatewayLocalAddr(sock, addr)
 	stream := CreateProxyStream(&ProxyStreamConfig{
 		Name:              fmt.Sprintf("up:%s", key),
 		Index:             idx,
 		BufferSize:        s.config.BufferSize,
-		Local:             s.upSock,
+		BatchSize:         s.config.BatchSize,
+		Local:             sock,
+		LocalBatch:        localBatch,
+		LocalAddress:      local,
 		LocalReplyAddress: addr,
 		RemoteAddress:     s.config.UpConnectAddr,
 		RemoteBindAddress: s.config.UpConnectBindAddr,
 		Events: ProxyStreamEvents{
 			DataReceived: func(data []byte, rxFrom *net.UDPAddr) {
-				s.writeDump(idx*2+1, data)
-				if s.config.Events != nil {
-					s.config.Events.UpRemoteData(data, addr)
-				}
+				s.writeDump(idx*2+1, false, local, addr, data)
+				s.emit(eventUpRemote, data, addr)
 			},
 			LocalError: func(err error) {
 				s.scheduleRestart()
@@ -299,31 +576,36 @@ func (s *UDPProxy) getUpStreamFor(addr *net.UDPAddr, idbytes []byte) *ProxyStrea
 			},
 		},
 	})
-	s.upStreams.Add(key, stream)
+	if s.upStreams.Add(key, stream) {
+		atomic.AddUint64(&s.evictedStreams, 1)
+	}
 
 	return stream
 }
 
-func (s *UDPProxy) getDnStreamFor(addr *net.UDPAddr, idBytes []byte) *ProxyStream {
-	key := addr.String()
+func (s *UDPProxy) getDnStreamFor(sock *net.UDPConn, localBatch batchConn, addr *net.UDPAddr, idBytes []byte) *ProxyStream {
+	key := streamKey(addr)
 	if found, ok := s.dnStreams.Get(key); ok {
 		return found
 	}
 
 	idx := s.getStreamId(addr.IP, idBytes)
+	local := gatewayLocalAddr(sock, addr)
 	stream := CreateProxyStream(&ProxyStreamConfig{
 		Name:              fmt.Sprintf("dn:%s", key),
+		Index:             idx,
 		BufferSize:        s.config.BufferSize,
-		Local:             s.dnSock,
+		BatchSize:         s.config.BatchSize,
+		Local:             sock,
+		LocalBatch:        localBatch,
+		LocalAddress:      local,
 		LocalReplyAddress: addr,
 		RemoteAddress:     s.config.DownConnectAddr,
 		RemoteBindAddress: s.config.DownConnectBindAddr,
 		Events: ProxyStreamEvents{
 			DataReceived: func(data []byte, rxFrom *net.UDPAddr) {
-				s.writeDump(idx*2+1, data)
-				if s.config.Events != nil {
-					s.config.Events.DnRemoteData(data, addr)
-				}
+				s.writeDump(idx*2+1, false, local, addr, data)
+				s.emit(eventDnRemote, data, addr)
 			},
 			LocalError: func(err error) {
 				s.scheduleRestart()
@@ -333,7 +615,9 @@ func (s *UDPProxy) getDnStreamFor(addr *net.UDPAddr, idBytes []byte) *ProxyStrea
 			},
 		},
 	})
-	s.dnStreams.Add(key, stream)
+	if s.dnStreams.Add(key, stream) {
+		atomic.AddUint64(&s.evictedStreams, 1)
+	}
 
 	return stream
 }
@@ -2,40 +2,61 @@ package main
 
 import (
 	"bytes"
+	"encoding/base64"
+	"fmt"
 	"net"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/stretchr/testify/assert"
 	// log "github.com/sirupsen/logrus"
 )
 
+// Keeps the last datagram of each kind, which is written by the proxy threads
+// and must be read with the accessors below
 type BufHandler struct {
+	lock         sync.Mutex
 	LastUpLocal  []byte
 	lastUpRemote []byte
 	LastDnLocal  []byte
 	LastDnRemote []byte
 }
 
-func (b *BufHandler) UpLocalData(data []byte, localEp *net.UDPAddr) {
+func (b *BufHandler) keep(dst *[]byte, data []byte) {
 	tmp := make([]byte, len(data))
 	copy(tmp, data)
-	b.LastUpLocal = tmp
+	b.lock.Lock()
+	*dst = tmp
+	b.lock.Unlock()
+}
+func (b *BufHandler) get(src *[]byte) []byte {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return *src
+}
+
+func (b *BufHandler) UpLocalData(data []byte, localEp *net.UDPAddr) {
+	b.keep(&b.LastUpLocal, data)
 }
 func (b *BufHandler) UpRemoteData(data []byte, localEp *net.UDPAddr) {
-	tmp := make([]byte, len(data))
-	copy(tmp, data)
-	b.lastUpRemote = tmp
+	b.keep(&b.lastUpRemote, data)
 }
 func (b *BufHandler) DnLocalData(data []byte, localEp *net.UDPAddr) {
-	tmp := make([]byte, len(data))
-	copy(tmp, data)
-	b.LastDnLocal = tmp
+	b.keep(&b.LastDnLocal, data)
 }
 func (b *BufHandler) DnRemoteData(data []byte, localEp *net.UDPAddr) {
-	tmp := make([]byte, len(data))
-	copy(tmp, data)
-	b.LastDnRemote = tmp
+	b.keep(&b.LastDnRemote, data)
 }
 
+func (b *BufHandler) upLocal() []byte  { return b.get(&b.LastUpLocal) }
+func (b *BufHandler) upRemote() []byte { return b.get(&b.lastUpRemote) }
+func (b *BufHandler) dnLocal() []byte  { return b.get(&b.LastDnLocal) }
+func (b *BufHandler) dnRemote() []byte { return b.get(&b.LastDnRemote) }
+
 func TestEventsOfUDPProxy(t *testing.T) {
 	// log.SetLevel(log.DebugLevel)
 
@@ -62,7 +83,7 @@ func TestEventsOfUDPProxy(t *testing.T) {
 	bufLocalUp := randBuf(1024)
 	clientUp.Send(bufLocalUp)
 	expectToReceive(t, serverUp, bufLocalUp)
-	if !bytes.Equal(bufLocalUp, bufs.LastUpLocal) {
+	if !bytes.Equal(bufLocalUp, bufs.upLocal()) {
 		t.Fail()
 	}
 
@@ -70,7 +91,7 @@ func TestEventsOfUDPProxy(t *testing.T) {
 	bufRemoteUp := randBuf(1024)
 	serverUp.Reply(bufRemoteUp)
 	expectToReceive(t, clientUp, bufRemoteUp)
-	if !bytes.Equal(bufRemoteUp, bufs.lastUpRemote) {
+	if !bytes.Equal(bufRemoteUp, bufs.upRemote()) {
 		t.Fail()
 	}
 
@@ -78,7 +99,7 @@ func TestEventsOfUDPProxy(t *testing.T) {
 	bufLocalDn := randBuf(1024)
 	clientDn.Send(bufLocalDn)
 	expectToReceive(t, serverDn, bufLocalDn)
-	if !bytes.Equal(bufLocalDn, bufs.LastDnLocal) {
+	if !bytes.Equal(bufLocalDn, bufs.dnLocal()) {
 		t.Fail()
 	}
 
@@ -86,7 +107,7 @@ func TestEventsOfUDPProxy(t *testing.T) {
 	bufRemoteDn := randBuf(1024)
 	serverDn.Reply(bufRemoteDn)
 	expectToReceive(t, clientDn, bufRemoteDn)
-	if !bytes.Equal(bufRemoteDn, bufs.LastDnRemote) {
+	if !bytes.Equal(bufRemoteDn, bufs.dnRemote()) {
 		t.Fail()
 	}
 
@@ -320,3 +341,346 @@ func TestLocalDisconnect(t *testing.T) {
 
 	proxy.Close()
 }
+
+func TestIPv6Proxy(t *testing.T) {
+	requireIPv6(t)
+
+	clientUp := CreateSocketOn(t, "::1")
+	clientDn := CreateSocketOn(t, "::1")
+	serverUp := CreateSocketOn(t, "::1")
+	serverDn := CreateSocketOn(t, "::1")
+
+	bufs := &BufHandler{}
+	proxy, err := CreateUDPProxy(&UDPProxyConfig{
+		UpListenAddr:        clientUp.remote,
+		UpConnectAddr:       serverUp.local,
+		UpConnectBindAddr:   nil,
+		DownListenAddr:      clientDn.remote,
+		DownConnectAddr:     serverDn.local,
+		DownConnectBindAddr: &net.UDPAddr{IP: net.IPv6loopback},
+		BufferSize:          1024,
+		SocketStreams:       16,
+		ReconnectInterval:   1,
+		Events:              bufs,
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		buf := randBuf(1024)
+		clientUp.Send(buf)
+		expectToReceive(t, serverUp, buf)
+		assert.Eventually(t, func() bool { return bytes.Equal(buf, bufs.upLocal()) }, time.Second, time.Millisecond)
+
+		buf = randBuf(1024)
+		serverUp.Reply(buf)
+		expectToReceive(t, clientUp, buf)
+
+		buf = randBuf(1024)
+		clientDn.Send(buf)
+		_, addr := serverDn.ReadWithAddr(1024)
+		assert.True(t, addr.IP.Equal(net.IPv6loopback), "Expected to be bound on ::1")
+
+		buf = randBuf(1024)
+		serverDn.Reply(buf)
+		expectToReceive(t, clientDn, buf)
+	}
+
+	proxy.Close()
+}
+
+func TestDualStackProxy(t *testing.T) {
+	requireIPv6(t)
+
+	client4 := CreateSocket(t)
+	client6 := CreateSocketOn(t, "::1")
+	client6.remote = &net.UDPAddr{IP: net.IPv6loopback, Port: client4.remote.Port}
+	server := CreateSocketOn(t, "::1")
+
+	// Listen on all addresses, for both address families
+	proxy, err := CreateUDPProxy(&UDPProxyConfig{
+		UpListenAddr:        &net.UDPAddr{IP: net.IPv6unspecified, Port: client4.remote.Port},
+		UpConnectAddr:       server.local,
+		UpConnectBindAddr:   nil,
+		DownListenAddr:      nil,
+		DownConnectAddr:     nil,
+		DownConnectBindAddr: nil,
+		BufferSize:          1024,
+		SocketStreams:       16,
+		ReconnectInterval:   1,
+		Events:              nil,
+	})
+	assert.NoError(t, err)
+
+	buf := randBuf(1024)
+	client4.Send(buf)
+	addr4 := expectToReceive(t, server, buf)
+
+	buf = randBuf(1024)
+	client6.Send(buf)
+	addr6 := expectToReceive(t, server, buf)
+	assert.NotEqual(t, addr4.String(), addr6.String())
+
+	// Replies must reach the client of each stream
+	for i := 0; i < 10; i++ {
+		buf = randBuf(1024)
+		server.SendToAddr(addr4, buf)
+		expectToReceive(t, client4, buf)
+
+		buf = randBuf(1024)
+		server.SendToAddr(addr6, buf)
+		expectToReceive(t, client6, buf)
+	}
+
+	// Both families should end up in a single stream each
+	assert.Equal(t, 2, proxy.upStreams.Len())
+	proxy.Close()
+}
+
+func TestWorkersProxy(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("SO_REUSEPORT is only used on linux")
+	}
+
+	for _, host := range []string{"127.0.0.1", "::1"} {
+		t.Run(host, func(t *testing.T) {
+			if host == "::1" {
+				requireIPv6(t)
+			}
+			client1 := CreateSocketOn(t, host)
+			server := CreateSocketOn(t, host)
+
+			// Several sockets on the same port, reading and writing in batches
+			proxy, err := CreateUDPProxy(&UDPProxyConfig{
+				UpListenAddr:      client1.remote,
+				UpConnectAddr:     server.local,
+				BufferSize:        1024,
+				SocketStreams:     16,
+				ReconnectInterval: 1,
+				BatchSize:         8,
+				Workers:           4,
+			})
+			assert.NoError(t, err)
+			defer proxy.Close()
+			assert.Len(t, proxy.workerSocks, 3)
+
+			for i := 0; i < 10; i++ {
+				c := CreateSocketWithSameRemote(t, client1)
+				buf := randBuf(1024)
+				c.Send(buf)
+				addr := expectToReceive(t, server, buf)
+
+				buf = randBuf(1024)
+				server.SendToAddr(addr, buf)
+				expectToReceive(t, c, buf)
+			}
+		})
+	}
+}
+
+// A handler that counts the events and is safe to use from the event thread
+type countingHandler struct {
+	lock    sync.Mutex
+	upLocal [][]byte
+	decode  bool
+	count   uint64
+}
+
+func (c *countingHandler) UpLocalData(data []byte, localEp *net.UDPAddr) {
+	if c.decode {
+		// Pay the same cost as the analytics processing
+		if msg, err := DecodeMessage(data, len(data), localEp, time.Now(), nil); err == nil {
+			msg.GetAllRxPkt()
+		}
+	} else {
+		c.lock.Lock()
+		c.upLocal = append(c.upLocal, append([]byte(nil), data...))
+		c.lock.Unlock()
+	}
+	atomic.AddUint64(&c.count, 1)
+}
+func (c *countingHandler) UpRemoteData(data []byte, localEp *net.UDPAddr) {}
+func (c *countingHandler) DnLocalData(data []byte, localEp *net.UDPAddr)  {}
+func (c *countingHandler) DnRemoteData(data []byte, localEp *net.UDPAddr) {}
+
+func TestQueuedEventsOfUDPProxy(t *testing.T) {
+	client := CreateSocket(t)
+	server := CreateSocket(t)
+
+	events := &countingHandler{}
+	proxy, _ := CreateUDPProxy(&UDPProxyConfig{
+		UpListenAddr:      client.remote,
+		UpConnectAddr:     server.local,
+		BufferSize:        1024,
+		SocketStreams:     16,
+		ReconnectInterval: 1,
+		Events:            events,
+		QueueSize:         64,
+		BatchSize:         8,
+	})
+
+	var sent [][]byte
+	for i := 0; i < 20; i++ {
+		buf := randBuf(1024)
+		sent = append(sent, buf)
+		client.Send(buf)
+		expectToReceive(t, server, buf)
+	}
+
+	// Closing the proxy drains the queue
+	proxy.Close()
+	assert.Equal(t, sent, events.upLocal)
+	assert.Equal(t, uint64(0), proxy.DroppedEvents())
+	assert.Equal(t, 0, proxy.QueueDepth())
+}
+
+// Measures how many datagrams from several gateways go through the proxy
+// while the event handler decodes every packet, with and without batching
+// and the event queue.
+func BenchmarkUDPProxyThroughput(b *testing.B) {
+	pkt, _ := base64.StdEncoding.DecodeString(PacketPushDataUp)
+
+	cases := []struct {
+		name      string
+		queueSize int
+		batchSize int
+	}{
+		{"sync", 0, 1},
+		{"batched", 4096, 32},
+	}
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			client := CreateSocket(b)
+			server := CreateSocket(b)
+			server.SetTimeout(time.Millisecond * 200)
+
+			events := &countingHandler{decode: true}
+			proxy, _ := CreateUDPProxy(&UDPProxyConfig{
+				UpListenAddr:      client.remote,
+				UpConnectAddr:     server.local,
+				BufferSize:        1500,
+				SocketStreams:     16,
+				ReconnectInterval: 1,
+				Events:            events,
+				QueueSize:         c.queueSize,
+				BatchSize:         c.batchSize,
+			})
+			defer proxy.Close()
+
+			// Count what the server receives in the background
+			var received uint64
+			done := make(chan bool)
+			go func() {
+				buf := make([]byte, 1500)
+				for {
+					server.conn.SetReadDeadline(time.Now().Add(server.timeout))
+					if _, _, err := server.conn.ReadFromUDP(buf); err != nil {
+						close(done)
+						return
+					}
+					atomic.AddUint64(&received, 1)
+				}
+			}()
+
+			// Send from several gateways at once, so the proxy reads full batches
+			const senders = 8
+			clients := []*UDPSock{client}
+			for len(clients) < senders {
+				clients = append(clients, CreateSocketWithSameRemote(b, client))
+			}
+
+			// Keep a bounded number of datagrams in flight, so the kernel buffers do
+			// not overflow, but do not wait forever for the ones that got lost
+			const window = 256
+			var sent uint64
+			var wg sync.WaitGroup
+			b.ResetTimer()
+			start := time.Now()
+			for i, sender := range clients {
+				count := b.N / senders
+				if i < b.N%senders {
+					count++
+				}
+				wg.Add(1)
+				go func(sender *UDPSock, count int) {
+					defer wg.Done()
+					for j := 0; j < count; j++ {
+						deadline := time.Now().Add(time.Millisecond)
+						for atomic.LoadUint64(&sent)-atomic.LoadUint64(&received) > window && time.Now().Before(deadline) {
+							time.Sleep(time.Microsecond * 50)
+						}
+						atomic.AddUint64(&sent, 1)
+						sender.conn.WriteToUDP(pkt, sender.remote)
+					}
+				}(sender, count)
+			}
+			wg.Wait()
+			<-done
+			elapsed := time.Since(start) - server.timeout
+			b.StopTimer()
+
+			got := atomic.LoadUint64(&received)
+			b.ReportMetric(float64(got)/elapsed.Seconds(), "pkts/s")
+			b.ReportMetric(100*float64(uint64(b.N)-got)/float64(b.N), "loss%")
+		})
+	}
+}
+
+func TestStreamKey(t *testing.T) {
+	mapped := &net.UDPAddr{IP: net.ParseIP("::ffff:10.0.0.1"), Port: 1700}
+	plain := &net.UDPAddr{IP: net.ParseIP("10.0.0.1").To4(), Port: 1700}
+	assert.Equal(t, streamKey(plain), streamKey(mapped))
+	assert.Equal(t, "10.0.0.1:1700", streamKey(mapped))
+	assert.Equal(t, "[2001:db8::1]:1700", streamKey(&net.UDPAddr{IP: net.ParseIP("2001:db8::1"), Port: 1700}))
+}
+
+// Assigns the stream IDs from parallel threads, as with several socket workers
+func TestStreamIds(t *testing.T) {
+	const gateways = 64
+	proxy := &UDPProxy{}
+	proxy.streamIds, _ = lru.New[string, int](gateways)
+	up := []byte{PROTOCOL_VERSION, 0, 0, PUSH_DATA}
+	dn := []byte{PROTOCOL_VERSION, 0, 0, PULL_DATA}
+
+	var wg sync.WaitGroup
+	ids := make([][2]int, gateways)
+	for gw := 0; gw < gateways; gw++ {
+		wg.Add(1)
+		go func(gw int) {
+			defer wg.Done()
+			ip := net.ParseIP(fmt.Sprintf("10.0.0.%d", gw+1))
+			ids[gw] = [2]int{proxy.getStreamId(ip, up), proxy.getStreamId(ip, dn)}
+		}(gw)
+	}
+	wg.Wait()
+
+	// Every direction of every gateway has its own ID
+	seen := make(map[int]bool)
+	for _, pair := range ids {
+		assert.Equal(t, pair[0]+1, pair[1])
+		for _, id := range pair {
+			assert.False(t, seen[id])
+			seen[id] = true
+		}
+	}
+	assert.Len(t, seen, 2*gateways)
+}
+
+func TestEndpointParsing(t *testing.T) {
+	assert.Equal(t, "[::1]:1700", parseEndpoint("test", "::1", 1700).String())
+	assert.Equal(t, "[::1]:1700", parseEndpoint("test", "[::1]", 1700).String())
+	assert.Equal(t, "127.0.0.1:1700", parseEndpoint("test", "127.0.0.1", 1700).String())
+
+	remote4 := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 1700}
+	remote6 := &net.UDPAddr{IP: net.IPv6loopback, Port: 1700}
+	assert.Nil(t, parseBindAddr("test", "", remote4))
+	assert.Nil(t, parseBindAddr("test", "0.0.0.0", remote6))
+	assert.Equal(t, "[::1]:0", parseBindAddr("test", "::1", remote6).String())
+
+	// Resolve the address of the loopback interface by name
+	ifaces, _ := net.Interfaces()
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			assert.Equal(t, "127.0.0.1:0", parseBindAddr("test", iface.Name, remote4).String())
+		}
+	}
+}
@@ -20,19 +20,23 @@ type UDPSock struct {
 	conn    *net.UDPConn
 	timeout time.Duration
 	closed  bool
-	t       *testing.T
+	t       testing.TB
 }
 
-func CreateSocket(t *testing.T) *UDPSock {
+func CreateSocket(t testing.TB) *UDPSock {
+	return CreateSocketOn(t, "127.0.0.1")
+}
+
+func CreateSocketOn(t testing.TB, host string) *UDPSock {
 	for {
 		pBase := 30500 + (rand.Intn(3500))*10
-		lUdp, err := net.ResolveUDPAddr("udp", fmt.Sprintf("127.0.0.1:%d", pBase))
+		lUdp, err := net.ResolveUDPAddr("udp", net.JoinHostPort(host, fmt.Sprint(pBase)))
 		if err != nil {
 			t.Errorf("Could not resolve addr: %s", err.Error())
 			t.FailNow()
 			return nil
 		}
-		rUdp, err := net.ResolveUDPAddr("udp", fmt.Sprintf("127.0.0.1:%d", pBase+1))
+		rUdp, err := net.ResolveUDPAddr("udp", net.JoinHostPort(host, fmt.Sprint(pBase+1)))
 		if err != nil {
 			t.Errorf("Could not resolve addr: %s", err.Error())
 			t.FailNow()
@@ -59,10 +63,10 @@ func CreateSocket(t *testing.T) *UDPSock {
 	}
 }
 
-func CreateSocketToRemote(t *testing.T, toSockeet *UDPSock) *UDPSock {
+func CreateSocketToRemote(t testing.TB, toSockeet *UDPSock) *UDPSock {
 	for {
 		pBase := 30500 + (rand.Intn(17500))*2
-		lUdp, err := net.ResolveUDPAddr("udp", fmt.Sprintf("127.0.0.1:%d", pBase))
+		lUdp, err := net.ResolveUDPAddr("udp", net.JoinHostPort(toSockeet.local.IP.String(), fmt.Sprint(pBase)))
 		if err != nil {
 			t.Errorf("Could not resolve addr: %s", err.Error())
 			t.FailNow()
@@ -88,10 +92,10 @@ func CreateSocketToRemote(t *testing.T, toSockeet *UDPSock) *UDPSock {
 	}
 }
 
-func CreateSocketWithSameRemote(t *testing.T, toSockeet *UDPSock) *UDPSock {
+func CreateSocketWithSameRemote(t testing.TB, toSockeet *UDPSock) *UDPSock {
 	for {
 		pBase := 30500 + (rand.Intn(17500))*2
-		lUdp, err := net.ResolveUDPAddr("udp", fmt.Sprintf("127.0.0.1:%d", pBase))
+		lUdp, err := net.ResolveUDPAddr("udp", net.JoinHostPort(toSockeet.local.IP.String(), fmt.Sprint(pBase)))
 		if err != nil {
 			t.Errorf("Could not resolve addr: %s", err.Error())
 			t.FailNow()
@@ -140,6 +144,14 @@ func isErrorAddressAlreadyInUse(err error) bool {
 	return false
 }
 
+func requireIPv6(t *testing.T) {
+	conn, err := net.ListenUDP("udp6", &net.UDPAddr{IP: net.IPv6loopback})
+	if err != nil {
+		t.Skipf("IPv6 is not available: %s", err.Error())
+	}
+	conn.Close()
+}
+
 func (r *UDPSock) SetTimeout(timeout time.Duration) {
 	r.timeout = timeout
 }
@@ -1,6 +1,10 @@
 module github.com/kudzutechnologies/analytics
 
-go 1.18
+// Go 1.22 is the minimum of github.com/klauspost/compress v1.18.0, used for
+// the zstd compression of the pushes. The releases that still support Go 1.18
+// (up to v1.17.2) are not used, since the forwarder also relies on the typed
+// atomics of Go 1.19.
+go 1.22
 
 require (
 	github.com/golang-jwt/jwt/v4 v4.4.3
@@ -10,19 +14,23 @@ require (
 	google.golang.org/protobuf v1.25.0
 )
 
-require github.com/namsral/flag v1.7.4-pre
+require (
+	github.com/hashicorp/golang-lru/v2 v2.0.7
+	github.com/namsral/flag v1.7.4-pre
+	github.com/stretchr/testify v1.8.4
+	golang.org/x/net v0.0.0-20211112202133-69e39bad7dc2
+	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/testify v1.8.4 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 require (
 	github.com/golang/snappy v0.0.1 // indirect
-	github.com/klauspost/compress v1.13.6 // indirect
+	github.com/klauspost/compress v1.18.0
 	github.com/montanaflynn/stats v0.0.0-20171201202039-1bf9dbcd8cbe // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/sirupsen/logrus v1.9.0
@@ -31,9 +39,7 @@ require (
 	github.com/xdg-go/stringprep v1.0.3 // indirect
 	github.com/youmark/pkcs8 v0.0.0-20181117223130-1be2e3e5546d // indirect
 	golang.org/x/crypto v0.0.0-20220622213112-05595931fe9d // indirect
-	golang.org/x/net v0.0.0-20211112202133-69e39bad7dc2 // indirect
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c // indirect
-	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
 	golang.org/x/text v0.3.7 // indirect
-	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013 // indirect
+	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013
 )
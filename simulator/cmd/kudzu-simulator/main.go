@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/kudzutechnologies/analytics/simulator"
+	"github.com/namsral/flag"
+	log "github.com/sirupsen/logrus"
+)
+
+type SimulatorConfig struct {
+	Mode              string
+	Gateways          int
+	EUI               string
+	ConnectHost       string
+	ConnectPortUp     int
+	ConnectPortDown   int
+	ListenHost        string
+	ListenPortUp      int
+	ListenPortDown    int
+	UplinkInterval    float64
+	SFMix             string
+	Antennas          int
+	Devices           int
+	PayloadSize       int
+	StatInterval      int
+	KeepaliveInterval int
+	DownlinkRatio     float64
+	RX1Delay          int
+	Duration          int
+	ReportInterval    int
+	Seed              int64
+	LogLevel          string
+}
+
+func parseConfig() SimulatorConfig {
+	var config SimulatorConfig
+	fs := flag.NewFlagSetWithEnvPrefix(os.Args[0], "SIMULATOR", flag.ExitOnError)
+	fs.StringVar(&config.Mode, "mode", "both", "what to simulate: 'gateways', 'lns' or 'both'")
+	fs.IntVar(&config.Gateways, "gateways", 1, "how many gateways to simulate")
+	fs.StringVar(&config.EUI, "eui", "0016c00100000000", "the EUI of the first gateway, the rest use consecutive EUIs")
+	fs.StringVar(&config.ConnectHost, "connect-host", "127.0.0.1", "where the gateways send their traffic (eg. the forwarder)")
+	fs.IntVar(&config.ConnectPortUp, "connect-port-up", 1800, "the port where the gateways send the uplink datagrams")
+	fs.IntVar(&config.ConnectPortDown, "connect-port-down", 1801, "the port where the gateways send the downlink datagrams")
+	fs.StringVar(&config.ListenHost, "listen-host", "127.0.0.1", "where the LNS listens for datagrams")
+	fs.IntVar(&config.ListenPortUp, "listen-port-up", 1700, "the port where the LNS receives the uplink datagrams")
+	fs.IntVar(&config.ListenPortDown, "listen-port-down", 1700, "the port where the LNS receives the downlink datagrams")
+	fs.Float64Var(&config.UplinkInterval, "uplink-interval", 10, "the mean interval between the uplinks of each gateway (in seconds)")
+	fs.StringVar(&config.SFMix, "sf-mix", "7:40,8:20,9:15,10:10,11:10,12:5", "the relative weight of each spreading factor, as <sf>:<weight>,...")
+	fs.IntVar(&config.Antennas, "antennas", 1, "how many antennas receive each uplink (more than 1 adds 'rsig' details)")
+	fs.IntVar(&config.Devices, "devices", 16, "how many devices send uplinks through each gateway")
+	fs.IntVar(&config.PayloadSize, "payload-size", 12, "the size of the application payload of the uplinks")
+	fs.IntVar(&config.StatInterval, "stat-interval", 30, "how frequently the gateways send 'stat' reports (in seconds, 0 to disable)")
+	fs.IntVar(&config.KeepaliveInterval, "keepalive-interval", 10, "how frequently the gateways send PULL_DATA keep-alives (in seconds)")
+	fs.Float64Var(&config.DownlinkRatio, "downlink-ratio", 0.1, "the fraction of the uplinks the LNS answers with a downlink (0 - 1)")
+	fs.IntVar(&config.RX1Delay, "rx1-delay", 1, "the delay of the RX1 window of the downlinks (in seconds)")
+	fs.IntVar(&config.Duration, "duration", 0, "how long to run the simulation (in seconds, 0 to run until interrupted)")
+	fs.IntVar(&config.ReportInterval, "report-interval", 10, "how frequently to log the traffic counters (in seconds)")
+	fs.Int64Var(&config.Seed, "seed", 0, "the seed of the random generators (0 for a random seed)")
+	fs.StringVar(&config.LogLevel, "log-level", "info", "selects the verbosity of logging, can be 'error', 'warn', 'info', 'debug'")
+	fs.Parse(os.Args[1:])
+
+	if config.Seed == 0 {
+		config.Seed = time.Now().UnixNano()
+	}
+	return config
+}
+
+func resolveAddr(host string, port int) *net.UDPAddr {
+	addr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(host, fmt.Sprint(port)))
+	if err != nil {
+		log.Fatalf("Could not resolve %s: %s", host, err.Error())
+	}
+	return addr
+}
+
+func main() {
+	config := parseConfig()
+	if lvl, err := log.ParseLevel(config.LogLevel); err == nil {
+		log.SetLevel(lvl)
+	}
+
+	runLNS := config.Mode == "lns" || config.Mode == "both"
+	runGateways := config.Mode == "gateways" || config.Mode == "both"
+	if !runLNS && !runGateways {
+		log.Fatalf("Invalid mode '%s'", config.Mode)
+	}
+
+	var lns *simulator.LNS
+	if runLNS {
+		lnsConfig := simulator.LNSConfig{
+			UpListenAddr:  resolveAddr(config.ListenHost, config.ListenPortUp),
+			DownlinkRatio: config.DownlinkRatio,
+			RX1Delay:      time.Duration(config.RX1Delay) * time.Second,
+			Seed:          config.Seed,
+		}
+		if config.ListenPortDown != config.ListenPortUp {
+			lnsConfig.DownListenAddr = resolveAddr(config.ListenHost, config.ListenPortDown)
+		}
+
+		var err error
+		lns, err = simulator.CreateLNS(lnsConfig)
+		if err != nil {
+			log.Fatalf("Could not start LNS: %s", err.Error())
+		}
+		log.Infof("LNS listening on %s", lns.UpAddr().String())
+	}
+
+	var gateways []*simulator.Gateway
+	if runGateways {
+		eui, err := hex.DecodeString(config.EUI)
+		if err != nil || len(eui) != 8 {
+			log.Fatalf("Invalid gateway EUI '%s'", config.EUI)
+		}
+		sfMix, err := simulator.ParseSFMix(config.SFMix)
+		if err != nil {
+			log.Fatalf("Invalid SF mix: %s", err.Error())
+		}
+
+		gateways, err = simulator.StartGateways(simulator.GatewayConfig{
+			EUI:               eui,
+			UpAddr:            resolveAddr(config.ConnectHost, config.ConnectPortUp),
+			DownAddr:          resolveAddr(config.ConnectHost, config.ConnectPortDown),
+			UplinkInterval:    time.Duration(config.UplinkInterval * float64(time.Second)),
+			SFMix:             sfMix,
+			Antennas:          config.Antennas,
+			Devices:           config.Devices,
+			PayloadSize:       config.PayloadSize,
+			StatInterval:      time.Duration(config.StatInterval) * time.Second,
+			KeepaliveInterval: time.Duration(config.KeepaliveInterval) * time.Second,
+			Seed:              config.Seed,
+		}, config.Gateways)
+		if err != nil {
+			log.Fatalf("Could not start gateways: %s", err.Error())
+		}
+		log.Infof("Started %d gateways sending to %s:%d/%d", len(gateways),
+			config.ConnectHost, config.ConnectPortUp, config.ConnectPortDown)
+	}
+
+	report := func() {
+		if gateways != nil {
+			s := simulator.TotalStats(gateways)
+			log.Infof("Gateways: uplinks=%d stats=%d push-ack=%d pull-ack=%d downlinks=%d tx-ack=%d",
+				s.Uplinks, s.Stats, s.PushAcks, s.PullAcks, s.Downlinks, s.TxAcks)
+		}
+		if lns != nil {
+			s := lns.Stats()
+			log.Infof("LNS: push-data=%d uplinks=%d pull-data=%d downlinks=%d tx-ack=%d tx-errors=%d",
+				s.PushData, s.Uplinks, s.PullData, s.Downlinks, s.TxAcks, s.TxErrors)
+		}
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	var doneCh <-chan time.Time
+	if config.Duration > 0 {
+		doneCh = time.After(time.Duration(config.Duration) * time.Second)
+	}
+	reportInterval := time.Duration(config.ReportInterval) * time.Second
+	if reportInterval <= 0 {
+		reportInterval = 10 * time.Second
+	}
+	ticker := time.NewTicker(reportInterval)
+	defer ticker.Stop()
+
+loop:
+	for {
+		select {
+		case <-ticker.C:
+			report()
+		case <-sigCh:
+			break loop
+		case <-doneCh:
+			break loop
+		}
+	}
+
+	for _, gw := range gateways {
+		gw.Close()
+	}
+	if lns != nil {
+		lns.Close()
+	}
+	report()
+}
@@ -0,0 +1,11 @@
+/*
+Semtech UDP gateway and LNS simulator
+
+This package emulates LoRa gateways speaking the Semtech UDP packet forwarder
+protocol, and a LoRa Network Server that acknowledges their traffic and sends
+class A downlinks. It can be used for load-testing the analytics forwarder, or
+for integration tests that need realistic PUSH_DATA/PULL_DATA/PULL_RESP/TX_ACK
+traffic.
+
+*/
+package simulator
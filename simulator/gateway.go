@@ -0,0 +1,534 @@
+package simulator
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The relative weight of each spreading factor in the simulated uplinks
+type SFMix map[int]int
+
+// The spreading factor mix of a typical network, favoring the faster rates
+var DefaultSFMix = SFMix{7: 40, 8: 20, 9: 15, 10: 10, 11: 10, 12: 5}
+
+// The uplink channels of the EU868 band plan
+var DefaultFrequencies = []float64{868.1, 868.3, 868.5, 867.1, 867.3, 867.5, 867.7, 867.9}
+
+// Parses an SF mix in the `<sf>:<weight>,...` format (eg. `7:50,12:50`)
+func ParseSFMix(s string) (SFMix, error) {
+	mix := SFMix{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid SF mix entry '%s'", part)
+		}
+		sf, err := strconv.Atoi(kv[0])
+		if err != nil || sf < 5 || sf > 12 {
+			return nil, fmt.Errorf("invalid spreading factor '%s'", kv[0])
+		}
+		weight, err := strconv.Atoi(kv[1])
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight '%s'", kv[1])
+		}
+		mix[sf] = weight
+	}
+	if len(mix) == 0 {
+		return nil, fmt.Errorf("empty SF mix")
+	}
+	return mix, nil
+}
+
+// Picks a spreading factor according to the weights of the mix
+func (m SFMix) pick(rnd *rand.Rand) int {
+	var sfs []int
+	total := 0
+	for sf, weight := range m {
+		sfs = append(sfs, sf)
+		total += weight
+	}
+	sort.Ints(sfs)
+	if total == 0 {
+		return sfs[0]
+	}
+
+	n := rnd.Intn(total)
+	for _, sf := range sfs {
+		n -= m[sf]
+		if n < 0 {
+			return sf
+		}
+	}
+	return sfs[len(sfs)-1]
+}
+
+type GatewayConfig struct {
+	// The EUI of the gateway
+	EUI []byte
+	// Where to send the uplink (PUSH_DATA) datagrams
+	UpAddr *net.UDPAddr
+	// Where to send the downlink (PULL_DATA) datagrams, defaults to UpAddr
+	DownAddr *net.UDPAddr
+	// The mean interval between uplinks (0 disables the uplinks)
+	UplinkInterval time.Duration
+	// The relative weight of the spreading factors of the uplinks
+	SFMix SFMix
+	// The frequencies (in MHz) where the uplinks are received
+	Frequencies []float64
+	// How many antennas receive each uplink (more than one adds `rsig` details)
+	Antennas int
+	// How many distinct devices are sending uplinks
+	Devices int
+	// The size of the application payload of the uplinks
+	PayloadSize int
+	// The interval of the `stat` reports (0 disables them)
+	StatInterval time.Duration
+	// The interval of the PULL_DATA keep-alives
+	KeepaliveInterval time.Duration
+	// The reported location of the gateway
+	Latitude  float64
+	Longitude float64
+	Altitude  int
+	// The seed of the random generator, for reproducible traffic
+	Seed int64
+}
+
+// Counters of the datagrams a simulated gateway has exchanged
+type GatewayStats struct {
+	Uplinks   uint64
+	Stats     uint64
+	PushAcks  uint64
+	PullData  uint64
+	PullAcks  uint64
+	Downlinks uint64
+	TxAcks    uint64
+}
+
+func (s *GatewayStats) add(o *GatewayStats) {
+	s.Uplinks += o.Uplinks
+	s.Stats += o.Stats
+	s.PushAcks += o.PushAcks
+	s.PullData += o.PullData
+	s.PullAcks += o.PullAcks
+	s.Downlinks += o.Downlinks
+	s.TxAcks += o.TxAcks
+}
+
+type simDevice struct {
+	devAddr uint32
+	fCnt    uint16
+}
+
+// A simulated gateway, running the Semtech UDP packet forwarder protocol
+type Gateway struct {
+	config    GatewayConfig
+	upSock    *net.UDPConn
+	dnSock    *net.UDPConn
+	rnd       *rand.Rand
+	devices   []simDevice
+	startTime time.Time
+	token     uint32
+	closeCh   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+	stats     GatewayStats
+
+	// Counters since the last `stat` report
+	lastStats GatewayStats
+}
+
+func CreateGateway(config GatewayConfig) (*Gateway, error) {
+	if len(config.EUI) != 8 {
+		return nil, fmt.Errorf("the gateway EUI must be 8 bytes")
+	}
+	if config.UpAddr == nil {
+		return nil, fmt.Errorf("missing uplink address")
+	}
+	if config.DownAddr == nil {
+		config.DownAddr = config.UpAddr
+	}
+	if config.SFMix == nil {
+		config.SFMix = DefaultSFMix
+	}
+	if len(config.Frequencies) == 0 {
+		config.Frequencies = DefaultFrequencies
+	}
+	if config.Antennas < 1 {
+		config.Antennas = 1
+	}
+	if config.Devices < 1 {
+		config.Devices = 16
+	}
+	if config.PayloadSize <= 0 {
+		config.PayloadSize = 12
+	}
+	if config.KeepaliveInterval <= 0 {
+		config.KeepaliveInterval = 10 * time.Second
+	}
+
+	upSock, err := net.DialUDP("udp", nil, config.UpAddr)
+	if err != nil {
+		return nil, fmt.Errorf("could not open uplink socket: %s", err.Error())
+	}
+	dnSock, err := net.DialUDP("udp", nil, config.DownAddr)
+	if err != nil {
+		upSock.Close()
+		return nil, fmt.Errorf("could not open downlink socket: %s", err.Error())
+	}
+
+	inst := &Gateway{
+		config:    config,
+		upSock:    upSock,
+		dnSock:    dnSock,
+		rnd:       rand.New(rand.NewSource(config.Seed)),
+		startTime: time.Now(),
+		closeCh:   make(chan struct{}),
+	}
+	for i := 0; i < config.Devices; i++ {
+		inst.devices = append(inst.devices, simDevice{devAddr: inst.rnd.Uint32()})
+	}
+
+	inst.wg.Add(4)
+	go inst.upRecvThread()
+	go inst.dnRecvThread()
+	go inst.uplinkThread()
+	go inst.keepaliveThread()
+	return inst, nil
+}
+
+// Starts `count` gateways with the same configuration, but consecutive EUIs
+// and seeds
+func StartGateways(config GatewayConfig, count int) ([]*Gateway, error) {
+	var ret []*Gateway
+	base := binary.BigEndian.Uint64(config.EUI)
+	for i := 0; i < count; i++ {
+		gwConfig := config
+		gwConfig.EUI = make([]byte, 8)
+		binary.BigEndian.PutUint64(gwConfig.EUI, base+uint64(i))
+		gwConfig.Seed = config.Seed + int64(i)
+
+		gw, err := CreateGateway(gwConfig)
+		if err != nil {
+			for _, gw := range ret {
+				gw.Close()
+			}
+			return nil, err
+		}
+		ret = append(ret, gw)
+	}
+	return ret, nil
+}
+
+// Returns the gateway ID, as reported by the forwarder
+func (g *Gateway) ID() string {
+	return fmt.Sprintf("eui-%016x", binary.BigEndian.Uint64(g.config.EUI))
+}
+
+// Returns a snapshot of the gateway counters
+func (g *Gateway) Stats() GatewayStats {
+	return GatewayStats{
+		Uplinks:   atomic.LoadUint64(&g.stats.Uplinks),
+		Stats:     atomic.LoadUint64(&g.stats.Stats),
+		PushAcks:  atomic.LoadUint64(&g.stats.PushAcks),
+		PullData:  atomic.LoadUint64(&g.stats.PullData),
+		PullAcks:  atomic.LoadUint64(&g.stats.PullAcks),
+		Downlinks: atomic.LoadUint64(&g.stats.Downlinks),
+		TxAcks:    atomic.LoadUint64(&g.stats.TxAcks),
+	}
+}
+
+// Sums the counters of all the gateways
+func TotalStats(gateways []*Gateway) GatewayStats {
+	var ret GatewayStats
+	for _, gw := range gateways {
+		s := gw.Stats()
+		ret.add(&s)
+	}
+	return ret
+}
+
+func (g *Gateway) Close() {
+	g.closeOnce.Do(func() {
+		close(g.closeCh)
+		g.upSock.Close()
+		g.dnSock.Close()
+	})
+	g.wg.Wait()
+}
+
+func (g *Gateway) nextToken() uint16 {
+	return uint16(atomic.AddUint32(&g.token, 1))
+}
+
+// The internal concentrator counter, in microseconds
+func (g *Gateway) tmst() uint32 {
+	return uint32(time.Since(g.startTime).Microseconds())
+}
+
+func (g *Gateway) send(sock *net.UDPConn, kind byte, payload []byte) error {
+	pkt := Packet{
+		Token:   g.nextToken(),
+		Kind:    kind,
+		EUI:     g.config.EUI,
+		Payload: payload,
+	}
+	_, err := sock.Write(pkt.Encode())
+	return err
+}
+
+func (g *Gateway) closed() bool {
+	select {
+	case <-g.closeCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// Waits for the given duration, returning false if the gateway was closed
+func (g *Gateway) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-g.closeCh:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Composes an unconfirmed data uplink from one of the devices
+func (g *Gateway) makeFrame() []byte {
+	dev := &g.devices[g.rnd.Intn(len(g.devices))]
+	dev.fCnt += 1
+
+	frame := make([]byte, 9, 9+g.config.PayloadSize+4)
+	frame[0] = 0x40
+	binary.LittleEndian.PutUint32(frame[1:], dev.devAddr)
+	frame[5] = 0x80
+	binary.LittleEndian.PutUint16(frame[6:], dev.fCnt)
+	frame[8] = byte(1 + g.rnd.Intn(223))
+
+	payload := make([]byte, g.config.PayloadSize+4)
+	g.rnd.Read(payload)
+	return append(frame, payload...)
+}
+
+func (g *Gateway) makeRxPkt() RxPkt {
+	sf := g.config.SFMix.pick(g.rnd)
+	chIdx := g.rnd.Intn(len(g.config.Frequencies))
+	data := g.makeFrame()
+
+	// Lower data rates are typically used by the devices that are further away
+	rssi := -40 - g.rnd.Intn(50) - (sf-7)*8
+	snr := float64(int((10-float64(sf-7)*3+g.rnd.Float64()*6-3)*10)) / 10
+
+	pkt := RxPkt{
+		Time:       time.Now().UTC().Format(time.RFC3339Nano),
+		Tmst:       g.tmst(),
+		Frequency:  g.config.Frequencies[chIdx],
+		Channel:    chIdx,
+		RfChain:    chIdx / 4,
+		Stat:       1,
+		Modulation: "LORA",
+		DataRate:   fmt.Sprintf("SF%dBW125", sf),
+		CodingRate: "4/5",
+		Rssi:       rssi,
+		Lsnr:       snr,
+		Size:       len(data),
+		Data:       data,
+	}
+
+	if g.config.Antennas > 1 {
+		for ant := 0; ant < g.config.Antennas; ant++ {
+			antRssi := rssi - g.rnd.Intn(6)
+			pkt.RSig = append(pkt.RSig, RxPktRsig{
+				Ant:    uint8(ant),
+				Chan:   uint8(chIdx),
+				RSSIC:  int16(antRssi),
+				RSSIS:  int16(antRssi - 2),
+				RSSISD: uint16(g.rnd.Intn(3)),
+				LSNR:   float64(int((snr-g.rnd.Float64()*2)*10)) / 10,
+			})
+		}
+	}
+	return pkt
+}
+
+func (g *Gateway) makeStat() *Stat {
+	total := g.Stats()
+	last := g.lastStats
+	g.lastStats = total
+
+	stat := &Stat{
+		Time: time.Now().UTC().Format(statTimeFormat),
+		Lati: g.config.Latitude,
+		Long: g.config.Longitude,
+		Alti: g.config.Altitude,
+		RxNb: int(total.Uplinks - last.Uplinks),
+		RxOk: int(total.Uplinks - last.Uplinks),
+		RxFw: int(total.Uplinks - last.Uplinks),
+		DwnB: int(total.Downlinks - last.Downlinks),
+		TxNb: int(total.TxAcks - last.TxAcks),
+		Ackr: 100,
+	}
+	if sent := total.Uplinks + total.Stats - last.Uplinks - last.Stats; sent > 0 {
+		stat.Ackr = float64(total.PushAcks-last.PushAcks) * 100 / float64(sent)
+	}
+	return stat
+}
+
+func (g *Gateway) pushData(payload *PushPayload) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		log.Errorf("Could not encode PUSH_DATA: %s", err.Error())
+		return
+	}
+	if err := g.send(g.upSock, PushData, b); err != nil {
+		if !g.closed() {
+			log.Warnf("Could not send PUSH_DATA from %s: %s", g.ID(), err.Error())
+		}
+		return
+	}
+
+	if payload.Stats != nil {
+		atomic.AddUint64(&g.stats.Stats, 1)
+	}
+	atomic.AddUint64(&g.stats.Uplinks, uint64(len(payload.RxPackets)))
+}
+
+// Sends the uplinks with exponentially distributed intervals, and the
+// periodic `stat` reports
+func (g *Gateway) uplinkThread() {
+	defer g.wg.Done()
+
+	var nextUplink, nextStat time.Time
+	now := time.Now()
+	if g.config.UplinkInterval > 0 {
+		nextUplink = now.Add(time.Duration(g.rnd.ExpFloat64() * float64(g.config.UplinkInterval)))
+	}
+	if g.config.StatInterval > 0 {
+		nextStat = now.Add(g.config.StatInterval)
+	}
+	if nextUplink.IsZero() && nextStat.IsZero() {
+		return
+	}
+
+	for {
+		next := nextUplink
+		if next.IsZero() || (!nextStat.IsZero() && nextStat.Before(next)) {
+			next = nextStat
+		}
+		if !g.wait(time.Until(next)) {
+			return
+		}
+
+		now = time.Now()
+		var payload PushPayload
+		for !nextUplink.IsZero() && !nextUplink.After(now) {
+			payload.RxPackets = append(payload.RxPackets, g.makeRxPkt())
+			nextUplink = nextUplink.Add(time.Duration(g.rnd.ExpFloat64() * float64(g.config.UplinkInterval)))
+		}
+		if !nextStat.IsZero() && !nextStat.After(now) {
+			payload.Stats = g.makeStat()
+			nextStat = now.Add(g.config.StatInterval)
+		}
+		g.pushData(&payload)
+	}
+}
+
+// Sends the PULL_DATA keep-alives that open the downlink path
+func (g *Gateway) keepaliveThread() {
+	defer g.wg.Done()
+	for {
+		if err := g.send(g.dnSock, PullData, nil); err != nil {
+			if !g.closed() {
+				log.Warnf("Could not send PULL_DATA from %s: %s", g.ID(), err.Error())
+			}
+		} else {
+			atomic.AddUint64(&g.stats.PullData, 1)
+		}
+		if !g.wait(g.config.KeepaliveInterval) {
+			return
+		}
+	}
+}
+
+func (g *Gateway) upRecvThread() {
+	defer g.wg.Done()
+	var buf [2048]byte
+	for {
+		n, err := g.upSock.Read(buf[:])
+		if err != nil {
+			if g.closed() {
+				return
+			}
+			continue
+		}
+
+		pkt, err := DecodePacket(buf[:n])
+		if err != nil {
+			log.Debugf("Gateway %s received invalid packet: %s", g.ID(), err.Error())
+			continue
+		}
+		if pkt.Kind == PushAck {
+			atomic.AddUint64(&g.stats.PushAcks, 1)
+		}
+	}
+}
+
+// Receives the PULL_ACK and PULL_RESP datagrams, acknowledging the downlinks
+// with TX_ACK
+func (g *Gateway) dnRecvThread() {
+	defer g.wg.Done()
+	var buf [2048]byte
+	for {
+		n, err := g.dnSock.Read(buf[:])
+		if err != nil {
+			if g.closed() {
+				return
+			}
+			continue
+		}
+
+		pkt, err := DecodePacket(buf[:n])
+		if err != nil {
+			log.Debugf("Gateway %s received invalid packet: %s", g.ID(), err.Error())
+			continue
+		}
+
+		switch pkt.Kind {
+		case PullAck:
+			atomic.AddUint64(&g.stats.PullAcks, 1)
+		case PullResp:
+			atomic.AddUint64(&g.stats.Downlinks, 1)
+
+			var resp PullRespPayload
+			ack := TxAckPayload{TxAck: &TxPktAck{Error: "NONE"}}
+			if err := pkt.Unmarshal(&resp); err != nil || resp.TxPacket == nil {
+				ack.TxAck.Error = "TX_FREQ"
+			}
+			b, _ := json.Marshal(&ack)
+
+			// The TX_ACK echoes the token of the PULL_RESP
+			reply := Packet{Token: pkt.Token, Kind: TxAck, EUI: g.config.EUI, Payload: b}
+			if _, err := g.dnSock.Write(reply.Encode()); err == nil {
+				atomic.AddUint64(&g.stats.TxAcks, 1)
+			}
+		}
+	}
+}
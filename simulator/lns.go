@@ -0,0 +1,233 @@
+package simulator
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type LNSConfig struct {
+	// Where to receive the uplink (PUSH_DATA) datagrams
+	UpListenAddr *net.UDPAddr
+	// Where to receive the downlink (PULL_DATA) datagrams, nil to use the
+	// uplink socket for both
+	DownListenAddr *net.UDPAddr
+	// The fraction of the uplinks that are answered with a downlink (0 - 1)
+	DownlinkRatio float64
+	// The delay of the RX1 window after the end of the uplink
+	RX1Delay time.Duration
+	// The seed of the random generator, for reproducible traffic
+	Seed int64
+}
+
+// Counters of the datagrams the simulated LNS has exchanged
+type LNSStats struct {
+	PushData  uint64
+	Uplinks   uint64
+	PullData  uint64
+	Downlinks uint64
+	TxAcks    uint64
+	TxErrors  uint64
+}
+
+// A simulated LoRa Network Server, that acknowledges the gateway traffic and
+// answers some of the uplinks with class A downlinks
+type LNS struct {
+	config    LNSConfig
+	upSock    *net.UDPConn
+	dnSock    *net.UDPConn
+	rndLock   sync.Mutex
+	rnd       *rand.Rand
+	pullLock  sync.Mutex
+	pullAddrs map[uint64]*net.UDPAddr
+	closed    int32
+	wg        sync.WaitGroup
+	stats     LNSStats
+}
+
+func CreateLNS(config LNSConfig) (*LNS, error) {
+	if config.UpListenAddr == nil {
+		return nil, fmt.Errorf("missing uplink listen address")
+	}
+	if config.RX1Delay <= 0 {
+		config.RX1Delay = time.Second
+	}
+
+	upSock, err := net.ListenUDP("udp", config.UpListenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on %s: %s", config.UpListenAddr.String(), err.Error())
+	}
+	dnSock := upSock
+	if config.DownListenAddr != nil {
+		dnSock, err = net.ListenUDP("udp", config.DownListenAddr)
+		if err != nil {
+			upSock.Close()
+			return nil, fmt.Errorf("could not listen on %s: %s", config.DownListenAddr.String(), err.Error())
+		}
+	}
+
+	inst := &LNS{
+		config:    config,
+		upSock:    upSock,
+		dnSock:    dnSock,
+		rnd:       rand.New(rand.NewSource(config.Seed)),
+		pullAddrs: make(map[uint64]*net.UDPAddr),
+	}
+
+	inst.wg.Add(1)
+	go inst.recvThread(upSock)
+	if dnSock != upSock {
+		inst.wg.Add(1)
+		go inst.recvThread(dnSock)
+	}
+	return inst, nil
+}
+
+// The address where the uplinks are received
+func (l *LNS) UpAddr() *net.UDPAddr {
+	return l.upSock.LocalAddr().(*net.UDPAddr)
+}
+
+// The address where the downlink keep-alives are received
+func (l *LNS) DownAddr() *net.UDPAddr {
+	return l.dnSock.LocalAddr().(*net.UDPAddr)
+}
+
+// Returns a snapshot of the LNS counters
+func (l *LNS) Stats() LNSStats {
+	return LNSStats{
+		PushData:  atomic.LoadUint64(&l.stats.PushData),
+		Uplinks:   atomic.LoadUint64(&l.stats.Uplinks),
+		PullData:  atomic.LoadUint64(&l.stats.PullData),
+		Downlinks: atomic.LoadUint64(&l.stats.Downlinks),
+		TxAcks:    atomic.LoadUint64(&l.stats.TxAcks),
+		TxErrors:  atomic.LoadUint64(&l.stats.TxErrors),
+	}
+}
+
+func (l *LNS) Close() {
+	if atomic.CompareAndSwapInt32(&l.closed, 0, 1) {
+		l.upSock.Close()
+		if l.dnSock != l.upSock {
+			l.dnSock.Close()
+		}
+	}
+	l.wg.Wait()
+}
+
+func (l *LNS) recvThread(sock *net.UDPConn) {
+	defer l.wg.Done()
+	var buf [65536]byte
+	for {
+		n, addr, err := sock.ReadFromUDP(buf[:])
+		if err != nil {
+			if atomic.LoadInt32(&l.closed) != 0 {
+				return
+			}
+			continue
+		}
+
+		pkt, err := DecodePacket(buf[:n])
+		if err != nil {
+			log.Debugf("LNS received invalid packet from %s: %s", addr.String(), err.Error())
+			continue
+		}
+		l.handlePacket(sock, addr, pkt)
+	}
+}
+
+func (l *LNS) handlePacket(sock *net.UDPConn, addr *net.UDPAddr, pkt *Packet) {
+	switch pkt.Kind {
+	case PushData:
+		atomic.AddUint64(&l.stats.PushData, 1)
+		ack := Packet{Token: pkt.Token, Kind: PushAck}
+		sock.WriteToUDP(ack.Encode(), addr)
+
+		var payload PushPayload
+		if err := pkt.Unmarshal(&payload); err != nil {
+			log.Debugf("LNS received invalid PUSH_DATA from %s: %s", addr.String(), err.Error())
+			return
+		}
+		for i := range payload.RxPackets {
+			rx := &payload.RxPackets[i]
+			atomic.AddUint64(&l.stats.Uplinks, 1)
+			if rx.Stat == 1 && l.shouldReply() {
+				l.sendDownlink(binary.BigEndian.Uint64(pkt.EUI), rx)
+			}
+		}
+
+	case PullData:
+		atomic.AddUint64(&l.stats.PullData, 1)
+		l.pullLock.Lock()
+		l.pullAddrs[binary.BigEndian.Uint64(pkt.EUI)] = addr
+		l.pullLock.Unlock()
+
+		ack := Packet{Token: pkt.Token, Kind: PullAck}
+		sock.WriteToUDP(ack.Encode(), addr)
+
+	case TxAck:
+		atomic.AddUint64(&l.stats.TxAcks, 1)
+		var payload TxAckPayload
+		if len(pkt.Payload) > 0 && pkt.Unmarshal(&payload) == nil &&
+			payload.TxAck != nil && payload.TxAck.Error != "" && payload.TxAck.Error != "NONE" {
+			atomic.AddUint64(&l.stats.TxErrors, 1)
+		}
+	}
+}
+
+func (l *LNS) shouldReply() bool {
+	l.rndLock.Lock()
+	defer l.rndLock.Unlock()
+	return l.rnd.Float64() < l.config.DownlinkRatio
+}
+
+// Sends a class A downlink in the RX1 window of the given uplink, through the
+// downlink path the gateway has opened with PULL_DATA
+func (l *LNS) sendDownlink(eui uint64, rx *RxPkt) {
+	l.pullLock.Lock()
+	addr := l.pullAddrs[eui]
+	l.pullLock.Unlock()
+	if addr == nil {
+		return
+	}
+
+	// Reply to the same device, with an empty unconfirmed data down
+	frame := make([]byte, 12)
+	frame[0] = 0x60
+	if len(rx.Data) >= 5 {
+		copy(frame[1:5], rx.Data[1:5])
+	}
+	l.rndLock.Lock()
+	token := uint16(l.rnd.Uint32())
+	l.rnd.Read(frame[8:])
+	l.rndLock.Unlock()
+
+	resp := PullRespPayload{TxPacket: &TxPkt{
+		Tmst:       rx.Tmst + uint32(l.config.RX1Delay.Microseconds()),
+		Frequency:  rx.Frequency,
+		RfChain:    0,
+		Power:      14,
+		Modulation: rx.Modulation,
+		DataRate:   rx.DataRate,
+		CodingRate: rx.CodingRate,
+		Ipol:       true,
+		Size:       len(frame),
+		Data:       frame,
+	}}
+	b, err := json.Marshal(&resp)
+	if err != nil {
+		return
+	}
+
+	pkt := Packet{Token: token, Kind: PullResp, Payload: b}
+	if _, err := l.dnSock.WriteToUDP(pkt.Encode(), addr); err == nil {
+		atomic.AddUint64(&l.stats.Downlinks, 1)
+	}
+}
@@ -0,0 +1,146 @@
+package simulator
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+const ProtocolVersion = 2
+const (
+	PushData = 0x00
+	PushAck  = 0x01
+	PullData = 0x02
+	PullResp = 0x03
+	PullAck  = 0x04
+	TxAck    = 0x05
+)
+
+// The format of the `time` field in the `stat` objects
+const statTimeFormat = "2006-01-02 15:04:05 MST"
+
+// A datagram of the Semtech UDP protocol
+type Packet struct {
+	Token uint16
+	Kind  byte
+	// The gateway EUI, on the packets sent by the gateway
+	EUI []byte
+	// The JSON payload, if any
+	Payload []byte
+}
+
+type PushPayload struct {
+	RxPackets []RxPkt `json:"rxpk,omitempty"`
+	Stats     *Stat   `json:"stat,omitempty"`
+}
+
+type PullRespPayload struct {
+	TxPacket *TxPkt `json:"txpk"`
+}
+
+type TxAckPayload struct {
+	TxAck *TxPktAck `json:"txpk_ack"`
+}
+
+type Stat struct {
+	Time string  `json:"time"`
+	Lati float64 `json:"lati,omitempty"`
+	Long float64 `json:"long,omitempty"`
+	Alti int     `json:"alti,omitempty"`
+	RxNb int     `json:"rxnb"`
+	RxOk int     `json:"rxok"`
+	RxFw int     `json:"rxfw"`
+	Ackr float64 `json:"ackr"`
+	DwnB int     `json:"dwnb"`
+	TxNb int     `json:"txnb"`
+}
+
+type RxPktRsig struct {
+	Ant    uint8   `json:"ant"`
+	Chan   uint8   `json:"chan"`
+	RSSIC  int16   `json:"rssic"`
+	RSSIS  int16   `json:"rssis"`
+	RSSISD uint16  `json:"rssisd"`
+	LSNR   float64 `json:"lsnr"`
+}
+
+type RxPkt struct {
+	Time       string      `json:"time,omitempty"`
+	Tmst       uint32      `json:"tmst"`
+	Frequency  float64     `json:"freq"`
+	Channel    int         `json:"chan"`
+	RfChain    int         `json:"rfch"`
+	Stat       int         `json:"stat"`
+	Modulation string      `json:"modu"`
+	DataRate   string      `json:"datr"`
+	CodingRate string      `json:"codr"`
+	Rssi       int         `json:"rssi"`
+	Lsnr       float64     `json:"lsnr"`
+	Size       int         `json:"size"`
+	Data       []byte      `json:"data"`
+	RSig       []RxPktRsig `json:"rsig,omitempty"`
+}
+
+type TxPkt struct {
+	Imme       bool    `json:"imme,omitempty"`
+	Tmst       uint32  `json:"tmst,omitempty"`
+	Frequency  float64 `json:"freq"`
+	RfChain    int     `json:"rfch"`
+	Power      int     `json:"powe"`
+	Modulation string  `json:"modu"`
+	DataRate   string  `json:"datr"`
+	CodingRate string  `json:"codr"`
+	Ipol       bool    `json:"ipol"`
+	Size       int     `json:"size"`
+	Data       []byte  `json:"data"`
+}
+
+type TxPktAck struct {
+	Error string `json:"error"`
+}
+
+// Encodes the packet into a datagram
+func (p *Packet) Encode() []byte {
+	buf := make([]byte, 4, 4+len(p.EUI)+len(p.Payload))
+	buf[0] = ProtocolVersion
+	binary.LittleEndian.PutUint16(buf[1:], p.Token)
+	buf[3] = p.Kind
+	buf = append(buf, p.EUI...)
+	return append(buf, p.Payload...)
+}
+
+// Decodes a datagram into a packet
+func DecodePacket(data []byte) (*Packet, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("packet too small")
+	}
+	if data[0] != ProtocolVersion {
+		return nil, fmt.Errorf("invalid protocol version (%d)", data[0])
+	}
+
+	p := &Packet{
+		Token: binary.LittleEndian.Uint16(data[1:]),
+		Kind:  data[3],
+	}
+	body := data[4:]
+	switch p.Kind {
+	case PushData, PullData, TxAck:
+		if len(body) < 8 {
+			return nil, fmt.Errorf("missing gateway EUI")
+		}
+		p.EUI = append([]byte(nil), body[0:8]...)
+		body = body[8:]
+	}
+	if len(body) > 0 {
+		p.Payload = append([]byte(nil), body...)
+	}
+	return p, nil
+}
+
+// Parses the JSON payload of the packet into `v`
+func (p *Packet) Unmarshal(v interface{}) error {
+	if err := json.Unmarshal(p.Payload, v); err != nil {
+		return fmt.Errorf("could not parse JSON: %s", err.Error())
+	}
+	return nil
+}
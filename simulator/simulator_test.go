@@ -0,0 +1,156 @@
+package simulator
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the simulated traffic")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestParseSFMix(t *testing.T) {
+	mix, err := ParseSFMix("7:50, 12:50")
+	assert.NoError(t, err)
+	assert.Equal(t, SFMix{7: 50, 12: 50}, mix)
+
+	_, err = ParseSFMix("13:10")
+	assert.Error(t, err)
+	_, err = ParseSFMix("7")
+	assert.Error(t, err)
+	_, err = ParseSFMix("")
+	assert.Error(t, err)
+
+	// The weights are respected
+	rnd := rand.New(rand.NewSource(1))
+	counts := map[int]int{}
+	for i := 0; i < 1000; i++ {
+		counts[SFMix{7: 3, 12: 1, 9: 0}.pick(rnd)] += 1
+	}
+	assert.Equal(t, 0, counts[9])
+	assert.InDelta(t, 750, counts[7], 60)
+	assert.InDelta(t, 250, counts[12], 60)
+}
+
+func TestPacketEncoding(t *testing.T) {
+	pkt := Packet{Token: 0x1234, Kind: PushData, EUI: []byte{1, 2, 3, 4, 5, 6, 7, 8}, Payload: []byte("{}")}
+	data := pkt.Encode()
+	assert.Equal(t, []byte{2, 0x34, 0x12, 0, 1, 2, 3, 4, 5, 6, 7, 8, '{', '}'}, data)
+
+	decoded, err := DecodePacket(data)
+	assert.NoError(t, err)
+	assert.Equal(t, &pkt, decoded)
+
+	_, err = DecodePacket([]byte{2, 0, 0, PullData, 1})
+	assert.Error(t, err)
+	_, err = DecodePacket([]byte{1, 0, 0, PushAck})
+	assert.Error(t, err)
+}
+
+func TestGatewaysWithLNS(t *testing.T) {
+	lns, err := CreateLNS(LNSConfig{
+		UpListenAddr:   &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)},
+		DownListenAddr: &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)},
+		DownlinkRatio:  1,
+	})
+	assert.NoError(t, err)
+	defer lns.Close()
+
+	gateways, err := StartGateways(GatewayConfig{
+		EUI:               []byte{0, 0x16, 0xc0, 0x01, 0, 0, 0, 0xfe},
+		UpAddr:            lns.UpAddr(),
+		DownAddr:          lns.DownAddr(),
+		UplinkInterval:    20 * time.Millisecond,
+		StatInterval:      100 * time.Millisecond,
+		KeepaliveInterval: 50 * time.Millisecond,
+		Antennas:          2,
+	}, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, "eui-0016c001000000fe", gateways[0].ID())
+	assert.Equal(t, "eui-0016c00100000101", gateways[3].ID())
+
+	waitFor(t, func() bool {
+		s := TotalStats(gateways)
+		return s.Stats >= 4 && s.TxAcks >= 20
+	})
+	for _, gw := range gateways {
+		gw.Close()
+	}
+
+	// Every datagram sent by the gateways reaches the LNS, once it has caught
+	// up with the ones still in flight. The LNS counts the datagrams it
+	// acknowledges as they arrive, so the gateways may have stopped before
+	// receiving the last acknowledgements and downlinks.
+	gws := TotalStats(gateways)
+	waitFor(t, func() bool {
+		lnss := lns.Stats()
+		return lnss.Uplinks == gws.Uplinks && lnss.PullData == gws.PullData && lnss.TxAcks == gws.TxAcks &&
+			lnss.PushData >= gws.PushAcks && lnss.Downlinks >= gws.Downlinks
+	})
+	lnss := lns.Stats()
+	assert.Equal(t, gws.Uplinks, lnss.Uplinks)
+	assert.Equal(t, gws.PullData, lnss.PullData)
+	assert.Equal(t, gws.TxAcks, lnss.TxAcks)
+	assert.LessOrEqual(t, gws.PushAcks, lnss.PushData)
+	assert.LessOrEqual(t, gws.PullAcks, lnss.PullData)
+	assert.LessOrEqual(t, gws.Downlinks, lnss.Downlinks)
+	assert.Equal(t, uint64(0), lnss.TxErrors)
+}
+
+func TestGatewayTraffic(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	gw, err := CreateGateway(GatewayConfig{
+		EUI:            []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		UpAddr:         conn.LocalAddr().(*net.UDPAddr),
+		UplinkInterval: 10 * time.Millisecond,
+		SFMix:          SFMix{12: 1},
+		Antennas:       3,
+		Frequencies:    []float64{868.1},
+	})
+	assert.NoError(t, err)
+	defer gw.Close()
+
+	var buf [4096]byte
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		n, _, err := conn.ReadFromUDP(buf[:])
+		if !assert.NoError(t, err) {
+			return
+		}
+		pkt, err := DecodePacket(buf[:n])
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8}, pkt.EUI)
+		if pkt.Kind != PushData {
+			continue
+		}
+
+		var payload PushPayload
+		assert.NoError(t, json.Unmarshal(pkt.Payload, &payload))
+		if len(payload.RxPackets) == 0 {
+			continue
+		}
+
+		rx := payload.RxPackets[0]
+		assert.Equal(t, "SF12BW125", rx.DataRate)
+		assert.Equal(t, 868.1, rx.Frequency)
+		assert.Equal(t, len(rx.Data), rx.Size)
+		assert.Equal(t, byte(0x40), rx.Data[0])
+		assert.Len(t, rx.RSig, 3)
+		assert.Equal(t, uint8(2), rx.RSig[2].Ant)
+		break
+	}
+}